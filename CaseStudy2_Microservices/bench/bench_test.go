@@ -0,0 +1,101 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestParse(t *testing.T) {
+	sizes, err := ParseSizes("512, 4KiB,1MiB,2KB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int64{512, 4096, 1 << 20, 2000}; !slices.Equal(sizes, want) {
+		t.Fatalf("sizes = %v, want %v", sizes, want)
+	}
+	if got := FormatSize(1 << 20); got != "1MiB" {
+		t.Fatalf("FormatSize = %s", got)
+	}
+	lingers, err := ParseDurations("0,5ms")
+	if err != nil || !slices.Equal(lingers, []time.Duration{0, 5 * time.Millisecond}) {
+		t.Fatalf("lingers = %v, %v", lingers, err)
+	}
+	acks, err := ParseAcks("none,1,all")
+	if err != nil || !slices.Equal(acks, []kafka.RequiredAcks{kafka.RequireNone, kafka.RequireOne, kafka.RequireAll}) {
+		t.Fatalf("acks = %v, %v", acks, err)
+	}
+	codecs, err := ParseCompressions("none,zstd")
+	if err != nil || !slices.Equal(codecs, []kafka.Compression{0, kafka.Zstd}) {
+		t.Fatalf("codecs = %v, %v", codecs, err)
+	}
+	for _, bad := range []string{"", "fast", "1XB"} {
+		if _, err := ParseSizes(bad); err == nil {
+			t.Errorf("ParseSizes(%q) succeeded", bad)
+		}
+	}
+	if _, err := ParseCompressions("brotli"); err == nil {
+		t.Error("unknown codec accepted")
+	}
+}
+
+func TestRunAgainstMemoryBroker(t *testing.T) {
+	sweep := Sweep{
+		Compressions: []kafka.Compression{0, kafka.Gzip},
+		Acks:         []kafka.RequiredAcks{kafka.RequireAll},
+		BatchSizes:   []int{100},
+		BatchBytes:   []int64{1 << 20},
+		Lingers:      []time.Duration{time.Millisecond},
+		MessageSizes: []int{256},
+		Partitions:   []int{3},
+	}
+	runner := &Runner{
+		Target:      NewMemoryBroker(time.Millisecond),
+		Options:     Options{Messages: 1000, Producers: 64, Payload: PayloadText, DrainTimeout: 10 * time.Second},
+		TopicPrefix: "test",
+	}
+	results, err := runner.Run(context.Background(), sweep.Scenarios())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("%d results, want 2", len(results))
+	}
+	for _, r := range results {
+		if r.Acked != 1000 || r.Received != 1000 || r.Errors != 0 {
+			t.Fatalf("%s: acked %d, received %d, errors %d", r.Scenario, r.Acked, r.Received, r.Errors)
+		}
+		if r.PayloadBytes != 1000*256 {
+			t.Fatalf("%s: payload bytes %d", r.Scenario, r.PayloadBytes)
+		}
+		for name, p := range map[string]Percentiles{"produce": r.Produce, "end-to-end": r.EndToEnd} {
+			if p.P50 <= 0 || p.P50 > p.P95 || p.P95 > p.P99 || p.P99 > p.Max {
+				t.Fatalf("%s: %s percentiles out of order: %+v", r.Scenario, name, p)
+			}
+		}
+		// acks=all waits one and a half round trips of the memory broker.
+		if r.Produce.P50 < 1500*time.Microsecond {
+			t.Fatalf("%s: produce p50 %s is below the simulated round trips", r.Scenario, r.Produce.P50)
+		}
+	}
+	if plain, gzip := results[0].CompressionRatio(), results[1].CompressionRatio(); plain > 1 || gzip <= 1.5*plain {
+		t.Fatalf("compression ratios: none %.2f, gzip %.2f", plain, gzip)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || len(rows[1]) != len(columns) || rows[2][0] != "gzip" {
+		t.Fatalf("csv rows = %v", rows)
+	}
+}
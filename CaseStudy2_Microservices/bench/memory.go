@@ -0,0 +1,253 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+	"github.com/segmentio/kafka-go/protocol/metadata"
+	"github.com/segmentio/kafka-go/protocol/produce"
+)
+
+// MemoryBroker is a single in-process Kafka broker for runs without a cluster. It serves the
+// metadata and produce requests of a kafka.Writer as its Transport, so batching, linger and
+// compression happen in the real writer. Each record set is encoded, which compresses it, and
+// decoded again, so codec costs in both directions are part of the measurement.
+type MemoryBroker struct {
+	// RTT delays produce responses to model the network. A request reaches the broker after half
+	// of it; acks=one answers after the other half and acks=all after one more RTT for replication.
+	// acks=none does not wait.
+	RTT time.Duration
+
+	mu     sync.Mutex
+	topics map[string]*memoryTopic
+}
+
+type memoryTopic struct {
+	partitions [][]kafka.Message
+	wireBytes  int64
+	// appended is closed and replaced whenever messages are appended.
+	appended chan struct{}
+}
+
+// NewMemoryBroker creates an empty broker.
+func NewMemoryBroker(rtt time.Duration) *MemoryBroker {
+	return &MemoryBroker{RTT: rtt, topics: make(map[string]*memoryTopic)}
+}
+
+var memoryAddr = &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 9092}
+
+func (b *MemoryBroker) Addr() net.Addr                { return memoryAddr }
+func (b *MemoryBroker) Transport() kafka.RoundTripper { return b }
+
+func (b *MemoryBroker) CreateTopic(_ context.Context, topic string, partitions int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.topics[topic]; ok {
+		return fmt.Errorf("topic %s already exists", topic)
+	}
+	b.topics[topic] = &memoryTopic{partitions: make([][]kafka.Message, partitions), appended: make(chan struct{})}
+	return nil
+}
+
+func (b *MemoryBroker) DeleteTopic(_ context.Context, topic string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.topics, topic)
+	return nil
+}
+
+// WireBytes returns the encoded size of the record sets produced to topic.
+func (b *MemoryBroker) WireBytes(topic string) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if t, ok := b.topics[topic]; ok {
+		return t.wireBytes
+	}
+	return 0
+}
+
+// Consume calls fn with the messages of every partition of topic, in offset order per partition,
+// until ctx is done.
+func (b *MemoryBroker) Consume(ctx context.Context, topic string, fn func(kafka.Message)) error {
+	var cursors []int
+	for {
+		b.mu.Lock()
+		t, ok := b.topics[topic]
+		if !ok {
+			b.mu.Unlock()
+			return fmt.Errorf("%s: %w", topic, kafka.UnknownTopicOrPartition)
+		}
+		if cursors == nil {
+			cursors = make([]int, len(t.partitions))
+		}
+		var batch []kafka.Message
+		for p, log := range t.partitions {
+			batch = append(batch, log[cursors[p]:]...)
+			cursors[p] = len(log)
+		}
+		appended := t.appended
+		b.mu.Unlock()
+
+		for _, m := range batch {
+			fn(m)
+		}
+		if len(batch) > 0 {
+			continue
+		}
+		select {
+		case <-appended:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (b *MemoryBroker) Close() error {
+	return nil
+}
+
+// RoundTrip implements kafka.RoundTripper.
+func (b *MemoryBroker) RoundTrip(ctx context.Context, _ net.Addr, req kafka.Request) (kafka.Response, error) {
+	switch req := req.(type) {
+	case *metadata.Request:
+		return b.metadata(req), nil
+	case *produce.Request:
+		return b.produce(ctx, req)
+	default:
+		return nil, fmt.Errorf("memory broker does not support %T", req)
+	}
+}
+
+func (b *MemoryBroker) metadata(req *metadata.Request) *metadata.Response {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	res := &metadata.Response{
+		Brokers:      []metadata.ResponseBroker{{NodeID: 0, Host: memoryAddr.IP.String(), Port: int32(memoryAddr.Port)}},
+		ControllerID: 0,
+	}
+	for _, name := range req.TopicNames {
+		rt := metadata.ResponseTopic{Name: name}
+		t, ok := b.topics[name]
+		if !ok {
+			rt.ErrorCode = int16(kafka.UnknownTopicOrPartition)
+			res.Topics = append(res.Topics, rt)
+			continue
+		}
+		for p := range t.partitions {
+			rt.Partitions = append(rt.Partitions, metadata.ResponsePartition{
+				PartitionIndex: int32(p), ReplicaNodes: []int32{0}, IsrNodes: []int32{0},
+			})
+		}
+		res.Topics = append(res.Topics, rt)
+	}
+	return res
+}
+
+func (b *MemoryBroker) produce(ctx context.Context, req *produce.Request) (*produce.Response, error) {
+	acks := kafka.RequiredAcks(req.Acks)
+	if acks != kafka.RequireNone {
+		if err := sleep(ctx, b.RTT/2); err != nil {
+			return nil, err
+		}
+	}
+	res := &produce.Response{}
+	for _, t := range req.Topics {
+		rt := produce.ResponseTopic{Topic: t.Topic}
+		for _, p := range t.Partitions {
+			rp := produce.ResponsePartition{Partition: p.Partition, LogAppendTime: time.Now().UnixMilli()}
+			base, err := b.append(t.Topic, int(p.Partition), p.RecordSet)
+			var kerr kafka.Error
+			switch {
+			case errors.As(err, &kerr):
+				rp.ErrorCode = int16(kerr)
+			case err != nil:
+				return nil, err
+			default:
+				rp.BaseOffset = base
+			}
+			rt.Partitions = append(rt.Partitions, rp)
+		}
+		res.Topics = append(res.Topics, rt)
+	}
+	wait := time.Duration(0)
+	switch acks {
+	case kafka.RequireOne:
+		wait = b.RTT / 2
+	case kafka.RequireAll:
+		wait = b.RTT/2 + b.RTT
+	}
+	if err := sleep(ctx, wait); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// append encodes and decodes rs as a broker and a consumer would, then appends its records.
+func (b *MemoryBroker) append(topic string, partition int, rs protocol.RecordSet) (int64, error) {
+	if rs.Version == 0 {
+		rs.Version = 2
+	}
+	var wire bytes.Buffer
+	if _, err := rs.WriteTo(&wire); err != nil {
+		return 0, fmt.Errorf("encode record set: %w", err)
+	}
+	size := int64(wire.Len())
+
+	var decoded protocol.RecordSet
+	if _, err := decoded.ReadFrom(&wire); err != nil {
+		return 0, fmt.Errorf("decode record set: %w", err)
+	}
+	var msgs []kafka.Message
+	for {
+		r, err := decoded.Records.ReadRecord()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("decode record: %w", err)
+		}
+		value, err := protocol.ReadAll(r.Value)
+		if err != nil {
+			return 0, fmt.Errorf("read record value: %w", err)
+		}
+		msgs = append(msgs, kafka.Message{Topic: topic, Partition: partition, Value: value, Time: r.Time})
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t, ok := b.topics[topic]
+	if !ok || partition < 0 || partition >= len(t.partitions) {
+		return 0, kafka.UnknownTopicOrPartition
+	}
+	base := int64(len(t.partitions[partition]))
+	for i := range msgs {
+		msgs[i].Offset = base + int64(i)
+	}
+	t.partitions[partition] = append(t.partitions[partition], msgs...)
+	t.wireBytes += size
+	close(t.appended)
+	t.appended = make(chan struct{})
+	return base, nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,81 @@
+package bench
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var columns = []string{
+	"compression", "acks", "batch_size", "batch_bytes", "linger", "msg_size", "partitions",
+	"acked", "errors", "received", "seconds", "msgs_per_sec", "mb_per_sec", "compression_ratio",
+	"produce_p50_ms", "produce_p95_ms", "produce_p99_ms", "produce_max_ms",
+	"e2e_p50_ms", "e2e_p95_ms", "e2e_p99_ms", "e2e_max_ms",
+}
+
+func ms(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+}
+
+func row(r Result) []string {
+	ratio := ""
+	if c := r.CompressionRatio(); c > 0 {
+		ratio = strconv.FormatFloat(c, 'f', 2, 64)
+	}
+	return []string{
+		r.Compression.String(), r.Acks.String(), strconv.Itoa(r.BatchSize), strconv.FormatInt(r.BatchBytes, 10),
+		r.Linger.String(), strconv.Itoa(r.MessageSize), strconv.Itoa(r.Partitions),
+		strconv.Itoa(r.Acked), strconv.Itoa(r.Errors), strconv.Itoa(r.Received),
+		strconv.FormatFloat(r.Duration.Seconds(), 'f', 3, 64),
+		strconv.FormatFloat(r.MessagesPerSec(), 'f', 0, 64),
+		strconv.FormatFloat(r.MBPerSec(), 'f', 2, 64),
+		ratio,
+		ms(r.Produce.P50), ms(r.Produce.P95), ms(r.Produce.P99), ms(r.Produce.Max),
+		ms(r.EndToEnd.P50), ms(r.EndToEnd.P95), ms(r.EndToEnd.P99), ms(r.EndToEnd.Max),
+	}
+}
+
+// WriteCSV writes one row per result with raw numbers; latencies are in milliseconds.
+func WriteCSV(w io.Writer, results []Result) error {
+	cw := csv.NewWriter(w)
+	cw.Write(columns)
+	for _, r := range results {
+		cw.Write(row(r))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteMarkdown writes a comparison table. The fastest scenario's throughput is in bold.
+func WriteMarkdown(w io.Writer, results []Result) error {
+	best := -1
+	for i, r := range results {
+		if best < 0 || r.MessagesPerSec() > results[best].MessagesPerSec() {
+			best = i
+		}
+	}
+	var b strings.Builder
+	b.WriteString("| Compression | Acks | Batch | Batch bytes | Linger | Msg size | Partitions | msg/s | MB/s | Ratio | Produce p50 / p95 / p99 (ms) | End-to-end p50 / p95 / p99 (ms) | Errors | Received |\n")
+	b.WriteString("|---|---|---:|---:|---:|---:|---:|---:|---:|---:|---:|---:|---:|---:|\n")
+	for i, r := range results {
+		throughput := strconv.FormatFloat(r.MessagesPerSec(), 'f', 0, 64)
+		if i == best {
+			throughput = "**" + throughput + "**"
+		}
+		ratio := "-"
+		if c := r.CompressionRatio(); c > 0 {
+			ratio = strconv.FormatFloat(c, 'f', 2, 64) + "x"
+		}
+		fmt.Fprintf(&b, "| %s | %s | %d | %s | %s | %s | %d | %s | %.2f | %s | %s / %s / %s | %s / %s / %s | %d | %d/%d |\n",
+			r.Compression, r.Acks, r.BatchSize, FormatSize(r.BatchBytes), r.Linger, FormatSize(int64(r.MessageSize)), r.Partitions,
+			throughput, r.MBPerSec(), ratio,
+			ms(r.Produce.P50), ms(r.Produce.P95), ms(r.Produce.P99),
+			ms(r.EndToEnd.P50), ms(r.EndToEnd.P95), ms(r.EndToEnd.P99),
+			r.Errors, r.Received, r.Acked+r.Errors)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,313 @@
+package bench
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Payload kinds.
+const (
+	// PayloadText fills messages with repeated JSON, which compresses like typical event data.
+	PayloadText = "text"
+	// PayloadRandom fills messages with random bytes, which do not compress.
+	PayloadRandom = "random"
+)
+
+const textFiller = `{"student_id":"S-104233","name":"Asha Rao","branch":"CSE","cgpa":8.4,"skills":["golang","kafka","docker","postgres"]} `
+
+// Options apply to every scenario of a run.
+type Options struct {
+	// Messages is the number of messages produced per scenario.
+	Messages int
+	// Producers is the number of goroutines calling WriteMessages, one message per call. With a
+	// synchronous writer batches only fill up from concurrent calls.
+	Producers int
+	// Async makes WriteMessages return without waiting for the broker.
+	Async bool
+	// Payload is PayloadText or PayloadRandom.
+	Payload string
+	// DrainTimeout bounds the wait for the consumer once everything has been produced.
+	DrainTimeout time.Duration
+	// KeepTopics leaves the scenario topics in place after the run.
+	KeepTopics bool
+}
+
+// DefaultOptions produces 100000 text messages per scenario from 64 producers.
+func DefaultOptions() Options {
+	return Options{Messages: 100_000, Producers: 64, Payload: PayloadText, DrainTimeout: 30 * time.Second}
+}
+
+// Percentiles summarises a latency distribution.
+type Percentiles struct {
+	P50, P95, P99, Max time.Duration
+}
+
+func percentiles(samples []time.Duration) Percentiles {
+	if len(samples) == 0 {
+		return Percentiles{}
+	}
+	slices.Sort(samples)
+	at := func(q float64) time.Duration {
+		i := int(q*float64(len(samples))+0.5) - 1
+		return samples[min(max(i, 0), len(samples)-1)]
+	}
+	return Percentiles{P50: at(0.50), P95: at(0.95), P99: at(0.99), Max: samples[len(samples)-1]}
+}
+
+// Result is the outcome of one scenario.
+type Result struct {
+	Scenario
+	Topic string
+	// Acked is the number of messages the writer reported as written, and Errors the number it
+	// reported as failed.
+	Acked  int
+	Errors int
+	// Received is the number of messages the consumer saw before the drain timeout.
+	Received int
+	// Duration runs from the first write until the writer was closed with everything flushed.
+	Duration time.Duration
+	// PayloadBytes is the size of the acked messages and WireBytes the size of their encoded
+	// record sets, or 0 when the target cannot tell.
+	PayloadBytes int64
+	WireBytes    int64
+	// Produce is the time from a message's WriteMessages call until its batch was acknowledged,
+	// and EndToEnd the time until the consumer read it.
+	Produce  Percentiles
+	EndToEnd Percentiles
+}
+
+// MessagesPerSec is the acked throughput.
+func (r Result) MessagesPerSec() float64 {
+	return float64(r.Acked) / r.Duration.Seconds()
+}
+
+// MBPerSec is the acked payload throughput in MB (10^6 bytes) per second.
+func (r Result) MBPerSec() float64 {
+	return float64(r.PayloadBytes) / 1e6 / r.Duration.Seconds()
+}
+
+// CompressionRatio is the payload size over the wire size, or 0 if the wire size is unknown.
+func (r Result) CompressionRatio() float64 {
+	if r.WireBytes == 0 {
+		return 0
+	}
+	return float64(r.PayloadBytes) / float64(r.WireBytes)
+}
+
+// Runner runs scenarios against a target.
+type Runner struct {
+	Target      Target
+	Options     Options
+	TopicPrefix string
+}
+
+// Run runs every scenario in turn. It stops at the first scenario that cannot be run and returns
+// the results so far.
+func (r *Runner) Run(ctx context.Context, scenarios []Scenario) ([]Result, error) {
+	var results []Result
+	runID := time.Now().Unix()
+	for i, s := range scenarios {
+		topic := fmt.Sprintf("%s-%d-%03d", r.TopicPrefix, runID, i)
+		log.Printf("[%d/%d] %s", i+1, len(scenarios), s)
+		res, err := r.RunScenario(ctx, s, topic)
+		if err != nil {
+			return results, fmt.Errorf("%s: %w", s, err)
+		}
+		log.Printf("[%d/%d] %.0f msg/s, produce p99 %s, end-to-end p99 %s", i+1, len(scenarios),
+			res.MessagesPerSec(), res.Produce.P99.Round(time.Microsecond), res.EndToEnd.P99.Round(time.Microsecond))
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// latencies collects samples from concurrent goroutines.
+type latencies struct {
+	mu      sync.Mutex
+	samples []time.Duration
+}
+
+func (l *latencies) add(d ...time.Duration) {
+	l.mu.Lock()
+	l.samples = append(l.samples, d...)
+	l.mu.Unlock()
+}
+
+// RunScenario produces Options.Messages messages to a new topic and consumes them concurrently.
+// Every payload starts with its send time, so the writer's completion callback and the consumer
+// can tell how long each message took.
+func (r *Runner) RunScenario(ctx context.Context, s Scenario, topic string) (Result, error) {
+	if err := s.Validate(); err != nil {
+		return Result{}, err
+	}
+	opts := r.Options
+	if err := r.Target.CreateTopic(ctx, topic, s.Partitions); err != nil {
+		return Result{}, err
+	}
+	if !opts.KeepTopics {
+		defer func() {
+			if err := r.Target.DeleteTopic(context.WithoutCancel(ctx), topic); err != nil {
+				log.Printf("Failed to delete topic %s: %v", topic, err)
+			}
+		}()
+	}
+	res := Result{Scenario: s, Topic: topic}
+
+	// Consume first, so that end-to-end lag does not include a late start.
+	var endToEnd latencies
+	endToEnd.samples = make([]time.Duration, 0, opts.Messages)
+	var received atomic.Int64
+	allReceived := make(chan struct{})
+	consumeCtx, stopConsumer := context.WithCancel(ctx)
+	consumed := make(chan error, 1)
+	go func() {
+		consumed <- r.Target.Consume(consumeCtx, topic, func(m kafka.Message) {
+			if sent, ok := sendTime(m.Value); ok {
+				endToEnd.add(time.Since(sent))
+			}
+			if received.Add(1) == int64(opts.Messages) {
+				close(allReceived)
+			}
+		})
+	}()
+
+	var produce latencies
+	produce.samples = make([]time.Duration, 0, opts.Messages)
+	var acked, failed, payloadBytes atomic.Int64
+	var firstErrOnce sync.Once
+	var firstErr error
+	writer := &kafka.Writer{
+		Addr:         r.Target.Addr(),
+		Transport:    r.Target.Transport(),
+		Topic:        topic,
+		Balancer:     &kafka.RoundRobin{},
+		Compression:  s.Compression,
+		RequiredAcks: s.Acks,
+		BatchSize:    s.BatchSize,
+		BatchBytes:   s.BatchBytes,
+		// The writer treats 0 as its 1s default, so no linger is the shortest timeout instead.
+		BatchTimeout: max(s.Linger, time.Microsecond),
+		Async:        opts.Async,
+		Completion: func(msgs []kafka.Message, err error) {
+			if err != nil {
+				failed.Add(int64(len(msgs)))
+				firstErrOnce.Do(func() { firstErr = err })
+				return
+			}
+			now := time.Now()
+			batch := make([]time.Duration, 0, len(msgs))
+			for _, m := range msgs {
+				if sent, ok := sendTime(m.Value); ok {
+					batch = append(batch, now.Sub(sent))
+				}
+				payloadBytes.Add(int64(len(m.Value)))
+			}
+			acked.Add(int64(len(msgs)))
+			produce.add(batch...)
+		},
+	}
+
+	values := newPayloads(opts.Payload, s.MessageSize)
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	start := time.Now()
+	for range max(opts.Producers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				seq := next.Add(1) - 1
+				if seq >= int64(opts.Messages) || ctx.Err() != nil {
+					return
+				}
+				// Failures reach the completion callback too; this only catches a cancelled run.
+				if err := writer.WriteMessages(ctx, kafka.Message{Value: values.next(seq)}); err != nil && ctx.Err() != nil {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err := writer.Close(); err != nil {
+		log.Printf("Failed to close writer: %v", err)
+	}
+	res.Duration = time.Since(start)
+
+	timer := time.NewTimer(opts.DrainTimeout)
+	select {
+	case <-allReceived:
+	case <-timer.C:
+		log.Printf("Consumer saw %d of %d messages after %s", received.Load(), opts.Messages, opts.DrainTimeout)
+	case <-ctx.Done():
+	}
+	timer.Stop()
+	stopConsumer()
+	if err := <-consumed; err != nil && !errors.Is(err, context.Canceled) {
+		return res, fmt.Errorf("consume: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return res, err
+	}
+
+	res.Acked, res.Errors, res.Received = int(acked.Load()), int(failed.Load()), int(received.Load())
+	res.PayloadBytes = payloadBytes.Load()
+	if ws, ok := r.Target.(wireSizer); ok {
+		res.WireBytes = ws.WireBytes(topic)
+	}
+	res.Produce = percentiles(produce.samples)
+	res.EndToEnd = percentiles(endToEnd.samples)
+	if firstErr != nil {
+		log.Printf("%d messages failed, first error: %v", res.Errors, firstErr)
+	}
+	return res, nil
+}
+
+// payloads builds message values: the send time and sequence number followed by filler.
+type payloads struct {
+	size   int
+	random bool
+	text   []byte
+}
+
+func newPayloads(kind string, size int) *payloads {
+	p := &payloads{size: size, random: kind == PayloadRandom}
+	if !p.random {
+		p.text = []byte(strings.Repeat(textFiller, size/len(textFiller)+1)[:size-headerSize])
+	}
+	return p
+}
+
+// next returns the value of message seq, stamped with the current time. Random filler is drawn
+// per message so that batches do not compress across repeated content.
+func (p *payloads) next(seq int64) []byte {
+	v := make([]byte, p.size)
+	if p.random {
+		for i := headerSize; i < len(v); i += 8 {
+			var word [8]byte
+			binary.LittleEndian.PutUint64(word[:], rand.Uint64())
+			copy(v[i:], word[:])
+		}
+	} else {
+		copy(v[headerSize:], p.text)
+	}
+	binary.BigEndian.PutUint64(v[0:8], uint64(time.Now().UnixNano()))
+	binary.BigEndian.PutUint64(v[8:16], uint64(seq))
+	return v
+}
+
+func sendTime(v []byte) (time.Time, bool) {
+	if len(v) < headerSize {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(v[0:8]))), true
+}
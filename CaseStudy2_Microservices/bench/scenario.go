@@ -0,0 +1,181 @@
+// Package bench measures Kafka producer throughput, produce latency and end-to-end lag for
+// combinations of writer settings, against a cluster or an in-memory broker.
+package bench
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// headerSize is the part of every payload holding the send timestamp and the sequence number.
+const headerSize = 16
+
+// Scenario is one combination of writer and topic settings.
+type Scenario struct {
+	Compression kafka.Compression
+	Acks        kafka.RequiredAcks
+	BatchSize   int
+	BatchBytes  int64
+	// Linger is how long the writer waits to fill a batch (kafka.Writer.BatchTimeout).
+	Linger      time.Duration
+	MessageSize int
+	Partitions  int
+}
+
+func (s Scenario) String() string {
+	return fmt.Sprintf("compression=%s acks=%s batch=%d/%s linger=%s size=%s partitions=%d",
+		s.Compression, s.Acks, s.BatchSize, FormatSize(s.BatchBytes), s.Linger, FormatSize(int64(s.MessageSize)), s.Partitions)
+}
+
+// Validate reports settings the harness cannot run.
+func (s Scenario) Validate() error {
+	switch {
+	case s.MessageSize < headerSize:
+		return fmt.Errorf("message size %d is below the %d byte timestamp header", s.MessageSize, headerSize)
+	case s.Partitions <= 0:
+		return fmt.Errorf("partitions must be positive, got %d", s.Partitions)
+	case s.BatchSize <= 0:
+		return fmt.Errorf("batch size must be positive, got %d", s.BatchSize)
+	case s.BatchBytes < int64(s.MessageSize):
+		return fmt.Errorf("batch bytes %d cannot hold a %d byte message", s.BatchBytes, s.MessageSize)
+	case s.Linger < 0:
+		return fmt.Errorf("linger must not be negative, got %s", s.Linger)
+	}
+	return nil
+}
+
+// Sweep lists the values to try for each setting; every combination is one Scenario.
+type Sweep struct {
+	Compressions []kafka.Compression
+	Acks         []kafka.RequiredAcks
+	BatchSizes   []int
+	BatchBytes   []int64
+	Lingers      []time.Duration
+	MessageSizes []int
+	Partitions   []int
+}
+
+// Scenarios returns every combination, varying the writer settings fastest so that rows for the
+// same topic layout and message size sit together.
+func (sw Sweep) Scenarios() []Scenario {
+	var out []Scenario
+	for _, partitions := range sw.Partitions {
+		for _, size := range sw.MessageSizes {
+			for _, acks := range sw.Acks {
+				for _, compression := range sw.Compressions {
+					for _, batchSize := range sw.BatchSizes {
+						for _, batchBytes := range sw.BatchBytes {
+							for _, linger := range sw.Lingers {
+								out = append(out, Scenario{
+									Compression: compression,
+									Acks:        acks,
+									BatchSize:   batchSize,
+									BatchBytes:  batchBytes,
+									Linger:      linger,
+									MessageSize: size,
+									Partitions:  partitions,
+								})
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+// ParseCompressions parses a comma-separated list of codecs: none, gzip, snappy, lz4, zstd.
+func ParseCompressions(s string) ([]kafka.Compression, error) {
+	return parseList(s, func(v string) (kafka.Compression, error) {
+		var c kafka.Compression
+		err := c.UnmarshalText([]byte(v))
+		return c, err
+	})
+}
+
+// ParseAcks parses a comma-separated list of none, one and all (or 0, 1 and -1).
+func ParseAcks(s string) ([]kafka.RequiredAcks, error) {
+	return parseList(s, func(v string) (kafka.RequiredAcks, error) {
+		var a kafka.RequiredAcks
+		err := a.UnmarshalText([]byte(v))
+		return a, err
+	})
+}
+
+// ParseInts parses a comma-separated list of integers.
+func ParseInts(s string) ([]int, error) {
+	return parseList(s, strconv.Atoi)
+}
+
+// ParseDurations parses a comma-separated list of durations such as 0,5ms,50ms.
+func ParseDurations(s string) ([]time.Duration, error) {
+	return parseList(s, func(v string) (time.Duration, error) {
+		if v == "0" {
+			return 0, nil
+		}
+		return time.ParseDuration(v)
+	})
+}
+
+var sizeUnits = []struct {
+	suffix string
+	factor int64
+}{
+	{"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10},
+	{"GB", 1e9}, {"MB", 1e6}, {"KB", 1e3}, {"B", 1},
+}
+
+// ParseSizes parses a comma-separated list of byte sizes such as 512,1KiB,1MiB.
+func ParseSizes(s string) ([]int64, error) {
+	return parseList(s, ParseSize)
+}
+
+// ParseSize parses a byte size with an optional B, KB, MB, GB, KiB, MiB or GiB suffix.
+func ParseSize(v string) (int64, error) {
+	factor := int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(v, u.suffix) {
+			v, factor = strings.TrimSpace(strings.TrimSuffix(v, u.suffix)), u.factor
+			break
+		}
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", v)
+	}
+	return n * factor, nil
+}
+
+// FormatSize renders n in the largest binary unit that divides it.
+func FormatSize(n int64) string {
+	for _, u := range sizeUnits[:3] {
+		if n >= u.factor && n%u.factor == 0 {
+			return strconv.FormatInt(n/u.factor, 10) + u.suffix
+		}
+	}
+	return strconv.FormatInt(n, 10) + "B"
+}
+
+func parseList[T any](s string, parse func(string) (T, error)) ([]T, error) {
+	var out []T
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		v, err := parse(field)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("empty list %q", s)
+	}
+	return out, nil
+}
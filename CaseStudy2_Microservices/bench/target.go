@@ -0,0 +1,145 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Target is where scenarios are run: a Kafka cluster or a MemoryBroker.
+type Target interface {
+	// Addr and Transport configure the kafka.Writer of a scenario; a nil Transport uses
+	// kafka.DefaultTransport.
+	Addr() net.Addr
+	Transport() kafka.RoundTripper
+	// CreateTopic creates topic and returns once it can be written to.
+	CreateTopic(ctx context.Context, topic string, partitions int) error
+	DeleteTopic(ctx context.Context, topic string) error
+	// Consume calls fn with every message of topic until ctx is done. fn may be called from
+	// several goroutines.
+	Consume(ctx context.Context, topic string, fn func(kafka.Message)) error
+	Close() error
+}
+
+// wireSizer is implemented by targets that see the encoded record sets.
+type wireSizer interface {
+	WireBytes(topic string) int64
+}
+
+// KafkaTarget runs scenarios against a Kafka cluster.
+type KafkaTarget struct {
+	brokers     []string
+	replication int
+	client      *kafka.Client
+
+	mu         sync.Mutex
+	partitions map[string]int
+}
+
+// NewKafkaTarget connects to brokers. Topics are created with the given replication factor.
+func NewKafkaTarget(brokers []string, replication int) *KafkaTarget {
+	return &KafkaTarget{
+		brokers:     brokers,
+		replication: replication,
+		client:      &kafka.Client{Addr: kafka.TCP(brokers...), Timeout: 30 * time.Second},
+		partitions:  make(map[string]int),
+	}
+}
+
+func (t *KafkaTarget) Addr() net.Addr                { return kafka.TCP(t.brokers...) }
+func (t *KafkaTarget) Transport() kafka.RoundTripper { return nil }
+
+func (t *KafkaTarget) CreateTopic(ctx context.Context, topic string, partitions int) error {
+	res, err := t.client.CreateTopics(ctx, &kafka.CreateTopicsRequest{
+		Topics: []kafka.TopicConfig{{Topic: topic, NumPartitions: partitions, ReplicationFactor: t.replication}},
+	})
+	if err != nil {
+		return fmt.Errorf("create topic %s: %w", topic, err)
+	}
+	if err := res.Errors[topic]; err != nil {
+		return fmt.Errorf("create topic %s: %w", topic, err)
+	}
+
+	// Wait for every partition to have a leader, or the first writes fail.
+	for {
+		meta, err := t.client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{topic}})
+		if err == nil && len(meta.Topics) == 1 && meta.Topics[0].Error == nil && len(meta.Topics[0].Partitions) == partitions {
+			ready := true
+			for _, p := range meta.Topics[0].Partitions {
+				ready = ready && p.Error == nil && p.Leader.Host != ""
+			}
+			if ready {
+				break
+			}
+		}
+		if err := sleep(ctx, 100*time.Millisecond); err != nil {
+			return fmt.Errorf("wait for topic %s: %w", topic, err)
+		}
+	}
+	t.mu.Lock()
+	t.partitions[topic] = partitions
+	t.mu.Unlock()
+	return nil
+}
+
+func (t *KafkaTarget) DeleteTopic(ctx context.Context, topic string) error {
+	t.mu.Lock()
+	delete(t.partitions, topic)
+	t.mu.Unlock()
+	res, err := t.client.DeleteTopics(ctx, &kafka.DeleteTopicsRequest{Topics: []string{topic}})
+	if err != nil {
+		return fmt.Errorf("delete topic %s: %w", topic, err)
+	}
+	return res.Errors[topic]
+}
+
+// Consume reads every partition of topic from its first offset with one reader each.
+func (t *KafkaTarget) Consume(ctx context.Context, topic string, fn func(kafka.Message)) error {
+	t.mu.Lock()
+	partitions, ok := t.partitions[topic]
+	t.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%s: %w", topic, kafka.UnknownTopicOrPartition)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errs := make(chan error, partitions)
+	for p := range partitions {
+		go func() {
+			r := kafka.NewReader(kafka.ReaderConfig{
+				Brokers:     t.brokers,
+				Topic:       topic,
+				Partition:   p,
+				StartOffset: kafka.FirstOffset,
+				MinBytes:    1,
+				MaxBytes:    10e6,
+				MaxWait:     50 * time.Millisecond,
+			})
+			defer r.Close()
+			for {
+				m, err := r.ReadMessage(ctx)
+				if err != nil {
+					errs <- err
+					return
+				}
+				fn(m)
+			}
+		}()
+	}
+	// The first reader to stop, usually because ctx is done, stops the others.
+	first := <-errs
+	cancel()
+	for range partitions - 1 {
+		<-errs
+	}
+	return first
+}
+
+func (t *KafkaTarget) Close() error {
+	return nil
+}
@@ -0,0 +1,191 @@
+package main
+
+/*
+
+Sweep producer settings against an in-memory broker (no cluster needed):
+
+go run ./cmd/kafkabench -target=memory -compression=none,snappy,zstd -acks=one,all -linger=0,5ms
+
+Against Kafka, writing the comparison to files:
+
+export KAFKA_BROKERS="localhost:9092"
+go run ./cmd/kafkabench -target=kafka -partitions=1,12 -msg-size=256,4KiB -csv=results.csv -md=results.md
+
+*/
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"CaseStudy2_Microservices/bench"
+)
+
+type Config struct {
+	Target       string
+	Brokers      string
+	Replication  int
+	TopicPrefix  string
+	RTT          time.Duration
+	Compression  string
+	Acks         string
+	BatchSize    string
+	BatchBytes   string
+	Linger       string
+	MsgSize      string
+	Partitions   string
+	Options      bench.Options
+	CSVPath      string
+	MarkdownPath string
+}
+
+func loadConfig() Config {
+	cfg := Config{Options: bench.DefaultOptions()}
+	flag.StringVar(&cfg.Target, "target", getEnv("BENCH_TARGET", "memory"), "Where to run: memory or kafka")
+	flag.StringVar(&cfg.Brokers, "brokers", getEnv("KAFKA_BROKERS", "localhost:9092"), "Kafka brokers (comma-separated)")
+	flag.IntVar(&cfg.Replication, "replication", 1, "Replication factor of the benchmark topics")
+	flag.StringVar(&cfg.TopicPrefix, "topic-prefix", getEnv("KAFKA_TOPIC", "bench_highspeed"), "Prefix of the per-scenario topics")
+	flag.DurationVar(&cfg.RTT, "rtt", 0, "Simulated network round trip of the memory target")
+
+	flag.StringVar(&cfg.Compression, "compression", "none,snappy,lz4,zstd", "Codecs to sweep: none, gzip, snappy, lz4, zstd")
+	flag.StringVar(&cfg.Acks, "acks", "one", "Required acks to sweep: none, one, all")
+	flag.StringVar(&cfg.BatchSize, "batch", "1000", "Batch sizes in messages to sweep")
+	flag.StringVar(&cfg.BatchBytes, "batch-bytes", "1MiB", "Batch size limits in bytes to sweep")
+	flag.StringVar(&cfg.Linger, "linger", "5ms", "Linger times (batch timeouts) to sweep")
+	flag.StringVar(&cfg.MsgSize, "msg-size", "256", "Message sizes to sweep (at least 16 bytes)")
+	flag.StringVar(&cfg.Partitions, "partitions", "6", "Partition counts to sweep")
+
+	flag.IntVar(&cfg.Options.Messages, "msg-count", getEnvInt("MSG_COUNT", cfg.Options.Messages), "Messages per scenario")
+	flag.IntVar(&cfg.Options.Producers, "producer-workers", getEnvInt("PRODUCER_WORKERS", cfg.Options.Producers), "Concurrent producer goroutines")
+	flag.BoolVar(&cfg.Options.Async, "async", false, "Use an async writer (produce latency is then measured to the ack)")
+	flag.StringVar(&cfg.Options.Payload, "payload", cfg.Options.Payload, "Payload content: text (compressible) or random")
+	flag.DurationVar(&cfg.Options.DrainTimeout, "drain-timeout", cfg.Options.DrainTimeout, "How long to wait for the consumer after producing")
+	flag.BoolVar(&cfg.Options.KeepTopics, "keep-topics", false, "Do not delete the benchmark topics")
+
+	flag.StringVar(&cfg.CSVPath, "csv", "", "Write the results as CSV to this file")
+	flag.StringVar(&cfg.MarkdownPath, "md", "", "Write the results as a Markdown table to this file")
+	flag.Parse()
+	return cfg
+}
+
+func (cfg Config) sweep() (bench.Sweep, error) {
+	var sw bench.Sweep
+	var err error
+	if sw.Compressions, err = bench.ParseCompressions(cfg.Compression); err != nil {
+		return sw, err
+	}
+	if sw.Acks, err = bench.ParseAcks(cfg.Acks); err != nil {
+		return sw, err
+	}
+	if sw.BatchSizes, err = bench.ParseInts(cfg.BatchSize); err != nil {
+		return sw, err
+	}
+	if sw.BatchBytes, err = bench.ParseSizes(cfg.BatchBytes); err != nil {
+		return sw, err
+	}
+	if sw.Lingers, err = bench.ParseDurations(cfg.Linger); err != nil {
+		return sw, err
+	}
+	sizes, err := bench.ParseSizes(cfg.MsgSize)
+	if err != nil {
+		return sw, err
+	}
+	for _, s := range sizes {
+		sw.MessageSizes = append(sw.MessageSizes, int(s))
+	}
+	if sw.Partitions, err = bench.ParseInts(cfg.Partitions); err != nil {
+		return sw, err
+	}
+	return sw, nil
+}
+
+func main() {
+	cfg := loadConfig()
+	if cfg.Options.Messages <= 0 {
+		log.Fatal("msg-count must be positive")
+	}
+	sw, err := cfg.sweep()
+	if err != nil {
+		log.Fatalf("Invalid sweep: %v", err)
+	}
+	scenarios := sw.Scenarios()
+	for _, s := range scenarios {
+		if err := s.Validate(); err != nil {
+			log.Fatalf("Invalid scenario %s: %v", s, err)
+		}
+	}
+
+	var target bench.Target
+	switch cfg.Target {
+	case "memory":
+		target = bench.NewMemoryBroker(cfg.RTT)
+	case "kafka":
+		target = bench.NewKafkaTarget(strings.Split(cfg.Brokers, ","), cfg.Replication)
+	default:
+		log.Fatalf("Unknown target: %s", cfg.Target)
+	}
+	defer target.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-signalChan
+		cancel()
+	}()
+
+	log.Printf("Running %d scenarios of %d messages against %s", len(scenarios), cfg.Options.Messages, cfg.Target)
+	runner := &bench.Runner{Target: target, Options: cfg.Options, TopicPrefix: cfg.TopicPrefix}
+	results, runErr := runner.Run(ctx, scenarios)
+	if runErr != nil {
+		log.Printf("Benchmark stopped: %v", runErr)
+	}
+
+	// Report whatever finished, even after an interrupted run.
+	if err := bench.WriteMarkdown(os.Stdout, results); err != nil {
+		log.Fatalf("Failed to write table: %v", err)
+	}
+	writeFile(cfg.CSVPath, results, bench.WriteCSV)
+	writeFile(cfg.MarkdownPath, results, bench.WriteMarkdown)
+	if runErr != nil {
+		os.Exit(1)
+	}
+}
+
+func writeFile(path string, results []bench.Result, write func(io.Writer, []bench.Result) error) {
+	if path == "" {
+		return
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		log.Fatalf("Failed to create %s: %v", path, err)
+	}
+	defer f.Close()
+	if err := write(f, results); err != nil {
+		log.Fatalf("Failed to write %s: %v", path, err)
+	}
+	log.Printf("Wrote %s", path)
+}
+
+func getEnv(key, fallback string) string {
+	if value, exists := os.LookupEnv(key); exists {
+		return value
+	}
+	return fallback
+}
+
+func getEnvInt(key string, fallback int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return fallback
+}
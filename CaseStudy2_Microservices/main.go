@@ -1,7 +1,12 @@
+//go:build ignore
+
 package main
 
 /*
 
+This file and optimisedcode.go are standalone programs; run them by file name. To compare
+compression codecs, acks, batching, linger, message sizes and partition counts, use the sweep
+harness in cmd/kafkabench instead (it also runs without a cluster: -target=memory).
 
 export KAFKA_BROKERS="localhost:9092"
 go run main.go -mode=producer
@@ -1,3 +1,5 @@
+//go:build ignore
+
 package main
 
 import (
@@ -103,13 +105,13 @@ func runProducer(ctx context.Context, cfg Config) {
 	log.Println("Starting optimized producer")
 
 	writer := kafka.NewWriter(kafka.WriterConfig{
-		Brokers:      cfg.Brokers,
-		Topic:        cfg.Topic,
-		BatchSize:    cfg.BatchSize,
-		BatchTimeout: 5 * time.Millisecond,
-		Async:        true,
-		RequiredAcks: kafka.RequireOne,
-		Compression:  kafka.Snappy,
+		Brokers:          cfg.Brokers,
+		Topic:            cfg.Topic,
+		BatchSize:        cfg.BatchSize,
+		BatchTimeout:     5 * time.Millisecond,
+		Async:            true,
+		RequiredAcks:     int(kafka.RequireOne),
+		CompressionCodec: kafka.Snappy.Codec(),
 	})
 	defer writer.Close()
 
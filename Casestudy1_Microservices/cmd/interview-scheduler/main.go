@@ -2,63 +2,64 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"log/slog"
-	"math/rand"
 	"net/http"
 	"os"
 	"os/signal"
-	"sync"
 	"syscall"
 	"time"
 
 	"casestudy1_microservices/internal/events"
-	"casestudy1_microservices/internal/infra/kafka"
-	"casestudy1_microservices/internal/infra/nats"
+	"casestudy1_microservices/internal/idempotency"
+	"casestudy1_microservices/internal/infra"
+	"casestudy1_microservices/internal/interview"
 )
 
-const (
-	kafkaTopic          = "shortlisted_candidates"
-	natsSubject         = "notifications.interview"
-	defaultKafkaBrokers = "localhost:9092"
-	natsDefaultURL      = "nats://localhost:4222"
-)
-
-type InterviewScheduler struct {
-	interviews               sync.Map
-	kafkaConsumer            *kafka.Consumer
-	natsPublisher            *nats.Publisher
-	totalCandidatesProcessed int64
-	totalInterviewsScheduled int64
-}
-
 func main() {
 	// Load configuration
-	kafkaBrokers := os.Getenv("KAFKA_BROKERS")
-	if kafkaBrokers == "" {
-		kafkaBrokers = defaultKafkaBrokers
+	dbPath := os.Getenv("INTERVIEW_DB")
+	if dbPath == "" {
+		dbPath = "interviews.db"
 	}
-	natsURL := os.Getenv("NATS_URL")
-	if natsURL == "" {
-		natsURL = natsDefaultURL
+	allocator, closeBookings, err := interview.Open(dbPath, os.Getenv("INTERVIEWERS_FILE"))
+	if err != nil {
+		slog.Error("Failed to set up interview allocation", slog.Any("error", err))
+		os.Exit(1)
 	}
+	defer closeBookings()
 
-	// Initialize Kafka consumer
-	consumer := kafka.NewConsumer([]string{kafkaBrokers}, kafkaTopic, "interview-scheduler")
-	defer consumer.Close()
+	// Remembers the shortlisted candidates already handled, so that a repeated event is not notified twice.
+	idempotencyConfig := idempotency.ConfigFromEnv()
+	seen, err := idempotency.Open(idempotencyConfig)
+	if err != nil {
+		slog.Error("Failed to open idempotency store", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer seen.Close()
+	slog.Info("Opened idempotency store", slog.String("driver", idempotencyConfig.Driver), slog.Duration("ttl", idempotencyConfig.TTL))
 
-	// Initialize NATS publisher
-	natsPublisher, err := nats.NewPublisher(natsURL)
+	eventTransport, err := infra.Open(infra.ConfigFromEnv("EVENT_BROKER", infra.DriverKafka))
 	if err != nil {
-		slog.Error("Failed to connect to NATS", slog.Any("error", err))
+		slog.Error("Failed to open event broker", slog.Any("error", err))
 		os.Exit(1)
 	}
-	defer natsPublisher.Close()
+	defer eventTransport.Close()
 
-	scheduler := &InterviewScheduler{
-		kafkaConsumer: consumer,
-		natsPublisher: natsPublisher,
+	notifyTransport, err := infra.Open(infra.ConfigFromEnv("NOTIFY_BROKER", infra.DriverNATS))
+	if err != nil {
+		slog.Error("Failed to open notification broker", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer notifyTransport.Close()
+
+	consumer, err := eventTransport.Subscribe(events.TopicShortlistedCandidates, interview.ConsumerGroup)
+	if err != nil {
+		slog.Error("Failed to subscribe", slog.String("topic", events.TopicShortlistedCandidates), slog.Any("error", err))
+		os.Exit(1)
 	}
+	defer consumer.Close()
+
+	scheduler := interview.NewScheduler(consumer, notifyTransport, allocator, seen)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -73,10 +74,11 @@ func main() {
 	}()
 
 	// Start HTTP server
-	http.HandleFunc("/interviews/", scheduler.handleGetInterview)
+	mux := http.NewServeMux()
+	scheduler.Register(mux)
 	server := &http.Server{
 		Addr:    ":8081",
-		Handler: nil,
+		Handler: mux,
 	}
 	go func() {
 		slog.Info("Starting HTTP server", slog.String("address", ":8081"))
@@ -86,81 +88,13 @@ func main() {
 		}
 	}()
 
-	// Start Kafka consumer loop
-	scheduler.runConsumerLoop(ctx)
+	// Start consumer loop
+	scheduler.Run(ctx)
 
 	// Shutdown HTTP server
-	if err := server.Shutdown(ctx); err != nil {
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
 		slog.Error("HTTP server shutdown failed", slog.Any("error", err))
 	}
 }
-
-func (s *InterviewScheduler) runConsumerLoop(ctx context.Context) {
-	slog.Info("Starting Kafka consumer loop")
-	if err := s.kafkaConsumer.Consume(ctx, func(key, value []byte) error {
-		var candidate events.ShortlistedCandidate
-		if err := json.Unmarshal(value, &candidate); err != nil {
-			slog.Error("Failed to deserialize message", slog.String("key", string(key)), slog.Any("error", err))
-			return nil // Skip invalid messages
-		}
-		s.processCandidate(ctx, candidate)
-		return nil
-	}); err != nil {
-		slog.Error("Kafka consumer error", slog.Any("error", err))
-	}
-}
-
-func (s *InterviewScheduler) processCandidate(ctx context.Context, candidate events.ShortlistedCandidate) {
-	// Simulate assigning an interview slot
-	slot := time.Now().Add(time.Duration(rand.Intn(7)+1) * 24 * time.Hour)
-	s.interviews.Store(candidate.StudentID, slot)
-
-	// Increment metrics
-	s.totalCandidatesProcessed++
-	s.totalInterviewsScheduled++
-
-	// Publish InterviewScheduled event to NATS
-	event := events.InterviewScheduled{
-		StudentID:     candidate.StudentID,
-		Name:          candidate.Name,
-		InterviewSlot: slot.Format(time.RFC3339),
-	}
-	data, err := json.Marshal(event)
-	if err != nil {
-		slog.Error("Failed to serialize InterviewScheduled event", slog.String("student_id", candidate.StudentID), slog.Any("error", err))
-		return
-	}
-	if err := s.natsPublisher.Publish(natsSubject, data); err != nil {
-		slog.Error("Failed to publish InterviewScheduled event to NATS", slog.String("student_id", candidate.StudentID), slog.Any("error", err))
-		return
-	}
-
-	slog.Info("Interview scheduled", slog.String("student_id", candidate.StudentID), slog.String("slot", slot.Format(time.RFC3339)))
-}
-
-func (s *InterviewScheduler) handleGetInterview(w http.ResponseWriter, r *http.Request) {
-	studentID := r.URL.Path[len("/interviews/"):]
-	if studentID == "" {
-		http.Error(w, "student_id is required", http.StatusBadRequest)
-		return
-	}
-
-	value, ok := s.interviews.Load(studentID)
-	if !ok {
-		http.Error(w, "Interview not found", http.StatusNotFound)
-		return
-	}
-
-	slot, ok := value.(time.Time)
-	if !ok {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	response := map[string]string{
-		"student_id": studentID,
-		"slot":       slot.Format(time.RFC3339),
-	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
@@ -0,0 +1,128 @@
+// Command pipeline runs the resume collector, the shortlisting service and the interview scheduler
+// in one process. Both brokers default to the in-memory bus, so it needs no Kafka or NATS.
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"casestudy1_microservices/internal/idempotency"
+	"casestudy1_microservices/internal/infra"
+	"casestudy1_microservices/internal/interview"
+	"casestudy1_microservices/internal/outbox"
+	"casestudy1_microservices/internal/pipeline"
+	"casestudy1_microservices/internal/resume"
+	"casestudy1_microservices/internal/shortlist"
+)
+
+func main() {
+	// Load configuration
+	addr := os.Getenv("HTTP_ADDR")
+	if addr == "" {
+		addr = ":8080"
+	}
+	workerCount := shortlist.DefaultWorkerCount
+	if wc := os.Getenv("WORKER_COUNT"); wc != "" {
+		if n, err := strconv.Atoi(wc); err == nil {
+			workerCount = n
+		}
+	}
+	policies, err := loadPolicies(os.Getenv("POLICY_DIR"))
+	if err != nil {
+		slog.Error("Failed to load shortlisting policies", slog.Any("error", err))
+		os.Exit(1)
+	}
+	slog.Info("Loaded shortlisting policies", slog.Any("job_ids", policies.JobIDs()))
+	allocator, closeBookings, err := interview.Open(os.Getenv("INTERVIEW_DB"), os.Getenv("INTERVIEWERS_FILE"))
+	if err != nil {
+		slog.Error("Failed to set up interview allocation", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer closeBookings()
+	resumes, err := resume.ConfigFromEnv("")
+	if err != nil {
+		slog.Error("Failed to set up resume storage", slog.Any("error", err))
+		os.Exit(1)
+	}
+	seen, err := idempotency.Open(idempotency.ConfigFromEnv())
+	if err != nil {
+		slog.Error("Failed to open idempotency store", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer seen.Close()
+	store, err := outbox.Open(outbox.ConfigFromEnv(outbox.DriverNone))
+	if err != nil {
+		slog.Error("Failed to open outbox", slog.Any("error", err))
+		os.Exit(1)
+	}
+	if store != nil {
+		defer store.Close()
+	}
+
+	eventTransport, err := infra.Open(infra.ConfigFromEnv("EVENT_BROKER", infra.DriverMemory))
+	if err != nil {
+		slog.Error("Failed to open event broker", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer eventTransport.Close()
+
+	notifyTransport, err := infra.Open(infra.ConfigFromEnv("NOTIFY_BROKER", infra.DriverMemory))
+	if err != nil {
+		slog.Error("Failed to open notification broker", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer notifyTransport.Close()
+
+	p, err := pipeline.New(eventTransport, notifyTransport, pipeline.Config{Workers: workerCount, Policies: policies, Allocator: allocator, Idempotency: seen, Outbox: store, Resumes: resumes})
+	if err != nil {
+		slog.Error("Failed to start pipeline", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer p.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		slog.Info("Shutting down gracefully")
+		cancel()
+	}()
+
+	server := &http.Server{
+		Addr:    addr,
+		Handler: p.Handler(),
+	}
+	go func() {
+		slog.Info("Starting pipeline", slog.String("address", addr))
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("HTTP server failed", slog.Any("error", err))
+			os.Exit(1)
+		}
+	}()
+
+	p.Run(ctx)
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("HTTP server shutdown failed", slog.Any("error", err))
+	}
+}
+
+// loadPolicies reads the policies in dir, or returns just the default policy when dir is empty.
+func loadPolicies(dir string) (*shortlist.Policies, error) {
+	if dir == "" {
+		return shortlist.NewPolicies()
+	}
+	return shortlist.LoadPolicies(dir)
+}
@@ -2,8 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
 	"log/slog"
 	"net/http"
 	"os"
@@ -11,88 +9,31 @@ import (
 	"syscall"
 	"time"
 
-	"casestudy1_microservices/internal/events"
-	"casestudy1_microservices/internal/infra/kafka"
+	"casestudy1_microservices/internal/infra"
+	"casestudy1_microservices/internal/resume"
 )
 
-// ResumeRequest represents the incoming HTTP request body.
-type ResumeRequest struct {
-	StudentID string   `json:"student_id"`
-	Name      string   `json:"name"`
-	CGPA      float64  `json:"cgpa"`
-	Branch    string   `json:"branch"`
-	Skills    []string `json:"skills"`
-}
-
 func main() {
-	// Load Kafka configuration
-	kafkaBrokers := os.Getenv("KAFKA_BROKERS")
-	if kafkaBrokers == "" {
-		slog.Error("KAFKA_BROKERS environment variable is required")
+	// Load broker configuration
+	transport, err := infra.Open(infra.ConfigFromEnv("EVENT_BROKER", infra.DriverKafka))
+	if err != nil {
+		slog.Error("Failed to open event broker", slog.Any("error", err))
 		os.Exit(1)
 	}
-	topic := "resume_uploaded"
+	defer transport.Close()
 
-	// Create Kafka producer
-	producer := kafka.NewProducer([]string{kafkaBrokers}, topic)
-	defer producer.Close()
+	resumes, err := resume.ConfigFromEnv("resumes")
+	if err != nil {
+		slog.Error("Failed to set up resume storage", slog.Any("error", err))
+		os.Exit(1)
+	}
 
 	// HTTP server setup
-	http.HandleFunc("/resumes", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
-			return
-		}
-
-		var req ResumeRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid JSON", http.StatusBadRequest)
-			return
-		}
-
-		// Validate input
-		if err := validateResumeRequest(req); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-
-		// Publish event to Kafka
-		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-		defer cancel()
-
-		event := events.ResumeUploaded{
-			StudentID: req.StudentID,
-			Name:      req.Name,
-			CGPA:      req.CGPA,
-			Branch:    req.Branch,
-			Skills:    req.Skills,
-		}
-		data, err := json.Marshal(event)
-		if err != nil {
-			slog.Error("Failed to serialize event", slog.String("student_id", req.StudentID), slog.Float64("cgpa", req.CGPA), slog.Any("error", err))
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
-		}
-		if err := producer.Publish(ctx, req.StudentID, data); err != nil {
-			slog.Error("Failed to publish event", slog.String("student_id", req.StudentID), slog.Float64("cgpa", req.CGPA), slog.Any("error", err))
-			http.Error(w, "Failed to queue resume", http.StatusInternalServerError)
-			return
-		}
-
-		// TODO: Add DB storage for the resume here.
-
-		response := map[string]string{
-			"status":     "queued",
-			"student_id": req.StudentID,
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusAccepted)
-		json.NewEncoder(w).Encode(response)
-	})
-
+	mux := http.NewServeMux()
+	resume.NewCollector(transport, resumes).Register(mux)
 	server := &http.Server{
 		Addr:    ":8080",
-		Handler: nil,
+		Handler: mux,
 	}
 
 	// Graceful shutdown
@@ -115,19 +56,3 @@ func main() {
 		slog.Error("Server shutdown failed", slog.Any("error", err))
 	}
 }
-
-func validateResumeRequest(req ResumeRequest) error {
-	if req.StudentID == "" {
-		return errors.New("student_id is required")
-	}
-	if req.Name == "" {
-		return errors.New("name is required")
-	}
-	if req.CGPA <= 0 {
-		return errors.New("cgpa must be greater than 0")
-	}
-	if len(req.Skills) == 0 {
-		return errors.New("at least one skill is required")
-	}
-	return nil
-}
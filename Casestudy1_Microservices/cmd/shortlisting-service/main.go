@@ -2,76 +2,81 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
-	"sync"
 	"syscall"
+	"time"
 
 	"casestudy1_microservices/internal/events"
-	"casestudy1_microservices/internal/infra/kafka"
-	"casestudy1_microservices/internal/infra/nats"
+	"casestudy1_microservices/internal/idempotency"
+	"casestudy1_microservices/internal/infra"
+	"casestudy1_microservices/internal/outbox"
+	"casestudy1_microservices/internal/shortlist"
 )
 
-const (
-	defaultWorkerCount = 5
-	defaultKafkaTopic  = "resume_uploaded"
-	shortlistedTopic   = "shortlisted_candidates"
-	natsSubject        = "notifications.shortlist"
-)
-
-type ShortlistingService struct {
-	kafkaConsumer *kafka.Consumer
-	kafkaProducer *kafka.Producer
-	natsPublisher *nats.Publisher
-	cache         sync.Map
-}
-
-// Define a local Message struct to replace kafka.Message
-type Message struct {
-	Key   []byte
-	Value []byte
-}
-
 func main() {
 	// Load configuration
-	kafkaBrokers := os.Getenv("KAFKA_BROKERS")
-	if kafkaBrokers == "" {
-		slog.Error("KAFKA_BROKERS environment variable is required")
+	workerCount := shortlist.DefaultWorkerCount
+	if wc := os.Getenv("WORKER_COUNT"); wc != "" {
+		if n, err := strconv.Atoi(wc); err == nil {
+			workerCount = n
+		}
+	}
+	policies, err := loadPolicies(os.Getenv("POLICY_DIR"))
+	if err != nil {
+		slog.Error("Failed to load shortlisting policies", slog.Any("error", err))
 		os.Exit(1)
 	}
-	natsURL := os.Getenv("NATS_URL")
-	if natsURL == "" {
-		slog.Error("NATS_URL environment variable is required")
+	slog.Info("Loaded shortlisting policies", slog.Any("job_ids", policies.JobIDs()))
+	outboxConfig := outbox.ConfigFromEnv(outbox.DriverSQLite)
+	store, err := outbox.Open(outboxConfig)
+	if err != nil {
+		slog.Error("Failed to open outbox", slog.Any("error", err))
 		os.Exit(1)
 	}
-	workerCount := defaultWorkerCount
-	if wc := os.Getenv("WORKER_COUNT"); wc != "" {
-		if n, err := strconv.Atoi(wc); err == nil {
-			workerCount = n
+	var seen idempotency.Store
+	if store != nil {
+		defer store.Close()
+		slog.Info("Opened outbox", slog.String("driver", outboxConfig.Driver), slog.Duration("ttl", outboxConfig.TTL))
+	} else {
+		// Without an outbox, decisions are published directly and duplicates are tracked separately.
+		idempotencyConfig := idempotency.ConfigFromEnv()
+		seen, err = idempotency.Open(idempotencyConfig)
+		if err != nil {
+			slog.Error("Failed to open idempotency store", slog.Any("error", err))
+			os.Exit(1)
 		}
+		defer seen.Close()
+		slog.Info("Opened idempotency store", slog.String("driver", idempotencyConfig.Driver), slog.Duration("ttl", idempotencyConfig.TTL))
 	}
 
-	// Initialize Kafka consumer and producer
-	consumer := kafka.NewConsumer([]string{kafkaBrokers}, defaultKafkaTopic, "shortlisting-service")
-	defer consumer.Close()
-	producer := kafka.NewProducer([]string{kafkaBrokers}, shortlistedTopic)
-	defer producer.Close()
+	eventTransport, err := infra.Open(infra.ConfigFromEnv("EVENT_BROKER", infra.DriverKafka))
+	if err != nil {
+		slog.Error("Failed to open event broker", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer eventTransport.Close()
+
+	notifyTransport, err := infra.Open(infra.ConfigFromEnv("NOTIFY_BROKER", infra.DriverNATS))
+	if err != nil {
+		slog.Error("Failed to open notification broker", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer notifyTransport.Close()
 
-	// Initialize NATS publisher
-	natsPublisher, err := nats.NewPublisher(natsURL)
+	consumer, err := eventTransport.Subscribe(events.TopicResumeUploaded, shortlist.ConsumerGroup)
 	if err != nil {
-		slog.Error("Failed to connect to NATS", slog.Any("error", err))
+		slog.Error("Failed to subscribe", slog.String("topic", events.TopicResumeUploaded), slog.Any("error", err))
 		os.Exit(1)
 	}
-	defer natsPublisher.Close()
+	defer consumer.Close()
 
-	service := &ShortlistingService{
-		kafkaConsumer: consumer,
-		kafkaProducer: producer,
-		natsPublisher: natsPublisher,
+	service := shortlist.NewService(consumer, eventTransport, notifyTransport, policies, seen, workerCount)
+	if store != nil {
+		service = shortlist.NewOutboxService(consumer, eventTransport, notifyTransport, policies, store, workerCount)
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -86,103 +91,39 @@ func main() {
 		cancel()
 	}()
 
-	// Start worker pool
-	service.runWorkerPool(ctx, workerCount)
-}
-
-func (s *ShortlistingService) runWorkerPool(ctx context.Context, workerCount int) {
-	eventsChan := make(chan Message, workerCount*2)
-	var wg sync.WaitGroup
-
-	// Start Kafka consumer loop
-	wg.Add(1)
+	// Start the dry-run HTTP server
+	addr := os.Getenv("HTTP_ADDR")
+	if addr == "" {
+		addr = ":8082"
+	}
+	mux := http.NewServeMux()
+	service.Register(mux)
+	server := &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
 	go func() {
-		defer wg.Done()
-		slog.Info("Starting Kafka consumer loop")
-		if err := s.kafkaConsumer.Consume(ctx, func(key, value []byte) error {
-			select {
-			case eventsChan <- Message{Key: key, Value: value}:
-			case <-ctx.Done():
-				return ctx.Err()
-			}
-			return nil
-		}); err != nil {
-			slog.Error("Kafka consumer error", slog.Any("error", err))
+		slog.Info("Starting HTTP server", slog.String("address", addr))
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("HTTP server failed", slog.Any("error", err))
+			os.Exit(1)
 		}
 	}()
 
-	// Start worker goroutines
-	for i := 0; i < workerCount; i++ {
-		wg.Add(1)
-		go func(workerID int) {
-			defer wg.Done()
-			slog.Info("Worker started", slog.Int("worker_id", workerID))
-			for {
-				select {
-				case msg := <-eventsChan:
-					s.processEvent(ctx, msg)
-				case <-ctx.Done():
-					slog.Info("Worker shutting down", slog.Int("worker_id", workerID))
-					return
-				}
-			}
-		}(i)
-	}
-
-	// Wait for all workers to finish
-	wg.Wait()
-	slog.Info("All workers have shut down")
-}
-
-func (s *ShortlistingService) processEvent(ctx context.Context, msg Message) {
-	// Check idempotency
-	if _, exists := s.cache.LoadOrStore(string(msg.Key), true); exists {
-		slog.Info("Duplicate event detected, skipping", slog.String("key", string(msg.Key)))
-		return
-	}
-
-	var resume events.ResumeUploaded
-	if err := json.Unmarshal(msg.Value, &resume); err != nil {
-		slog.Error("Failed to deserialize message", slog.String("key", string(msg.Key)), slog.Any("error", err))
-		return
-	}
-
-	// Apply shortlisting rules
-	shortlisted := resume.CGPA >= 8.0 || contains(resume.Skills, "golang") || contains(resume.Skills, "distributed systems")
-	if shortlisted {
-		slog.Info("Candidate shortlisted", slog.String("student_id", resume.StudentID), slog.Float64("cgpa", resume.CGPA))
-
-		// Publish to Kafka
-		shortlistedEvent := events.ShortlistedCandidate{
-			StudentID: resume.StudentID,
-			Name:      resume.Name,
-			CGPA:      resume.CGPA,
-			Branch:    resume.Branch,
-		}
-		data, err := json.Marshal(shortlistedEvent)
-		if err != nil {
-			slog.Error("Failed to serialize shortlisted event", slog.String("student_id", resume.StudentID), slog.Any("error", err))
-			return
-		}
-		if err := s.kafkaProducer.Publish(ctx, resume.StudentID, data); err != nil {
-			slog.Error("Failed to publish shortlisted event", slog.String("student_id", resume.StudentID), slog.Any("error", err))
-			return
-		}
+	// Start worker pool
+	service.Run(ctx)
 
-		// Publish to NATS
-		if err := s.natsPublisher.Publish(natsSubject, data); err != nil {
-			slog.Error("Failed to publish NATS notification", slog.String("student_id", resume.StudentID), slog.Any("error", err))
-		}
-	} else {
-		slog.Info("Candidate rejected", slog.String("student_id", resume.StudentID), slog.Float64("cgpa", resume.CGPA))
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("HTTP server shutdown failed", slog.Any("error", err))
 	}
 }
 
-func contains(slice []string, item string) bool {
-	for _, s := range slice {
-		if s == item {
-			return true
-		}
+// loadPolicies reads the policies in dir, or returns just the default policy when dir is empty.
+func loadPolicies(dir string) (*shortlist.Policies, error) {
+	if dir == "" {
+		return shortlist.NewPolicies()
 	}
-	return false
+	return shortlist.LoadPolicies(dir)
 }
@@ -0,0 +1,77 @@
+// Package broker defines the messaging interfaces the pipeline services depend on. Kafka, NATS and
+// an in-process bus implement them (see internal/infra), so a service's wiring decides where its
+// messages go and the services themselves do not change.
+package broker
+
+import (
+	"context"
+	"errors"
+)
+
+// Message is one record on a topic (a Kafka topic or a NATS subject).
+type Message struct {
+	Topic   string
+	Key     []byte
+	Value   []byte
+	Headers map[string]string
+}
+
+// HeaderMessageID identifies a message across redeliveries: a producer that may publish a message
+// more than once sets the same ID on every copy, so consumers can drop the repeats (see
+// idempotency.Deduplicate). Messages without it are treated as distinct.
+const HeaderMessageID = "x-message-id"
+
+// Publisher sends messages to the topic named in each message.
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// Handler processes one consumed message. A returned error means the message was not handled;
+// transports that support it retry the message and then dead-letter it, the others log the error.
+// Wrap errors that retrying cannot fix, such as undecodable payloads, with Permanent.
+type Handler func(ctx context.Context, msg Message) error
+
+// Consumer delivers the messages of one topic to a handler. Consumers sharing a group split the
+// messages between them; every group receives every message.
+type Consumer interface {
+	// Consume blocks, calling handler for each message, until ctx is cancelled (it then returns nil)
+	// or the transport fails. It may be called from several goroutines to handle messages in
+	// parallel.
+	Consume(ctx context.Context, handler Handler) error
+	Close() error
+}
+
+// Transport is a connection to one messaging system.
+type Transport interface {
+	Publisher
+	// Subscribe joins group on topic. Messages published after Subscribe returns are delivered even
+	// if Consume has not been called yet.
+	Subscribe(topic, group string) (Consumer, error)
+	Close() error
+}
+
+// ErrClosed is returned when publishing to or subscribing on a closed transport.
+var ErrClosed = errors.New("broker: transport closed")
+
+// PermanentError marks a handler error that retrying will not fix.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// Permanent wraps err so that consumers skip retries and dead-letter the message at once.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// IsPermanent reports whether err was wrapped with Permanent.
+func IsPermanent(err error) bool {
+	var p *PermanentError
+	return errors.As(err, &p)
+}
@@ -7,19 +7,66 @@ type ResumeUploaded struct {
 	CGPA      float64  `json:"cgpa"`
 	Branch    string   `json:"branch"`
 	Skills    []string `json:"skills"`
+	// JobID names the job opening applied for; empty applies the default shortlisting policy.
+	JobID string `json:"job_id,omitempty"`
+	// ResumeID identifies the stored submission (GET /resumes/{id} on the collector).
+	ResumeID string `json:"resume_id,omitempty"`
+	// Confidence is "high", "medium" or "low" for fields parsed from an uploaded document, and empty
+	// when the candidate entered them.
+	Confidence string `json:"confidence,omitempty"`
 }
 
 // ShortlistedCandidate represents the event for a shortlisted candidate.
 type ShortlistedCandidate struct {
-	StudentID string  `json:"student_id"`
-	Name      string  `json:"name"`
-	CGPA      float64 `json:"cgpa"`
-	Branch    string  `json:"branch"`
+	StudentID string   `json:"student_id"`
+	Name      string   `json:"name"`
+	CGPA      float64  `json:"cgpa"`
+	Branch    string   `json:"branch"`
+	JobID     string   `json:"job_id,omitempty"`
+	Score     float64  `json:"score"`
+	Reasons   []string `json:"reasons,omitempty"`
 }
 
-// InterviewScheduled represents the event for an interview schedule.
+// CandidateRejected represents the event for a candidate the shortlisting policy turned down.
+type CandidateRejected struct {
+	StudentID string   `json:"student_id"`
+	Name      string   `json:"name"`
+	JobID     string   `json:"job_id,omitempty"`
+	Score     float64  `json:"score"`
+	Reasons   []string `json:"reasons"`
+}
+
+// InterviewScheduled represents the event for an interview schedule. It is sent again, with
+// Rescheduled set, when the interview moves.
 type InterviewScheduled struct {
+	StudentID       string `json:"student_id"`
+	Name            string `json:"name"`
+	InterviewSlot   string `json:"interview_slot"`
+	InterviewEnd    string `json:"interview_end,omitempty"`
+	BookingID       string `json:"booking_id,omitempty"`
+	JobID           string `json:"job_id,omitempty"`
+	InterviewerID   string `json:"interviewer_id,omitempty"`
+	InterviewerName string `json:"interviewer_name,omitempty"`
+	Rescheduled     bool   `json:"rescheduled,omitempty"`
+}
+
+// InterviewCancelled represents the event for a cancelled interview.
+type InterviewCancelled struct {
 	StudentID     string `json:"student_id"`
 	Name          string `json:"name"`
+	BookingID     string `json:"booking_id"`
+	JobID         string `json:"job_id,omitempty"`
 	InterviewSlot string `json:"interview_slot"`
+	Reason        string `json:"reason,omitempty"`
 }
+
+// Topics and subjects the pipeline services exchange events on.
+const (
+	TopicResumeUploaded        = "resume_uploaded"
+	TopicShortlistedCandidates = "shortlisted_candidates"
+	TopicRejectedCandidates    = "rejected_candidates"
+	SubjectShortlistNotify     = "notifications.shortlist"
+	SubjectRejectionNotify     = "notifications.rejection"
+	SubjectInterviewNotify     = "notifications.interview"
+	SubjectInterviewCancelled  = "notifications.interview.cancelled"
+)
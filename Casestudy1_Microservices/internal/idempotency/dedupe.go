@@ -0,0 +1,43 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"casestudy1_microservices/internal/broker"
+)
+
+// Deduplicate wraps next so that a message whose broker.HeaderMessageID was already handled within
+// the store's TTL is acknowledged without calling next again. The ID is claimed while next runs and
+// released if it fails, so a retry is not mistaken for a duplicate; a copy arriving meanwhile is
+// returned ErrInProgress and retried. Messages without an ID are passed through.
+func Deduplicate(seen Store, next broker.Handler) broker.Handler {
+	return func(ctx context.Context, msg broker.Message) (err error) {
+		id := msg.Headers[broker.HeaderMessageID]
+		if id == "" {
+			return next(ctx, msg)
+		}
+		key := "msg:" + msg.Topic + ":" + id
+		acquired, err := seen.Acquire(ctx, key)
+		if err != nil {
+			return err
+		}
+		if !acquired {
+			slog.Info("Duplicate message dropped", slog.String("topic", msg.Topic), slog.String("message_id", id))
+			return nil
+		}
+		defer func() {
+			ctx := context.WithoutCancel(ctx)
+			if err != nil {
+				err = errors.Join(err, seen.Release(ctx, key))
+				return
+			}
+			// next has run, so a failure here only risks handling a later copy again.
+			if cerr := seen.Complete(ctx, key); cerr != nil {
+				slog.Error("Failed to record handled message", slog.String("message_id", id), slog.Any("error", cerr))
+			}
+		}()
+		return next(ctx, msg)
+	}
+}
@@ -0,0 +1,66 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"casestudy1_microservices/internal/broker"
+)
+
+func TestDeduplicate(t *testing.T) {
+	ctx := context.Background()
+	seen := NewMemoryStore(0, testTTL, testLease)
+	calls := 0
+	fail := false
+	handler := Deduplicate(seen, func(context.Context, broker.Message) error {
+		calls++
+		if fail {
+			return errors.New("downstream unavailable")
+		}
+		return nil
+	})
+	msg := func(topic, id string) broker.Message {
+		m := broker.Message{Topic: topic, Value: []byte("v")}
+		if id != "" {
+			m.Headers = map[string]string{broker.HeaderMessageID: id}
+		}
+		return m
+	}
+
+	steps := []struct {
+		name      string
+		msg       broker.Message
+		fail      bool
+		wantErr   bool
+		wantCalls int
+	}{
+		{name: "first copy", msg: msg("a", "k/0"), wantCalls: 1},
+		{name: "repeat is dropped", msg: msg("a", "k/0"), wantCalls: 1},
+		{name: "same ID on another topic", msg: msg("b", "k/0"), wantCalls: 2},
+		{name: "failure", msg: msg("a", "k/1"), fail: true, wantErr: true, wantCalls: 3},
+		{name: "retry after failure", msg: msg("a", "k/1"), wantCalls: 4},
+		{name: "no ID", msg: msg("a", ""), wantCalls: 5},
+		{name: "no ID again", msg: msg("a", ""), wantCalls: 6},
+	}
+	for _, step := range steps {
+		fail = step.fail
+		err := handler(ctx, step.msg)
+		if (err != nil) != step.wantErr {
+			t.Fatalf("%s: err = %v, want error %v", step.name, err, step.wantErr)
+		}
+		if calls != step.wantCalls {
+			t.Fatalf("%s: handler called %d times, want %d", step.name, calls, step.wantCalls)
+		}
+	}
+}
+
+func TestDeduplicateKeepsPermanentErrors(t *testing.T) {
+	handler := Deduplicate(NewMemoryStore(0, testTTL, testLease), func(context.Context, broker.Message) error {
+		return broker.Permanent(errors.New("undecodable"))
+	})
+	err := handler(context.Background(), broker.Message{Topic: "a", Headers: map[string]string{broker.HeaderMessageID: "k/0"}})
+	if !broker.IsPermanent(err) {
+		t.Fatalf("err = %v, want it still marked permanent", err)
+	}
+}
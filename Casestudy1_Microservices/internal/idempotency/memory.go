@@ -0,0 +1,116 @@
+package idempotency
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps keys in process memory. Beyond its capacity the least recently used keys are
+// evicted, even before their TTL, so it bounds memory at the cost of missing old duplicates. Keys
+// are lost on restart.
+type MemoryStore struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	lease    time.Duration
+	entries  map[string]*list.Element
+	order    *list.List // of *memoryEntry, most recently used first
+	now      func() time.Time
+}
+
+type memoryEntry struct {
+	key     string
+	done    bool
+	expires time.Time
+}
+
+// NewMemoryStore creates a store holding up to capacity keys (DefaultCapacity when <= 0).
+func NewMemoryStore(capacity int, ttl, lease time.Duration) *MemoryStore {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	return &MemoryStore{
+		capacity: capacity,
+		ttl:      ttl,
+		lease:    lease,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+func (s *MemoryStore) Acquire(_ context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if e := s.lookup(key, now); e != nil {
+		if e.done {
+			return false, nil
+		}
+		return false, ErrInProgress
+	}
+	s.set(key, false, now.Add(s.lease))
+	return true, nil
+}
+
+func (s *MemoryStore) Complete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.set(key, true, s.now().Add(s.ttl))
+	return nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.entries[key]; ok && !el.Value.(*memoryEntry).done {
+		s.remove(el)
+	}
+	return nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// Len returns the number of keys held, including expired ones not yet evicted.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+// lookup returns the live entry for key, marking it recently used, and drops it if it has expired.
+func (s *MemoryStore) lookup(key string, now time.Time) *memoryEntry {
+	el, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	e := el.Value.(*memoryEntry)
+	if !now.Before(e.expires) {
+		s.remove(el)
+		return nil
+	}
+	s.order.MoveToFront(el)
+	return e
+}
+
+func (s *MemoryStore) set(key string, done bool, expires time.Time) {
+	if el, ok := s.entries[key]; ok {
+		e := el.Value.(*memoryEntry)
+		e.done, e.expires = done, expires
+		s.order.MoveToFront(el)
+		return
+	}
+	s.entries[key] = s.order.PushFront(&memoryEntry{key: key, done: done, expires: expires})
+	for s.order.Len() > s.capacity {
+		s.remove(s.order.Back())
+	}
+}
+
+func (s *MemoryStore) remove(el *list.Element) {
+	s.order.Remove(el)
+	delete(s.entries, el.Value.(*memoryEntry).key)
+}
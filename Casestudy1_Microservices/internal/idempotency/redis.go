@@ -0,0 +1,71 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisDone is the value of a completed key; a claim is "pending".
+const redisDone = "done"
+
+// acquireScript returns the current state of KEYS[1], or claims it for ARGV[1] milliseconds and
+// returns an empty string when it is absent.
+var acquireScript = redis.NewScript(`
+local state = redis.call("GET", KEYS[1])
+if state then
+	return state
+end
+redis.call("SET", KEYS[1], "pending", "PX", ARGV[1])
+return ""
+`)
+
+// releaseScript deletes KEYS[1] only if it is still a claim, so that a completed key survives.
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == "pending" then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// RedisStore keeps keys in Redis, where they expire on their own and are shared by every instance
+// of the service.
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+	ttl    time.Duration
+	lease  time.Duration
+}
+
+// NewRedisStore creates a store keeping its keys under prefix. Close closes client.
+func NewRedisStore(client redis.UniversalClient, prefix string, ttl, lease time.Duration) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix, ttl: ttl, lease: lease}
+}
+
+func (s *RedisStore) Acquire(ctx context.Context, key string) (bool, error) {
+	state, err := acquireScript.Run(ctx, s.client, []string{s.prefix + key}, s.lease.Milliseconds()).Text()
+	if err != nil {
+		return false, err
+	}
+	switch state {
+	case "":
+		return true, nil
+	case redisDone:
+		return false, nil
+	default:
+		return false, ErrInProgress
+	}
+}
+
+func (s *RedisStore) Complete(ctx context.Context, key string) error {
+	return s.client.Set(ctx, s.prefix+key, redisDone, s.ttl).Err()
+}
+
+func (s *RedisStore) Release(ctx context.Context, key string) error {
+	return releaseScript.Run(ctx, s.client, []string{s.prefix + key}).Err()
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,114 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	key        TEXT PRIMARY KEY,
+	done       INTEGER NOT NULL,
+	expires_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires ON idempotency_keys (expires_at);
+`
+
+// purgeInterval is how often expired keys are deleted from the database.
+const purgeInterval = 10 * time.Minute
+
+// SQLiteStore keeps keys in a SQLite database file, so they survive restarts of a single host.
+type SQLiteStore struct {
+	db    *sql.DB
+	ttl   time.Duration
+	lease time.Duration
+	now   func() time.Time
+
+	mu        sync.Mutex
+	lastPurge time.Time
+}
+
+// OpenSQLiteStore opens (creating if needed) the database at path. Transactions take the write lock
+// when they begin, so concurrent claims of one key are serialised even across processes.
+func OpenSQLiteStore(path string, ttl, lease time.Duration) (*SQLiteStore, error) {
+	q := url.Values{}
+	q.Add("_pragma", "busy_timeout(5000)")
+	q.Add("_pragma", "journal_mode(WAL)")
+	q.Set("_txlock", "immediate")
+	db, err := sql.Open("sqlite", "file:"+path+"?"+q.Encode())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create idempotency schema: %w", err)
+	}
+	return &SQLiteStore{db: db, ttl: ttl, lease: lease, now: time.Now}, nil
+}
+
+func (s *SQLiteStore) Acquire(ctx context.Context, key string) (bool, error) {
+	now := s.now()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var done bool
+	var expiresAt int64
+	err = tx.QueryRowContext(ctx, `SELECT done, expires_at FROM idempotency_keys WHERE key = ?`, key).Scan(&done, &expiresAt)
+	switch {
+	case err == nil && expiresAt > now.UnixNano():
+		if done {
+			return false, nil
+		}
+		return false, ErrInProgress
+	case err != nil && !errors.Is(err, sql.ErrNoRows):
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO idempotency_keys (key, done, expires_at) VALUES (?, 0, ?)
+		ON CONFLICT (key) DO UPDATE SET done = 0, expires_at = excluded.expires_at`,
+		key, now.Add(s.lease).UnixNano()); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (s *SQLiteStore) Complete(ctx context.Context, key string) error {
+	now := s.now()
+	if _, err := s.db.ExecContext(ctx, `INSERT INTO idempotency_keys (key, done, expires_at) VALUES (?, 1, ?)
+		ON CONFLICT (key) DO UPDATE SET done = 1, expires_at = excluded.expires_at`,
+		key, now.Add(s.ttl).UnixNano()); err != nil {
+		return err
+	}
+	return s.purge(ctx, now)
+}
+
+func (s *SQLiteStore) Release(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key = ? AND done = 0`, key)
+	return err
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// purge deletes expired keys at most once per purgeInterval.
+func (s *SQLiteStore) purge(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	if now.Sub(s.lastPurge) < purgeInterval {
+		s.mu.Unlock()
+		return nil
+	}
+	s.lastPurge = now
+	s.mu.Unlock()
+	_, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= ?`, now.UnixNano())
+	return err
+}
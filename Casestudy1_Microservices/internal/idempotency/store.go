@@ -0,0 +1,130 @@
+// Package idempotency remembers which messages a consumer has already processed, so that redelivered
+// duplicates can be skipped.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrInProgress is returned by Acquire when another attempt holds the key and its lease has not run
+// out. The message should be retried rather than skipped, in case that attempt fails.
+var ErrInProgress = errors.New("message is being processed by another attempt")
+
+// Store tracks the processing of messages by key. A key is claimed with Acquire, then either
+// completed once the message has been processed or released when processing failed.
+type Store interface {
+	// Acquire claims key for the store's lease. It reports false if key was completed within the
+	// TTL, and returns ErrInProgress if another attempt holds it.
+	Acquire(ctx context.Context, key string) (bool, error)
+	// Complete marks key as processed; Acquire skips it until the TTL has passed.
+	Complete(ctx context.Context, key string) error
+	// Release drops a claim that was not completed, so that the message can be retried.
+	Release(ctx context.Context, key string) error
+	Close() error
+}
+
+// Key identifies a message by its key and a hash of its value: an exact duplicate has the same Key,
+// while a message resubmitted with changed content does not.
+func Key(msgKey, value []byte) string {
+	sum := sha256.Sum256(value)
+	return string(msgKey) + "/" + hex.EncodeToString(sum[:])
+}
+
+// Supported drivers.
+const (
+	DriverMemory = "memory"
+	DriverRedis  = "redis"
+	DriverSQLite = "sqlite"
+)
+
+const (
+	// DefaultTTL is how long a processed message is remembered.
+	DefaultTTL = 24 * time.Hour
+	// DefaultLease is how long a claim blocks other attempts before it is considered abandoned.
+	DefaultLease = time.Minute
+	// DefaultCapacity is the number of keys the memory store keeps.
+	DefaultCapacity = 100_000
+
+	defaultRedisURL   = "redis://localhost:6379/0"
+	defaultSQLitePath = "idempotency.db"
+	redisKeyPrefix    = "idempotency:"
+)
+
+// Config selects and configures a store. Only the settings of the chosen driver are used.
+type Config struct {
+	Driver     string
+	TTL        time.Duration
+	Lease      time.Duration
+	Capacity   int
+	RedisURL   string
+	SQLitePath string
+}
+
+// ConfigFromEnv reads the driver from IDEMPOTENCY_STORE (memory when unset), the TTL from
+// IDEMPOTENCY_TTL, the memory store's size from IDEMPOTENCY_CACHE_SIZE, the Redis server from
+// REDIS_URL and the SQLite file from IDEMPOTENCY_DB.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Driver:     strings.ToLower(getenv("IDEMPOTENCY_STORE", DriverMemory)),
+		TTL:        DefaultTTL,
+		Lease:      DefaultLease,
+		Capacity:   DefaultCapacity,
+		RedisURL:   getenv("REDIS_URL", defaultRedisURL),
+		SQLitePath: getenv("IDEMPOTENCY_DB", defaultSQLitePath),
+	}
+	if d, err := time.ParseDuration(os.Getenv("IDEMPOTENCY_TTL")); err == nil && d > 0 {
+		cfg.TTL = d
+	}
+	if n, err := strconv.Atoi(os.Getenv("IDEMPOTENCY_CACHE_SIZE")); err == nil && n > 0 {
+		cfg.Capacity = n
+	}
+	return cfg
+}
+
+// Open creates the configured store. Zero TTL, Lease and Capacity use the defaults.
+func Open(cfg Config) (Store, error) {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultTTL
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = DefaultLease
+	}
+	switch cfg.Driver {
+	case DriverMemory:
+		return NewMemoryStore(cfg.Capacity, cfg.TTL, cfg.Lease), nil
+	case DriverRedis:
+		opts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			return nil, fmt.Errorf("parse REDIS_URL: %w", err)
+		}
+		client := redis.NewClient(opts)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := client.Ping(ctx).Err(); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("connect to Redis at %s: %w", opts.Addr, err)
+		}
+		return NewRedisStore(client, redisKeyPrefix, cfg.TTL, cfg.Lease), nil
+	case DriverSQLite:
+		return OpenSQLiteStore(cfg.SQLitePath, cfg.TTL, cfg.Lease)
+	default:
+		return nil, fmt.Errorf("unknown idempotency store %q (want %s, %s or %s)", cfg.Driver, DriverMemory, DriverRedis, DriverSQLite)
+	}
+}
+
+func getenv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
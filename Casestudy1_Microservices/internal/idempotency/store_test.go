@@ -0,0 +1,196 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	testTTL   = time.Hour
+	testLease = time.Minute
+)
+
+// clock is a settable time source for the memory and SQLite stores.
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// openStores returns each store implementation with a function that moves its clock forward.
+func openStores() map[string]func(t *testing.T) (Store, func(time.Duration)) {
+	return map[string]func(t *testing.T) (Store, func(time.Duration)){
+		"memory": func(t *testing.T) (Store, func(time.Duration)) {
+			c := &clock{now: time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)}
+			s := NewMemoryStore(0, testTTL, testLease)
+			s.now = c.Now
+			return s, c.Advance
+		},
+		"sqlite": func(t *testing.T) (Store, func(time.Duration)) {
+			c := &clock{now: time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)}
+			s, err := OpenSQLiteStore(filepath.Join(t.TempDir(), "idempotency.db"), testTTL, testLease)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { s.Close() })
+			s.now = c.Now
+			return s, c.Advance
+		},
+		"redis": func(t *testing.T) (Store, func(time.Duration)) {
+			mr := miniredis.RunT(t)
+			s := NewRedisStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "test:", testTTL, testLease)
+			t.Cleanup(func() { s.Close() })
+			return s, mr.FastForward
+		},
+	}
+}
+
+func TestKey(t *testing.T) {
+	resume := []byte(`{"student_id":"S-1","cgpa":8.1}`)
+	if Key([]byte("S-1"), resume) != Key([]byte("S-1"), []byte(string(resume))) {
+		t.Fatal("identical messages have different keys")
+	}
+	if Key([]byte("S-1"), resume) == Key([]byte("S-1"), []byte(`{"student_id":"S-1","cgpa":8.4}`)) {
+		t.Fatal("a changed resume has the same key")
+	}
+	if Key([]byte("S-1"), resume) == Key([]byte("S-2"), resume) {
+		t.Fatal("different message keys have the same key")
+	}
+}
+
+func TestStoreLifecycle(t *testing.T) {
+	ctx := context.Background()
+	for name, open := range openStores() {
+		t.Run(name, func(t *testing.T) {
+			s, advance := open(t)
+			acquire := func(key string, want bool, wantErr error) {
+				t.Helper()
+				got, err := s.Acquire(ctx, key)
+				if got != want || !errors.Is(err, wantErr) {
+					t.Fatalf("Acquire(%s) = %v, %v; want %v, %v", key, got, err, want, wantErr)
+				}
+			}
+
+			acquire("a", true, nil)
+			acquire("a", false, ErrInProgress)
+			if err := s.Release(ctx, "a"); err != nil {
+				t.Fatal(err)
+			}
+			acquire("a", true, nil)
+			if err := s.Complete(ctx, "a"); err != nil {
+				t.Fatal(err)
+			}
+			acquire("a", false, nil)
+			if err := s.Release(ctx, "a"); err != nil {
+				t.Fatal(err)
+			}
+			acquire("a", false, nil) // releasing does not undo a completion
+
+			// A claim whose holder disappeared runs out with its lease.
+			acquire("b", true, nil)
+			advance(testLease + time.Second)
+			acquire("b", true, nil)
+
+			advance(testTTL)
+			acquire("a", true, nil)
+		})
+	}
+}
+
+func TestStoreAcquireIsExclusive(t *testing.T) {
+	ctx := context.Background()
+	for name, open := range openStores() {
+		t.Run(name, func(t *testing.T) {
+			s, _ := open(t)
+			const attempts = 16
+			var wg sync.WaitGroup
+			results := make(chan error, attempts)
+			for range attempts {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					acquired, err := s.Acquire(ctx, "k")
+					if err == nil && !acquired {
+						err = fmt.Errorf("claim reported as completed")
+					}
+					results <- err
+				}()
+			}
+			wg.Wait()
+			close(results)
+			won := 0
+			for err := range results {
+				switch {
+				case err == nil:
+					won++
+				case !errors.Is(err, ErrInProgress):
+					t.Fatal(err)
+				}
+			}
+			if won != 1 {
+				t.Fatalf("%d attempts acquired the key, want 1", won)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(2, testTTL, testLease)
+	for _, key := range []string{"a", "b"} {
+		s.Acquire(ctx, key)
+		s.Complete(ctx, key)
+	}
+	s.Acquire(ctx, "a") // a duplicate of a makes b the least recently used
+	s.Acquire(ctx, "c")
+
+	if s.Len() != 2 {
+		t.Fatalf("store holds %d keys, want 2", s.Len())
+	}
+	if ok, _ := s.Acquire(ctx, "a"); ok {
+		t.Fatal("a was evicted")
+	}
+	if ok, _ := s.Acquire(ctx, "b"); !ok {
+		t.Fatal("b was not evicted")
+	}
+}
+
+func TestSQLiteStoreSurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "idempotency.db")
+	s, err := OpenSQLiteStore(path, testTTL, testLease)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Acquire(ctx, "a")
+	s.Complete(ctx, "a")
+	s.Close()
+
+	s, err = OpenSQLiteStore(path, testTTL, testLease)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if ok, err := s.Acquire(ctx, "a"); ok || err != nil {
+		t.Fatalf("Acquire after restart = %v, %v; want a duplicate", ok, err)
+	}
+}
@@ -0,0 +1,200 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"casestudy1_microservices/internal/broker"
+)
+
+// DLQSuffix is appended to a topic's name to form its dead-letter topic.
+const DLQSuffix = ".dlq"
+
+// Headers added to dead-lettered messages.
+const (
+	HeaderError             = "x-error"
+	HeaderAttempts          = "x-attempts"
+	HeaderOriginalTopic     = "x-original-topic"
+	HeaderOriginalPartition = "x-original-partition"
+	HeaderOriginalOffset    = "x-original-offset"
+	HeaderFailedAt          = "x-failed-at"
+)
+
+// commitTimeout bounds an offset commit. Commits use their own context so that messages finished
+// while shutting down are still committed.
+const commitTimeout = 10 * time.Second
+
+// ConsumerConfig controls how a failing message is retried.
+type ConsumerConfig struct {
+	// MaxAttempts is how many times the handler is called for a message before it is dead-lettered.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry; it doubles up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultConsumerConfig makes 3 attempts, waiting 200ms and then 400ms between them.
+func DefaultConsumerConfig() ConsumerConfig {
+	return ConsumerConfig{
+		MaxAttempts:    3,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+	}
+}
+
+// messageReader is the part of *kafka.Reader the consumer uses.
+type messageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// Consumer wraps Kafka reader for consuming messages. Offsets are committed explicitly once a
+// message has been handled or dead-lettered, so a crash redelivers whatever was in flight.
+type Consumer struct {
+	reader  messageReader
+	config  ConsumerConfig
+	dlq     broker.Publisher
+	offsets *offsetTracker
+	// sleep waits between attempts; it returns early with ctx's error when ctx is cancelled.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewConsumer creates a new Kafka consumer. Messages that still fail after the configured attempts
+// are published to the topic's DLQ through dlq.
+func NewConsumer(brokers []string, topic, groupID string, config ConsumerConfig, dlq broker.Publisher) *Consumer {
+	defaults := DefaultConsumerConfig()
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = defaults.InitialBackoff
+	}
+	if config.MaxBackoff < config.InitialBackoff {
+		config.MaxBackoff = config.InitialBackoff
+	}
+	return &Consumer{
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers: brokers,
+			Topic:   topic,
+			GroupID: groupID,
+		}),
+		config:  config,
+		dlq:     dlq,
+		offsets: newOffsetTracker(),
+		sleep:   sleep,
+	}
+}
+
+// Consume fetches messages and hands them to handler. When several goroutines call Consume, each
+// partition's offset is only committed once every earlier message of the partition is done.
+func (c *Consumer) Consume(ctx context.Context, handler broker.Handler) error {
+	for {
+		m, err := c.fetch(ctx)
+		if err != nil {
+			if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+				return nil
+			}
+			return err
+		}
+		if err := c.handle(ctx, m, handler); err != nil {
+			if ctx.Err() != nil {
+				// Left uncommitted; the message is redelivered after a restart.
+				return nil
+			}
+			return err
+		}
+		if err := c.offsets.finish(m, c.commit); err != nil {
+			return fmt.Errorf("commit offset %d of %s/%d: %w", m.Offset, m.Topic, m.Partition, err)
+		}
+	}
+}
+
+// fetch reads the next message and registers it with the tracker in the same step, so the tracker
+// sees each partition's offsets in order even with concurrent callers.
+func (c *Consumer) fetch(ctx context.Context) (kafka.Message, error) {
+	c.offsets.fetchMu.Lock()
+	defer c.offsets.fetchMu.Unlock()
+	m, err := c.reader.FetchMessage(ctx)
+	if err != nil {
+		return m, err
+	}
+	c.offsets.start(m)
+	return m, nil
+}
+
+// handle calls handler with retries and dead-letters the message if every attempt fails. It only
+// returns an error when the message could neither be handled nor dead-lettered.
+func (c *Consumer) handle(ctx context.Context, m kafka.Message, handler broker.Handler) error {
+	msg := broker.Message{Topic: m.Topic, Key: m.Key, Value: m.Value, Headers: fromHeaders(m.Headers)}
+	backoff := c.config.InitialBackoff
+	attempt := 1
+	for {
+		err := handler(ctx, msg)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if broker.IsPermanent(err) || attempt >= c.config.MaxAttempts {
+			return c.deadLetter(ctx, m, err, attempt)
+		}
+		log.Printf("Error handling message %s/%d@%d (attempt %d/%d): %v", m.Topic, m.Partition, m.Offset, attempt, c.config.MaxAttempts, err)
+
+		if err := c.sleep(ctx, backoff); err != nil {
+			return err
+		}
+		backoff = min(backoff*2, c.config.MaxBackoff)
+		attempt++
+	}
+}
+
+func (c *Consumer) deadLetter(ctx context.Context, m kafka.Message, cause error, attempts int) error {
+	headers := fromHeaders(m.Headers)
+	if headers == nil {
+		headers = make(map[string]string, 6)
+	}
+	headers[HeaderError] = cause.Error()
+	headers[HeaderAttempts] = strconv.Itoa(attempts)
+	headers[HeaderOriginalTopic] = m.Topic
+	headers[HeaderOriginalPartition] = strconv.Itoa(m.Partition)
+	headers[HeaderOriginalOffset] = strconv.FormatInt(m.Offset, 10)
+	headers[HeaderFailedAt] = time.Now().UTC().Format(time.RFC3339)
+
+	dlqTopic := m.Topic + DLQSuffix
+	err := c.dlq.Publish(ctx, broker.Message{Topic: dlqTopic, Key: m.Key, Value: m.Value, Headers: headers})
+	if err != nil {
+		return fmt.Errorf("dead-letter %s/%d@%d to %s: %w", m.Topic, m.Partition, m.Offset, dlqTopic, err)
+	}
+	log.Printf("Message %s/%d@%d moved to %s after %d attempt(s): %v", m.Topic, m.Partition, m.Offset, dlqTopic, attempts, cause)
+	return nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Consumer) commit(m kafka.Message) error {
+	ctx, cancel := context.WithTimeout(context.Background(), commitTimeout)
+	defer cancel()
+	return c.reader.CommitMessages(ctx, m)
+}
+
+// Close shuts down the consumer.
+func (c *Consumer) Close() error {
+	return c.reader.Close()
+}
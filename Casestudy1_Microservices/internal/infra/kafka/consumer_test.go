@@ -0,0 +1,244 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"casestudy1_microservices/internal/broker"
+)
+
+// journal records dead letters and commits in the order they happen.
+type journal struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (j *journal) add(format string, args ...any) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.entries = append(j.entries, fmt.Sprintf(format, args...))
+}
+
+func (j *journal) list() []string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return slices.Clone(j.entries)
+}
+
+// fakeReader hands out msgs in order and then cancels the consumer's context, as a shutdown would.
+type fakeReader struct {
+	journal *journal
+	cancel  context.CancelFunc
+
+	mu   sync.Mutex
+	msgs []kafka.Message
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.msgs) == 0 {
+		r.cancel()
+		return kafka.Message{}, ctx.Err()
+	}
+	m := r.msgs[0]
+	r.msgs = r.msgs[1:]
+	return m, nil
+}
+
+func (r *fakeReader) CommitMessages(_ context.Context, msgs ...kafka.Message) error {
+	for _, m := range msgs {
+		r.journal.add("commit %d", m.Offset)
+	}
+	return nil
+}
+
+func (r *fakeReader) Close() error { return nil }
+
+// fakePublisher stands in for the DLQ producer.
+type fakePublisher struct {
+	journal *journal
+	err     error
+
+	mu        sync.Mutex
+	published []broker.Message
+}
+
+func (p *fakePublisher) Publish(_ context.Context, msg broker.Message) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.mu.Lock()
+	p.published = append(p.published, msg)
+	p.mu.Unlock()
+	p.journal.add("dead-letter %s", msg.Headers[HeaderOriginalOffset])
+	return nil
+}
+
+type consumerFixture struct {
+	consumer *Consumer
+	ctx      context.Context
+	journal  *journal
+	dlq      *fakePublisher
+	sleeps   []time.Duration
+}
+
+func newConsumerFixture(t *testing.T, config ConsumerConfig, msgs ...kafka.Message) *consumerFixture {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	f := &consumerFixture{ctx: ctx, journal: &journal{}}
+	f.dlq = &fakePublisher{journal: f.journal}
+	f.consumer = &Consumer{
+		reader:  &fakeReader{journal: f.journal, cancel: cancel, msgs: msgs},
+		config:  config,
+		dlq:     f.dlq,
+		offsets: newOffsetTracker(),
+		sleep: func(ctx context.Context, d time.Duration) error {
+			f.sleeps = append(f.sleeps, d)
+			return ctx.Err()
+		},
+	}
+	return f
+}
+
+var testConfig = ConsumerConfig{MaxAttempts: 4, InitialBackoff: 100 * time.Millisecond, MaxBackoff: 150 * time.Millisecond}
+
+func message(offset int64) kafka.Message {
+	return kafka.Message{Topic: "resume_uploaded", Partition: 2, Offset: offset, Key: []byte("cand-1"), Value: []byte(`{"id":"r-1"}`)}
+}
+
+// failing returns a handler that fails the first failures calls with err and counts every call.
+func failing(failures int, err error, calls *int) broker.Handler {
+	return func(context.Context, broker.Message) error {
+		*calls++
+		if *calls <= failures {
+			return err
+		}
+		return nil
+	}
+}
+
+func TestConsumerRetriesWithBackoff(t *testing.T) {
+	transient := errors.New("scoring service unavailable")
+	tests := []struct {
+		name       string
+		failures   int
+		err        error
+		wantCalls  int
+		wantSleeps []time.Duration
+		wantDLQ    bool
+	}{
+		{"succeeds first time", 0, transient, 1, nil, false},
+		{"succeeds on third attempt", 2, transient, 3, []time.Duration{100 * time.Millisecond, 150 * time.Millisecond}, false},
+		{"fails every attempt", 99, transient, 4, []time.Duration{100 * time.Millisecond, 150 * time.Millisecond, 150 * time.Millisecond}, true},
+		{"permanent error is not retried", 99, broker.Permanent(errors.New("bad payload")), 1, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newConsumerFixture(t, testConfig, message(7))
+			var calls int
+			if err := f.consumer.Consume(f.ctx, failing(tt.failures, tt.err, &calls)); err != nil {
+				t.Fatalf("Consume: %v", err)
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("handler called %d times, want %d", calls, tt.wantCalls)
+			}
+			if !slices.Equal(f.sleeps, tt.wantSleeps) {
+				t.Fatalf("backoffs %v, want %v", f.sleeps, tt.wantSleeps)
+			}
+			want := []string{"commit 7"}
+			if tt.wantDLQ {
+				want = []string{"dead-letter 7", "commit 7"}
+			}
+			if got := f.journal.list(); !slices.Equal(got, want) {
+				t.Fatalf("journal %q, want %q", got, want)
+			}
+			if tt.wantDLQ {
+				if got := f.dlq.published[0].Headers[HeaderAttempts]; got != strconv.Itoa(tt.wantCalls) {
+					t.Fatalf("%s = %q, want %d", HeaderAttempts, got, tt.wantCalls)
+				}
+			}
+		})
+	}
+}
+
+func TestDeadLetterCarriesOriginalMessageAndHeaders(t *testing.T) {
+	m := message(41)
+	m.Headers = []kafka.Header{{Key: "trace-id", Value: []byte("abc")}}
+	f := newConsumerFixture(t, testConfig, m)
+	before := time.Now().UTC().Truncate(time.Second)
+
+	handler := func(context.Context, broker.Message) error {
+		return broker.Permanent(errors.New("invalid resume json"))
+	}
+	if err := f.consumer.Consume(f.ctx, handler); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.dlq.published) != 1 {
+		t.Fatalf("published %d dead letters, want 1", len(f.dlq.published))
+	}
+	dl := f.dlq.published[0]
+	if dl.Topic != "resume_uploaded"+DLQSuffix || string(dl.Key) != "cand-1" || string(dl.Value) != `{"id":"r-1"}` {
+		t.Fatalf("dead letter = %s %q %q", dl.Topic, dl.Key, dl.Value)
+	}
+	wantHeaders := map[string]string{
+		"trace-id":              "abc",
+		HeaderError:             "invalid resume json",
+		HeaderAttempts:          "1",
+		HeaderOriginalTopic:     "resume_uploaded",
+		HeaderOriginalPartition: "2",
+		HeaderOriginalOffset:    "41",
+	}
+	for k, v := range wantHeaders {
+		if dl.Headers[k] != v {
+			t.Errorf("header %s = %q, want %q", k, dl.Headers[k], v)
+		}
+	}
+	failedAt, err := time.Parse(time.RFC3339, dl.Headers[HeaderFailedAt])
+	if err != nil || failedAt.Before(before) || failedAt.After(time.Now().Add(time.Second)) {
+		t.Errorf("header %s = %q, want the time of failure", HeaderFailedAt, dl.Headers[HeaderFailedAt])
+	}
+}
+
+func TestFailedDeadLetterIsNotCommitted(t *testing.T) {
+	f := newConsumerFixture(t, testConfig, message(3), message(4))
+	f.dlq.err = errors.New("broker down")
+
+	handler := func(context.Context, broker.Message) error { return broker.Permanent(errors.New("bad payload")) }
+	err := f.consumer.Consume(f.ctx, handler)
+	if err == nil || !errors.Is(err, f.dlq.err) {
+		t.Fatalf("Consume = %v, want the dead-letter error", err)
+	}
+	// The offset stays uncommitted and the message is redelivered after a restart.
+	if got := f.journal.list(); len(got) != 0 {
+		t.Fatalf("journal %q, want nothing committed", got)
+	}
+}
+
+func TestShutdownDuringBackoffLeavesMessageUncommitted(t *testing.T) {
+	f := newConsumerFixture(t, testConfig, message(9))
+	ctx, cancel := context.WithCancel(f.ctx)
+	f.consumer.sleep = func(ctx context.Context, d time.Duration) error {
+		cancel()
+		return ctx.Err()
+	}
+	var calls int
+	if err := f.consumer.Consume(ctx, failing(99, errors.New("timeout"), &calls)); err != nil {
+		t.Fatalf("Consume = %v, want nil on shutdown", err)
+	}
+	if calls != 1 {
+		t.Fatalf("handler called %d times, want 1", calls)
+	}
+	if got := f.journal.list(); len(got) != 0 {
+		t.Fatalf("journal %q, want no dead letter and no commit", got)
+	}
+}
@@ -2,70 +2,72 @@ package kafka
 
 import (
 	"context"
-	"log"
 
 	"github.com/segmentio/kafka-go"
+
+	"casestudy1_microservices/internal/broker"
 )
 
-// Producer wraps Kafka writer for producing messages.
-type Producer struct {
-	writer *kafka.Writer
+// Transport publishes to and consumes from a Kafka cluster.
+type Transport struct {
+	brokers []string
+	config  ConsumerConfig
+	writer  *kafka.Writer
 }
 
-// NewProducer creates a new Kafka producer.
-func NewProducer(brokers []string, topic string) *Producer {
-	return &Producer{
+// NewTransport creates a transport for brokers whose consumers use config. Connections are opened
+// lazily on first use.
+func NewTransport(brokers []string, config ConsumerConfig) *Transport {
+	return &Transport{
+		brokers: brokers,
+		config:  config,
 		writer: &kafka.Writer{
 			Addr:     kafka.TCP(brokers...),
-			Topic:    topic,
 			Balancer: &kafka.Hash{},
 		},
 	}
 }
 
-// Publish sends a message to the Kafka topic.
-func (p *Producer) Publish(ctx context.Context, key string, value []byte) error {
-	return p.writer.WriteMessages(ctx, kafka.Message{
-		Key:   []byte(key),
-		Value: value,
+// Publish sends a message to msg.Topic, partitioned by key. The writer is not an idempotent
+// producer, so a retried publish can append a second copy; headers, including
+// broker.HeaderMessageID, travel as record headers for consumers to deduplicate on.
+func (t *Transport) Publish(ctx context.Context, msg broker.Message) error {
+	return t.writer.WriteMessages(ctx, kafka.Message{
+		Topic:   msg.Topic,
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: toHeaders(msg.Headers),
 	})
 }
 
-// Close shuts down the producer.
-func (p *Producer) Close() error {
-	return p.writer.Close()
+// Subscribe creates a consumer-group reader for topic that dead-letters through this transport.
+func (t *Transport) Subscribe(topic, group string) (broker.Consumer, error) {
+	return NewConsumer(t.brokers, topic, group, t.config, t), nil
 }
 
-// Consumer wraps Kafka reader for consuming messages.
-type Consumer struct {
-	reader *kafka.Reader
+// Close shuts down the producer.
+func (t *Transport) Close() error {
+	return t.writer.Close()
 }
 
-// NewConsumer creates a new Kafka consumer.
-func NewConsumer(brokers []string, topic, groupID string) *Consumer {
-	return &Consumer{
-		reader: kafka.NewReader(kafka.ReaderConfig{
-			Brokers: brokers,
-			Topic:   topic,
-			GroupID: groupID,
-		}),
+func toHeaders(h map[string]string) []kafka.Header {
+	if len(h) == 0 {
+		return nil
 	}
-}
-
-// Consume reads messages from the Kafka topic.
-func (c *Consumer) Consume(ctx context.Context, handler func(key, value []byte) error) error {
-	for {
-		m, err := c.reader.ReadMessage(ctx)
-		if err != nil {
-			return err
-		}
-		if err := handler(m.Key, m.Value); err != nil {
-			log.Printf("Error handling message: %v", err)
-		}
+	out := make([]kafka.Header, 0, len(h))
+	for k, v := range h {
+		out = append(out, kafka.Header{Key: k, Value: []byte(v)})
 	}
+	return out
 }
 
-// Close shuts down the consumer.
-func (c *Consumer) Close() error {
-	return c.reader.Close()
+func fromHeaders(h []kafka.Header) map[string]string {
+	if len(h) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(h))
+	for _, header := range h {
+		out[header.Key] = string(header.Value)
+	}
+	return out
 }
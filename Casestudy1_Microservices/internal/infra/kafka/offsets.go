@@ -0,0 +1,78 @@
+package kafka
+
+import (
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// offsetTracker decides which offsets are safe to commit when messages of a partition finish out of
+// order. A partition's committed offset only advances past a message once it and every earlier
+// fetched message of the partition are done.
+type offsetTracker struct {
+	// fetchMu serialises fetching with start, so offsets are registered in fetch order.
+	fetchMu sync.Mutex
+
+	mu         sync.Mutex
+	partitions map[partitionKey]*partitionOffsets
+}
+
+type partitionKey struct {
+	topic     string
+	partition int
+}
+
+type partitionOffsets struct {
+	pending []int64 // fetched and not yet committed, in fetch order
+	done    map[int64]bool
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{partitions: make(map[partitionKey]*partitionOffsets)}
+}
+
+// start registers a fetched message as in flight.
+func (t *offsetTracker) start(m kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := partitionKey{m.Topic, m.Partition}
+	p, ok := t.partitions[key]
+	// An offset at or before one already pending means the partition was rewound, as happens after
+	// a rebalance, and everything still pending will be redelivered.
+	if !ok || (len(p.pending) > 0 && m.Offset <= p.pending[len(p.pending)-1]) {
+		p = &partitionOffsets{done: make(map[int64]bool)}
+		t.partitions[key] = p
+	}
+	p.pending = append(p.pending, m.Offset)
+}
+
+// finish marks m as done and calls commit with the highest offset of the partition whose
+// predecessors are all done, if that moved. commit runs under the tracker's lock so commits of a
+// partition never go backwards. If commit fails the offsets stay pending.
+func (t *offsetTracker) finish(m kafka.Message, commit func(kafka.Message) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p, ok := t.partitions[partitionKey{m.Topic, m.Partition}]
+	if !ok || len(p.pending) == 0 || m.Offset < p.pending[0] {
+		// Fetched before the partition was rewound; its redelivery is tracked instead.
+		return nil
+	}
+	p.done[m.Offset] = true
+
+	n := 0
+	for n < len(p.pending) && p.done[p.pending[n]] {
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+	last := p.pending[n-1]
+	if err := commit(kafka.Message{Topic: m.Topic, Partition: m.Partition, Offset: last}); err != nil {
+		return err
+	}
+	for _, offset := range p.pending[:n] {
+		delete(p.done, offset)
+	}
+	p.pending = p.pending[n:]
+	return nil
+}
@@ -0,0 +1,63 @@
+package kafka
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestOffsetTrackerCommitsInOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		fetched []int64
+		finish  []int64
+		want    []int64 // committed offsets, in order
+	}{
+		{name: "in order", fetched: []int64{0, 1, 2}, finish: []int64{0, 1, 2}, want: []int64{0, 1, 2}},
+		{name: "later message finishes first", fetched: []int64{0, 1, 2}, finish: []int64{2, 1, 0}, want: []int64{2}},
+		{name: "gap holds back commit", fetched: []int64{5, 6, 7, 8}, finish: []int64{6, 5, 8, 7}, want: []int64{6, 8}},
+		{name: "rewind after rebalance", fetched: []int64{3, 4, 3, 4}, finish: []int64{4, 3, 4}, want: []int64{4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := newOffsetTracker()
+			for _, off := range tt.fetched {
+				tracker.start(kafka.Message{Topic: "resume_uploaded", Partition: 1, Offset: off})
+			}
+			var got []int64
+			for _, off := range tt.finish {
+				err := tracker.finish(kafka.Message{Topic: "resume_uploaded", Partition: 1, Offset: off}, func(m kafka.Message) error {
+					got = append(got, m.Offset)
+					return nil
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("committed %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOffsetTrackerKeepsOffsetsWhenCommitFails(t *testing.T) {
+	tracker := newOffsetTracker()
+	msg := func(off int64) kafka.Message { return kafka.Message{Topic: "t", Partition: 0, Offset: off} }
+	tracker.start(msg(0))
+	tracker.start(msg(1))
+
+	if err := tracker.finish(msg(0), func(kafka.Message) error { return errors.New("broker down") }); err == nil {
+		t.Fatal("expected the commit error")
+	}
+	var got []int64
+	if err := tracker.finish(msg(1), func(m kafka.Message) error { got = append(got, m.Offset); return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, []int64{1}) {
+		t.Fatalf("committed %v, want [1]", got)
+	}
+}
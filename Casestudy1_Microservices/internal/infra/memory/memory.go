@@ -0,0 +1,140 @@
+// Package memory is an in-process broker.Transport for local development and tests. It mimics
+// consumer groups: every group subscribed to a topic receives each message once, shared between the
+// group's consumers. Nothing is persisted, and messages published to a topic with no groups are
+// dropped.
+package memory
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"casestudy1_microservices/internal/broker"
+)
+
+// DefaultBuffer is the number of messages a group holds before Publish blocks.
+const DefaultBuffer = 1024
+
+// Bus routes messages between publishers and consumers in the same process.
+type Bus struct {
+	mu     sync.RWMutex
+	groups map[string]map[string]*group // topic -> group name
+	buffer int
+	closed bool
+}
+
+type group struct {
+	ch      chan broker.Message
+	members int
+}
+
+// NewBus creates a bus whose groups buffer up to buffer messages; buffer <= 0 uses DefaultBuffer.
+func NewBus(buffer int) *Bus {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	return &Bus{groups: make(map[string]map[string]*group), buffer: buffer}
+}
+
+// Publish hands msg to every group subscribed to msg.Topic, blocking while a group's buffer is full.
+func (b *Bus) Publish(ctx context.Context, msg broker.Message) error {
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return broker.ErrClosed
+	}
+	targets := make([]*group, 0, len(b.groups[msg.Topic]))
+	for _, g := range b.groups[msg.Topic] {
+		targets = append(targets, g)
+	}
+	b.mu.RUnlock()
+
+	for _, g := range targets {
+		select {
+		case g.ch <- cloneMessage(msg):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Subscribe joins group on topic, creating the group on first use.
+func (b *Bus) Subscribe(topic, groupName string) (broker.Consumer, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, broker.ErrClosed
+	}
+	groups, ok := b.groups[topic]
+	if !ok {
+		groups = make(map[string]*group)
+		b.groups[topic] = groups
+	}
+	g, ok := groups[groupName]
+	if !ok {
+		g = &group{ch: make(chan broker.Message, b.buffer)}
+		groups[groupName] = g
+	}
+	g.members++
+	return &Consumer{bus: b, topic: topic, name: groupName, group: g}, nil
+}
+
+// Close stops accepting messages and subscriptions.
+func (b *Bus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	return nil
+}
+
+// Consumer is one member of a group.
+type Consumer struct {
+	bus       *Bus
+	topic     string
+	name      string
+	group     *group
+	closeOnce sync.Once
+}
+
+// Consume delivers the group's messages to handler until ctx is cancelled.
+func (c *Consumer) Consume(ctx context.Context, handler broker.Handler) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg := <-c.group.ch:
+			if err := handler(ctx, msg); err != nil {
+				log.Printf("Error handling message: %v", err)
+			}
+		}
+	}
+}
+
+// Close leaves the group. The last member to leave removes the group and its undelivered messages.
+func (c *Consumer) Close() error {
+	c.closeOnce.Do(func() {
+		c.bus.mu.Lock()
+		defer c.bus.mu.Unlock()
+		c.group.members--
+		if c.group.members == 0 {
+			delete(c.bus.groups[c.topic], c.name)
+		}
+	})
+	return nil
+}
+
+func cloneMessage(msg broker.Message) broker.Message {
+	out := broker.Message{
+		Topic: msg.Topic,
+		Key:   append([]byte(nil), msg.Key...),
+		Value: append([]byte(nil), msg.Value...),
+	}
+	if msg.Headers != nil {
+		out.Headers = make(map[string]string, len(msg.Headers))
+		for k, v := range msg.Headers {
+			out.Headers[k] = v
+		}
+	}
+	return out
+}
@@ -1,56 +1,109 @@
 package nats
 
 import (
+	"context"
 	"log"
 
 	"github.com/nats-io/nats.go"
+
+	"casestudy1_microservices/internal/broker"
 )
 
-// Publisher wraps NATS connection for publishing messages.
-type Publisher struct {
+// subscriptionBuffer bounds the messages a subscription holds before NATS reports it as a slow
+// consumer.
+const subscriptionBuffer = 1024
+
+// Transport wraps NATS connection for publishing and subscribing. Topics map to subjects and
+// consumer groups to queue groups. Core NATS does not retain messages, so subjects without a
+// subscriber drop what is published to them.
+type Transport struct {
 	nc *nats.Conn
 }
 
-// NewPublisher creates a new NATS publisher.
-func NewPublisher(url string) (*Publisher, error) {
+// NewTransport connects to the NATS server at url.
+func NewTransport(url string) (*Transport, error) {
 	nc, err := nats.Connect(url)
 	if err != nil {
 		return nil, err
 	}
-	return &Publisher{nc: nc}, nil
+	return &Transport{nc: nc}, nil
 }
 
-// Publish sends a message to a NATS subject.
-func (p *Publisher) Publish(subject string, data []byte) error {
-	return p.nc.Publish(subject, data)
+// Publish sends a message to the subject msg.Topic. The key travels in the Nats-Msg-Key header. A
+// broker.HeaderMessageID is also sent as Nats-Msg-Id, so that a JetStream stream capturing the
+// subject drops repeated copies; core NATS subscribers still receive them.
+func (t *Transport) Publish(_ context.Context, msg broker.Message) error {
+	m := nats.NewMsg(msg.Topic)
+	m.Data = msg.Value
+	for k, v := range msg.Headers {
+		m.Header.Set(k, v)
+	}
+	if len(msg.Key) > 0 {
+		m.Header.Set(keyHeader, string(msg.Key))
+	}
+	if id := msg.Headers[broker.HeaderMessageID]; id != "" {
+		m.Header.Set(nats.MsgIdHdr, id)
+	}
+	return t.nc.PublishMsg(m)
 }
 
-// Close shuts down the publisher.
-func (p *Publisher) Close() {
-	p.nc.Close()
+// Subscribe joins the queue group on subject topic.
+func (t *Transport) Subscribe(topic, group string) (broker.Consumer, error) {
+	ch := make(chan *nats.Msg, subscriptionBuffer)
+	sub, err := t.nc.ChanQueueSubscribe(topic, group, ch)
+	if err != nil {
+		return nil, err
+	}
+	return &Subscriber{sub: sub, ch: ch}, nil
 }
 
+// Close closes the connection.
+func (t *Transport) Close() error {
+	t.nc.Close()
+	return nil
+}
+
+const keyHeader = "Nats-Msg-Key"
+
 // Subscriber wraps NATS subscription for consuming messages.
 type Subscriber struct {
 	sub *nats.Subscription
+	ch  chan *nats.Msg
 }
 
-// NewSubscriber creates a new NATS subscriber.
-func NewSubscriber(url, subject string, handler func(msg *nats.Msg)) (*Subscriber, error) {
-	nc, err := nats.Connect(url)
-	if err != nil {
-		return nil, err
-	}
-	sub, err := nc.Subscribe(subject, handler)
-	if err != nil {
-		return nil, err
+// Consume delivers subscription messages to handler until ctx is cancelled.
+func (s *Subscriber) Consume(ctx context.Context, handler broker.Handler) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case m := <-s.ch:
+			msg := broker.Message{Topic: m.Subject, Value: m.Data}
+			if len(m.Header) > 0 {
+				msg.Headers = make(map[string]string, len(m.Header))
+				for k := range m.Header {
+					if k == keyHeader {
+						msg.Key = []byte(m.Header.Get(k))
+						continue
+					}
+					if k == nats.MsgIdHdr {
+						continue // a copy of broker.HeaderMessageID
+					}
+					msg.Headers[k] = m.Header.Get(k)
+				}
+			}
+			if err := handler(ctx, msg); err != nil {
+				log.Printf("Error handling message: %v", err)
+			}
+		}
 	}
-	return &Subscriber{sub: sub}, nil
 }
 
 // Close unsubscribes from the subject.
-func (s *Subscriber) Close() {
+func (s *Subscriber) Close() error {
 	if err := s.sub.Unsubscribe(); err != nil {
 		log.Printf("Error unsubscribing: %v", err)
+		return err
 	}
+	return nil
 }
@@ -0,0 +1,90 @@
+// Package infra opens the broker.Transport selected by configuration.
+package infra
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"casestudy1_microservices/internal/broker"
+	"casestudy1_microservices/internal/infra/kafka"
+	"casestudy1_microservices/internal/infra/memory"
+	"casestudy1_microservices/internal/infra/nats"
+)
+
+// Supported drivers.
+const (
+	DriverKafka  = "kafka"
+	DriverNATS   = "nats"
+	DriverMemory = "memory"
+)
+
+const (
+	defaultKafkaBrokers = "localhost:9092"
+	defaultNATSURL      = "nats://localhost:4222"
+)
+
+// Config selects and configures a transport. Only the settings of the chosen driver are used.
+type Config struct {
+	Driver        string
+	KafkaBrokers  []string
+	KafkaConsumer kafka.ConsumerConfig
+	NATSURL       string
+}
+
+// ConfigFromEnv reads the driver from the environment variable driverVar (defaultDriver when unset),
+// the Kafka brokers from KAFKA_BROKERS (comma separated), the Kafka retry policy from
+// KAFKA_MAX_ATTEMPTS and KAFKA_RETRY_BACKOFF, and the NATS server from NATS_URL.
+func ConfigFromEnv(driverVar, defaultDriver string) Config {
+	cfg := Config{
+		Driver:        strings.ToLower(getenv(driverVar, defaultDriver)),
+		KafkaBrokers:  strings.Split(getenv("KAFKA_BROKERS", defaultKafkaBrokers), ","),
+		KafkaConsumer: kafka.DefaultConsumerConfig(),
+		NATSURL:       getenv("NATS_URL", defaultNATSURL),
+	}
+	for i := range cfg.KafkaBrokers {
+		cfg.KafkaBrokers[i] = strings.TrimSpace(cfg.KafkaBrokers[i])
+	}
+	if n, err := strconv.Atoi(os.Getenv("KAFKA_MAX_ATTEMPTS")); err == nil && n > 0 {
+		cfg.KafkaConsumer.MaxAttempts = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("KAFKA_RETRY_BACKOFF")); err == nil && d > 0 {
+		cfg.KafkaConsumer.InitialBackoff = d
+	}
+	return cfg
+}
+
+var (
+	processBusOnce sync.Once
+	processBus     *memory.Bus
+)
+
+// Open connects the configured transport. Every memory transport opened in a process is the same
+// bus, so services wired into one binary reach each other.
+func Open(cfg Config) (broker.Transport, error) {
+	switch cfg.Driver {
+	case DriverKafka:
+		return kafka.NewTransport(cfg.KafkaBrokers, cfg.KafkaConsumer), nil
+	case DriverNATS:
+		t, err := nats.NewTransport(cfg.NATSURL)
+		if err != nil {
+			return nil, fmt.Errorf("connect to NATS at %s: %w", cfg.NATSURL, err)
+		}
+		return t, nil
+	case DriverMemory:
+		processBusOnce.Do(func() { processBus = memory.NewBus(memory.DefaultBuffer) })
+		return processBus, nil
+	default:
+		return nil, fmt.Errorf("unknown broker driver %q (want %s, %s or %s)", cfg.Driver, DriverKafka, DriverNATS, DriverMemory)
+	}
+}
+
+func getenv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
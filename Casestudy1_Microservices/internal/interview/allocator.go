@@ -0,0 +1,327 @@
+package interview
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxBookingAttempts bounds how often Book looks for another slot after losing one to a booking
+// made through the same store by another instance.
+const maxBookingAttempts = 5
+
+// Allocator books interview slots. Within one process bookings are serialised so capacity limits
+// hold; the store guarantees no interviewer is double-booked across processes.
+type Allocator struct {
+	mu           sync.Mutex
+	store        Store
+	interviewers []*Interviewer
+	byID         map[string]*Interviewer
+	config       Config
+	now          func() time.Time
+}
+
+// NewAllocator validates the interviewers and creates an allocator. Zero Config fields take their
+// DefaultConfig values.
+func NewAllocator(store Store, interviewers []Interviewer, config Config) (*Allocator, error) {
+	defaults := DefaultConfig()
+	if config.SlotDuration <= 0 {
+		config.SlotDuration = defaults.SlotDuration
+	}
+	if config.LeadTime < 0 {
+		config.LeadTime = 0
+	}
+	if config.Horizon <= 0 {
+		config.Horizon = defaults.Horizon
+	}
+	if len(interviewers) == 0 {
+		return nil, errors.New("at least one interviewer is required")
+	}
+	a := &Allocator{store: store, byID: make(map[string]*Interviewer), config: config, now: time.Now}
+	for i := range interviewers {
+		iv := interviewers[i]
+		if err := iv.init(); err != nil {
+			return nil, fmt.Errorf("interviewer %q: %w", iv.ID, err)
+		}
+		if _, dup := a.byID[iv.ID]; dup {
+			return nil, fmt.Errorf("interviewer %q is listed twice", iv.ID)
+		}
+		if iv.end-iv.start < config.SlotDuration {
+			return nil, fmt.Errorf("interviewer %q: working hours are shorter than a slot", iv.ID)
+		}
+		a.interviewers = append(a.interviewers, &iv)
+		a.byID[iv.ID] = &iv
+	}
+	sort.Slice(a.interviewers, func(i, j int) bool { return a.interviewers[i].ID < a.interviewers[j].ID })
+	return a, nil
+}
+
+// Interviewers lists the panel ordered by ID.
+func (a *Allocator) Interviewers() []Interviewer {
+	out := make([]Interviewer, 0, len(a.interviewers))
+	for _, iv := range a.interviewers {
+		out = append(out, *iv)
+	}
+	return out
+}
+
+// Interviewer looks up an interviewer by ID.
+func (a *Allocator) Interviewer(id string) (*Interviewer, bool) {
+	iv, ok := a.byID[id]
+	return iv, ok
+}
+
+// Store returns the allocator's booking store.
+func (a *Allocator) Store() Store { return a.store }
+
+// BookingRequest names the candidate to book.
+type BookingRequest struct {
+	StudentID     string
+	CandidateName string
+	JobID         string
+}
+
+// Book gives the candidate the earliest free slot across the panel. A candidate already scheduled
+// for the same job opening keeps their booking, so redelivered events do not book twice.
+func (a *Allocator) Book(req BookingRequest) (*Booking, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	existing, err := a.store.ListByStudent(req.StudentID)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range existing {
+		if b.Status == StatusScheduled && b.JobID == req.JobID {
+			return b, nil
+		}
+	}
+
+	now := a.now().UTC()
+	for attempt := 0; attempt < maxBookingAttempts; attempt++ {
+		iv, start, err := a.nextSlot(now.Add(a.config.LeadTime), "")
+		if err != nil {
+			return nil, err
+		}
+		b := &Booking{
+			ID:            newBookingID(),
+			StudentID:     req.StudentID,
+			CandidateName: req.CandidateName,
+			JobID:         req.JobID,
+			InterviewerID: iv.ID,
+			Start:         start,
+			End:           start.Add(a.config.SlotDuration),
+			Status:        StatusScheduled,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		err = a.store.Create(b)
+		if errors.Is(err, ErrSlotUnavailable) {
+			continue // taken by another instance since we looked
+		}
+		if err != nil {
+			return nil, err
+		}
+		return b, nil
+	}
+	return nil, ErrNoAvailability
+}
+
+// RescheduleRequest moves a booking. With Start set the booking moves to exactly that time, which
+// must be a free slot in the interviewer's working hours; otherwise it moves to the next free slot.
+// InterviewerID, when set, also changes the interviewer.
+type RescheduleRequest struct {
+	Start         *time.Time
+	InterviewerID string
+}
+
+// Reschedule moves a scheduled booking.
+func (a *Allocator) Reschedule(id string, req RescheduleRequest) (*Booking, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	b, err := a.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if b.Status != StatusScheduled {
+		return nil, ErrNotScheduled
+	}
+	now := a.now().UTC()
+	earliest := now.Add(a.config.LeadTime)
+
+	var iv *Interviewer
+	var start time.Time
+	if req.Start != nil {
+		ivID := req.InterviewerID
+		if ivID == "" {
+			ivID = b.InterviewerID
+		}
+		var ok bool
+		if iv, ok = a.byID[ivID]; !ok {
+			return nil, ErrUnknownInterviewer
+		}
+		start = req.Start.UTC()
+		if err := a.checkSlot(iv, start, earliest, b.ID); err != nil {
+			return nil, err
+		}
+	} else {
+		if req.InterviewerID != "" {
+			if _, ok := a.byID[req.InterviewerID]; !ok {
+				return nil, ErrUnknownInterviewer
+			}
+		}
+		// The current slot stays occupied while searching, so the booking moves somewhere else.
+		if iv, start, err = a.nextSlot(earliest, req.InterviewerID); err != nil {
+			return nil, err
+		}
+	}
+
+	b.InterviewerID = iv.ID
+	b.Start = start
+	b.End = start.Add(a.config.SlotDuration)
+	b.Sequence++
+	b.UpdatedAt = now
+	if err := a.store.Update(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Cancel cancels a scheduled booking, freeing its slot.
+func (a *Allocator) Cancel(id, reason string) (*Booking, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	b, err := a.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if b.Status != StatusScheduled {
+		return nil, ErrNotScheduled
+	}
+	b.Status = StatusCancelled
+	b.CancelReason = reason
+	b.Sequence++
+	b.UpdatedAt = a.now().UTC()
+	if err := a.store.Update(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// ActiveBooking returns the candidate's next scheduled booking.
+func (a *Allocator) ActiveBooking(studentID string) (*Booking, error) {
+	bookings, err := a.store.ListByStudent(studentID)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range bookings {
+		if b.Status == StatusScheduled {
+			return b, nil
+		}
+	}
+	return nil, ErrBookingNotFound
+}
+
+// checkSlot verifies that iv can take [start, start+slot) for the booking ignore.
+func (a *Allocator) checkSlot(iv *Interviewer, start, earliest time.Time, ignore string) error {
+	end := start.Add(a.config.SlotDuration)
+	if start.Before(earliest) {
+		return fmt.Errorf("%w: starts before %s", ErrSlotUnavailable, earliest.Format(time.RFC3339))
+	}
+	if !iv.fits(start, end) {
+		return fmt.Errorf("%w: outside %s's working hours", ErrSlotUnavailable, iv.ID)
+	}
+	day := iv.localDay(start)
+	booked, err := a.store.ListByInterviewer(iv.ID, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+	count := 0
+	for _, b := range booked {
+		if b.Status != StatusScheduled || b.ID == ignore {
+			continue
+		}
+		if b.overlaps(start, end) {
+			return fmt.Errorf("%w: %s is already booked", ErrSlotUnavailable, iv.ID)
+		}
+		if iv.localDay(b.Start).Equal(day) {
+			count++
+		}
+	}
+	if iv.MaxPerDay > 0 && count >= iv.MaxPerDay {
+		return fmt.Errorf("%w: %s is at capacity that day", ErrSlotUnavailable, iv.ID)
+	}
+	return nil
+}
+
+// nextSlot finds the earliest free slot at or after from within the horizon, restricted to one
+// interviewer when only is set. Ties go to the interviewer with fewer interviews that day, then to
+// the lower ID. Slots start at the beginning of working hours and follow each other back to back.
+func (a *Allocator) nextSlot(from time.Time, only string) (*Interviewer, time.Time, error) {
+	until := from.Add(a.config.Horizon)
+	var best *Interviewer
+	var bestStart time.Time
+	bestLoad := 0
+
+	for _, iv := range a.interviewers {
+		if only != "" && iv.ID != only {
+			continue
+		}
+		booked, err := a.store.ListByInterviewer(iv.ID, iv.localDay(from), until.Add(24*time.Hour))
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		perDay := make(map[time.Time]int)
+		var active []*Booking
+		for _, b := range booked {
+			if b.Status == StatusScheduled {
+				active = append(active, b)
+				perDay[iv.localDay(b.Start)]++
+			}
+		}
+
+		start, load, ok := a.firstFree(iv, from, until, active, perDay)
+		if !ok {
+			continue
+		}
+		if best == nil || start.Before(bestStart) || (start.Equal(bestStart) && load < bestLoad) {
+			best, bestStart, bestLoad = iv, start, load
+		}
+	}
+	if best == nil {
+		return nil, time.Time{}, ErrNoAvailability
+	}
+	return best, bestStart, nil
+}
+
+// firstFree scans iv's working days for the first slot in [from, until) that overlaps no active
+// booking and falls on a day under capacity. It returns the slot and that day's booking count.
+func (a *Allocator) firstFree(iv *Interviewer, from, until time.Time, active []*Booking, perDay map[time.Time]int) (time.Time, int, bool) {
+	for day := iv.localDay(from); day.Before(until); day = day.AddDate(0, 0, 1) {
+		ws, we, ok := iv.window(day)
+		if !ok || (iv.MaxPerDay > 0 && perDay[day] >= iv.MaxPerDay) {
+			continue
+		}
+		for start := ws; !start.Add(a.config.SlotDuration).After(we); start = start.Add(a.config.SlotDuration) {
+			if start.Before(from) || !start.Before(until) {
+				continue
+			}
+			end := start.Add(a.config.SlotDuration)
+			free := true
+			for _, b := range active {
+				if b.overlaps(start, end) {
+					free = false
+					break
+				}
+			}
+			if free {
+				return start.UTC(), perDay[day], true
+			}
+		}
+	}
+	return time.Time{}, 0, false
+}
@@ -0,0 +1,249 @@
+package interview
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// monday is 05:30 in Kolkata and 00:00 UTC.
+var monday = time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+func newTestAllocator(t *testing.T, store Store, interviewers ...Interviewer) *Allocator {
+	t.Helper()
+	a, err := NewAllocator(store, interviewers, Config{SlotDuration: time.Hour, Horizon: 7 * 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.now = func() time.Time { return monday }
+	return a
+}
+
+func kolkata(maxPerDay int) Interviewer {
+	return Interviewer{ID: "iv-1", Name: "Priya", Email: "priya@example.com", TimeZone: "Asia/Kolkata", WorkStart: "10:00", WorkEnd: "12:00", MaxPerDay: maxPerDay}
+}
+
+func mustBook(t *testing.T, a *Allocator, studentID string) *Booking {
+	t.Helper()
+	b, err := a.Book(BookingRequest{StudentID: studentID, CandidateName: "Candidate " + studentID})
+	if err != nil {
+		t.Fatalf("book %s: %v", studentID, err)
+	}
+	return b
+}
+
+func TestBookRespectsWorkingHoursTimeZoneAndCapacity(t *testing.T) {
+	a := newTestAllocator(t, NewMemoryStore(), kolkata(2), Interviewer{
+		ID: "iv-2", TimeZone: "Europe/Berlin", WorkStart: "09:00", WorkEnd: "10:00", WorkDays: []string{"tue"},
+	})
+
+	// Kolkata is UTC+5:30 and takes two interviews a day at 10:00 and 11:00 local. Berlin only
+	// works 09:00-10:00 on Tuesdays, 07:00 UTC, after Kolkata's Tuesday slots.
+	want := []struct {
+		interviewer string
+		start       string
+	}{
+		{"iv-1", "2026-10-19T04:30:00Z"},
+		{"iv-1", "2026-10-19T05:30:00Z"},
+		{"iv-1", "2026-10-20T04:30:00Z"},
+		{"iv-1", "2026-10-20T05:30:00Z"},
+		{"iv-2", "2026-10-20T07:00:00Z"},
+		{"iv-1", "2026-10-21T04:30:00Z"},
+	}
+	for i, w := range want {
+		b := mustBook(t, a, fmt.Sprintf("S-%d", i))
+		if got := b.Start.Format(time.RFC3339); b.InterviewerID != w.interviewer || got != w.start {
+			t.Fatalf("booking %d: %s at %s, want %s at %s", i, b.InterviewerID, got, w.interviewer, w.start)
+		}
+		if b.End.Sub(b.Start) != time.Hour {
+			t.Fatalf("booking %d lasts %s", i, b.End.Sub(b.Start))
+		}
+	}
+
+	again := mustBook(t, a, "S-0")
+	if again.Start.Format(time.RFC3339) != want[0].start {
+		t.Fatalf("rebooking a scheduled candidate moved them to %s", again.Start)
+	}
+}
+
+func TestBookIsConflictFreeUnderConcurrency(t *testing.T) {
+	stores := map[string]func(t *testing.T) []Store{
+		"memory": func(t *testing.T) []Store { return []Store{NewMemoryStore()} },
+		// Two allocators on one database stand in for two scheduler instances.
+		"sqlite shared by two instances": func(t *testing.T) []Store {
+			path := filepath.Join(t.TempDir(), "interviews.db")
+			var out []Store
+			for range 2 {
+				s, err := OpenSQLiteStore(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(func() { s.Close() })
+				out = append(out, s)
+			}
+			return out
+		},
+	}
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			var allocators []*Allocator
+			for _, s := range open(t) {
+				allocators = append(allocators, newTestAllocator(t, s, kolkata(0),
+					Interviewer{ID: "iv-2", TimeZone: "UTC", WorkStart: "09:00", WorkEnd: "13:00", MaxPerDay: 3}))
+			}
+
+			const candidates = 20 // of 25 free slots in the horizon
+			var wg sync.WaitGroup
+			errs := make(chan error, candidates)
+			for i := range candidates {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					a := allocators[i%len(allocators)]
+					if _, err := a.Book(BookingRequest{StudentID: fmt.Sprintf("S-%d", i)}); err != nil {
+						errs <- err
+					}
+				}()
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				t.Fatal(err)
+			}
+
+			store := allocators[0].Store()
+			for _, iv := range []string{"iv-1", "iv-2"} {
+				bookings, err := store.ListByInterviewer(iv, time.Time{}, time.Time{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				perDay := map[string]int{}
+				for i, b := range bookings {
+					perDay[b.Start.Format(time.DateOnly)]++
+					if i > 0 && bookings[i-1].overlaps(b.Start, b.End) {
+						t.Fatalf("%s double-booked: %s and %s", iv, bookings[i-1].Start, b.Start)
+					}
+				}
+				if iv == "iv-2" {
+					for day, n := range perDay {
+						if n > 3 {
+							t.Fatalf("iv-2 has %d interviews on %s, cap is 3", n, day)
+						}
+					}
+				}
+			}
+			for i := range candidates {
+				if _, err := allocators[0].ActiveBooking(fmt.Sprintf("S-%d", i)); err != nil {
+					t.Fatalf("S-%d: %v", i, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRescheduleAndCancel(t *testing.T) {
+	a := newTestAllocator(t, NewMemoryStore(), kolkata(0))
+	first := mustBook(t, a, "S-1")  // Monday 10:00 IST
+	second := mustBook(t, a, "S-2") // Monday 11:00 IST
+
+	at := func(s string) *time.Time {
+		tm, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &tm
+	}
+	tests := []struct {
+		name    string
+		req     RescheduleRequest
+		wantErr error
+	}{
+		{"taken by another booking", RescheduleRequest{Start: &second.Start}, ErrSlotUnavailable},
+		{"outside working hours", RescheduleRequest{Start: at("2026-10-20T07:00:00Z")}, ErrSlotUnavailable},
+		{"weekend", RescheduleRequest{Start: at("2026-10-24T04:30:00Z")}, ErrSlotUnavailable},
+		{"in the past", RescheduleRequest{Start: at("2026-10-18T04:30:00Z")}, ErrSlotUnavailable},
+		{"unknown interviewer", RescheduleRequest{InterviewerID: "iv-9"}, ErrUnknownInterviewer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := a.Reschedule(first.ID, tt.req); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	moved, err := a.Reschedule(first.ID, RescheduleRequest{Start: at("2026-10-21T05:30:00Z")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved.Sequence != 1 || moved.End.Sub(moved.Start) != time.Hour {
+		t.Fatalf("moved booking = %+v", moved)
+	}
+	// Monday 10:00 is free again; with no start given the booking takes the next free slot.
+	auto, err := a.Reschedule(first.ID, RescheduleRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := auto.Start.Format(time.RFC3339); got != "2026-10-19T04:30:00Z" || auto.Sequence != 2 {
+		t.Fatalf("auto reschedule to %s sequence %d", got, auto.Sequence)
+	}
+
+	cancelled, err := a.Cancel(second.ID, "candidate withdrew")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelled.Status != StatusCancelled || cancelled.CancelReason != "candidate withdrew" {
+		t.Fatalf("cancelled booking = %+v", cancelled)
+	}
+	if _, err := a.Cancel(second.ID, ""); !errors.Is(err, ErrNotScheduled) {
+		t.Fatalf("second cancel: %v", err)
+	}
+	if _, err := a.Reschedule(second.ID, RescheduleRequest{}); !errors.Is(err, ErrNotScheduled) {
+		t.Fatalf("reschedule cancelled booking: %v", err)
+	}
+	if third := mustBook(t, a, "S-3"); !third.Start.Equal(second.Start) {
+		t.Fatalf("cancelled slot not reused: got %s, want %s", third.Start, second.Start)
+	}
+}
+
+func TestWriteICS(t *testing.T) {
+	a := newTestAllocator(t, NewMemoryStore(), kolkata(0))
+	kept := mustBook(t, a, "S-1")
+	dropped := mustBook(t, a, "S-2")
+	if _, err := a.Cancel(dropped.ID, "clash; rescheduling, later"); err != nil {
+		t.Fatal(err)
+	}
+	bookings, _ := a.Store().ListByInterviewer("iv-1", time.Time{}, time.Time{})
+
+	var buf bytes.Buffer
+	if err := a.WriteICS(&buf, "Interviews with Priya", bookings); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"UID:" + kept.ID + "@interview-scheduler\r\n",
+		"DTSTART:20261019T043000Z\r\n",
+		"DTEND:20261019T053000Z\r\n",
+		"STATUS:CONFIRMED\r\n",
+		"STATUS:CANCELLED\r\n",
+		"SEQUENCE:1\r\n",
+		`clash\; rescheduling\, later`,
+		"ORGANIZER;CN=Priya:mailto:priya@example.com\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(strings.ReplaceAll(out, "\r\n ", ""), want) {
+			t.Errorf("calendar is missing %q", want)
+		}
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+	}
+}
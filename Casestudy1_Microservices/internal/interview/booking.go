@@ -0,0 +1,151 @@
+package interview
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status is the state of a booking.
+type Status string
+
+const (
+	StatusScheduled Status = "scheduled"
+	StatusCancelled Status = "cancelled"
+)
+
+var (
+	ErrBookingNotFound    = errors.New("booking not found")
+	ErrSlotUnavailable    = errors.New("slot unavailable")
+	ErrNoAvailability     = errors.New("no interview slot available")
+	ErrNotScheduled       = errors.New("booking is not scheduled")
+	ErrUnknownInterviewer = errors.New("unknown interviewer")
+)
+
+// Booking is an interview between a candidate and an interviewer.
+type Booking struct {
+	ID            string    `json:"id"`
+	StudentID     string    `json:"student_id"`
+	CandidateName string    `json:"candidate_name"`
+	JobID         string    `json:"job_id,omitempty"`
+	InterviewerID string    `json:"interviewer_id"`
+	Start         time.Time `json:"start"`
+	End           time.Time `json:"end"`
+	Status        Status    `json:"status"`
+	// Sequence counts changes after creation, as calendar clients expect in ICS updates.
+	Sequence     int       `json:"sequence"`
+	CancelReason string    `json:"cancel_reason,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (b *Booking) overlaps(start, end time.Time) bool {
+	return b.Start.Before(end) && start.Before(b.End)
+}
+
+func newBookingID() string {
+	var buf [12]byte
+	rand.Read(buf[:])
+	return "bk_" + hex.EncodeToString(buf[:])
+}
+
+// Store persists bookings. Create and Update fail with ErrSlotUnavailable when the booking would
+// overlap another scheduled booking of the same interviewer; that check and the write are atomic,
+// so instances sharing a store cannot double-book an interviewer.
+type Store interface {
+	Create(b *Booking) error
+	Update(b *Booking) error
+	Get(id string) (*Booking, error)
+	// ListByStudent returns the candidate's bookings ordered by start.
+	ListByStudent(studentID string) ([]*Booking, error)
+	// ListByInterviewer returns the interviewer's bookings that overlap [from, to), ordered by start.
+	// A zero to means no upper bound.
+	ListByInterviewer(interviewerID string, from, to time.Time) ([]*Booking, error)
+}
+
+// MemoryStore keeps bookings in memory.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	bookings map[string]Booking
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{bookings: make(map[string]Booking)}
+}
+
+func (s *MemoryStore) Create(b *Booking) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkConflict(b); err != nil {
+		return err
+	}
+	s.bookings[b.ID] = *b
+	return nil
+}
+
+func (s *MemoryStore) Update(b *Booking) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.bookings[b.ID]; !ok {
+		return ErrBookingNotFound
+	}
+	if err := s.checkConflict(b); err != nil {
+		return err
+	}
+	s.bookings[b.ID] = *b
+	return nil
+}
+
+func (s *MemoryStore) checkConflict(b *Booking) error {
+	if b.Status != StatusScheduled {
+		return nil
+	}
+	for _, other := range s.bookings {
+		if other.ID != b.ID && other.Status == StatusScheduled && other.InterviewerID == b.InterviewerID && other.overlaps(b.Start, b.End) {
+			return ErrSlotUnavailable
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) Get(id string) (*Booking, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	b, ok := s.bookings[id]
+	if !ok {
+		return nil, ErrBookingNotFound
+	}
+	return &b, nil
+}
+
+func (s *MemoryStore) ListByStudent(studentID string) ([]*Booking, error) {
+	return s.list(func(b *Booking) bool { return b.StudentID == studentID }), nil
+}
+
+func (s *MemoryStore) ListByInterviewer(interviewerID string, from, to time.Time) ([]*Booking, error) {
+	return s.list(func(b *Booking) bool {
+		return b.InterviewerID == interviewerID && b.End.After(from) && (to.IsZero() || b.Start.Before(to))
+	}), nil
+}
+
+func (s *MemoryStore) list(match func(*Booking) bool) []*Booking {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []*Booking
+	for _, b := range s.bookings {
+		if match(&b) {
+			copied := b
+			out = append(out, &copied)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].Start.Equal(out[j].Start) {
+			return out[i].Start.Before(out[j].Start)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
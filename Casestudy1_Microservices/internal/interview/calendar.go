@@ -0,0 +1,204 @@
+package interview
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	_ "time/tzdata" // interviewer time zones must resolve on hosts without a zoneinfo database
+
+	"gopkg.in/yaml.v3"
+)
+
+// Interviewer is someone candidates can be booked with.
+type Interviewer struct {
+	ID    string `json:"id" yaml:"id"`
+	Name  string `json:"name" yaml:"name"`
+	Email string `json:"email,omitempty" yaml:"email,omitempty"`
+	// TimeZone is an IANA zone name; working hours and daily capacity are counted in it.
+	TimeZone string `json:"time_zone" yaml:"time_zone"`
+	// WorkStart and WorkEnd are local times of day as "15:04".
+	WorkStart string `json:"work_start" yaml:"work_start"`
+	WorkEnd   string `json:"work_end" yaml:"work_end"`
+	// WorkDays lists the working weekdays as "mon".."sun"; empty means Monday to Friday.
+	WorkDays []string `json:"work_days,omitempty" yaml:"work_days,omitempty"`
+	// MaxPerDay caps the interviews on one local day; 0 means no cap.
+	MaxPerDay int `json:"max_per_day,omitempty" yaml:"max_per_day,omitempty"`
+
+	loc      *time.Location
+	start    time.Duration // offset of WorkStart from local midnight
+	end      time.Duration
+	weekdays map[time.Weekday]bool
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// init parses the interviewer's settings.
+func (iv *Interviewer) init() error {
+	if iv.ID == "" {
+		return errors.New("id is required")
+	}
+	loc, err := time.LoadLocation(iv.TimeZone)
+	if err != nil {
+		return fmt.Errorf("time_zone: %w", err)
+	}
+	iv.loc = loc
+	if iv.start, err = parseClock(iv.WorkStart); err != nil {
+		return fmt.Errorf("work_start: %w", err)
+	}
+	if iv.end, err = parseClock(iv.WorkEnd); err != nil {
+		return fmt.Errorf("work_end: %w", err)
+	}
+	if iv.end <= iv.start {
+		return errors.New("work_end must be after work_start")
+	}
+	if iv.MaxPerDay < 0 {
+		return errors.New("max_per_day must not be negative")
+	}
+	iv.weekdays = make(map[time.Weekday]bool)
+	days := iv.WorkDays
+	if len(days) == 0 {
+		days = []string{"mon", "tue", "wed", "thu", "fri"}
+	}
+	for _, d := range days {
+		wd, ok := weekdayNames[strings.ToLower(d)[:min(3, len(d))]]
+		if !ok {
+			return fmt.Errorf("work_days: unknown day %q", d)
+		}
+		iv.weekdays[wd] = true
+	}
+	return nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("want HH:MM, got %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Location returns the interviewer's time zone.
+func (iv *Interviewer) Location() *time.Location { return iv.loc }
+
+// localDay returns local midnight of the day t falls on for the interviewer.
+func (iv *Interviewer) localDay(t time.Time) time.Time {
+	l := t.In(iv.loc)
+	return time.Date(l.Year(), l.Month(), l.Day(), 0, 0, 0, 0, iv.loc)
+}
+
+// window returns the working hours of the local day starting at midnight day, and whether it is a
+// working day.
+func (iv *Interviewer) window(day time.Time) (time.Time, time.Time, bool) {
+	if !iv.weekdays[day.Weekday()] {
+		return time.Time{}, time.Time{}, false
+	}
+	// Built as wall-clock times rather than by adding to midnight, so DST days keep local hours.
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, int(iv.start/time.Minute), 0, 0, iv.loc)
+	end := time.Date(day.Year(), day.Month(), day.Day(), 0, int(iv.end/time.Minute), 0, 0, iv.loc)
+	return start, end, true
+}
+
+// fits reports whether [start, end) lies inside the interviewer's working hours.
+func (iv *Interviewer) fits(start, end time.Time) bool {
+	ws, we, ok := iv.window(iv.localDay(start))
+	return ok && !start.Before(ws) && !end.After(we)
+}
+
+// Config controls slot allocation.
+type Config struct {
+	// SlotDuration is the length of an interview.
+	SlotDuration time.Duration
+	// LeadTime is the minimum notice before an interview.
+	LeadTime time.Duration
+	// Horizon is how far ahead of the lead time slots are searched.
+	Horizon time.Duration
+}
+
+// DefaultConfig books 45 minute interviews at least 24 hours ahead, within the next 14 days.
+func DefaultConfig() Config {
+	return Config{SlotDuration: 45 * time.Minute, LeadTime: 24 * time.Hour, Horizon: 14 * 24 * time.Hour}
+}
+
+// DefaultInterviewers is the panel used when no interviewers file is configured.
+func DefaultInterviewers() []Interviewer {
+	return []Interviewer{
+		{ID: "iv-priya", Name: "Priya Sharma", Email: "priya@example.com", TimeZone: "Asia/Kolkata", WorkStart: "10:00", WorkEnd: "18:00", MaxPerDay: 6},
+		{ID: "iv-daniel", Name: "Daniel Weber", Email: "daniel@example.com", TimeZone: "Europe/Berlin", WorkStart: "09:00", WorkEnd: "17:00", MaxPerDay: 5},
+		{ID: "iv-maya", Name: "Maya Chen", Email: "maya@example.com", TimeZone: "America/Los_Angeles", WorkStart: "08:00", WorkEnd: "16:00", MaxPerDay: 5},
+	}
+}
+
+// calendarFile is the layout of an interviewers file.
+type calendarFile struct {
+	SlotDuration string        `yaml:"slot_duration"`
+	LeadTime     string        `yaml:"lead_time"`
+	HorizonDays  int           `yaml:"horizon_days"`
+	Interviewers []Interviewer `yaml:"interviewers"`
+}
+
+// LoadCalendar reads the interviewers and allocation settings from a YAML or JSON file. Settings
+// the file leaves out keep their DefaultConfig values.
+func LoadCalendar(path string) ([]Interviewer, Config, error) {
+	cfg := DefaultConfig()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, cfg, err
+	}
+	var f calendarFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	for name, value := range map[string]struct {
+		s   string
+		dst *time.Duration
+	}{"slot_duration": {f.SlotDuration, &cfg.SlotDuration}, "lead_time": {f.LeadTime, &cfg.LeadTime}} {
+		if value.s == "" {
+			continue
+		}
+		d, err := time.ParseDuration(value.s)
+		if err != nil {
+			return nil, cfg, fmt.Errorf("%s: %s: %w", path, name, err)
+		}
+		*value.dst = d
+	}
+	if f.HorizonDays > 0 {
+		cfg.Horizon = time.Duration(f.HorizonDays) * 24 * time.Hour
+	}
+	if len(f.Interviewers) == 0 {
+		return nil, cfg, fmt.Errorf("%s: no interviewers", path)
+	}
+	return f.Interviewers, cfg, nil
+}
+
+// Open builds an allocator from an interviewers file (the default panel and settings when
+// calendarPath is empty) and a SQLite database (bookings kept in memory when dbPath is empty). The
+// returned function closes the database.
+func Open(dbPath, calendarPath string) (*Allocator, func() error, error) {
+	interviewers, cfg := DefaultInterviewers(), DefaultConfig()
+	if calendarPath != "" {
+		var err error
+		if interviewers, cfg, err = LoadCalendar(calendarPath); err != nil {
+			return nil, nil, err
+		}
+	}
+	var store Store = NewMemoryStore()
+	closeStore := func() error { return nil }
+	if dbPath != "" {
+		db, err := OpenSQLiteStore(dbPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		store, closeStore = db, db.Close
+	}
+	a, err := NewAllocator(store, interviewers, cfg)
+	if err != nil {
+		closeStore()
+		return nil, nil, err
+	}
+	return a, closeStore, nil
+}
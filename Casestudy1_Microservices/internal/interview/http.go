@@ -0,0 +1,196 @@
+package interview
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Register attaches the scheduler's routes to mux.
+func (s *Scheduler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /interviews/{student_id}", s.handleGetInterview)
+	mux.HandleFunc("GET /interviews/{student_id}/calendar.ics", s.handleCandidateCalendar)
+	mux.HandleFunc("GET /bookings/{id}", s.handleGetBooking)
+	mux.HandleFunc("POST /bookings/{id}/reschedule", s.handleReschedule)
+	mux.HandleFunc("POST /bookings/{id}/cancel", s.handleCancel)
+	mux.HandleFunc("GET /interviewers", s.handleListInterviewers)
+	mux.HandleFunc("GET /interviewers/{id}/bookings", s.handleInterviewerBookings)
+	mux.HandleFunc("GET /interviewers/{id}/calendar.ics", s.handleInterviewerCalendar)
+}
+
+// interviewResponse keeps the original student_id and slot fields alongside the booking.
+type interviewResponse struct {
+	StudentID string `json:"student_id"`
+	Slot      string `json:"slot"`
+	*Booking
+}
+
+func (s *Scheduler) handleGetInterview(w http.ResponseWriter, r *http.Request) {
+	studentID := r.PathValue("student_id")
+	b, err := s.allocator.ActiveBooking(studentID)
+	if errors.Is(err, ErrBookingNotFound) {
+		http.Error(w, "Interview not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, interviewResponse{StudentID: studentID, Slot: b.Start.Format(time.RFC3339), Booking: b})
+}
+
+func (s *Scheduler) handleGetBooking(w http.ResponseWriter, r *http.Request) {
+	b, err := s.allocator.Store().Get(r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, b)
+}
+
+type rescheduleRequest struct {
+	Start         *time.Time `json:"start,omitempty"`
+	InterviewerID string     `json:"interviewer_id,omitempty"`
+}
+
+func (s *Scheduler) handleReschedule(w http.ResponseWriter, r *http.Request) {
+	var req rescheduleRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+	b, err := s.allocator.Reschedule(r.PathValue("id"), RescheduleRequest{Start: req.Start, InterviewerID: req.InterviewerID})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.notifyScheduled(r.Context(), b, true, ""); err != nil {
+		slog.Error("Booking rescheduled without notification", slog.String("booking_id", b.ID), slog.Any("error", err))
+	}
+	writeJSON(w, http.StatusOK, b)
+}
+
+type cancelRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (s *Scheduler) handleCancel(w http.ResponseWriter, r *http.Request) {
+	var req cancelRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+	b, err := s.allocator.Cancel(r.PathValue("id"), req.Reason)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.notifyCancelled(r.Context(), b); err != nil {
+		slog.Error("Booking cancelled without notification", slog.String("booking_id", b.ID), slog.Any("error", err))
+	}
+	writeJSON(w, http.StatusOK, b)
+}
+
+func (s *Scheduler) handleListInterviewers(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.allocator.Interviewers())
+}
+
+func (s *Scheduler) handleInterviewerBookings(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, ok := s.allocator.Interviewer(id); !ok {
+		writeError(w, ErrUnknownInterviewer)
+		return
+	}
+	from, to, ok := timeRange(w, r)
+	if !ok {
+		return
+	}
+	bookings, err := s.allocator.Store().ListByInterviewer(id, from, to)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if bookings == nil {
+		bookings = []*Booking{}
+	}
+	writeJSON(w, http.StatusOK, bookings)
+}
+
+func (s *Scheduler) handleCandidateCalendar(w http.ResponseWriter, r *http.Request) {
+	studentID := r.PathValue("student_id")
+	bookings, err := s.allocator.Store().ListByStudent(studentID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if len(bookings) == 0 {
+		http.Error(w, "Interview not found", http.StatusNotFound)
+		return
+	}
+	s.writeCalendar(w, "Interviews for "+studentID, bookings)
+}
+
+func (s *Scheduler) handleInterviewerCalendar(w http.ResponseWriter, r *http.Request) {
+	iv, ok := s.allocator.Interviewer(r.PathValue("id"))
+	if !ok {
+		writeError(w, ErrUnknownInterviewer)
+		return
+	}
+	bookings, err := s.allocator.Store().ListByInterviewer(iv.ID, time.Time{}, time.Time{})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.writeCalendar(w, "Interviews with "+iv.Name, bookings)
+}
+
+func (s *Scheduler) writeCalendar(w http.ResponseWriter, name string, bookings []*Booking) {
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	if err := s.allocator.WriteICS(w, name, bookings); err != nil {
+		slog.Error("Failed to write calendar", slog.Any("error", err))
+	}
+}
+
+// timeRange reads the optional RFC 3339 from and to query parameters.
+func timeRange(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, bool) {
+	var out [2]time.Time
+	for i, name := range []string{"from", "to"} {
+		v := r.URL.Query().Get(name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			http.Error(w, "Invalid "+name+": want RFC 3339", http.StatusBadRequest)
+			return time.Time{}, time.Time{}, false
+		}
+		out[i] = t
+	}
+	return out[0], out[1], true
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrBookingNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrUnknownInterviewer):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrSlotUnavailable), errors.Is(err, ErrNoAvailability), errors.Is(err, ErrNotScheduled):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		slog.Error("Request failed", slog.Any("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,92 @@
+package interview
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const icsTimeLayout = "20060102T150405Z"
+
+// WriteICS writes bookings as an iCalendar (RFC 5545) feed named name. Cancelled bookings are kept
+// with STATUS:CANCELLED so that subscribed calendars remove them.
+func (a *Allocator) WriteICS(w io.Writer, name string, bookings []*Booking) error {
+	ics := &icsWriter{w: w}
+	ics.line("BEGIN:VCALENDAR")
+	ics.line("VERSION:2.0")
+	ics.line("PRODID:-//casestudy1_microservices//interview-scheduler//EN")
+	ics.line("CALSCALE:GREGORIAN")
+	ics.line("METHOD:PUBLISH")
+	ics.line("X-WR-CALNAME:" + escapeText(name))
+	stamp := a.now().UTC().Format(icsTimeLayout)
+	for _, b := range bookings {
+		iv, _ := a.Interviewer(b.InterviewerID)
+		interviewer := b.InterviewerID
+		if iv != nil && iv.Name != "" {
+			interviewer = iv.Name
+		}
+		ics.line("BEGIN:VEVENT")
+		ics.line("UID:" + b.ID + "@interview-scheduler")
+		ics.line("DTSTAMP:" + stamp)
+		ics.line("DTSTART:" + b.Start.UTC().Format(icsTimeLayout))
+		ics.line("DTEND:" + b.End.UTC().Format(icsTimeLayout))
+		ics.line(fmt.Sprintf("SEQUENCE:%d", b.Sequence))
+		ics.line("SUMMARY:" + escapeText(fmt.Sprintf("Interview: %s with %s", b.CandidateName, interviewer)))
+		description := fmt.Sprintf("Candidate %s (%s)", b.CandidateName, b.StudentID)
+		if b.JobID != "" {
+			description += " for " + b.JobID
+		}
+		if b.CancelReason != "" {
+			description += ". Cancelled: " + b.CancelReason
+		}
+		ics.line("DESCRIPTION:" + escapeText(description))
+		if iv != nil && iv.Email != "" {
+			ics.line("ORGANIZER;CN=" + escapeParam(interviewer) + ":mailto:" + iv.Email)
+		}
+		if b.Status == StatusCancelled {
+			ics.line("STATUS:CANCELLED")
+		} else {
+			ics.line("STATUS:CONFIRMED")
+		}
+		ics.line("LAST-MODIFIED:" + b.UpdatedAt.UTC().Format(icsTimeLayout))
+		ics.line("END:VEVENT")
+	}
+	ics.line("END:VCALENDAR")
+	return ics.err
+}
+
+// icsWriter writes CRLF-terminated content lines folded at 75 octets.
+type icsWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (iw *icsWriter) line(s string) {
+	if iw.err != nil {
+		return
+	}
+	var b strings.Builder
+	width := 0
+	for _, r := range s {
+		n := len(string(r))
+		if width+n > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += n
+	}
+	b.WriteString("\r\n")
+	_, iw.err = io.WriteString(iw.w, b.String())
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+func escapeText(s string) string { return textEscaper.Replace(s) }
+
+func escapeParam(s string) string {
+	if strings.ContainsAny(s, ";:,") {
+		return `"` + strings.ReplaceAll(s, `"`, "") + `"`
+	}
+	return s
+}
@@ -0,0 +1,136 @@
+// Package interview books interviews for shortlisted candidates with a panel of interviewers,
+// notifies the candidates and serves the bookings and calendars over HTTP.
+package interview
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"casestudy1_microservices/internal/broker"
+	"casestudy1_microservices/internal/events"
+	"casestudy1_microservices/internal/idempotency"
+)
+
+// ConsumerGroup is the group the scheduler joins on the shortlisted topic.
+const ConsumerGroup = "interview-scheduler"
+
+// Scheduler books shortlisted candidates through an Allocator.
+type Scheduler struct {
+	allocator                *Allocator
+	consumer                 broker.Consumer
+	notifications            broker.Publisher
+	seen                     idempotency.Store
+	totalCandidatesProcessed atomic.Int64
+	totalInterviewsScheduled atomic.Int64
+}
+
+// NewScheduler creates a scheduler reading shortlisted candidates from consumer, booking them with
+// allocator and publishing notifications to notifyPublisher. Candidates whose
+// broker.HeaderMessageID is recorded in seen were already handled and are skipped, so a shortlisting
+// event the outbox relay published twice is not notified twice; a nil seen remembers them in memory.
+func NewScheduler(consumer broker.Consumer, notifyPublisher broker.Publisher, allocator *Allocator, seen idempotency.Store) *Scheduler {
+	if seen == nil {
+		seen = idempotency.NewMemoryStore(idempotency.DefaultCapacity, idempotency.DefaultTTL, idempotency.DefaultLease)
+	}
+	return &Scheduler{allocator: allocator, consumer: consumer, notifications: notifyPublisher, seen: seen}
+}
+
+// Run consumes shortlisted candidates until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	slog.Info("Starting consumer loop")
+	if err := s.consumer.Consume(ctx, idempotency.Deduplicate(s.seen, s.handle)); err != nil {
+		slog.Error("Consumer error", slog.Any("error", err))
+	}
+}
+
+func (s *Scheduler) handle(ctx context.Context, msg broker.Message) error {
+	var candidate events.ShortlistedCandidate
+	if err := json.Unmarshal(msg.Value, &candidate); err != nil {
+		slog.Error("Failed to deserialize message", slog.String("key", string(msg.Key)), slog.Any("error", err))
+		return broker.Permanent(fmt.Errorf("decode shortlisted candidate: %w", err))
+	}
+	return s.processCandidate(ctx, candidate, msg.Headers[broker.HeaderMessageID])
+}
+
+// processCandidate books candidate and notifies them. A non-empty msgID is the consumed message's ID;
+// the notification gets an ID derived from it, so that a notification repeated because the scheduler
+// crashed before recording the candidate as handled can be dropped by its consumers too.
+func (s *Scheduler) processCandidate(ctx context.Context, candidate events.ShortlistedCandidate, msgID string) error {
+	s.totalCandidatesProcessed.Add(1)
+	booking, err := s.allocator.Book(BookingRequest{
+		StudentID:     candidate.StudentID,
+		CandidateName: candidate.Name,
+		JobID:         candidate.JobID,
+	})
+	if errors.Is(err, ErrNoAvailability) {
+		slog.Error("No interview slot available", slog.String("student_id", candidate.StudentID))
+		return broker.Permanent(err)
+	}
+	if err != nil {
+		slog.Error("Failed to book interview", slog.String("student_id", candidate.StudentID), slog.Any("error", err))
+		return fmt.Errorf("book interview: %w", err)
+	}
+	s.totalInterviewsScheduled.Add(1)
+
+	notifyID := ""
+	if msgID != "" {
+		notifyID = msgID + "/scheduled"
+	}
+	if err := s.notifyScheduled(ctx, booking, false, notifyID); err != nil {
+		return err
+	}
+	slog.Info("Interview scheduled", slog.String("student_id", candidate.StudentID), slog.String("booking_id", booking.ID),
+		slog.String("interviewer_id", booking.InterviewerID), slog.String("slot", booking.Start.Format(time.RFC3339)))
+	return nil
+}
+
+func (s *Scheduler) notifyScheduled(ctx context.Context, b *Booking, rescheduled bool, msgID string) error {
+	event := events.InterviewScheduled{
+		StudentID:     b.StudentID,
+		Name:          b.CandidateName,
+		InterviewSlot: b.Start.Format(time.RFC3339),
+		InterviewEnd:  b.End.Format(time.RFC3339),
+		BookingID:     b.ID,
+		JobID:         b.JobID,
+		InterviewerID: b.InterviewerID,
+		Rescheduled:   rescheduled,
+	}
+	if iv, ok := s.allocator.Interviewer(b.InterviewerID); ok {
+		event.InterviewerName = iv.Name
+	}
+	return s.notify(ctx, events.SubjectInterviewNotify, b.StudentID, msgID, event)
+}
+
+func (s *Scheduler) notifyCancelled(ctx context.Context, b *Booking) error {
+	return s.notify(ctx, events.SubjectInterviewCancelled, b.StudentID, "", events.InterviewCancelled{
+		StudentID:     b.StudentID,
+		Name:          b.CandidateName,
+		BookingID:     b.ID,
+		JobID:         b.JobID,
+		InterviewSlot: b.Start.Format(time.RFC3339),
+		Reason:        b.CancelReason,
+	})
+}
+
+// notify publishes event to subject, with msgID as its broker.HeaderMessageID when set.
+func (s *Scheduler) notify(ctx context.Context, subject, studentID, msgID string, event any) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		slog.Error("Failed to serialize notification", slog.String("subject", subject), slog.String("student_id", studentID), slog.Any("error", err))
+		return broker.Permanent(err)
+	}
+	msg := broker.Message{Topic: subject, Key: []byte(studentID), Value: data}
+	if msgID != "" {
+		msg.Headers = map[string]string{broker.HeaderMessageID: msgID}
+	}
+	if err := s.notifications.Publish(ctx, msg); err != nil {
+		slog.Error("Failed to publish notification", slog.String("subject", subject), slog.String("student_id", studentID), slog.Any("error", err))
+		return fmt.Errorf("publish %s: %w", subject, err)
+	}
+	return nil
+}
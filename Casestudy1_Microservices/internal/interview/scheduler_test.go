@@ -0,0 +1,129 @@
+package interview
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"casestudy1_microservices/internal/broker"
+	"casestudy1_microservices/internal/events"
+)
+
+// replayConsumer hands its messages to the handler in order and then returns, recording each
+// handler result.
+type replayConsumer struct {
+	msgs []broker.Message
+	errs []error
+}
+
+func (c *replayConsumer) Consume(ctx context.Context, handler broker.Handler) error {
+	for _, m := range c.msgs {
+		c.errs = append(c.errs, handler(ctx, m))
+	}
+	return nil
+}
+
+func (c *replayConsumer) Close() error { return nil }
+
+type recordingPublisher struct {
+	mu   sync.Mutex
+	msgs []broker.Message
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, msg broker.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.msgs = append(p.msgs, msg)
+	return nil
+}
+
+func shortlistedMessage(t *testing.T, studentID, msgID string) broker.Message {
+	t.Helper()
+	data, err := json.Marshal(events.ShortlistedCandidate{StudentID: studentID, Name: "Candidate " + studentID, JobID: "job-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := broker.Message{Topic: events.TopicShortlistedCandidates, Key: []byte(studentID), Value: data}
+	if msgID != "" {
+		msg.Headers = map[string]string{broker.HeaderMessageID: msgID}
+	}
+	return msg
+}
+
+func TestSchedulerDropsRepeatedMessages(t *testing.T) {
+	consumer := &replayConsumer{msgs: []broker.Message{
+		shortlistedMessage(t, "S-1", "k1/0"),
+		// The outbox relay crashed after publishing k1/0 and published it again.
+		shortlistedMessage(t, "S-1", "k1/0"),
+		shortlistedMessage(t, "S-2", "k2/0"),
+	}}
+	notifications := &recordingPublisher{}
+	s := NewScheduler(consumer, notifications, newTestAllocator(t, NewMemoryStore(), kolkata(4)), nil)
+	s.Run(context.Background())
+
+	for i, err := range consumer.errs {
+		if err != nil {
+			t.Fatalf("message %d: %v", i, err)
+		}
+	}
+	if len(notifications.msgs) != 2 {
+		t.Fatalf("%d notifications for two distinct candidates, want 2", len(notifications.msgs))
+	}
+	for i, want := range []string{"k1/0/scheduled", "k2/0/scheduled"} {
+		if got := notifications.msgs[i].Headers[broker.HeaderMessageID]; got != want {
+			t.Errorf("notification %d has message ID %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestSchedulerRetriesFailedMessage(t *testing.T) {
+	consumer := &replayConsumer{msgs: []broker.Message{
+		shortlistedMessage(t, "S-1", "k1/0"),
+		shortlistedMessage(t, "S-1", "k1/0"),
+	}}
+	notifications := &failOncePublisher{}
+	s := NewScheduler(consumer, notifications, newTestAllocator(t, NewMemoryStore(), kolkata(4)), nil)
+	s.Run(context.Background())
+
+	// The first attempt failed to notify, so the redelivery is a retry rather than a duplicate.
+	if consumer.errs[0] == nil || consumer.errs[1] != nil {
+		t.Fatalf("handler results %v, want a failure then a success", consumer.errs)
+	}
+	if notifications.published != 1 {
+		t.Fatalf("%d notifications published, want 1", notifications.published)
+	}
+}
+
+func TestSchedulerHandlesMessagesWithoutID(t *testing.T) {
+	// Without an ID, copies cannot be told apart from a deliberate resend; the booking is reused
+	// and the candidate is notified each time.
+	consumer := &replayConsumer{msgs: []broker.Message{
+		shortlistedMessage(t, "S-1", ""),
+		shortlistedMessage(t, "S-1", ""),
+	}}
+	notifications := &recordingPublisher{}
+	s := NewScheduler(consumer, notifications, newTestAllocator(t, NewMemoryStore(), kolkata(4)), nil)
+	s.Run(context.Background())
+
+	if len(notifications.msgs) != 2 {
+		t.Fatalf("%d notifications, want 2", len(notifications.msgs))
+	}
+	if id := notifications.msgs[0].Headers[broker.HeaderMessageID]; id != "" {
+		t.Fatalf("notification has message ID %q without a consumed ID", id)
+	}
+}
+
+type failOncePublisher struct {
+	failed    bool
+	published int
+}
+
+func (p *failOncePublisher) Publish(context.Context, broker.Message) error {
+	if !p.failed {
+		p.failed = true
+		return context.DeadlineExceeded
+	}
+	p.published++
+	return nil
+}
@@ -0,0 +1,184 @@
+package interview
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteTimeLayout is fixed width in UTC so that times compare correctly as text.
+const sqliteTimeLayout = "2006-01-02T15:04:05.000000Z"
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS interview_bookings (
+	id             TEXT PRIMARY KEY,
+	student_id     TEXT NOT NULL,
+	candidate_name TEXT NOT NULL,
+	job_id         TEXT NOT NULL DEFAULT '',
+	interviewer_id TEXT NOT NULL,
+	start_at       TEXT NOT NULL,
+	end_at         TEXT NOT NULL,
+	status         TEXT NOT NULL,
+	sequence       INTEGER NOT NULL DEFAULT 0,
+	cancel_reason  TEXT NOT NULL DEFAULT '',
+	created_at     TEXT NOT NULL,
+	updated_at     TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS interview_bookings_interviewer ON interview_bookings (interviewer_id, start_at);
+CREATE INDEX IF NOT EXISTS interview_bookings_student ON interview_bookings (student_id, start_at);
+`
+
+// SQLiteStore persists bookings in a SQLite database file.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// OpenSQLiteStore opens (creating if needed) the database at path. Transactions take the write lock
+// when they begin, so the conflict check and the write of concurrent bookings are serialised even
+// across processes.
+func OpenSQLiteStore(path string) (*SQLiteStore, error) {
+	q := url.Values{}
+	q.Add("_pragma", "busy_timeout(5000)")
+	q.Add("_pragma", "journal_mode(WAL)")
+	q.Set("_txlock", "immediate")
+	db, err := sql.Open("sqlite", "file:"+path+"?"+q.Encode())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create interview schema: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLiteStore) Create(b *Booking) error {
+	return s.write(b, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`INSERT INTO interview_bookings
+			(id, student_id, candidate_name, job_id, interviewer_id, start_at, end_at, status, sequence, cancel_reason, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			b.ID, b.StudentID, b.CandidateName, b.JobID, b.InterviewerID, formatTime(b.Start), formatTime(b.End),
+			string(b.Status), b.Sequence, b.CancelReason, formatTime(b.CreatedAt), formatTime(b.UpdatedAt))
+		return err
+	})
+}
+
+func (s *SQLiteStore) Update(b *Booking) error {
+	return s.write(b, func(tx *sql.Tx) error {
+		res, err := tx.Exec(`UPDATE interview_bookings SET interviewer_id = ?, start_at = ?, end_at = ?, status = ?,
+			sequence = ?, cancel_reason = ?, updated_at = ? WHERE id = ?`,
+			b.InterviewerID, formatTime(b.Start), formatTime(b.End), string(b.Status), b.Sequence, b.CancelReason,
+			formatTime(b.UpdatedAt), b.ID)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrBookingNotFound
+		}
+		return nil
+	})
+}
+
+// write runs the conflict check and apply in one transaction.
+func (s *SQLiteStore) write(b *Booking, apply func(*sql.Tx) error) error {
+	tx, err := s.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if b.Status == StatusScheduled {
+		var conflicts int
+		err := tx.QueryRow(`SELECT COUNT(*) FROM interview_bookings
+			WHERE interviewer_id = ? AND status = ? AND id <> ? AND start_at < ? AND end_at > ?`,
+			b.InterviewerID, string(StatusScheduled), b.ID, formatTime(b.End), formatTime(b.Start)).Scan(&conflicts)
+		if err != nil {
+			return err
+		}
+		if conflicts > 0 {
+			return ErrSlotUnavailable
+		}
+	}
+	if err := apply(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+const bookingColumns = `id, student_id, candidate_name, job_id, interviewer_id, start_at, end_at, status, sequence, cancel_reason, created_at, updated_at`
+
+func (s *SQLiteStore) Get(id string) (*Booking, error) {
+	b, err := scanBooking(s.db.QueryRow(`SELECT `+bookingColumns+` FROM interview_bookings WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBookingNotFound
+	}
+	return b, err
+}
+
+func (s *SQLiteStore) ListByStudent(studentID string) ([]*Booking, error) {
+	return s.query(`SELECT `+bookingColumns+` FROM interview_bookings WHERE student_id = ? ORDER BY start_at, id`, studentID)
+}
+
+func (s *SQLiteStore) ListByInterviewer(interviewerID string, from, to time.Time) ([]*Booking, error) {
+	if to.IsZero() {
+		return s.query(`SELECT `+bookingColumns+` FROM interview_bookings
+			WHERE interviewer_id = ? AND end_at > ? ORDER BY start_at, id`, interviewerID, formatTime(from))
+	}
+	return s.query(`SELECT `+bookingColumns+` FROM interview_bookings
+		WHERE interviewer_id = ? AND end_at > ? AND start_at < ? ORDER BY start_at, id`,
+		interviewerID, formatTime(from), formatTime(to))
+}
+
+func (s *SQLiteStore) query(query string, args ...any) ([]*Booking, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*Booking
+	for rows.Next() {
+		b, err := scanBooking(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanBooking(row scanner) (*Booking, error) {
+	var b Booking
+	var status, start, end, created, updated string
+	if err := row.Scan(&b.ID, &b.StudentID, &b.CandidateName, &b.JobID, &b.InterviewerID, &start, &end,
+		&status, &b.Sequence, &b.CancelReason, &created, &updated); err != nil {
+		return nil, err
+	}
+	b.Status = Status(status)
+	for _, f := range []struct {
+		s   string
+		dst *time.Time
+	}{{start, &b.Start}, {end, &b.End}, {created, &b.CreatedAt}, {updated, &b.UpdatedAt}} {
+		t, err := time.Parse(sqliteTimeLayout, f.s)
+		if err != nil {
+			return nil, fmt.Errorf("booking %s: %w", b.ID, err)
+		}
+		*f.dst = t
+	}
+	return &b, nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeLayout)
+}
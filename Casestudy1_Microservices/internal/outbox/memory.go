@@ -0,0 +1,71 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps the outbox in process memory. It makes publishing independent of the consumer's
+// acknowledgements, but everything is lost on restart; use it for development and tests.
+type MemoryStore struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu        sync.Mutex
+	consumed  map[string]time.Time
+	messages  []Message
+	nextID    int64
+	lastPurge time.Time
+}
+
+// NewMemoryStore returns an empty store that remembers consumed keys for ttl.
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return &MemoryStore{ttl: ttl, now: time.Now, consumed: make(map[string]time.Time)}
+}
+
+func (s *MemoryStore) Commit(_ context.Context, key string, msgs []Message) (bool, error) {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if expires, ok := s.consumed[key]; ok && expires.After(now) {
+		return false, nil
+	}
+	s.consumed[key] = now.Add(s.ttl)
+	for _, m := range withIDs(key, msgs) {
+		s.nextID++
+		m.ID = s.nextID
+		s.messages = append(s.messages, m)
+	}
+	if now.Sub(s.lastPurge) >= purgeInterval {
+		s.lastPurge = now
+		for k, expires := range s.consumed {
+			if !expires.After(now) {
+				delete(s.consumed, k)
+			}
+		}
+	}
+	return true, nil
+}
+
+func (s *MemoryStore) Pending(_ context.Context, limit int) ([]Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages[:min(limit, len(s.messages))]...), nil
+}
+
+func (s *MemoryStore) Delivered(_ context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, m := range s.messages {
+		if m.ID == id {
+			s.messages = append(s.messages[:i], s.messages[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
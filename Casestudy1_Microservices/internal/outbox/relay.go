@@ -0,0 +1,98 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"casestudy1_microservices/internal/broker"
+)
+
+const (
+	// relayBatch is the number of messages read from the store at a time.
+	relayBatch = 100
+	// pollInterval bounds how long a committed message waits when the relay is not notified, for
+	// example when it was committed before a restart.
+	pollInterval   = time.Second
+	initialBackoff = 200 * time.Millisecond
+	maxBackoff     = 5 * time.Second
+)
+
+// Relay publishes the messages of a store in commit order. A message that cannot be published stops
+// the relay until it can, so messages for one key are never reordered.
+type Relay struct {
+	store  Store
+	routes map[string]broker.Publisher
+	wake   chan struct{}
+}
+
+// NewRelay returns a relay delivering each message of store with the publisher of its route.
+func NewRelay(store Store, routes map[string]broker.Publisher) *Relay {
+	return &Relay{store: store, routes: routes, wake: make(chan struct{}, 1)}
+}
+
+// Notify tells a running relay that messages were committed.
+func (r *Relay) Notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Flush publishes pending messages until the outbox is empty or a message fails. It returns the
+// number of messages delivered.
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	delivered := 0
+	for {
+		msgs, err := r.store.Pending(ctx, relayBatch)
+		if err != nil {
+			return delivered, fmt.Errorf("read outbox: %w", err)
+		}
+		if len(msgs) == 0 {
+			return delivered, nil
+		}
+		for _, m := range msgs {
+			pub, ok := r.routes[m.Route]
+			if !ok {
+				return delivered, fmt.Errorf("outbox message %d: no publisher for route %q", m.ID, m.Route)
+			}
+			if err := pub.Publish(ctx, m.Message); err != nil {
+				return delivered, fmt.Errorf("publish outbox message %d to %s: %w", m.ID, m.Topic, err)
+			}
+			// A crash before this line publishes the message again, with the same message ID.
+			if err := r.store.Delivered(ctx, m.ID); err != nil {
+				return delivered, fmt.Errorf("mark outbox message %d delivered: %w", m.ID, err)
+			}
+			delivered++
+		}
+	}
+}
+
+// Run flushes the outbox whenever it is notified, and at least every second, until ctx is cancelled.
+// Failures are retried with backoff; undelivered messages stay in the store for the next Run.
+func (r *Relay) Run(ctx context.Context) {
+	backoff := initialBackoff
+	for {
+		wait := pollInterval
+		wake := r.wake
+		if _, err := r.Flush(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Error("Outbox relay failed", slog.Duration("retry_in", backoff), slog.Any("error", err))
+			// Retry on the backoff schedule even if more messages are committed meanwhile.
+			wait, wake = backoff, nil
+			backoff = min(backoff*2, maxBackoff)
+		} else {
+			backoff = initialBackoff
+		}
+
+		select {
+		case <-wake:
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS outbox_consumed (
+	key        TEXT PRIMARY KEY,
+	expires_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS outbox_consumed_expires ON outbox_consumed (expires_at);
+CREATE TABLE IF NOT EXISTS outbox_messages (
+	id      INTEGER PRIMARY KEY AUTOINCREMENT,
+	route   TEXT NOT NULL,
+	topic   TEXT NOT NULL,
+	key     BLOB,
+	value   BLOB,
+	headers TEXT NOT NULL
+);
+`
+
+// purgeInterval is how often expired keys are deleted from the database.
+const purgeInterval = 10 * time.Minute
+
+// SQLiteStore keeps the outbox in a SQLite database file, so it survives restarts of a single host.
+type SQLiteStore struct {
+	db  *sql.DB
+	ttl time.Duration
+	now func() time.Time
+
+	mu        sync.Mutex
+	lastPurge time.Time
+}
+
+// OpenSQLiteStore opens (creating if needed) the database at path. Transactions take the write lock
+// when they begin, so concurrent commits of one key are serialised even across processes.
+func OpenSQLiteStore(path string, ttl time.Duration) (*SQLiteStore, error) {
+	q := url.Values{}
+	q.Add("_pragma", "busy_timeout(5000)")
+	q.Add("_pragma", "journal_mode(WAL)")
+	q.Set("_txlock", "immediate")
+	db, err := sql.Open("sqlite", "file:"+path+"?"+q.Encode())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create outbox schema: %w", err)
+	}
+	return &SQLiteStore{db: db, ttl: ttl, now: time.Now}, nil
+}
+
+func (s *SQLiteStore) Commit(ctx context.Context, key string, msgs []Message) (bool, error) {
+	now := s.now()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// An expired key is taken over; a live one leaves the insert without effect.
+	res, err := tx.ExecContext(ctx, `INSERT INTO outbox_consumed (key, expires_at) VALUES (?, ?)
+		ON CONFLICT (key) DO UPDATE SET expires_at = excluded.expires_at WHERE outbox_consumed.expires_at <= ?`,
+		key, now.Add(s.ttl).UnixNano(), now.UnixNano())
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	for _, m := range withIDs(key, msgs) {
+		headers, err := json.Marshal(m.Headers)
+		if err != nil {
+			return false, err
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO outbox_messages (route, topic, key, value, headers) VALUES (?, ?, ?, ?, ?)`,
+			m.Route, m.Topic, m.Key, m.Value, string(headers)); err != nil {
+			return false, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, s.purge(ctx, now)
+}
+
+func (s *SQLiteStore) Pending(ctx context.Context, limit int) ([]Message, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, route, topic, key, value, headers FROM outbox_messages ORDER BY id LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var msgs []Message
+	for rows.Next() {
+		var m Message
+		var headers string
+		if err := rows.Scan(&m.ID, &m.Route, &m.Topic, &m.Key, &m.Value, &headers); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(headers), &m.Headers); err != nil {
+			return nil, fmt.Errorf("decode headers of outbox message %d: %w", m.ID, err)
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs, rows.Err()
+}
+
+func (s *SQLiteStore) Delivered(ctx context.Context, id int64) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM outbox_messages WHERE id = ?`, id)
+	return err
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// purge deletes expired keys at most once per purgeInterval.
+func (s *SQLiteStore) purge(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	if now.Sub(s.lastPurge) < purgeInterval {
+		s.mu.Unlock()
+		return nil
+	}
+	s.lastPurge = now
+	s.mu.Unlock()
+	_, err := s.db.ExecContext(ctx, `DELETE FROM outbox_consumed WHERE expires_at <= ?`, now.UnixNano())
+	return err
+}
@@ -0,0 +1,121 @@
+// Package outbox makes consume-transform-produce steps atomic. A handler records that it consumed a
+// message together with the messages it produces, in one transaction, before the consumed message is
+// acknowledged. A Relay then delivers the recorded messages, so a crash at any point either loses
+// nothing or leaves the work to be finished after a restart.
+//
+// Delivery is at least once, not exactly once: a crash between publishing a message and recording
+// its delivery publishes it again after the restart, and neither the Kafka writer nor core NATS
+// suppresses the copy. Every message carries a HeaderMessageID derived from the consumed message, so
+// the copies share it; a consumer only sees each message once if it drops repeats of that ID, as
+// idempotency.Deduplicate does. The NATS transport also sends the ID as Nats-Msg-Id, which a
+// JetStream stream on the subject uses to discard copies within its duplicate window.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"casestudy1_microservices/internal/broker"
+)
+
+// HeaderMessageID identifies a produced message. Redeliveries of a message, including ones produced
+// by another instance from the same consumed message, have the same ID.
+const HeaderMessageID = broker.HeaderMessageID
+
+// Message is a message waiting in the outbox.
+type Message struct {
+	// ID orders the outbox; it is assigned by the store.
+	ID int64
+	// Route names the publisher the relay delivers the message with.
+	Route string
+	broker.Message
+}
+
+// Store holds the keys of consumed messages and the messages produced from them.
+type Store interface {
+	// Commit records key as consumed and enqueues msgs, atomically. It reports false, enqueueing
+	// nothing, if key was committed within the store's TTL.
+	Commit(ctx context.Context, key string, msgs []Message) (bool, error)
+	// Pending returns up to limit undelivered messages in the order they were committed.
+	Pending(ctx context.Context, limit int) ([]Message, error)
+	// Delivered removes a published message from the outbox.
+	Delivered(ctx context.Context, id int64) error
+	Close() error
+}
+
+// withIDs copies msgs, setting the HeaderMessageID of the i-th message to key/i.
+func withIDs(key string, msgs []Message) []Message {
+	out := make([]Message, len(msgs))
+	for i, m := range msgs {
+		headers := make(map[string]string, len(m.Headers)+1)
+		maps.Copy(headers, m.Headers)
+		headers[HeaderMessageID] = key + "/" + strconv.Itoa(i)
+		m.Headers = headers
+		out[i] = m
+	}
+	return out
+}
+
+// Supported drivers. DriverNone disables the outbox: Open returns a nil Store.
+const (
+	DriverNone   = "none"
+	DriverMemory = "memory"
+	DriverSQLite = "sqlite"
+)
+
+const (
+	// DefaultTTL is how long a consumed key is remembered.
+	DefaultTTL = 24 * time.Hour
+
+	defaultSQLitePath = "outbox.db"
+)
+
+// Config selects and configures a store.
+type Config struct {
+	Driver     string
+	TTL        time.Duration
+	SQLitePath string
+}
+
+// ConfigFromEnv reads the driver from OUTBOX_STORE (defaultDriver when unset), the TTL from
+// OUTBOX_TTL and the SQLite file from OUTBOX_DB.
+func ConfigFromEnv(defaultDriver string) Config {
+	cfg := Config{
+		Driver:     strings.ToLower(getenv("OUTBOX_STORE", defaultDriver)),
+		TTL:        DefaultTTL,
+		SQLitePath: getenv("OUTBOX_DB", defaultSQLitePath),
+	}
+	if d, err := time.ParseDuration(os.Getenv("OUTBOX_TTL")); err == nil && d > 0 {
+		cfg.TTL = d
+	}
+	return cfg
+}
+
+// Open creates the configured store, or returns nil for DriverNone. A zero TTL uses DefaultTTL.
+func Open(cfg Config) (Store, error) {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultTTL
+	}
+	switch cfg.Driver {
+	case DriverNone:
+		return nil, nil
+	case DriverMemory:
+		return NewMemoryStore(cfg.TTL), nil
+	case DriverSQLite:
+		return OpenSQLiteStore(cfg.SQLitePath, cfg.TTL)
+	default:
+		return nil, fmt.Errorf("unknown outbox store %q (want %s, %s or %s)", cfg.Driver, DriverNone, DriverMemory, DriverSQLite)
+	}
+}
+
+func getenv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
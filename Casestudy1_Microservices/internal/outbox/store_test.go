@@ -0,0 +1,137 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"casestudy1_microservices/internal/broker"
+)
+
+func message(route, topic, value string) Message {
+	return Message{Route: route, Message: broker.Message{Topic: topic, Key: []byte("S-1"), Value: []byte(value)}}
+}
+
+func testStore(t *testing.T, s Store) {
+	ctx := context.Background()
+	ok, err := s.Commit(ctx, "k1", []Message{message("events", "decisions", "a"), message("notifications", "notify", "a")})
+	if err != nil || !ok {
+		t.Fatalf("Commit(k1) = %v, %v", ok, err)
+	}
+	if ok, err := s.Commit(ctx, "k1", []Message{message("events", "decisions", "again")}); err != nil || ok {
+		t.Fatalf("second Commit(k1) = %v, %v, want false", ok, err)
+	}
+	if ok, err := s.Commit(ctx, "k2", []Message{message("events", "decisions", "b")}); err != nil || !ok {
+		t.Fatalf("Commit(k2) = %v, %v", ok, err)
+	}
+
+	pending, err := s.Pending(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var values, ids []string
+	for _, m := range pending {
+		values = append(values, string(m.Value))
+		ids = append(ids, m.Headers[HeaderMessageID])
+	}
+	if len(pending) != 3 || values[0] != "a" || values[1] != "a" || values[2] != "b" {
+		t.Fatalf("pending values = %v", values)
+	}
+	if ids[0] != "k1/0" || ids[1] != "k1/1" || ids[2] != "k2/0" {
+		t.Fatalf("message IDs = %v", ids)
+	}
+	if pending[1].Route != "notifications" || pending[1].Topic != "notify" || string(pending[1].Key) != "S-1" {
+		t.Fatalf("second message = %+v", pending[1])
+	}
+
+	if err := s.Delivered(ctx, pending[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if pending, err = s.Pending(ctx, 1); err != nil || len(pending) != 1 || pending[0].Headers[HeaderMessageID] != "k1/1" {
+		t.Fatalf("Pending after delivery = %+v, %v", pending, err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	s := NewMemoryStore(time.Hour)
+	testStore(t, s)
+
+	// An expired key is committed again.
+	s.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if ok, err := s.Commit(context.Background(), "k1", nil); err != nil || !ok {
+		t.Fatalf("Commit after TTL = %v, %v", ok, err)
+	}
+}
+
+func TestSQLiteStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.db")
+	s, err := OpenSQLiteStore(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, s)
+	s.Close()
+
+	// Keys and undelivered messages survive a restart.
+	s, err = OpenSQLiteStore(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	ctx := context.Background()
+	if ok, err := s.Commit(ctx, "k2", nil); err != nil || ok {
+		t.Fatalf("Commit(k2) after restart = %v, %v, want false", ok, err)
+	}
+	if pending, err := s.Pending(ctx, 10); err != nil || len(pending) != 2 {
+		t.Fatalf("Pending after restart = %d messages, %v", len(pending), err)
+	}
+	s.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if ok, err := s.Commit(ctx, "k2", nil); err != nil || !ok {
+		t.Fatalf("Commit after TTL = %v, %v", ok, err)
+	}
+}
+
+// flaky fails every publish while down is set.
+type flaky struct {
+	down bool
+	sent []broker.Message
+}
+
+func (f *flaky) Publish(_ context.Context, msg broker.Message) error {
+	if f.down {
+		return errors.New("broker unavailable")
+	}
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+func TestRelayKeepsOrderAcrossFailures(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(time.Hour)
+	events, notify := &flaky{}, &flaky{down: true}
+	relay := NewRelay(s, map[string]broker.Publisher{"events": events, "notifications": notify})
+	s.Commit(ctx, "k1", []Message{message("events", "decisions", "a"), message("notifications", "notify", "a")})
+	s.Commit(ctx, "k2", []Message{message("events", "decisions", "b")})
+
+	// The failing notification holds back the later event.
+	if n, err := relay.Flush(ctx); err == nil || n != 1 {
+		t.Fatalf("Flush = %d, %v, want 1 and an error", n, err)
+	}
+	if len(events.sent) != 1 {
+		t.Fatalf("%d events sent past a failed notification", len(events.sent))
+	}
+	notify.down = false
+	if n, err := relay.Flush(ctx); err != nil || n != 2 {
+		t.Fatalf("Flush = %d, %v, want 2", n, err)
+	}
+	if len(events.sent) != 2 || string(events.sent[1].Value) != "b" || len(notify.sent) != 1 {
+		t.Fatalf("sent %d events, %d notifications", len(events.sent), len(notify.sent))
+	}
+
+	s.Commit(ctx, "k3", []Message{message("audit", "audit", "c")})
+	if _, err := relay.Flush(ctx); err == nil {
+		t.Fatal("Flush delivered a message without a publisher for its route")
+	}
+}
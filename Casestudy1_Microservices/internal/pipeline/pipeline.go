@@ -0,0 +1,109 @@
+// Package pipeline wires the resume collector, the shortlisting service and the interview scheduler
+// into one process, for local development and end-to-end tests.
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"casestudy1_microservices/internal/broker"
+	"casestudy1_microservices/internal/events"
+	"casestudy1_microservices/internal/idempotency"
+	"casestudy1_microservices/internal/interview"
+	"casestudy1_microservices/internal/outbox"
+	"casestudy1_microservices/internal/resume"
+	"casestudy1_microservices/internal/shortlist"
+)
+
+// Pipeline is the three services sharing one event transport and one notification transport.
+type Pipeline struct {
+	collector *resume.Collector
+	shortlist *shortlist.Service
+	scheduler *interview.Scheduler
+	consumers []broker.Consumer
+}
+
+// Config tunes the services.
+type Config struct {
+	// Workers is the number of shortlisting workers; <= 0 uses shortlist.DefaultWorkerCount.
+	Workers int
+	// Policies decides the resumes; nil uses shortlist.DefaultPolicy.
+	Policies *shortlist.Policies
+	// Idempotency records the processed resumes and the IDs of the shortlisted candidates the
+	// scheduler handled; nil keeps them in memory. The shortlisting service does not use it with an
+	// Outbox.
+	Idempotency idempotency.Store
+	// Outbox, if set, makes the shortlisting service commit its decisions there before acknowledging
+	// a resume; see shortlist.NewOutboxService.
+	Outbox outbox.Store
+	// Allocator books the interviews; nil books the default panel in memory.
+	Allocator *interview.Allocator
+	// Resumes configures document uploads to the collector; they are disabled without an archive.
+	Resumes resume.Config
+}
+
+// New subscribes the services' consumers. Events published after New returns are processed once Run
+// is called.
+func New(eventTransport, notifyTransport broker.Transport, cfg Config) (*Pipeline, error) {
+	if cfg.Allocator == nil {
+		a, _, err := interview.Open("", "")
+		if err != nil {
+			return nil, err
+		}
+		cfg.Allocator = a
+	}
+	resumes, err := eventTransport.Subscribe(events.TopicResumeUploaded, shortlist.ConsumerGroup)
+	if err != nil {
+		return nil, err
+	}
+	shortlisted, err := eventTransport.Subscribe(events.TopicShortlistedCandidates, interview.ConsumerGroup)
+	if err != nil {
+		resumes.Close()
+		return nil, err
+	}
+	svc := shortlist.NewService(resumes, eventTransport, notifyTransport, cfg.Policies, cfg.Idempotency, cfg.Workers)
+	if cfg.Outbox != nil {
+		svc = shortlist.NewOutboxService(resumes, eventTransport, notifyTransport, cfg.Policies, cfg.Outbox, cfg.Workers)
+	}
+	return &Pipeline{
+		collector: resume.NewCollector(eventTransport, cfg.Resumes),
+		shortlist: svc,
+		scheduler: interview.NewScheduler(shortlisted, notifyTransport, cfg.Allocator, cfg.Idempotency),
+		consumers: []broker.Consumer{resumes, shortlisted},
+	}, nil
+}
+
+// Handler serves the routes of all three services.
+func (p *Pipeline) Handler() http.Handler {
+	mux := http.NewServeMux()
+	p.collector.Register(mux)
+	p.shortlist.Register(mux)
+	p.scheduler.Register(mux)
+	return mux
+}
+
+// Run processes events until ctx is cancelled.
+func (p *Pipeline) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		p.shortlist.Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		p.scheduler.Run(ctx)
+	}()
+	wg.Wait()
+}
+
+// Close closes the consumers. The transports belong to the caller.
+func (p *Pipeline) Close() error {
+	var errs []error
+	for _, c := range p.consumers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
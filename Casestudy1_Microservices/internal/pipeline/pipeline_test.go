@@ -0,0 +1,137 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"casestudy1_microservices/internal/broker"
+	"casestudy1_microservices/internal/events"
+	"casestudy1_microservices/internal/infra/memory"
+	"casestudy1_microservices/internal/outbox"
+)
+
+// collect subscribes to subject and sends the student IDs of its messages to the returned channel.
+func collect(t *testing.T, ctx context.Context, bus *memory.Bus, subject string) <-chan string {
+	t.Helper()
+	consumer, err := bus.Subscribe(subject, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { consumer.Close() })
+	out := make(chan string, 16)
+	go consumer.Consume(ctx, func(_ context.Context, msg broker.Message) error {
+		var body struct {
+			StudentID string `json:"student_id"`
+		}
+		if err := json.Unmarshal(msg.Value, &body); err != nil {
+			return err
+		}
+		out <- body.StudentID
+		return nil
+	})
+	return out
+}
+
+func TestPipelineEndToEnd(t *testing.T) {
+	t.Run("direct", func(t *testing.T) { testPipeline(t, Config{Workers: 2}) })
+	t.Run("outbox", func(t *testing.T) { testPipeline(t, Config{Workers: 2, Outbox: outbox.NewMemoryStore(time.Hour)}) })
+}
+
+func testPipeline(t *testing.T, cfg Config) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bus := memory.NewBus(0)
+	defer bus.Close()
+	shortlisted := collect(t, ctx, bus, events.SubjectShortlistNotify)
+	scheduled := collect(t, ctx, bus, events.SubjectInterviewNotify)
+	rejected := collect(t, ctx, bus, events.SubjectRejectionNotify)
+
+	p, err := New(bus, bus, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	done := make(chan struct{})
+	go func() {
+		p.Run(ctx)
+		close(done)
+	}()
+	server := httptest.NewServer(p.Handler())
+	defer server.Close()
+
+	resumes := []map[string]any{
+		{"student_id": "S-1", "name": "Asha", "cgpa": 6.5, "branch": "CSE", "skills": []string{"GoLang"}},
+		{"student_id": "S-2", "name": "Ravi", "cgpa": 6.0, "branch": "MECH", "skills": []string{"java"}},
+		{"student_id": "S-3", "name": "Meera", "cgpa": 9.1, "branch": "ECE", "skills": []string{"python"}},
+	}
+	for _, r := range resumes {
+		body, _ := json.Marshal(r)
+		resp, err := http.Post(server.URL+"/resumes", "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusAccepted {
+			t.Fatalf("POST /resumes %s: status %d", r["student_id"], resp.StatusCode)
+		}
+	}
+
+	want := map[string]bool{"S-1": true, "S-3": true}
+	for name, ch := range map[string]<-chan string{"shortlist": shortlisted, "interview": scheduled} {
+		got := map[string]bool{}
+		for len(got) < len(want) {
+			select {
+			case id := <-ch:
+				if !want[id] {
+					t.Fatalf("unexpected %s notification for %s", name, id)
+				}
+				got[id] = true
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timed out waiting for %s notifications, got %v", name, got)
+			}
+		}
+	}
+
+	select {
+	case id := <-rejected:
+		if id != "S-2" {
+			t.Fatalf("unexpected rejection for %s", id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the rejection of S-2")
+	}
+
+	for id := range want {
+		resp, err := http.Get(server.URL + "/interviews/" + id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body map[string]string
+		json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || body["slot"] == "" {
+			t.Errorf("GET /interviews/%s: status %d body %v", id, resp.StatusCode, body)
+		}
+	}
+	resp, err := http.Get(server.URL + "/interviews/S-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("rejected candidate has an interview: status %d", resp.StatusCode)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("pipeline did not stop after cancel")
+	}
+}
@@ -0,0 +1,163 @@
+package resume
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"casestudy1_microservices/internal/events"
+)
+
+// ErrNotFound is returned for a resume ID that has no record.
+var ErrNotFound = errors.New("resume not found")
+
+// Record describes a stored resume submission.
+type Record struct {
+	ID        string `json:"id"`
+	StudentID string `json:"student_id"`
+	// The document fields are empty for JSON submissions. SHA256 is the document's hash, under which
+	// its content is stored.
+	FileName    string `json:"file_name,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+	SHA256      string `json:"sha256,omitempty"`
+	// Extracted is what the parser found in the document, before fields sent with the upload were
+	// applied.
+	Extracted   *Extraction           `json:"extracted,omitempty"`
+	Event       events.ResumeUploaded `json:"event"`
+	SubmittedAt time.Time             `json:"submitted_at"`
+}
+
+// Archive keeps resume documents and records in a directory. Documents are stored once per content
+// hash under blobs/, records as JSON under records/.
+type Archive struct {
+	dir string
+}
+
+// OpenArchive creates (if needed) and opens the archive in dir.
+func OpenArchive(dir string) (*Archive, error) {
+	for _, sub := range []string{"blobs", "records"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, err
+		}
+	}
+	return &Archive{dir: dir}, nil
+}
+
+// Hash returns the hex SHA-256 of data.
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// RecordID identifies the submission of content by a student. Submitting the same content again
+// gives the same ID, so a retried upload replaces its record instead of adding one.
+func RecordID(studentID string, content []byte) string {
+	sum := sha256.Sum256(append([]byte(studentID+"\x00"), content...))
+	return hex.EncodeToString(sum[:16])
+}
+
+// PutBlob stores data under its hash and returns the hash. Storing identical content again is a
+// no-op.
+func (a *Archive) PutBlob(data []byte) (string, error) {
+	hash := Hash(data)
+	path := a.blobPath(hash)
+	if _, err := os.Stat(path); err == nil {
+		return hash, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	return hash, writeFileAtomic(path, data)
+}
+
+// Blob returns the content stored under hash.
+func (a *Archive) Blob(hash string) ([]byte, error) {
+	if !validID(hash) {
+		return nil, ErrNotFound
+	}
+	data, err := os.ReadFile(a.blobPath(hash))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// PutRecord stores rec, replacing any record with its ID.
+func (a *Archive) PutRecord(rec Record) error {
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(a.recordPath(rec.ID), data)
+}
+
+// Record returns the record with the given ID.
+func (a *Archive) Record(id string) (Record, error) {
+	var rec Record
+	if !validID(id) {
+		return rec, ErrNotFound
+	}
+	data, err := os.ReadFile(a.recordPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return rec, ErrNotFound
+	}
+	if err != nil {
+		return rec, err
+	}
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return rec, fmt.Errorf("record %s: %w", id, err)
+	}
+	return rec, nil
+}
+
+// blobPath fans blobs out over subdirectories named after the first two hex digits.
+func (a *Archive) blobPath(hash string) string {
+	return filepath.Join(a.dir, "blobs", hash[:2], hash)
+}
+
+func (a *Archive) recordPath(id string) string {
+	return filepath.Join(a.dir, "records", id+".json")
+}
+
+// validID reports whether id is a lower-case hex string, so that it cannot name a path outside the
+// archive.
+func validID(id string) bool {
+	if len(id) < 2 {
+		return false
+	}
+	for _, r := range id {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// writeFileAtomic writes data to a temporary file and renames it to path, so that readers never see
+// a partial file.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
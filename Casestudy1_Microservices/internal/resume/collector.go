@@ -0,0 +1,358 @@
+// Package resume accepts resume submissions over HTTP and publishes them as ResumeUploaded events.
+// A submission is either a JSON body with the candidate's details or an uploaded PDF, DOCX or text
+// document that the fields are parsed from.
+package resume
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"maps"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"casestudy1_microservices/internal/broker"
+	"casestudy1_microservices/internal/events"
+)
+
+const (
+	// publishTimeout bounds how long a request waits for the broker to accept its event.
+	publishTimeout = 5 * time.Second
+	// DefaultMaxDocumentSize is the largest document accepted when no limit is configured.
+	DefaultMaxDocumentSize = 5 << 20
+	// formOverhead is the room left in an upload for the form fields and multipart framing.
+	formOverhead = 64 << 10
+)
+
+// Request represents the incoming HTTP request body.
+type Request struct {
+	StudentID string   `json:"student_id"`
+	Name      string   `json:"name"`
+	CGPA      float64  `json:"cgpa"`
+	Branch    string   `json:"branch"`
+	Skills    []string `json:"skills"`
+	JobID     string   `json:"job_id,omitempty"`
+}
+
+// Config configures document uploads.
+type Config struct {
+	// Archive stores submissions; nil disables document uploads and GET /resumes/{id}.
+	Archive *Archive
+	// Taxonomy recognises skills and branches; nil uses DefaultTaxonomy.
+	Taxonomy *Taxonomy
+	// MaxDocumentSize limits uploads in bytes; <= 0 uses DefaultMaxDocumentSize.
+	MaxDocumentSize int64
+}
+
+// Collector serves POST /resumes and GET /resumes/{id}.
+type Collector struct {
+	publisher broker.Publisher
+	archive   *Archive
+	taxonomy  *Taxonomy
+	maxSize   int64
+}
+
+// NewCollector creates a collector that publishes to publisher.
+func NewCollector(publisher broker.Publisher, cfg Config) *Collector {
+	if cfg.Taxonomy == nil {
+		cfg.Taxonomy = DefaultTaxonomy()
+	}
+	if cfg.MaxDocumentSize <= 0 {
+		cfg.MaxDocumentSize = DefaultMaxDocumentSize
+	}
+	return &Collector{publisher: publisher, archive: cfg.Archive, taxonomy: cfg.Taxonomy, maxSize: cfg.MaxDocumentSize}
+}
+
+// Register attaches the collector's routes to mux.
+func (c *Collector) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /resumes", c.handleUpload)
+	mux.HandleFunc("GET /resumes/{id}", c.handleGet)
+}
+
+func (c *Collector) handleUpload(w http.ResponseWriter, r *http.Request) {
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		c.handleDocument(w, r)
+		return
+	}
+
+	var req Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	// Validate input
+	if err := Validate(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	event := events.ResumeUploaded{
+		StudentID: req.StudentID,
+		Name:      req.Name,
+		CGPA:      req.CGPA,
+		Branch:    req.Branch,
+		Skills:    req.Skills,
+		JobID:     req.JobID,
+	}
+	rec := Record{StudentID: req.StudentID}
+	if c.archive != nil {
+		content, _ := json.Marshal(event)
+		rec.ID = RecordID(req.StudentID, content)
+	}
+	c.submit(w, r, rec, event)
+}
+
+// handleDocument accepts a multipart form with the document in the "resume" part. The form fields
+// student_id (required), name, cgpa, branch, skills (comma-separated) and job_id override or add to
+// what is parsed from the document.
+func (c *Collector) handleDocument(w http.ResponseWriter, r *http.Request) {
+	if c.archive == nil {
+		http.Error(w, "Document uploads are not enabled", http.StatusNotImplemented)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, c.maxSize+formOverhead)
+	if err := r.ParseMultipartForm(c.maxSize + formOverhead); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "Document too large: the limit is "+strconv.FormatInt(c.maxSize, 10)+" bytes", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid multipart form", http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("resume")
+	if err != nil {
+		http.Error(w, "The resume file part is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, c.maxSize+1))
+	if err != nil {
+		slog.Error("Failed to read upload", slog.Any("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if int64(len(data)) > c.maxSize {
+		http.Error(w, "Document too large: the limit is "+strconv.FormatInt(c.maxSize, 10)+" bytes", http.StatusRequestEntityTooLarge)
+		return
+	}
+	contentType, err := DetectType(header.Header.Get("Content-Type"), data)
+	if err != nil {
+		http.Error(w, err.Error()+": want PDF, DOCX or plain text", http.StatusUnsupportedMediaType)
+		return
+	}
+	text, err := ExtractText(contentType, data)
+	if err != nil {
+		status := http.StatusUnprocessableEntity
+		if errors.Is(err, ErrUnsupportedType) {
+			status = http.StatusUnsupportedMediaType
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	extracted := c.taxonomy.Parse(text)
+	req, applied, err := applyForm(r, extracted)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := Validate(req); err != nil {
+		// Tell the candidate what was found, so that they can send the missing fields.
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"error": err.Error(), "extracted": extracted})
+		return
+	}
+
+	hash, err := c.archive.PutBlob(data)
+	if err != nil {
+		slog.Error("Failed to store document", slog.String("student_id", req.StudentID), slog.Any("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	rec := Record{
+		ID:          RecordID(req.StudentID, data),
+		StudentID:   req.StudentID,
+		FileName:    header.Filename,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		SHA256:      hash,
+		Extracted:   &extracted,
+	}
+	c.submit(w, r, rec, events.ResumeUploaded{
+		StudentID:  req.StudentID,
+		Name:       req.Name,
+		CGPA:       req.CGPA,
+		Branch:     req.Branch,
+		Skills:     req.Skills,
+		JobID:      req.JobID,
+		Confidence: applied.Level,
+	})
+}
+
+// applyForm builds the submission from the extracted fields and the form fields, which take
+// precedence. The returned extraction rates the fields given in the form as certain.
+func applyForm(r *http.Request, e Extraction) (Request, Extraction, error) {
+	req := Request{
+		StudentID: strings.TrimSpace(r.FormValue("student_id")),
+		Name:      e.Name,
+		CGPA:      e.CGPA,
+		Branch:    e.Branch,
+		Skills:    e.SkillNames(),
+		JobID:     strings.TrimSpace(r.FormValue("job_id")),
+	}
+	e.Fields = maps.Clone(e.Fields)
+	if v := strings.TrimSpace(r.FormValue("name")); v != "" {
+		req.Name, e.Fields["name"] = v, 1
+	}
+	if v := strings.TrimSpace(r.FormValue("cgpa")); v != "" {
+		cgpa, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return req, e, errors.New("cgpa must be a number")
+		}
+		req.CGPA, e.Fields["cgpa"] = cgpa, 1
+	}
+	if v := strings.TrimSpace(r.FormValue("branch")); v != "" {
+		req.Branch, e.Fields["branch"] = v, 1
+	}
+	if v := r.FormValue("skills"); v != "" {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" && !contains(req.Skills, s) {
+				req.Skills = append(req.Skills, s)
+			}
+		}
+		e.Fields["skills"] = 1
+	}
+	e.rate()
+	return req, e, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// submit records the submission, when there is an archive, and publishes its event.
+func (c *Collector) submit(w http.ResponseWriter, r *http.Request, rec Record, event events.ResumeUploaded) {
+	if c.archive != nil {
+		event.ResumeID = rec.ID
+		rec.Event = event
+		rec.SubmittedAt = time.Now().UTC()
+		if err := c.archive.PutRecord(rec); err != nil {
+			slog.Error("Failed to store resume", slog.String("student_id", event.StudentID), slog.Any("error", err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), publishTimeout)
+	defer cancel()
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		slog.Error("Failed to serialize event", slog.String("student_id", event.StudentID), slog.Float64("cgpa", event.CGPA), slog.Any("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	msg := broker.Message{Topic: events.TopicResumeUploaded, Key: []byte(event.StudentID), Value: data}
+	if err := c.publisher.Publish(ctx, msg); err != nil {
+		slog.Error("Failed to publish event", slog.String("student_id", event.StudentID), slog.Float64("cgpa", event.CGPA), slog.Any("error", err))
+		http.Error(w, "Failed to queue resume", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]any{
+		"status":     "queued",
+		"student_id": event.StudentID,
+	}
+	if event.ResumeID != "" {
+		response["resume_id"] = event.ResumeID
+	}
+	if rec.Extracted != nil {
+		response["confidence"] = event.Confidence
+		response["extracted"] = rec.Extracted
+	}
+	writeJSON(w, http.StatusAccepted, response)
+}
+
+func (c *Collector) handleGet(w http.ResponseWriter, r *http.Request) {
+	if c.archive == nil {
+		http.Error(w, ErrNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	rec, err := c.archive.Record(r.PathValue("id"))
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to read resume", slog.String("id", r.PathValue("id")), slog.Any("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, rec)
+}
+
+// Validate checks the required fields of a submission.
+func Validate(req Request) error {
+	if req.StudentID == "" {
+		return errors.New("student_id is required")
+	}
+	if req.Name == "" {
+		return errors.New("name is required")
+	}
+	if req.CGPA <= 0 {
+		return errors.New("cgpa must be greater than 0")
+	}
+	if len(req.Skills) == 0 {
+		return errors.New("at least one skill is required")
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// ConfigFromEnv opens the archive in RESUME_DIR (defaultDir when unset; no archive when both are
+// empty), loads the taxonomy from SKILLS_FILE (DefaultTaxonomy when unset) and reads the upload limit
+// from RESUME_MAX_BYTES.
+func ConfigFromEnv(defaultDir string) (Config, error) {
+	var cfg Config
+	dir := defaultDir
+	if v := os.Getenv("RESUME_DIR"); v != "" {
+		dir = v
+	}
+	if dir != "" {
+		archive, err := OpenArchive(dir)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Archive = archive
+	}
+	if path := os.Getenv("SKILLS_FILE"); path != "" {
+		taxonomy, err := LoadTaxonomy(path)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Taxonomy = taxonomy
+	}
+	if n, err := strconv.ParseInt(os.Getenv("RESUME_MAX_BYTES"), 10, 64); err == nil && n > 0 {
+		cfg.MaxDocumentSize = n
+	}
+	return cfg, nil
+}
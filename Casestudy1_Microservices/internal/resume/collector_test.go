@@ -0,0 +1,277 @@
+package resume
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"casestudy1_microservices/internal/broker"
+	"casestudy1_microservices/internal/events"
+)
+
+const resumeText = `Curriculum Vitae
+Asha Rao
+B.Tech, Computer Science and Engineering, CGPA: 8.4/10
+Skills: Golang, Kubernetes (k8s), PostgreSQL and distributed systems.
+Built a golang service on Apache Kafka.`
+
+// recorder is a publisher that keeps what it is sent.
+type recorder struct {
+	mu   sync.Mutex
+	msgs []broker.Message
+}
+
+func (r *recorder) Publish(_ context.Context, msg broker.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.msgs = append(r.msgs, msg)
+	return nil
+}
+
+func TestParse(t *testing.T) {
+	e := DefaultTaxonomy().Parse(resumeText)
+	if e.Name != "Asha Rao" || e.CGPA != 8.4 || e.Branch != "CSE" {
+		t.Fatalf("parsed name %q, cgpa %v, branch %q", e.Name, e.CGPA, e.Branch)
+	}
+	if got, want := e.SkillNames(), []string{"golang", "kubernetes", "distributed systems", "kafka", "sql"}; !slices.Equal(got, want) {
+		t.Fatalf("skills = %v, want %v", got, want)
+	}
+	// golang is named twice and kubernetes once by name and once by alias; sql and kafka are only
+	// found through aliases (the longer "apache kafka" wins).
+	scores := map[string]float64{}
+	for _, s := range e.Skills {
+		scores[s.Skill] = s.Score
+	}
+	if scores["golang"] != 0.95 || scores["kubernetes"] != 0.95 || scores["sql"] != 0.7 {
+		t.Fatalf("skill confidences = %v", scores)
+	}
+	if e.Level != ConfidenceHigh {
+		t.Fatalf("confidence %v (%s), want high", e.Confidence, e.Level)
+	}
+	// Without a branch, and with the name only guessed from the first line, the parse is less certain.
+	if e := DefaultTaxonomy().Parse("Asha Rao\nCGPA 8.4, golang"); e.Name != "Asha Rao" || e.Branch != "" || e.Level != ConfidenceMedium {
+		t.Fatalf("resume without branch parsed as %+v", e)
+	}
+
+	if e := DefaultTaxonomy().Parse("Name: Ravi Kumar\nGPA 3.6 / 4\nMechanical engineering, Java"); e.Name != "Ravi Kumar" || e.CGPA != 9 || e.Branch != "MECH" || e.Level != ConfidenceHigh {
+		t.Fatalf("labelled resume parsed as %+v", e)
+	}
+	example, err := LoadTaxonomy("../../skills.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := example.Parse(resumeText).SkillNames(); !slices.Equal(got, e.SkillNames()) {
+		t.Fatalf("skills.yaml finds %v, the default taxonomy %v", got, e.SkillNames())
+	}
+	if e := DefaultTaxonomy().Parse("references available on request"); e.Level != ConfidenceLow || len(e.Skills) != 0 {
+		t.Fatalf("empty resume parsed as %+v", e)
+	}
+}
+
+func TestExtractText(t *testing.T) {
+	for name, doc := range map[string][]byte{"pdf": pdfDocument(resumeText), "docx": docxDocument(resumeText)} {
+		contentType, err := DetectType("", doc)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		text, err := ExtractText(contentType, doc)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if e := DefaultTaxonomy().Parse(text); e.Name != "Asha Rao" || e.CGPA != 8.4 || len(e.Skills) != 5 {
+			t.Fatalf("%s: parsed %+v from %q", name, e, text)
+		}
+	}
+
+	if _, err := DetectType(TypePDF, []byte("plain text")); err == nil {
+		t.Fatal("text declared as PDF accepted")
+	}
+	if _, err := DetectType("", []byte{0x89, 'P', 'N', 'G', 0}); err == nil {
+		t.Fatal("binary content accepted")
+	}
+	if _, err := ExtractText(TypePDF, []byte("%PDF-1.4 truncated")); err == nil {
+		t.Fatal("broken PDF read")
+	}
+}
+
+func newTestCollector(t *testing.T, maxSize int64) (*httptest.Server, *recorder) {
+	t.Helper()
+	archive, err := OpenArchive(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &recorder{}
+	mux := http.NewServeMux()
+	NewCollector(pub, Config{Archive: archive, MaxDocumentSize: maxSize}).Register(mux)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, pub
+}
+
+// upload posts a multipart form with doc in the resume part, declared as contentType.
+func upload(t *testing.T, url string, fields map[string]string, filename, contentType string, doc []byte) *http.Response {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for k, v := range fields {
+		w.WriteField(k, v)
+	}
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="resume"; filename=%q`, filename))
+	if contentType != "" {
+		h.Set("Content-Type", contentType)
+	}
+	part, err := w.CreatePart(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(doc)
+	w.Close()
+	resp, err := http.Post(url+"/resumes", w.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestUploadDocument(t *testing.T) {
+	server, pub := newTestCollector(t, 1<<20)
+	doc := docxDocument(resumeText)
+
+	resp := upload(t, server.URL, map[string]string{"student_id": "S-1", "job_id": "backend-engineer", "skills": "Rust"}, "asha.docx", TypeDOCX, doc)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("upload: status %d", resp.StatusCode)
+	}
+	var accepted struct {
+		ResumeID   string `json:"resume_id"`
+		Confidence string `json:"confidence"`
+	}
+	json.NewDecoder(resp.Body).Decode(&accepted)
+	if accepted.ResumeID == "" || accepted.Confidence != ConfidenceHigh {
+		t.Fatalf("accepted %+v, want an ID and high confidence now that skills were sent", accepted)
+	}
+
+	var event events.ResumeUploaded
+	if len(pub.msgs) != 1 || json.Unmarshal(pub.msgs[0].Value, &event) != nil {
+		t.Fatalf("published %d messages", len(pub.msgs))
+	}
+	if event.StudentID != "S-1" || event.Name != "Asha Rao" || event.CGPA != 8.4 || event.Branch != "CSE" ||
+		event.JobID != "backend-engineer" || event.ResumeID != accepted.ResumeID || !slices.Contains(event.Skills, "Rust") || !slices.Contains(event.Skills, "golang") {
+		t.Fatalf("event = %+v", event)
+	}
+
+	get, err := http.Get(server.URL + "/resumes/" + accepted.ResumeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer get.Body.Close()
+	var rec Record
+	if get.StatusCode != http.StatusOK || json.NewDecoder(get.Body).Decode(&rec) != nil {
+		t.Fatalf("GET /resumes/%s: status %d", accepted.ResumeID, get.StatusCode)
+	}
+	if rec.SHA256 != Hash(doc) || rec.ContentType != TypeDOCX || rec.FileName != "asha.docx" || rec.Extracted == nil || rec.Event.Confidence != ConfidenceHigh {
+		t.Fatalf("record = %+v", rec)
+	}
+
+	// The same document again keeps its ID.
+	resp = upload(t, server.URL, map[string]string{"student_id": "S-1"}, "asha.docx", "", doc)
+	var again struct {
+		ResumeID string `json:"resume_id"`
+	}
+	json.NewDecoder(resp.Body).Decode(&again)
+	if resp.StatusCode != http.StatusAccepted || again.ResumeID != accepted.ResumeID {
+		t.Fatalf("re-upload: status %d, ID %s", resp.StatusCode, again.ResumeID)
+	}
+
+	for _, path := range []string{"/resumes/0123abcd", "/resumes/..%2Fblobs"} {
+		if get, err := http.Get(server.URL + path); err != nil || get.StatusCode != http.StatusNotFound {
+			t.Fatalf("GET %s: %v, want 404", path, get.Status)
+		}
+	}
+}
+
+func TestUploadRejections(t *testing.T) {
+	server, pub := newTestCollector(t, 1024)
+	for _, tc := range []struct {
+		name        string
+		fields      map[string]string
+		contentType string
+		doc         []byte
+		status      int
+	}{
+		{"too large", map[string]string{"student_id": "S-1"}, TypeText, bytes.Repeat([]byte("a"), 2048), http.StatusRequestEntityTooLarge},
+		{"type mismatch", map[string]string{"student_id": "S-1"}, TypePDF, []byte(resumeText), http.StatusUnsupportedMediaType},
+		{"binary", map[string]string{"student_id": "S-1"}, "", []byte{0, 1, 2, 3}, http.StatusUnsupportedMediaType},
+		{"zip that is not a Word document", map[string]string{"student_id": "S-1"}, "", zipDocument("xl/workbook.xml", "<workbook/>"), http.StatusUnsupportedMediaType},
+		{"missing student", nil, TypeText, []byte(resumeText), http.StatusUnprocessableEntity},
+		{"nothing to parse", map[string]string{"student_id": "S-1"}, TypeText, []byte("hello"), http.StatusUnprocessableEntity},
+		{"bad cgpa", map[string]string{"student_id": "S-1", "cgpa": "high"}, TypeText, []byte(resumeText), http.StatusBadRequest},
+	} {
+		if resp := upload(t, server.URL, tc.fields, "resume", tc.contentType, tc.doc); resp.StatusCode != tc.status {
+			t.Errorf("%s: status %d, want %d", tc.name, resp.StatusCode, tc.status)
+		}
+	}
+	if len(pub.msgs) != 0 {
+		t.Fatalf("published %d events for rejected uploads", len(pub.msgs))
+	}
+}
+
+func zipDocument(name, content string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, _ := zw.Create(name)
+	f.Write([]byte(content))
+	zw.Close()
+	return buf.Bytes()
+}
+
+// docxDocument builds a minimal Word document with one paragraph per line of text.
+func docxDocument(text string) []byte {
+	var body strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		fmt.Fprintf(&body, `<w:p><w:r><w:t xml:space="preserve">%s</w:t></w:r></w:p>`, line)
+	}
+	return zipDocument("word/document.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`+
+		`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>`+body.String()+`</w:body></w:document>`)
+}
+
+// pdfDocument builds a single-page PDF showing each line of text on its own row.
+func pdfDocument(text string) []byte {
+	var content strings.Builder
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(line)
+		fmt.Fprintf(&content, "BT /F1 11 Tf 72 %d Td (%s) Tj ET\n", 740-16*i, line)
+	}
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	}
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
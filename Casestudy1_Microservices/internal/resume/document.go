@@ -0,0 +1,143 @@
+package resume
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Document content types accepted for upload.
+const (
+	TypePDF  = "application/pdf"
+	TypeDOCX = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	TypeText = "text/plain"
+)
+
+// maxDocumentXML bounds the uncompressed size of a DOCX's text, so that a small upload cannot expand
+// into an arbitrarily large one.
+const maxDocumentXML = 32 << 20
+
+var (
+	// ErrUnsupportedType is returned for documents that are not PDF, DOCX or UTF-8 text, or whose
+	// content does not match their declared content type.
+	ErrUnsupportedType = errors.New("unsupported document type")
+	// ErrUnreadable is returned for documents of a supported type that cannot be read.
+	ErrUnreadable = errors.New("unreadable document")
+)
+
+// DetectType returns the content type of data from its content. declared, the type the client sent,
+// must be empty, application/octet-stream or the detected type.
+func DetectType(declared string, data []byte) (string, error) {
+	var detected string
+	switch {
+	case bytes.HasPrefix(data, []byte("%PDF-")):
+		detected = TypePDF
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		detected = TypeDOCX
+	case utf8.Valid(data) && bytes.IndexByte(data, 0) < 0:
+		detected = TypeText
+	default:
+		return "", ErrUnsupportedType
+	}
+	if declared == "" {
+		return detected, nil
+	}
+	mediaType, _, err := mime.ParseMediaType(declared)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnsupportedType, err)
+	}
+	if mediaType != "application/octet-stream" && mediaType != detected {
+		return "", fmt.Errorf("%w: declared %s but the content is %s", ErrUnsupportedType, mediaType, detected)
+	}
+	return detected, nil
+}
+
+// ExtractText returns the text of a document of the given content type.
+func ExtractText(contentType string, data []byte) (string, error) {
+	switch contentType {
+	case TypeText:
+		return string(data), nil
+	case TypePDF:
+		return pdfText(data)
+	case TypeDOCX:
+		return docxText(data)
+	default:
+		return "", ErrUnsupportedType
+	}
+}
+
+func pdfText(data []byte) (text string, err error) {
+	// The PDF reader panics on some malformed files.
+	defer func() {
+		if r := recover(); r != nil {
+			text, err = "", fmt.Errorf("%w: %v", ErrUnreadable, r)
+		}
+	}()
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnreadable, err)
+	}
+	plain, err := r.GetPlainText()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnreadable, err)
+	}
+	b, err := io.ReadAll(plain)
+	return string(b), err
+}
+
+// docxText reads the paragraphs of word/document.xml, one per line.
+func docxText(data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnreadable, err)
+	}
+	f, err := zr.Open("word/document.xml")
+	if err != nil {
+		// Other zip files, such as spreadsheets, get here too.
+		return "", fmt.Errorf("%w: not a Word document", ErrUnsupportedType)
+	}
+	defer f.Close()
+
+	var b strings.Builder
+	dec := xml.NewDecoder(io.LimitReader(f, maxDocumentXML))
+	inText := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return b.String(), nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrUnreadable, err)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			switch tok.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteByte(' ')
+			case "br":
+				b.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch tok.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				b.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(tok)
+			}
+		}
+	}
+}
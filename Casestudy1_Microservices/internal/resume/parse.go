@@ -0,0 +1,170 @@
+package resume
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Confidence levels of an Extraction.
+const (
+	ConfidenceHigh   = "high"
+	ConfidenceMedium = "medium"
+	ConfidenceLow    = "low"
+)
+
+// fieldWeights is how much each field counts towards the overall confidence.
+var fieldWeights = map[string]float64{"name": 0.2, "cgpa": 0.3, "branch": 0.2, "skills": 0.3}
+
+// Extraction is what the parser found in a resume document.
+type Extraction struct {
+	Name   string       `json:"name,omitempty"`
+	CGPA   float64      `json:"cgpa,omitempty"`
+	Branch string       `json:"branch,omitempty"`
+	Skills []SkillMatch `json:"skills,omitempty"`
+	// Fields is the confidence, from 0 to 1, in each of name, cgpa, branch and skills; 0 means the
+	// field was not found.
+	Fields map[string]float64 `json:"field_confidence"`
+	// Confidence weighs the fields' confidences into one score, and Level buckets it into
+	// ConfidenceHigh, ConfidenceMedium or ConfidenceLow.
+	Confidence float64 `json:"confidence"`
+	Level      string  `json:"level"`
+}
+
+// SkillMatch is a taxonomy skill found in a resume.
+type SkillMatch struct {
+	Skill    string  `json:"skill"`
+	Mentions int     `json:"mentions"`
+	Score    float64 `json:"confidence"`
+}
+
+var (
+	// cgpaPattern matches "CGPA: 8.4", "GPA 3.6/4" and the like.
+	cgpaPattern = regexp.MustCompile(`(?i)\b(?:c\.?g\.?p\.?a|gpa|cpi)\b[^0-9\n]{0,12}(\d{1,2}(?:\.\d{1,2})?)(?:\s*/\s*(10|4)(?:\.0+)?)?`)
+	// namePattern matches a labelled name.
+	namePattern = regexp.MustCompile(`(?im)^\s*name\s*[:\-]\s*(.+?)\s*$`)
+)
+
+// Parse extracts the fields of a resume from its text.
+func (t *Taxonomy) Parse(text string) Extraction {
+	e := Extraction{Fields: make(map[string]float64, len(fieldWeights))}
+	e.Name, e.Fields["name"] = parseName(text)
+	e.CGPA, e.Fields["cgpa"] = parseCGPA(text)
+
+	tokens := tokenize(text)
+	if branches := t.branches.find(tokens); len(branches) > 0 {
+		e.Branch, e.Fields["branch"] = branches[0].canonical, 0.8
+	}
+
+	// A skill named as in the taxonomy is more certain than one found through an alias, and every
+	// further mention adds a little.
+	bySkill := make(map[string]*SkillMatch)
+	for _, m := range t.skills.find(tokens) {
+		s, ok := bySkill[m.canonical]
+		if !ok {
+			s = &SkillMatch{Skill: m.canonical}
+			bySkill[m.canonical] = s
+		}
+		s.Mentions++
+		base := 0.9
+		if m.alias {
+			base = 0.7
+		}
+		s.Score = min(max(s.Score, base)+0.05*float64(s.Mentions-1), 1)
+	}
+	var total float64
+	for _, s := range bySkill {
+		s.Score = round2(s.Score)
+		e.Skills = append(e.Skills, *s)
+		total += s.Score
+	}
+	sort.Slice(e.Skills, func(i, j int) bool {
+		if e.Skills[i].Score != e.Skills[j].Score {
+			return e.Skills[i].Score > e.Skills[j].Score
+		}
+		return e.Skills[i].Skill < e.Skills[j].Skill
+	})
+	if len(e.Skills) > 0 {
+		e.Fields["skills"] = round2(total / float64(len(e.Skills)))
+	}
+	e.rate()
+	return e
+}
+
+// SkillNames returns the canonical names of the skills found.
+func (e Extraction) SkillNames() []string {
+	names := make([]string, len(e.Skills))
+	for i, s := range e.Skills {
+		names[i] = s.Skill
+	}
+	return names
+}
+
+// rate computes Confidence and Level from Fields.
+func (e *Extraction) rate() {
+	var score float64
+	for field, weight := range fieldWeights {
+		score += weight * e.Fields[field]
+	}
+	e.Confidence = round2(score)
+	switch {
+	case e.Confidence >= 0.8:
+		e.Level = ConfidenceHigh
+	case e.Confidence >= 0.5:
+		e.Level = ConfidenceMedium
+	default:
+		e.Level = ConfidenceLow
+	}
+}
+
+// resumeHeadings are title lines skipped when looking for the candidate's name.
+var resumeHeadings = map[string]bool{"resume": true, "résumé": true, "curriculum vitae": true, "cv": true}
+
+// parseName takes a "Name:" line, or else the first line that looks like a person's name.
+func parseName(text string) (string, float64) {
+	if m := namePattern.FindStringSubmatch(text); m != nil {
+		return m[1], 0.9
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || resumeHeadings[strings.ToLower(line)] {
+			continue
+		}
+		words := strings.Fields(line)
+		if len(words) < 2 || len(words) > 4 {
+			return "", 0
+		}
+		for _, w := range words {
+			if r := []rune(w); !unicode.IsUpper(r[0]) || strings.IndexFunc(w, func(r rune) bool { return !unicode.IsLetter(r) && r != '.' && r != '-' && r != '\'' }) >= 0 {
+				return "", 0
+			}
+		}
+		return line, 0.6
+	}
+	return "", 0
+}
+
+// parseCGPA finds a grade point average and converts it to a 10-point scale.
+func parseCGPA(text string) (float64, float64) {
+	for _, m := range cgpaPattern.FindAllStringSubmatch(text, -1) {
+		v, err := strconv.ParseFloat(m[1], 64)
+		if err != nil || v <= 0 {
+			continue
+		}
+		switch {
+		case m[2] == "4" && v <= 4:
+			return round2(v * 2.5), 0.8
+		case m[2] == "10" && v <= 10:
+			return v, 0.95
+		case m[2] == "" && v <= 10:
+			return v, 0.9
+		}
+	}
+	return 0, 0
+}
+
+func round2(f float64) float64 {
+	return float64(int(f*100+0.5)) / 100
+}
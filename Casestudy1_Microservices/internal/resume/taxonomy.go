@@ -0,0 +1,146 @@
+package resume
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Taxonomy lists the skills and branches the parser recognises. Each maps a canonical name, the one
+// put in the ResumeUploaded event, to the other ways a resume may write it.
+type Taxonomy struct {
+	Skills   map[string][]string `yaml:"skills"`
+	Branches map[string][]string `yaml:"branches"`
+
+	skills   *phraseIndex
+	branches *phraseIndex
+}
+
+// DefaultTaxonomy is used when no skills file is configured. Its skills are the ones the bundled
+// policies score.
+func DefaultTaxonomy() *Taxonomy {
+	t := &Taxonomy{
+		Skills: map[string][]string{
+			"golang":              {"go lang", "go language"},
+			"java":                {"java se", "java ee"},
+			"python":              {"python3"},
+			"distributed systems": {"distributed computing", "distributed system"},
+			"kubernetes":          {"k8s"},
+			"react":               {"react.js", "reactjs"},
+			"typescript":          nil,
+			"javascript":          {"js", "ecmascript"},
+			"docker":              nil,
+			"kafka":               {"apache kafka"},
+			"sql":                 {"postgresql", "mysql", "postgres"},
+		},
+		Branches: map[string][]string{
+			"CSE":   {"computer science", "computer science and engineering", "cs"},
+			"ECE":   {"electronics and communication", "electronics and communication engineering"},
+			"EEE":   {"electrical and electronics", "electrical engineering"},
+			"MECH":  {"mechanical", "mechanical engineering"},
+			"CIVIL": {"civil engineering"},
+		},
+	}
+	t.index()
+	return t
+}
+
+// LoadTaxonomy reads a taxonomy from a YAML or JSON file.
+func LoadTaxonomy(path string) (*Taxonomy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var t Taxonomy
+	if err := yaml.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(t.Skills) == 0 {
+		return nil, fmt.Errorf("%s: no skills", path)
+	}
+	t.index()
+	return &t, nil
+}
+
+func (t *Taxonomy) index() {
+	t.skills = newPhraseIndex(t.Skills)
+	t.branches = newPhraseIndex(t.Branches)
+}
+
+// phraseIndex finds the phrases of a taxonomy in tokenised text.
+type phraseIndex struct {
+	// byFirst maps a phrase's first token to the phrases starting with it, longest first.
+	byFirst map[string][]phrase
+}
+
+type phrase struct {
+	tokens    []string
+	canonical string
+	// alias is set for phrases other than the canonical name itself.
+	alias bool
+}
+
+func newPhraseIndex(entries map[string][]string) *phraseIndex {
+	idx := &phraseIndex{byFirst: make(map[string][]phrase)}
+	add := func(text, canonical string, alias bool) {
+		tokens := tokenize(text)
+		if len(tokens) > 0 {
+			idx.byFirst[tokens[0]] = append(idx.byFirst[tokens[0]], phrase{tokens: tokens, canonical: canonical, alias: alias})
+		}
+	}
+	for canonical, aliases := range entries {
+		add(canonical, canonical, false)
+		for _, a := range aliases {
+			add(a, canonical, true)
+		}
+	}
+	for _, ps := range idx.byFirst {
+		sort.Slice(ps, func(i, j int) bool { return len(ps[i].tokens) > len(ps[j].tokens) })
+	}
+	return idx
+}
+
+// match is one occurrence of a phrase.
+type match struct {
+	canonical string
+	alias     bool
+}
+
+// find returns the phrases occurring in tokens, preferring the longest phrase at each position.
+func (idx *phraseIndex) find(tokens []string) []match {
+	var out []match
+	for i := 0; i < len(tokens); i++ {
+		for _, p := range idx.byFirst[tokens[i]] {
+			if i+len(p.tokens) <= len(tokens) && slices.Equal(tokens[i:i+len(p.tokens)], p.tokens) {
+				out = append(out, match{canonical: p.canonical, alias: p.alias})
+				i += len(p.tokens) - 1
+				break
+			}
+		}
+	}
+	return out
+}
+
+// tokenize lower-cases text and splits it into words. Characters that occur in skill names, such as
+// the + of c++ and the . of node.js, stay part of a word; a trailing full stop does not.
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '+', r == '#', r == '.':
+			return false
+		default:
+			return r < 0x80
+		}
+	})
+	tokens := fields[:0]
+	for _, f := range fields {
+		if f = strings.Trim(f, "."); f != "" {
+			tokens = append(tokens, f)
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,77 @@
+package shortlist
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"casestudy1_microservices/internal/events"
+)
+
+// DryRunRequest evaluates Resume against Policy when one is given, otherwise against the configured
+// policy of JobID (or of the resume's job opening).
+type DryRunRequest struct {
+	Resume events.ResumeUploaded `json:"resume"`
+	JobID  string                `json:"job_id,omitempty"`
+	Policy *Policy               `json:"policy,omitempty"`
+}
+
+// Register attaches the policy routes to mux.
+func (s *Service) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /shortlist/dry-run", s.handleDryRun)
+	mux.HandleFunc("GET /policies", s.handleListPolicies)
+	mux.HandleFunc("GET /policies/{job_id}", s.handleGetPolicy)
+}
+
+func (s *Service) handleDryRun(w http.ResponseWriter, r *http.Request) {
+	var req DryRunRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	policy := req.Policy
+	if policy != nil {
+		if policy.JobID == "" {
+			policy.JobID = "dry-run"
+		}
+		if err := policy.Validate(); err != nil {
+			http.Error(w, "Invalid policy: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		jobID := req.JobID
+		if jobID == "" {
+			jobID = req.Resume.JobID
+		}
+		p, ok := s.policies.Lookup(jobID)
+		if !ok {
+			http.Error(w, fmt.Sprintf("No policy for job opening %q", jobID), http.StatusNotFound)
+			return
+		}
+		policy = p
+	}
+
+	writeJSON(w, http.StatusOK, policy.Evaluate(req.Resume))
+}
+
+func (s *Service) handleListPolicies(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string][]string{"job_ids": s.policies.JobIDs()})
+}
+
+func (s *Service) handleGetPolicy(w http.ResponseWriter, r *http.Request) {
+	p, ok := s.policies.Lookup(r.PathValue("job_id"))
+	if !ok {
+		http.Error(w, "Policy not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
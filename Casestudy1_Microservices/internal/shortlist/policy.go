@@ -0,0 +1,403 @@
+package shortlist
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"casestudy1_microservices/internal/events"
+)
+
+// DefaultJobID is the policy used for resumes that name no job opening.
+const DefaultJobID = "default"
+
+// Policy decides which candidates a job opening shortlists.
+type Policy struct {
+	JobID string `json:"job_id" yaml:"job_id"`
+	Title string `json:"title,omitempty" yaml:"title,omitempty"`
+	// SkillWeights scores a resume: its score is the sum of the weights of the skills it lists.
+	// Skill names are matched case-insensitively.
+	SkillWeights map[string]float64 `json:"skill_weights,omitempty" yaml:"skill_weights,omitempty"`
+	// Rule must hold for a candidate to be shortlisted.
+	Rule Rule `json:"rule" yaml:"rule"`
+}
+
+// Rule is one node of a policy's decision tree. Exactly one field is set: All, Any and Not combine
+// other rules, the rest test the resume.
+type Rule struct {
+	All    []Rule      `json:"all,omitempty" yaml:"all,omitempty"`
+	Any    []Rule      `json:"any,omitempty" yaml:"any,omitempty"`
+	Not    *Rule       `json:"not,omitempty" yaml:"not,omitempty"`
+	CGPA   *CGPARule   `json:"cgpa,omitempty" yaml:"cgpa,omitempty"`
+	Branch *BranchRule `json:"branch,omitempty" yaml:"branch,omitempty"`
+	Skills *SkillsRule `json:"skills,omitempty" yaml:"skills,omitempty"`
+	Score  *ScoreRule  `json:"score,omitempty" yaml:"score,omitempty"`
+}
+
+// CGPARule bounds the CGPA; either bound may be omitted.
+type CGPARule struct {
+	Min *float64 `json:"min,omitempty" yaml:"min,omitempty"`
+	Max *float64 `json:"max,omitempty" yaml:"max,omitempty"`
+}
+
+// BranchRule allows or excludes branches, compared case-insensitively.
+type BranchRule struct {
+	In    []string `json:"in,omitempty" yaml:"in,omitempty"`
+	NotIn []string `json:"not_in,omitempty" yaml:"not_in,omitempty"`
+}
+
+// SkillsRule requires at least one of Any and every one of All.
+type SkillsRule struct {
+	Any []string `json:"any,omitempty" yaml:"any,omitempty"`
+	All []string `json:"all,omitempty" yaml:"all,omitempty"`
+}
+
+// ScoreRule requires a minimum weighted skill score.
+type ScoreRule struct {
+	Min float64 `json:"min" yaml:"min"`
+}
+
+// Decision is the outcome of evaluating a resume, with the reasons that decided it.
+type Decision struct {
+	JobID       string   `json:"job_id"`
+	Shortlisted bool     `json:"shortlisted"`
+	Score       float64  `json:"score"`
+	Reasons     []string `json:"reasons"`
+}
+
+// DefaultPolicy shortlists a CGPA of 8 or more, or Go or distributed systems experience.
+func DefaultPolicy() *Policy {
+	minCGPA := 8.0
+	return &Policy{
+		JobID: DefaultJobID,
+		Title: "Default shortlist",
+		SkillWeights: map[string]float64{
+			"golang":              1,
+			"distributed systems": 1,
+		},
+		Rule: Rule{Any: []Rule{
+			{CGPA: &CGPARule{Min: &minCGPA}},
+			{Skills: &SkillsRule{Any: []string{"golang", "distributed systems"}}},
+		}},
+	}
+}
+
+// Validate checks that every rule node sets exactly one field and that the job ID is present.
+func (p *Policy) Validate() error {
+	if p.JobID == "" {
+		return errors.New("job_id is required")
+	}
+	return p.Rule.validate("rule")
+}
+
+func (r *Rule) validate(path string) error {
+	set := 0
+	for _, ok := range []bool{r.All != nil, r.Any != nil, r.Not != nil, r.CGPA != nil, r.Branch != nil, r.Skills != nil, r.Score != nil} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("%s: exactly one of all, any, not, cgpa, branch, skills or score must be set", path)
+	}
+	switch {
+	case r.All != nil || r.Any != nil:
+		children, name := r.All, "all"
+		if r.Any != nil {
+			children, name = r.Any, "any"
+		}
+		if len(children) == 0 {
+			return fmt.Errorf("%s.%s: needs at least one rule", path, name)
+		}
+		for i := range children {
+			if err := children[i].validate(fmt.Sprintf("%s.%s[%d]", path, name, i)); err != nil {
+				return err
+			}
+		}
+	case r.Not != nil:
+		return r.Not.validate(path + ".not")
+	case r.CGPA != nil:
+		if r.CGPA.Min == nil && r.CGPA.Max == nil {
+			return fmt.Errorf("%s.cgpa: min or max is required", path)
+		}
+	case r.Branch != nil:
+		if len(r.Branch.In) == 0 && len(r.Branch.NotIn) == 0 {
+			return fmt.Errorf("%s.branch: in or not_in is required", path)
+		}
+	case r.Skills != nil:
+		if len(r.Skills.Any) == 0 && len(r.Skills.All) == 0 {
+			return fmt.Errorf("%s.skills: any or all is required", path)
+		}
+	}
+	return nil
+}
+
+// Evaluate applies the policy to a resume.
+func (p *Policy) Evaluate(resume events.ResumeUploaded) Decision {
+	skills := make(map[string]bool, len(resume.Skills))
+	for _, s := range resume.Skills {
+		skills[normalize(s)] = true
+	}
+	score, breakdown := p.score(skills)
+	c := candidate{resume: resume, skills: skills, score: score}
+
+	ok, reasons := p.Rule.eval(c)
+	if len(p.SkillWeights) > 0 {
+		reasons = append([]string{fmt.Sprintf("skill score %s (%s)", formatNumber(score), breakdown)}, reasons...)
+	}
+	return Decision{JobID: p.JobID, Shortlisted: ok, Score: score, Reasons: reasons}
+}
+
+func (p *Policy) score(skills map[string]bool) (float64, string) {
+	var total float64
+	var parts []string
+	for skill, weight := range p.SkillWeights {
+		if skills[normalize(skill)] {
+			total += weight
+			parts = append(parts, fmt.Sprintf("%s %+g", skill, weight))
+		}
+	}
+	if len(parts) == 0 {
+		return 0, "no weighted skills"
+	}
+	sort.Strings(parts)
+	return total, strings.Join(parts, ", ")
+}
+
+type candidate struct {
+	resume events.ResumeUploaded
+	skills map[string]bool
+	score  float64
+}
+
+// eval reports whether the rule holds and why. A combinator explains itself with the children that
+// decided it: the passing ones when it holds, the failing ones when it does not.
+func (r *Rule) eval(c candidate) (bool, []string) {
+	switch {
+	case r.All != nil:
+		return combine(r.All, c, false)
+	case r.Any != nil:
+		return combine(r.Any, c, true)
+	case r.Not != nil:
+		ok, reasons := r.Not.eval(c)
+		for i, reason := range reasons {
+			reasons[i] = "not (" + reason + ")"
+		}
+		return !ok, reasons
+	case r.CGPA != nil:
+		return r.CGPA.eval(c.resume.CGPA)
+	case r.Branch != nil:
+		return r.Branch.eval(c.resume.Branch)
+	case r.Skills != nil:
+		return r.Skills.eval(c.skills)
+	case r.Score != nil:
+		if c.score >= r.Score.Min {
+			return true, []string{fmt.Sprintf("score %s meets minimum %s", formatNumber(c.score), formatNumber(r.Score.Min))}
+		}
+		return false, []string{fmt.Sprintf("score %s below minimum %s", formatNumber(c.score), formatNumber(r.Score.Min))}
+	}
+	return false, []string{"empty rule"}
+}
+
+// combine evaluates an any or an all combinator over rules.
+func combine(rules []Rule, c candidate, isAny bool) (bool, []string) {
+	var passed, failed []string
+	anyPassed, allPassed := false, true
+	for i := range rules {
+		ok, reasons := rules[i].eval(c)
+		if ok {
+			anyPassed = true
+			passed = append(passed, reasons...)
+		} else {
+			allPassed = false
+			failed = append(failed, reasons...)
+		}
+	}
+	if isAny {
+		if anyPassed {
+			return true, passed
+		}
+		return false, failed
+	}
+	if !allPassed {
+		return false, failed
+	}
+	return true, passed
+}
+
+func (r *CGPARule) eval(cgpa float64) (bool, []string) {
+	if r.Min != nil && cgpa < *r.Min {
+		return false, []string{fmt.Sprintf("cgpa %.2f below minimum %.2f", cgpa, *r.Min)}
+	}
+	if r.Max != nil && cgpa > *r.Max {
+		return false, []string{fmt.Sprintf("cgpa %.2f above maximum %.2f", cgpa, *r.Max)}
+	}
+	switch {
+	case r.Min != nil && r.Max != nil:
+		return true, []string{fmt.Sprintf("cgpa %.2f within %.2f-%.2f", cgpa, *r.Min, *r.Max)}
+	case r.Min != nil:
+		return true, []string{fmt.Sprintf("cgpa %.2f meets minimum %.2f", cgpa, *r.Min)}
+	default:
+		return true, []string{fmt.Sprintf("cgpa %.2f within maximum %.2f", cgpa, *r.Max)}
+	}
+}
+
+func (r *BranchRule) eval(branch string) (bool, []string) {
+	b := normalize(branch)
+	has := func(list []string) bool {
+		return slices.ContainsFunc(list, func(s string) bool { return normalize(s) == b })
+	}
+	if len(r.NotIn) > 0 && has(r.NotIn) {
+		return false, []string{fmt.Sprintf("branch %q is excluded", branch)}
+	}
+	if len(r.In) > 0 && !has(r.In) {
+		return false, []string{fmt.Sprintf("branch %q not in %s", branch, strings.Join(r.In, ", "))}
+	}
+	return true, []string{fmt.Sprintf("branch %q accepted", branch)}
+}
+
+func (r *SkillsRule) eval(skills map[string]bool) (bool, []string) {
+	var missing []string
+	for _, s := range r.All {
+		if !skills[normalize(s)] {
+			missing = append(missing, s)
+		}
+	}
+	if len(missing) > 0 {
+		return false, []string{"missing required skills: " + strings.Join(missing, ", ")}
+	}
+	var matched []string
+	for _, s := range r.Any {
+		if skills[normalize(s)] {
+			matched = append(matched, s)
+		}
+	}
+	if len(r.Any) > 0 && len(matched) == 0 {
+		return false, []string{"none of the skills: " + strings.Join(r.Any, ", ")}
+	}
+	if len(r.All) > 0 {
+		matched = append(slices.Clone(r.All), matched...)
+	}
+	return true, []string{"has skills: " + strings.Join(matched, ", ")}
+}
+
+// normalize makes skill and branch names comparable: lower case with single spaces.
+func normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+func formatNumber(f float64) string {
+	return fmt.Sprintf("%g", f)
+}
+
+// Policies holds the policy of every job opening.
+type Policies struct {
+	byJob map[string]*Policy
+}
+
+// NewPolicies indexes policies by job ID. The built-in DefaultPolicy is added unless one of them
+// has the DefaultJobID.
+func NewPolicies(policies ...*Policy) (*Policies, error) {
+	set := &Policies{byJob: map[string]*Policy{DefaultJobID: DefaultPolicy()}}
+	seen := make(map[string]bool, len(policies))
+	for _, p := range policies {
+		if err := p.Validate(); err != nil {
+			return nil, fmt.Errorf("policy %q: %w", p.JobID, err)
+		}
+		if seen[p.JobID] {
+			return nil, fmt.Errorf("policy %q is defined twice", p.JobID)
+		}
+		seen[p.JobID] = true
+		set.byJob[p.JobID] = p
+	}
+	return set, nil
+}
+
+// LoadPolicies reads every .yaml, .yml and .json file in dir as one policy.
+func LoadPolicies(dir string) (*Policies, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var policies []*Policy
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		p, err := ParsePolicy(data, filepath.Ext(path))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		policies = append(policies, p)
+	}
+	return NewPolicies(policies...)
+}
+
+// ParsePolicy decodes a policy from JSON when ext is ".json" and from YAML otherwise, rejecting
+// unknown fields.
+func ParsePolicy(data []byte, ext string) (*Policy, error) {
+	var p Policy
+	if strings.EqualFold(ext, ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&p); err != nil {
+			return nil, err
+		}
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&p); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Lookup returns the policy of a job opening; an empty job ID selects the default policy.
+func (s *Policies) Lookup(jobID string) (*Policy, bool) {
+	if jobID == "" {
+		jobID = DefaultJobID
+	}
+	p, ok := s.byJob[jobID]
+	return p, ok
+}
+
+// JobIDs lists the configured job openings.
+func (s *Policies) JobIDs() []string {
+	ids := make([]string, 0, len(s.byJob))
+	for id := range s.byJob {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Evaluate applies the resume's job opening policy. A resume for an unknown job opening is rejected.
+func (s *Policies) Evaluate(resume events.ResumeUploaded) Decision {
+	p, ok := s.Lookup(resume.JobID)
+	if !ok {
+		return Decision{JobID: resume.JobID, Reasons: []string{fmt.Sprintf("no policy for job opening %q", resume.JobID)}}
+	}
+	return p.Evaluate(resume)
+}
@@ -0,0 +1,106 @@
+package shortlist
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"casestudy1_microservices/internal/events"
+)
+
+func TestPolicyEvaluate(t *testing.T) {
+	policies, err := LoadPolicies("../../policies")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		resume      events.ResumeUploaded
+		shortlisted bool
+		score       float64
+		reason      string // expected among the reasons
+	}{
+		{
+			name:        "default: high cgpa",
+			resume:      events.ResumeUploaded{CGPA: 8.2, Skills: []string{"react"}},
+			shortlisted: true,
+			reason:      "cgpa 8.20 meets minimum 8.00",
+		},
+		{
+			name:        "default: skill matched case-insensitively",
+			resume:      events.ResumeUploaded{CGPA: 6, Skills: []string{" GoLang "}},
+			shortlisted: true,
+			score:       1,
+			reason:      "has skills: golang",
+		},
+		{
+			name:   "default: rejected with every failed rule",
+			resume: events.ResumeUploaded{CGPA: 6, Skills: []string{"java"}},
+			reason: "none of the skills: golang, distributed systems",
+		},
+		{
+			name:        "backend: weighted score",
+			resume:      events.ResumeUploaded{JobID: "backend-engineer", CGPA: 7.5, Branch: "cse", Skills: []string{"Golang", "Kubernetes"}},
+			shortlisted: true,
+			score:       55,
+			reason:      "score 55 meets minimum 50",
+		},
+		{
+			name:        "backend: high cgpa instead of score",
+			resume:      events.ResumeUploaded{JobID: "backend-engineer", CGPA: 9.4, Branch: "ECE", Skills: []string{"python"}},
+			shortlisted: true,
+			score:       10,
+			reason:      "cgpa 9.40 meets minimum 9.00",
+		},
+		{
+			name:   "backend: branch filter",
+			resume: events.ResumeUploaded{JobID: "backend-engineer", CGPA: 9.4, Branch: "MECH", Skills: []string{"golang", "distributed systems"}},
+			score:  70,
+			reason: `branch "MECH" not in CSE, ECE`,
+		},
+		{
+			name:   "frontend: not combinator",
+			resume: events.ResumeUploaded{JobID: "frontend-engineer", CGPA: 9, Branch: "CIVIL", Skills: []string{"react", "typescript"}},
+			score:  80,
+			reason: `not (branch "CIVIL" accepted)`,
+		},
+		{
+			name:   "unknown job opening",
+			resume: events.ResumeUploaded{JobID: "data-scientist", CGPA: 10, Skills: []string{"golang"}},
+			reason: `no policy for job opening "data-scientist"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := policies.Evaluate(tt.resume)
+			if d.Shortlisted != tt.shortlisted || d.Score != tt.score {
+				t.Fatalf("got shortlisted=%v score=%v, want %v %v (reasons %q)", d.Shortlisted, d.Score, tt.shortlisted, tt.score, d.Reasons)
+			}
+			if !slices.Contains(d.Reasons, tt.reason) {
+				t.Fatalf("reasons %q do not include %q", d.Reasons, tt.reason)
+			}
+		})
+	}
+}
+
+func TestParsePolicyRejectsInvalidRules(t *testing.T) {
+	tests := []struct {
+		name, ext, data, wantErr string
+	}{
+		{"two fields in one node", ".yaml", "job_id: x\nrule:\n  cgpa: {min: 7}\n  branch: {in: [CSE]}\n", "exactly one of"},
+		{"empty any", ".json", `{"job_id":"x","rule":{"any":[]}}`, "rule.any: needs at least one rule"},
+		{"nested error path", ".yaml", "job_id: x\nrule:\n  all:\n    - cgpa: {}\n", "rule.all[0].cgpa"},
+		{"unknown field", ".yaml", "job_id: x\nrule:\n  gpa: {min: 7}\n", "gpa"},
+		{"missing job id", ".json", `{"rule":{"cgpa":{"min":7}}}`, "job_id is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePolicy([]byte(tt.data), tt.ext)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
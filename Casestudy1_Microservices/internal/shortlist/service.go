@@ -0,0 +1,244 @@
+// Package shortlist consumes ResumeUploaded events, applies the shortlisting rules and publishes the
+// shortlisted candidates along with a notification.
+package shortlist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"casestudy1_microservices/internal/broker"
+	"casestudy1_microservices/internal/events"
+	"casestudy1_microservices/internal/idempotency"
+	"casestudy1_microservices/internal/outbox"
+)
+
+const (
+	// ConsumerGroup is the group the service joins on the resume topic.
+	ConsumerGroup = "shortlisting-service"
+	// DefaultWorkerCount is the number of concurrent workers when none is configured.
+	DefaultWorkerCount = 5
+)
+
+// Service shortlists candidates from a pool of workers.
+type Service struct {
+	consumer      broker.Consumer
+	events        broker.Publisher
+	notifications broker.Publisher
+	policies      *Policies
+	workerCount   int
+	seen          idempotency.Store
+	// outbox and relay are set by NewOutboxService; they replace seen and the direct publishing.
+	outbox outbox.Store
+	relay  *outbox.Relay
+}
+
+// Outbox routes of the two publishers.
+const (
+	routeEvents        = "events"
+	routeNotifications = "notifications"
+)
+
+// NewService creates a service reading resumes from consumer, deciding them with policies and
+// publishing the decisions to eventPublisher and notifyPublisher. Resumes already recorded in seen
+// are skipped. A nil policies uses only the DefaultPolicy; a nil seen remembers resumes in memory;
+// workerCount <= 0 uses DefaultWorkerCount.
+func NewService(consumer broker.Consumer, eventPublisher, notifyPublisher broker.Publisher, policies *Policies, seen idempotency.Store, workerCount int) *Service {
+	if policies == nil {
+		policies, _ = NewPolicies()
+	}
+	if seen == nil {
+		seen = idempotency.NewMemoryStore(idempotency.DefaultCapacity, idempotency.DefaultTTL, idempotency.DefaultLease)
+	}
+	if workerCount <= 0 {
+		workerCount = DefaultWorkerCount
+	}
+	return &Service{
+		consumer:      consumer,
+		events:        eventPublisher,
+		notifications: notifyPublisher,
+		policies:      policies,
+		workerCount:   workerCount,
+		seen:          seen,
+	}
+}
+
+// NewOutboxService creates a service like NewService that commits each decision to store before
+// the resume is acknowledged, and publishes it from there. The store also skips duplicate resumes.
+// The event, the notification and the consumer offset therefore all advance together: a crash
+// neither drops a notification nor publishes one without its event, at the cost of possibly
+// publishing both again with the same outbox.HeaderMessageID.
+func NewOutboxService(consumer broker.Consumer, eventPublisher, notifyPublisher broker.Publisher, policies *Policies, store outbox.Store, workerCount int) *Service {
+	s := NewService(consumer, eventPublisher, notifyPublisher, policies, nil, workerCount)
+	s.outbox = store
+	s.relay = outbox.NewRelay(store, map[string]broker.Publisher{
+		routeEvents:        eventPublisher,
+		routeNotifications: notifyPublisher,
+	})
+	return s
+}
+
+// Run consumes and processes resumes until ctx is cancelled. Each worker handles a message to
+// completion before taking the next, so the consumer only acknowledges resumes that were processed.
+// If a worker's consumer fails, the other workers are stopped too. An outbox service also runs its
+// relay until ctx is cancelled.
+func (s *Service) Run(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+
+	if s.relay != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.relay.Run(ctx)
+		}()
+	}
+
+	for i := 0; i < s.workerCount; i++ {
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+			slog.Info("Worker started", slog.Int("worker_id", workerID))
+			if err := s.consumer.Consume(ctx, s.processEvent); err != nil {
+				slog.Error("Consumer error", slog.Int("worker_id", workerID), slog.Any("error", err))
+				cancel()
+			}
+			slog.Info("Worker shutting down", slog.Int("worker_id", workerID))
+		}(i)
+	}
+
+	// Wait for all workers to finish
+	wg.Wait()
+	slog.Info("All workers have shut down")
+}
+
+func (s *Service) processEvent(ctx context.Context, msg broker.Message) (err error) {
+	// The key covers the content, so a changed resume for the same student is evaluated again.
+	key := idempotency.Key(msg.Key, msg.Value)
+	if s.outbox != nil {
+		return s.processTransactional(ctx, key, msg)
+	}
+
+	// Without an outbox, a failed attempt is released so that a retry is not taken for a duplicate.
+	acquired, err := s.seen.Acquire(ctx, key)
+	if err != nil {
+		return fmt.Errorf("check idempotency: %w", err)
+	}
+	if !acquired {
+		slog.Info("Duplicate event detected, skipping", slog.String("key", string(msg.Key)))
+		return nil
+	}
+	defer func() {
+		// Recording the outcome must not be cut short by a cancelled consumer context.
+		ctx := context.WithoutCancel(ctx)
+		if err != nil {
+			if rerr := s.seen.Release(ctx, key); rerr != nil {
+				slog.Error("Failed to release idempotency key", slog.String("key", string(msg.Key)), slog.Any("error", rerr))
+			}
+			return
+		}
+		// The decision is published, so a failure here only risks evaluating a duplicate again.
+		if cerr := s.seen.Complete(ctx, key); cerr != nil {
+			slog.Error("Failed to record processed event", slog.String("key", string(msg.Key)), slog.Any("error", cerr))
+		}
+	}()
+
+	out, err := s.decide(msg)
+	if err != nil {
+		return err
+	}
+	return s.publish(ctx, out)
+}
+
+// processTransactional records the resume as consumed together with its event and notification,
+// so that returning (and the consumer committing the offset) implies both will be published.
+func (s *Service) processTransactional(ctx context.Context, key string, msg broker.Message) error {
+	out, err := s.decide(msg)
+	if err != nil {
+		return err
+	}
+	msgKey := []byte(out.studentID)
+	committed, err := s.outbox.Commit(ctx, key, []outbox.Message{
+		{Route: routeEvents, Message: broker.Message{Topic: out.topic, Key: msgKey, Value: out.data}},
+		{Route: routeNotifications, Message: broker.Message{Topic: out.subject, Key: msgKey, Value: out.data}},
+	})
+	if err != nil {
+		slog.Error("Failed to write outbox", slog.String("student_id", out.studentID), slog.Any("error", err))
+		return fmt.Errorf("write outbox: %w", err)
+	}
+	if !committed {
+		slog.Info("Duplicate event detected, skipping", slog.String("key", string(msg.Key)))
+		return nil
+	}
+	s.relay.Notify()
+	return nil
+}
+
+// output is a decision ready to publish: an event for topic and a notification for subject, both
+// carrying data.
+type output struct {
+	studentID string
+	topic     string
+	subject   string
+	data      []byte
+}
+
+// decide evaluates a resume and encodes the resulting event.
+func (s *Service) decide(msg broker.Message) (output, error) {
+	var resume events.ResumeUploaded
+	if err := json.Unmarshal(msg.Value, &resume); err != nil {
+		slog.Error("Failed to deserialize message", slog.String("key", string(msg.Key)), slog.Any("error", err))
+		return output{}, broker.Permanent(fmt.Errorf("decode resume: %w", err))
+	}
+
+	decision := s.policies.Evaluate(resume)
+	if !decision.Shortlisted {
+		slog.Info("Candidate rejected", slog.String("student_id", resume.StudentID), slog.String("job_id", decision.JobID),
+			slog.Float64("score", decision.Score), slog.Any("reasons", decision.Reasons))
+		return encode(resume.StudentID, events.TopicRejectedCandidates, events.SubjectRejectionNotify, events.CandidateRejected{
+			StudentID: resume.StudentID,
+			Name:      resume.Name,
+			JobID:     decision.JobID,
+			Score:     decision.Score,
+			Reasons:   decision.Reasons,
+		})
+	}
+	slog.Info("Candidate shortlisted", slog.String("student_id", resume.StudentID), slog.String("job_id", decision.JobID),
+		slog.Float64("score", decision.Score), slog.Any("reasons", decision.Reasons))
+
+	return encode(resume.StudentID, events.TopicShortlistedCandidates, events.SubjectShortlistNotify, events.ShortlistedCandidate{
+		StudentID: resume.StudentID,
+		Name:      resume.Name,
+		CGPA:      resume.CGPA,
+		Branch:    resume.Branch,
+		JobID:     decision.JobID,
+		Score:     decision.Score,
+		Reasons:   decision.Reasons,
+	})
+}
+
+func encode(studentID, topic, subject string, event any) (output, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return output{}, broker.Permanent(fmt.Errorf("encode %s event: %w", topic, err))
+	}
+	return output{studentID: studentID, topic: topic, subject: subject, data: data}, nil
+}
+
+// publish sends a decision to its event topic and then to its notification subject.
+func (s *Service) publish(ctx context.Context, out output) error {
+	key := []byte(out.studentID)
+	if err := s.events.Publish(ctx, broker.Message{Topic: out.topic, Key: key, Value: out.data}); err != nil {
+		slog.Error("Failed to publish event", slog.String("topic", out.topic), slog.String("student_id", out.studentID), slog.Any("error", err))
+		return fmt.Errorf("publish %s event: %w", out.topic, err)
+	}
+
+	// The notification is best effort; failing it must not republish the event.
+	if err := s.notifications.Publish(ctx, broker.Message{Topic: out.subject, Key: key, Value: out.data}); err != nil {
+		slog.Error("Failed to publish notification", slog.String("subject", out.subject), slog.String("student_id", out.studentID), slog.Any("error", err))
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package shortlist
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"casestudy1_microservices/internal/broker"
+	"casestudy1_microservices/internal/events"
+	"casestudy1_microservices/internal/idempotency"
+	"casestudy1_microservices/internal/outbox"
+)
+
+// recorder is a publisher that keeps what it is sent.
+type recorder struct {
+	mu   sync.Mutex
+	msgs []broker.Message
+}
+
+func (r *recorder) Publish(_ context.Context, msg broker.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.msgs = append(r.msgs, msg)
+	return nil
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.msgs)
+}
+
+func resumeMessage(t *testing.T, resume events.ResumeUploaded) broker.Message {
+	t.Helper()
+	data, err := json.Marshal(resume)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return broker.Message{Topic: events.TopicResumeUploaded, Key: []byte(resume.StudentID), Value: data}
+}
+
+func TestProcessEventSkipsDuplicatesAcrossRestarts(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "idempotency.db")
+	resume := events.ResumeUploaded{StudentID: "S-1", Name: "Asha", CGPA: 8.5, Branch: "CSE"}
+	decisions := &recorder{}
+
+	// newService stands in for a restarted service instance: only the database carries over.
+	newService := func() (*Service, func()) {
+		seen, err := idempotency.OpenSQLiteStore(path, idempotency.DefaultTTL, idempotency.DefaultLease)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return NewService(nil, decisions, &recorder{}, nil, seen, 1), func() { seen.Close() }
+	}
+
+	s, stop := newService()
+	for range 2 {
+		if err := s.processEvent(ctx, resumeMessage(t, resume)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stop()
+	s, stop = newService()
+	defer stop()
+	if err := s.processEvent(ctx, resumeMessage(t, resume)); err != nil {
+		t.Fatal(err)
+	}
+	if n := decisions.count(); n != 1 {
+		t.Fatalf("published %d decisions for one resume, want 1", n)
+	}
+
+	resume.CGPA = 6.1 // a resubmitted, changed resume is evaluated again
+	if err := s.processEvent(ctx, resumeMessage(t, resume)); err != nil {
+		t.Fatal(err)
+	}
+	if n := decisions.count(); n != 2 {
+		t.Fatalf("published %d decisions after the resume changed, want 2", n)
+	}
+	if got := decisions.msgs[1].Topic; got != events.TopicRejectedCandidates {
+		t.Fatalf("changed resume published to %s, want %s", got, events.TopicRejectedCandidates)
+	}
+}
+
+// crash unwinds the stack at a fault point, standing in for the process being killed: only the outbox
+// database, the partition and what was already published outlive it.
+type crash struct{ step string }
+
+// faults crashes the second time step is reached, so that one message has gone through first.
+type faults struct {
+	step    string
+	reached int
+}
+
+func (f *faults) at(step string) {
+	if f == nil || step != f.step {
+		return
+	}
+	if f.reached++; f.reached == 2 {
+		panic(crash{step})
+	}
+}
+
+type faultyStore struct {
+	outbox.Store
+	faults *faults
+}
+
+func (s faultyStore) Commit(ctx context.Context, key string, msgs []outbox.Message) (bool, error) {
+	s.faults.at("before-outbox-commit")
+	ok, err := s.Store.Commit(ctx, key, msgs)
+	s.faults.at("after-outbox-commit")
+	return ok, err
+}
+
+type faultyPublisher struct {
+	broker.Publisher
+	faults *faults
+	name   string
+}
+
+func (p faultyPublisher) Publish(ctx context.Context, msg broker.Message) error {
+	p.faults.at("before-" + p.name + "-publish")
+	err := p.Publisher.Publish(ctx, msg)
+	p.faults.at("after-" + p.name + "-publish")
+	return err
+}
+
+func TestOutboxSurvivesCrashAtEveryStep(t *testing.T) {
+	ctx := context.Background()
+	resumes := []events.ResumeUploaded{
+		{StudentID: "S-1", Name: "Asha", CGPA: 8.5, Branch: "CSE"},
+		{StudentID: "S-2", Name: "Ravi", CGPA: 6.1, Branch: "ECE"},
+		{StudentID: "S-3", Name: "Meera", CGPA: 9.2, Branch: "CSE"},
+	}
+	steps := []string{
+		"before-handle", "before-outbox-commit", "after-outbox-commit", "after-offset-commit",
+		"before-event-publish", "after-event-publish", "before-notification-publish", "after-notification-publish",
+	}
+	for _, step := range steps {
+		t.Run(step, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "outbox.db")
+			partition := make([]broker.Message, len(resumes))
+			for i, r := range resumes {
+				partition[i] = resumeMessage(t, r)
+			}
+			committed := 0 // the consumer group's offset
+			decisions, notifications := &recorder{}, &recorder{}
+
+			// run is one lifetime of the service, consuming and relaying in turn as the workers and
+			// the relay do. It reports whether the process was killed.
+			run := func(f *faults) (crashed bool) {
+				store, err := outbox.OpenSQLiteStore(path, outbox.DefaultTTL)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer store.Close()
+				defer func() {
+					if r := recover(); r != nil {
+						if _, ok := r.(crash); !ok {
+							panic(r)
+						}
+						crashed = true
+					}
+				}()
+				s := NewOutboxService(nil, faultyPublisher{decisions, f, "event"}, faultyPublisher{notifications, f, "notification"},
+					nil, faultyStore{store, f}, 1)
+				for {
+					if _, err := s.relay.Flush(ctx); err != nil {
+						t.Fatal(err)
+					}
+					if committed == len(partition) {
+						return false
+					}
+					f.at("before-handle")
+					if err := s.processEvent(ctx, partition[committed]); err != nil {
+						t.Fatal(err)
+					}
+					committed++
+					f.at("after-offset-commit")
+				}
+			}
+
+			if !run(&faults{step: step}) {
+				t.Fatal("the service was not killed")
+			}
+			if run(nil) {
+				t.Fatal("the restarted service was killed")
+			}
+
+			if committed != len(partition) {
+				t.Fatalf("committed offset %d, want %d", committed, len(partition))
+			}
+			// Redelivered copies carry the ID of the original, so consumers see each message once.
+			events := distinct(t, decisions.msgs)
+			notes := distinct(t, notifications.msgs)
+			if len(events) != len(resumes) || len(notes) != len(resumes) {
+				t.Fatalf("%d distinct events and %d distinct notifications for %d resumes", len(events), len(notes), len(resumes))
+			}
+			for id, event := range events {
+				note, ok := notes[strings.TrimSuffix(id, "/0")+"/1"]
+				if !ok || string(note.Key) != string(event.Key) || string(note.Value) != string(event.Value) {
+					t.Fatalf("event %s has no matching notification", id)
+				}
+			}
+			// Only a crash between publishing a message and recording its delivery repeats it.
+			duplicates := len(decisions.msgs) + len(notifications.msgs) - 2*len(resumes)
+			want := 0
+			if step == "after-event-publish" || step == "after-notification-publish" {
+				want = 1
+			}
+			if duplicates != want {
+				t.Fatalf("%d messages published twice, want %d", duplicates, want)
+			}
+		})
+	}
+}
+
+// distinct indexes msgs by message ID, failing if two messages with one ID differ.
+func distinct(t *testing.T, msgs []broker.Message) map[string]broker.Message {
+	t.Helper()
+	byID := make(map[string]broker.Message)
+	for _, m := range msgs {
+		id := m.Headers[outbox.HeaderMessageID]
+		if prev, ok := byID[id]; ok && string(prev.Value) != string(m.Value) {
+			t.Fatalf("message ID %s reused for different messages", id)
+		}
+		byID[id] = m
+	}
+	return byID
+}
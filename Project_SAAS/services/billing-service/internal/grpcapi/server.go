@@ -0,0 +1,45 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"project_saas/services/billing-service/internal/engine"
+	"project_saas/shared/pkg/concurrency"
+	"project_saas/shared/pkg/grpcx"
+	billingv1 "project_saas/shared/pkg/pb/billing/v1"
+)
+
+// BillingServer exposes engine.Processor over gRPC.
+type BillingServer struct {
+	billingv1.UnimplementedBillingServiceServer
+	proc *engine.Processor
+}
+
+// NewBillingServer builds a BillingServer.
+func NewBillingServer(proc *engine.Processor) *BillingServer {
+	return &BillingServer{proc: proc}
+}
+
+func (s *BillingServer) RunBilling(ctx context.Context, req *billingv1.RunBillingRequest) (*billingv1.RunBillingResponse, error) {
+	if err := grpcx.AuthorizeTenant(ctx, req.GetTenantId()); err != nil {
+		return nil, err
+	}
+	result, err := s.proc.Run(ctx, req.GetTenantId(), int(req.GetRecords()))
+	if err != nil {
+		if errors.Is(err, concurrency.ErrExceededDeadline) {
+			return nil, status.Error(codes.DeadlineExceeded, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &billingv1.RunBillingResponse{
+		TenantId:     result.Tenant,
+		Processed:    result.Processed,
+		OpsPerSecond: result.OpsPerSecond,
+		DurationMs:   result.DurationMS,
+		Budget:       result.Budget,
+	}, nil
+}
@@ -7,17 +7,17 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 
 	"project_saas/services/billing-service/internal/engine"
+	"project_saas/services/billing-service/internal/grpcapi"
 	"project_saas/shared/pkg/config"
+	billingv1 "project_saas/shared/pkg/pb/billing/v1"
 )
 
 // Register exposes billing aggregation endpoints.
 func Register(r chi.Router, cfg config.ServiceConfig, log *zap.Logger) {
 	proc := engine.NewProcessor(cfg, log)
-	r.Get("/health", func(w http.ResponseWriter, _ *http.Request) {
-		respond(w, http.StatusOK, map[string]string{"status": "ok"})
-	})
 	r.Route("/billing", func(r chi.Router) {
 		r.Post("/tenants/{tenantID}/run", func(w http.ResponseWriter, req *http.Request) {
 			countParam := req.URL.Query().Get("records")
@@ -38,6 +38,11 @@ func Register(r chi.Router, cfg config.ServiceConfig, log *zap.Logger) {
 	})
 }
 
+// RegisterGRPC exposes billing runs over gRPC.
+func RegisterGRPC(g *grpc.Server, cfg config.ServiceConfig, log *zap.Logger) {
+	billingv1.RegisterBillingServiceServer(g, grpcapi.NewBillingServer(engine.NewProcessor(cfg, log)))
+}
+
 func respond(w http.ResponseWriter, status int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
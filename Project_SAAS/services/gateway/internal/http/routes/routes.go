@@ -1,44 +1,151 @@
 package routes
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"project_saas/shared/pkg/auth"
 	"project_saas/shared/pkg/config"
+	"project_saas/shared/pkg/grpcx"
+	"project_saas/shared/pkg/httpx"
+	subscriptionsv1 "project_saas/shared/pkg/pb/subscriptions/v1"
+	usersv1 "project_saas/shared/pkg/pb/users/v1"
 )
 
+// Config holds gateway settings layered on top of config.ServiceConfig. Empty targets leave the
+// corresponding /api/status component "unconfigured".
+type Config struct {
+	UserServiceGRPCAddr         string `yaml:"user_service_grpc_addr" env:"USER_SERVICE_GRPC_ADDR"`
+	SubscriptionServiceGRPCAddr string `yaml:"subscription_service_grpc_addr" env:"SUBSCRIPTION_SERVICE_GRPC_ADDR"`
+}
+
+// Server carries the gateway's extension config into Register.
+type Server struct {
+	Config Config
+}
+
 // Register sets up API gateway routes used by external clients.
-func Register(r chi.Router, cfg config.ServiceConfig, log *zap.Logger) {
+func (s *Server) Register(r chi.Router, cfg config.ServiceConfig, log *zap.Logger) {
 	logger := log.Named("http")
 	logger.Info("gateway ready", zap.String("port", cfg.HTTPPort))
-	validator := auth.NewValidator(cfg.AuthSecret)
+	validator := auth.NewValidator(cfg.AuthSecret.Value())
 	mw := auth.Middleware(validator, log.Named("auth"))
-	r.Get("/health", health)
+	agg := newAggregator(s.Config, auth.NewSigner(cfg.AuthSecret.Value()), logger)
 	r.Route("/api", func(r chi.Router) {
 		r.Use(mw)
-		r.Get("/status", aggregateStatus)
+		r.Get("/status", agg.status)
 		r.Get("/me", me)
 	})
 }
 
-func health(w http.ResponseWriter, _ *http.Request) {
-	respond(w, http.StatusOK, map[string]string{"status": "ok"})
+// aggregator fans out to internal services over gRPC. Clients are nil when the target is not configured.
+type aggregator struct {
+	users         usersv1.UserServiceClient
+	subscriptions subscriptionsv1.SubscriptionServiceClient
+	log           *zap.Logger
 }
 
-func aggregateStatus(w http.ResponseWriter, r *http.Request) {
+func newAggregator(cfg Config, signer *auth.Signer, log *zap.Logger) *aggregator {
+	agg := &aggregator{log: log}
+	if cfg.UserServiceGRPCAddr != "" {
+		conn, err := grpcx.Dial(cfg.UserServiceGRPCAddr, signer)
+		if err != nil {
+			log.Fatal("failed to dial user-service", zap.Error(err))
+		}
+		agg.users = usersv1.NewUserServiceClient(conn)
+		httpx.RegisterCheck("user-service", grpcx.HealthCheck(conn), httpx.Optional())
+	}
+	if cfg.SubscriptionServiceGRPCAddr != "" {
+		conn, err := grpcx.Dial(cfg.SubscriptionServiceGRPCAddr, signer)
+		if err != nil {
+			log.Fatal("failed to dial subscription-service", zap.Error(err))
+		}
+		agg.subscriptions = subscriptionsv1.NewSubscriptionServiceClient(conn)
+		httpx.RegisterCheck("subscription-service", grpcx.HealthCheck(conn), httpx.Optional())
+	}
+	return agg
+}
+
+type componentStatus struct {
+	Status string      `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// status queries user and subscription services concurrently on behalf of the caller's tenant. The
+// caller's claims travel with each RPC, so downstream tenant checks still apply.
+func (a *aggregator) status(w http.ResponseWriter, r *http.Request) {
 	claims, _ := auth.ClaimsFromContext(r.Context())
-	payload := map[string]interface{}{"user": "ok", "billing": "ok"}
-	if claims != nil {
-		payload["tenant_id"] = claims.TenantID
-		payload["roles"] = claims.Roles
+	payload := map[string]interface{}{}
+	if claims == nil {
+		respond(w, http.StatusOK, payload)
+		return
 	}
+	payload["tenant_id"] = claims.TenantID
+	payload["roles"] = claims.Roles
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+	var (
+		wg           sync.WaitGroup
+		usersStatus  componentStatus
+		subscription componentStatus
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		usersStatus = a.userStatus(ctx, claims.TenantID)
+	}()
+	go func() {
+		defer wg.Done()
+		subscription = a.subscriptionStatus(ctx, claims.TenantID)
+	}()
+	wg.Wait()
+	payload["users"] = usersStatus
+	payload["subscription"] = subscription
 	respond(w, http.StatusOK, payload)
 }
 
+func (a *aggregator) userStatus(ctx context.Context, tenantID string) componentStatus {
+	if a.users == nil {
+		return componentStatus{Status: "unconfigured"}
+	}
+	resp, err := a.users.ListUsers(ctx, &usersv1.ListUsersRequest{TenantId: tenantID})
+	if err != nil {
+		a.log.Warn("user-service call failed", zap.Error(err))
+		return componentStatus{Status: "unavailable", Error: err.Error()}
+	}
+	return componentStatus{Status: "ok", Data: map[string]int{"count": len(resp.GetUsers())}}
+}
+
+func (a *aggregator) subscriptionStatus(ctx context.Context, tenantID string) componentStatus {
+	if a.subscriptions == nil {
+		return componentStatus{Status: "unconfigured"}
+	}
+	resp, err := a.subscriptions.GetSubscription(ctx, &subscriptionsv1.GetSubscriptionRequest{TenantId: tenantID})
+	if status.Code(err) == codes.NotFound {
+		return componentStatus{Status: "none"}
+	}
+	if err != nil {
+		a.log.Warn("subscription-service call failed", zap.Error(err))
+		return componentStatus{Status: "unavailable", Error: err.Error()}
+	}
+	sub := resp.GetSubscription()
+	return componentStatus{Status: "ok", Data: map[string]interface{}{
+		"plan_id": sub.GetPlanId(),
+		"status":  sub.GetStatus(),
+		"seats":   sub.GetSeats(),
+	}}
+}
+
 func me(w http.ResponseWriter, r *http.Request) {
 	if claims, ok := auth.ClaimsFromContext(r.Context()); ok {
 		respond(w, http.StatusOK, claims)
@@ -13,17 +13,12 @@ import (
 // Register exposes payment orchestration endpoints.
 func Register(r chi.Router, cfg config.ServiceConfig, log *zap.Logger) {
 	log.Named("http").Info("payment routes ready", zap.String("port", cfg.HTTPPort))
-	r.Get("/health", health)
 	r.Route("/payments", func(r chi.Router) {
 		r.Post("/intents", createIntent)
 		// Additional PSP callbacks would be wired here.
 	})
 }
 
-func health(w http.ResponseWriter, _ *http.Request) {
-	respond(w, http.StatusOK, map[string]string{"status": "ok"})
-}
-
 func createIntent(w http.ResponseWriter, _ *http.Request) {
 	respond(w, http.StatusAccepted, map[string]string{"intent": "queued"})
 }
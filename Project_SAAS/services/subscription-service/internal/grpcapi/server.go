@@ -0,0 +1,112 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"project_saas/services/subscription-service/internal/subscriptions"
+	"project_saas/shared/pkg/grpcx"
+	subscriptionsv1 "project_saas/shared/pkg/pb/subscriptions/v1"
+)
+
+// SubscriptionServer exposes subscriptions.Service over gRPC.
+type SubscriptionServer struct {
+	subscriptionsv1.UnimplementedSubscriptionServiceServer
+	svc *subscriptions.Service
+}
+
+// NewSubscriptionServer builds a SubscriptionServer.
+func NewSubscriptionServer(svc *subscriptions.Service) *SubscriptionServer {
+	return &SubscriptionServer{svc: svc}
+}
+
+func (s *SubscriptionServer) ListPlans(ctx context.Context, _ *subscriptionsv1.ListPlansRequest) (*subscriptionsv1.ListPlansResponse, error) {
+	plans, err := s.svc.Plans(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &subscriptionsv1.ListPlansResponse{Plans: make([]*subscriptionsv1.Plan, 0, len(plans))}
+	for _, p := range plans {
+		resp.Plans = append(resp.Plans, &subscriptionsv1.Plan{
+			Id:            p.ID,
+			Name:          p.Name,
+			Description:   p.Description,
+			PriceCents:    int32(p.PriceCents),
+			BillingPeriod: p.BillingPeriod,
+			MaxSeats:      int32(p.MaxSeats),
+		})
+	}
+	return resp, nil
+}
+
+func (s *SubscriptionServer) GetSubscription(ctx context.Context, req *subscriptionsv1.GetSubscriptionRequest) (*subscriptionsv1.GetSubscriptionResponse, error) {
+	if err := grpcx.AuthorizeTenant(ctx, req.GetTenantId()); err != nil {
+		return nil, err
+	}
+	sub, err := s.svc.Subscription(ctx, req.GetTenantId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &subscriptionsv1.GetSubscriptionResponse{Subscription: toProto(sub)}, nil
+}
+
+func (s *SubscriptionServer) ActivatePlan(ctx context.Context, req *subscriptionsv1.ActivatePlanRequest) (*subscriptionsv1.ActivatePlanResponse, error) {
+	if err := grpcx.AuthorizeTenant(ctx, req.GetTenantId()); err != nil {
+		return nil, err
+	}
+	sub, err := s.svc.Activate(ctx, subscriptions.ActivateInput{
+		TenantID: req.GetTenantId(),
+		PlanID:   req.GetPlanId(),
+		Seats:    int(req.GetSeats()),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &subscriptionsv1.ActivatePlanResponse{Subscription: toProto(sub)}, nil
+}
+
+func (s *SubscriptionServer) CheckSeats(ctx context.Context, req *subscriptionsv1.CheckSeatsRequest) (*subscriptionsv1.CheckSeatsResponse, error) {
+	if err := grpcx.AuthorizeTenant(ctx, req.GetTenantId()); err != nil {
+		return nil, err
+	}
+	check, err := s.svc.CheckSeats(ctx, req.GetTenantId(), int(req.GetRequestedSeats()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &subscriptionsv1.CheckSeatsResponse{
+		Allowed:       check.Allowed,
+		LicensedSeats: int32(check.LicensedSeats),
+		Reason:        check.Reason,
+	}, nil
+}
+
+func toProto(sub subscriptions.TenantSubscription) *subscriptionsv1.Subscription {
+	return &subscriptionsv1.Subscription{
+		Id:               sub.ID,
+		TenantId:         sub.TenantID,
+		PlanId:           sub.PlanID,
+		Status:           sub.Status,
+		Seats:            int32(sub.Seats),
+		ActivatedAt:      timestamppb.New(sub.ActivatedAt),
+		CurrentPeriodEnd: timestamppb.New(sub.CurrentPeriodEnd),
+	}
+}
+
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, subscriptions.ErrInvalidTenantID),
+		errors.Is(err, subscriptions.ErrInvalidPlanID),
+		errors.Is(err, subscriptions.ErrInvalidSeats):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, subscriptions.ErrSeatLimit):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, subscriptions.ErrPlanNotFound), errors.Is(err, subscriptions.ErrSubscriptionNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	default:
+		return status.Error(codes.Internal, "internal error")
+	}
+}
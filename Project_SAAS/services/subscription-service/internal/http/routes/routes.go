@@ -8,17 +8,29 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nats-io/nats.go"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 
 	"project_saas/services/subscription-service/internal/data/migrations"
+	"project_saas/services/subscription-service/internal/grpcapi"
 	"project_saas/services/subscription-service/internal/subscriptions"
 	"project_saas/shared/pkg/config"
+	"project_saas/shared/pkg/httpx"
+	"project_saas/shared/pkg/outbox"
+	subscriptionsv1 "project_saas/shared/pkg/pb/subscriptions/v1"
 	"project_saas/shared/pkg/postgres"
 	"project_saas/shared/pkg/postgres/migrate"
 )
 
+// Server owns the dependencies shared by the HTTP and gRPC transports.
+type Server struct {
+	svc *subscriptions.Service
+}
+
 // Register wires subscription catalog endpoints backed by Postgres persistence.
-func Register(r chi.Router, cfg config.ServiceConfig, log *zap.Logger) {
+func (s *Server) Register(r chi.Router, cfg config.ServiceConfig, log *zap.Logger) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	pool, err := postgres.Pool(ctx, cfg.PostgresURL, 16)
@@ -28,12 +40,17 @@ func Register(r chi.Router, cfg config.ServiceConfig, log *zap.Logger) {
 	if err := migrate.Run(ctx, pool, migrations.Files, "."); err != nil {
 		log.Fatal("failed to apply migrations", zap.Error(err))
 	}
+	if err := migrate.Run(ctx, pool, outbox.Migrations, "migrations"); err != nil {
+		log.Fatal("failed to apply outbox migrations", zap.Error(err))
+	}
+	httpx.RegisterCheck("postgres", httpx.PostgresCheck(pool))
+	startOutboxRelay(cfg, pool, log)
+	s.svc = subscriptions.NewService(subscriptions.NewRepository(pool))
 	h := &handler{
 		log: log.Named("http"),
-		svc: subscriptions.NewService(subscriptions.NewRepository(pool)),
+		svc: s.svc,
 	}
 	h.log.Info("subscription routes ready", zap.String("port", cfg.HTTPPort))
-	r.Get("/health", health)
 	r.Route("/subscriptions", func(r chi.Router) {
 		r.Get("/plans", h.listPlans)
 		r.Get("/tenants/{tenantID}", h.getSubscription)
@@ -41,15 +58,36 @@ func Register(r chi.Router, cfg config.ServiceConfig, log *zap.Logger) {
 	})
 }
 
+// RegisterGRPC exposes the subscriptions.Service built by Register over gRPC.
+func (s *Server) RegisterGRPC(g *grpc.Server, _ config.ServiceConfig, _ *zap.Logger) {
+	subscriptionsv1.RegisterSubscriptionServiceServer(g, grpcapi.NewSubscriptionServer(s.svc))
+}
+
+// startOutboxRelay forwards committed subscription events to NATS JetStream. The connection retries in the
+// background so a broker outage delays delivery instead of blocking startup. The relay stops and the
+// connection closes when the service shuts down.
+func startOutboxRelay(cfg config.ServiceConfig, pool *pgxpool.Pool, log *zap.Logger) {
+	nc, err := nats.Connect(cfg.NATSURL, nats.RetryOnFailedConnect(true), nats.MaxReconnects(-1))
+	if err != nil {
+		log.Fatal("failed to connect to nats", zap.Error(err))
+	}
+	httpx.RegisterCheck("nats", httpx.NATSCheck(nc), httpx.Optional())
+	pub, err := outbox.NewJetStreamPublisher(nc, "SUBSCRIPTIONS", "subscriptions.>")
+	if err != nil {
+		log.Fatal("failed to init jetstream", zap.Error(err))
+	}
+	stop := outbox.NewRelay(outbox.NewPostgresStore(pool), subscriptions.OutboxSource, pub, log, outbox.RelayConfig{}).Start()
+	httpx.OnShutdown(func(ctx context.Context) error {
+		defer nc.Close()
+		return stop(ctx)
+	})
+}
+
 type handler struct {
 	svc *subscriptions.Service
 	log *zap.Logger
 }
 
-func health(w http.ResponseWriter, _ *http.Request) {
-	respond(w, http.StatusOK, map[string]string{"status": "ok"})
-}
-
 func (h *handler) listPlans(w http.ResponseWriter, r *http.Request) {
 	plans, err := h.svc.Plans(r.Context())
 	if err != nil {
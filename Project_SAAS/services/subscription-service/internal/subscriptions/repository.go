@@ -7,6 +7,8 @@ import (
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"project_saas/shared/pkg/outbox"
 )
 
 // Repository persists plans and tenant subscriptions.
@@ -23,6 +25,12 @@ var (
 	ErrSubscriptionNotFound = errors.New("subscription not found")
 )
 
+// SubjectSubscriptionActivated is the broker subject for plan activations and switches.
+const SubjectSubscriptionActivated = "subscriptions.activated"
+
+// OutboxSource marks the outbox rows this service writes, so that only its relay publishes them.
+const OutboxSource = "subscription-service"
+
 func (r *Repository) ListPlans(ctx context.Context) ([]Plan, error) {
 	rows, err := r.pool.Query(ctx, `SELECT id, name, description, price_cents, billing_period, max_seats, created_at FROM plans ORDER BY price_cents ASC`)
 	if err != nil {
@@ -50,9 +58,16 @@ func (r *Repository) GetPlan(ctx context.Context, planID string) (Plan, error) {
 	return p, err
 }
 
+// UpsertSubscription activates or switches the tenant's plan and records a subscriptions.activated outbox
+// event in the same transaction.
 func (r *Repository) UpsertSubscription(ctx context.Context, input ActivateInput, activatedAt time.Time, currentPeriodEnd time.Time) (TenantSubscription, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return TenantSubscription{}, err
+	}
+	defer tx.Rollback(ctx)
 	var sub TenantSubscription
-	err := r.pool.QueryRow(ctx, `
+	err = tx.QueryRow(ctx, `
 INSERT INTO tenant_subscriptions (tenant_id, plan_id, seats, status, activated_at, current_period_end, updated_at)
 VALUES ($1, $2, $3, 'active', $4, $5, NOW())
 ON CONFLICT (tenant_id) DO UPDATE SET
@@ -65,7 +80,20 @@ ON CONFLICT (tenant_id) DO UPDATE SET
 RETURNING id, tenant_id, plan_id, status, seats, activated_at, current_period_end
 `, input.TenantID, input.PlanID, input.Seats, activatedAt, currentPeriodEnd).
 		Scan(&sub.ID, &sub.TenantID, &sub.PlanID, &sub.Status, &sub.Seats, &sub.ActivatedAt, &sub.CurrentPeriodEnd)
-	return sub, err
+	if err != nil {
+		return TenantSubscription{}, err
+	}
+	msg, err := outbox.NewMessage(OutboxSource, SubjectSubscriptionActivated, "tenant_subscription", sub.TenantID, sub)
+	if err != nil {
+		return TenantSubscription{}, err
+	}
+	if err := outbox.Write(ctx, tx, msg); err != nil {
+		return TenantSubscription{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return TenantSubscription{}, err
+	}
+	return sub, nil
 }
 
 func (r *Repository) GetSubscription(ctx context.Context, tenantID string) (TenantSubscription, error) {
@@ -65,3 +65,35 @@ func (s *Service) Subscription(ctx context.Context, tenantID string) (TenantSubs
 	}
 	return s.repo.GetSubscription(ctx, tenantID)
 }
+
+// SeatCheck reports whether a tenant's subscription covers a requested seat count.
+type SeatCheck struct {
+	Allowed       bool   `json:"allowed"`
+	LicensedSeats int    `json:"licensed_seats"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+// CheckSeats answers entitlement checks from other services. A tenant without a subscription, or with a
+// non-active one, has no seats.
+func (s *Service) CheckSeats(ctx context.Context, tenantID string, requested int) (SeatCheck, error) {
+	sub, err := s.Subscription(ctx, tenantID)
+	if errors.Is(err, ErrSubscriptionNotFound) {
+		return SeatCheck{Reason: "no subscription"}, nil
+	}
+	if err != nil {
+		return SeatCheck{}, err
+	}
+	if requested <= 0 {
+		return SeatCheck{}, ErrInvalidSeats
+	}
+	check := SeatCheck{LicensedSeats: sub.Seats}
+	switch {
+	case sub.Status != "active":
+		check.Reason = "subscription " + sub.Status
+	case requested > sub.Seats:
+		check.Reason = "seat limit reached"
+	default:
+		check.Allowed = true
+	}
+	return check, nil
+}
@@ -124,3 +124,29 @@ func TestServiceSubscriptionValidation(t *testing.T) {
 		t.Fatalf("expected ErrInvalidTenantID, got %v", err)
 	}
 }
+
+func TestServiceCheckSeats(t *testing.T) {
+	repo := &stubSubscriptionRepo{
+		subscription: TenantSubscription{ID: "sub1", TenantID: "tenant-1", PlanID: "growth", Status: "active", Seats: 5},
+	}
+	svc := NewService(repo)
+	cases := []struct {
+		name      string
+		tenant    string
+		requested int
+		allowed   bool
+	}{
+		{"within seats", "tenant-1", 5, true},
+		{"over seats", "tenant-1", 6, false},
+		{"no subscription", "tenant-2", 1, false},
+	}
+	for _, tc := range cases {
+		check, err := svc.CheckSeats(context.Background(), tc.tenant, tc.requested)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if check.Allowed != tc.allowed {
+			t.Fatalf("%s: expected allowed=%v, got %+v", tc.name, tc.allowed, check)
+		}
+	}
+}
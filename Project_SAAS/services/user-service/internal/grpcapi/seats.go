@@ -0,0 +1,38 @@
+package grpcapi
+
+import (
+	"context"
+
+	"project_saas/shared/pkg/auth"
+	"project_saas/shared/pkg/grpcx"
+	subscriptionsv1 "project_saas/shared/pkg/pb/subscriptions/v1"
+)
+
+// SeatClient implements users.SeatChecker against subscription-service's CheckSeats RPC.
+type SeatClient struct {
+	client subscriptionsv1.SubscriptionServiceClient
+}
+
+// NewSeatClient wraps a subscription-service client.
+func NewSeatClient(client subscriptionsv1.SubscriptionServiceClient) *SeatClient {
+	return &SeatClient{client: client}
+}
+
+// LicensedSeats asks for a single seat so that an inactive or missing subscription is reported as
+// not allowed, in which case the tenant has no usable seats.
+func (c *SeatClient) LicensedSeats(ctx context.Context, tenantID string) (int, error) {
+	if _, ok := auth.ClaimsFromContext(ctx); !ok {
+		ctx = grpcx.WithServiceClaims(ctx, "user-service", tenantID)
+	}
+	resp, err := c.client.CheckSeats(ctx, &subscriptionsv1.CheckSeatsRequest{
+		TenantId:       tenantID,
+		RequestedSeats: 1,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if !resp.GetAllowed() {
+		return 0, nil
+	}
+	return int(resp.GetLicensedSeats()), nil
+}
@@ -0,0 +1,78 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"project_saas/services/user-service/internal/users"
+	"project_saas/shared/pkg/grpcx"
+	usersv1 "project_saas/shared/pkg/pb/users/v1"
+)
+
+// UserServer exposes users.Service over gRPC.
+type UserServer struct {
+	usersv1.UnimplementedUserServiceServer
+	svc *users.Service
+}
+
+// NewUserServer builds a UserServer.
+func NewUserServer(svc *users.Service) *UserServer {
+	return &UserServer{svc: svc}
+}
+
+func (s *UserServer) ListUsers(ctx context.Context, req *usersv1.ListUsersRequest) (*usersv1.ListUsersResponse, error) {
+	if err := grpcx.AuthorizeTenant(ctx, req.GetTenantId()); err != nil {
+		return nil, err
+	}
+	list, err := s.svc.List(ctx, req.GetTenantId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &usersv1.ListUsersResponse{Users: make([]*usersv1.User, 0, len(list))}
+	for _, u := range list {
+		resp.Users = append(resp.Users, toProto(u))
+	}
+	return resp, nil
+}
+
+func (s *UserServer) CreateUser(ctx context.Context, req *usersv1.CreateUserRequest) (*usersv1.CreateUserResponse, error) {
+	if err := grpcx.AuthorizeTenant(ctx, req.GetTenantId()); err != nil {
+		return nil, err
+	}
+	u, err := s.svc.Create(ctx, users.CreateInput{
+		TenantID: req.GetTenantId(),
+		Email:    req.GetEmail(),
+		FullName: req.GetFullName(),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &usersv1.CreateUserResponse{User: toProto(u)}, nil
+}
+
+func toProto(u users.User) *usersv1.User {
+	return &usersv1.User{
+		Id:        u.ID,
+		TenantId:  u.TenantID,
+		Email:     u.Email,
+		FullName:  u.FullName,
+		CreatedAt: timestamppb.New(u.CreatedAt),
+	}
+}
+
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, users.ErrInvalidTenant), errors.Is(err, users.ErrInvalidEmail), errors.Is(err, users.ErrInvalidName):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, users.ErrSeatLimit):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, users.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	default:
+		return status.Error(codes.Internal, "internal error")
+	}
+}
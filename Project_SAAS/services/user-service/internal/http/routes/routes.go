@@ -8,17 +8,39 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nats-io/nats.go"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 
 	"project_saas/services/user-service/internal/data/migrations"
+	"project_saas/services/user-service/internal/grpcapi"
 	"project_saas/services/user-service/internal/users"
+	"project_saas/shared/pkg/auth"
 	"project_saas/shared/pkg/config"
+	"project_saas/shared/pkg/grpcx"
+	"project_saas/shared/pkg/httpx"
+	"project_saas/shared/pkg/outbox"
+	subscriptionsv1 "project_saas/shared/pkg/pb/subscriptions/v1"
+	usersv1 "project_saas/shared/pkg/pb/users/v1"
 	"project_saas/shared/pkg/postgres"
 	"project_saas/shared/pkg/postgres/migrate"
 )
 
+// Config holds user-service settings layered on top of config.ServiceConfig.
+type Config struct {
+	// SubscriptionServiceGRPCAddr enables seat checks against subscription-service when set.
+	SubscriptionServiceGRPCAddr string `yaml:"subscription_service_grpc_addr" env:"SUBSCRIPTION_SERVICE_GRPC_ADDR"`
+}
+
+// Server owns the dependencies shared by the HTTP and gRPC transports.
+type Server struct {
+	Config Config
+	svc    *users.Service
+}
+
 // Register wires user-service HTTP endpoints.
-func Register(r chi.Router, cfg config.ServiceConfig, log *zap.Logger) {
+func (s *Server) Register(r chi.Router, cfg config.ServiceConfig, log *zap.Logger) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	pool, err := postgres.Pool(ctx, cfg.PostgresURL, 32)
@@ -28,27 +50,61 @@ func Register(r chi.Router, cfg config.ServiceConfig, log *zap.Logger) {
 	if err := migrate.Run(ctx, pool, migrations.Files, "."); err != nil {
 		log.Fatal("failed to apply migrations", zap.Error(err))
 	}
+	if err := migrate.Run(ctx, pool, outbox.Migrations, "migrations"); err != nil {
+		log.Fatal("failed to apply outbox migrations", zap.Error(err))
+	}
+	httpx.RegisterCheck("postgres", httpx.PostgresCheck(pool))
+	startOutboxRelay(cfg, pool, log)
+	s.svc = users.NewService(users.NewRepository(pool))
+	if s.Config.SubscriptionServiceGRPCAddr != "" {
+		conn, err := grpcx.Dial(s.Config.SubscriptionServiceGRPCAddr, auth.NewSigner(cfg.AuthSecret.Value()))
+		if err != nil {
+			log.Fatal("failed to dial subscription-service", zap.Error(err))
+		}
+		s.svc.WithSeatChecker(grpcapi.NewSeatClient(subscriptionsv1.NewSubscriptionServiceClient(conn)))
+		httpx.RegisterCheck("subscription-service", grpcx.HealthCheck(conn))
+	}
 	h := &handler{
 		log: log.Named("http"),
-		svc: users.NewService(users.NewRepository(pool)),
+		svc: s.svc,
 	}
 	h.log.Info("registering routes", zap.String("port", cfg.HTTPPort))
-	r.Get("/health", health)
 	r.Route("/tenants/{tenantID}", func(r chi.Router) {
 		r.Get("/users", h.listUsers)
 		r.Post("/users", h.createUser)
 	})
 }
 
+// RegisterGRPC exposes the users.Service built by Register over gRPC.
+func (s *Server) RegisterGRPC(g *grpc.Server, _ config.ServiceConfig, _ *zap.Logger) {
+	usersv1.RegisterUserServiceServer(g, grpcapi.NewUserServer(s.svc))
+}
+
+// startOutboxRelay forwards committed user events to NATS JetStream. The connection retries in the
+// background so a broker outage delays delivery instead of blocking startup. The relay stops and the
+// connection closes when the service shuts down.
+func startOutboxRelay(cfg config.ServiceConfig, pool *pgxpool.Pool, log *zap.Logger) {
+	nc, err := nats.Connect(cfg.NATSURL, nats.RetryOnFailedConnect(true), nats.MaxReconnects(-1))
+	if err != nil {
+		log.Fatal("failed to connect to nats", zap.Error(err))
+	}
+	httpx.RegisterCheck("nats", httpx.NATSCheck(nc), httpx.Optional())
+	pub, err := outbox.NewJetStreamPublisher(nc, "USERS", "users.>")
+	if err != nil {
+		log.Fatal("failed to init jetstream", zap.Error(err))
+	}
+	stop := outbox.NewRelay(outbox.NewPostgresStore(pool), users.OutboxSource, pub, log, outbox.RelayConfig{}).Start()
+	httpx.OnShutdown(func(ctx context.Context) error {
+		defer nc.Close()
+		return stop(ctx)
+	})
+}
+
 type handler struct {
 	svc *users.Service
 	log *zap.Logger
 }
 
-func health(w http.ResponseWriter, _ *http.Request) {
-	respond(w, http.StatusOK, map[string]string{"status": "ok"})
-}
-
 func (h *handler) listUsers(w http.ResponseWriter, r *http.Request) {
 	tenantID := chi.URLParam(r, "tenantID")
 	result, err := h.svc.List(r.Context(), tenantID)
@@ -106,6 +162,8 @@ func (h *handler) handleError(w http.ResponseWriter, err error) {
 	switch {
 	case errors.Is(err, users.ErrInvalidTenant), errors.Is(err, users.ErrInvalidEmail), errors.Is(err, users.ErrInvalidName):
 		respond(w, http.StatusBadRequest, apiError{Message: err.Error(), Code: "validation"})
+	case errors.Is(err, users.ErrSeatLimit):
+		respond(w, http.StatusConflict, apiError{Message: err.Error(), Code: "seat_limit"})
 	default:
 		var apiErr *apiError
 		if errors.As(err, &apiErr) {
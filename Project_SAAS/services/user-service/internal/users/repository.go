@@ -4,7 +4,11 @@ import (
 	"context"
 	"errors"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"project_saas/shared/pkg/outbox"
 )
 
 // Repository provides persistence for users.
@@ -18,6 +22,12 @@ func NewRepository(pool *pgxpool.Pool) *Repository {
 
 var ErrNotFound = errors.New("user not found")
 
+// SubjectUserCreated is the broker subject for events emitted when a user is created.
+const SubjectUserCreated = "users.created"
+
+// OutboxSource marks the outbox rows this service writes, so that only its relay publishes them.
+const OutboxSource = "user-service"
+
 func (r *Repository) ListByTenant(ctx context.Context, tenantID string) ([]User, error) {
 	rows, err := r.pool.Query(ctx, `SELECT id, tenant_id, email, full_name, created_at FROM users WHERE tenant_id = $1 ORDER BY created_at DESC`, tenantID)
 	if err != nil {
@@ -35,25 +45,76 @@ func (r *Repository) ListByTenant(ctx context.Context, tenantID string) ([]User,
 	return list, rows.Err()
 }
 
+// countUsers returns how many users (seats) the tenant currently has.
+func countUsers(ctx context.Context, q querier, tenantID string) (int, error) {
+	var n int
+	err := q.QueryRow(ctx, `SELECT COUNT(*) FROM users WHERE tenant_id = $1`, tenantID).Scan(&n)
+	return n, err
+}
+
 func (r *Repository) Create(ctx context.Context, input CreateInput) (User, error) {
+	return insertUser(ctx, r.pool, input)
+}
+
+// querier is satisfied by both the pool and a transaction.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func insertUser(ctx context.Context, q querier, input CreateInput) (User, error) {
 	var u User
-	err := r.pool.QueryRow(ctx, `INSERT INTO users (tenant_id, email, full_name) VALUES ($1, $2, $3) RETURNING id, tenant_id, email, full_name, created_at`, input.TenantID, input.Email, input.FullName).
+	err := q.QueryRow(ctx, `INSERT INTO users (tenant_id, email, full_name) VALUES ($1, $2, $3) RETURNING id, tenant_id, email, full_name, created_at`, input.TenantID, input.Email, input.FullName).
 		Scan(&u.ID, &u.TenantID, &u.Email, &u.FullName, &u.CreatedAt)
 	return u, err
 }
 
-func ensureTenant(ctx context.Context, pool *pgxpool.Pool, tenantID string) error {
-	cmd, err := pool.Exec(ctx, `INSERT INTO tenants (id, name) VALUES ($1, $1) ON CONFLICT (id) DO NOTHING`, tenantID)
-	if err != nil {
-		return err
-	}
-	_ = cmd
-	return nil
+func ensureTenant(ctx context.Context, q querier, tenantID string) error {
+	_, err := q.Exec(ctx, `INSERT INTO tenants (id, name) VALUES ($1, $1) ON CONFLICT (id) DO NOTHING`, tenantID)
+	return err
 }
 
-func (r *Repository) CreateWithTenant(ctx context.Context, input CreateInput) (User, error) {
-	if err := ensureTenant(ctx, r.pool, input.TenantID); err != nil {
+// CreateWithTenant provisions the tenant if needed, inserts the user and records a users.created outbox
+// event, all in one transaction.
+//
+// A non-nil admit is called with the tenant's seat count including the new user and can veto the
+// insert by returning an error. The tenant row stays locked from the count until the commit, so
+// concurrent creates for the same tenant are admitted one at a time and cannot overshoot the limit.
+// admit runs while that lock is held and must not block; fetch anything remote before calling.
+func (r *Repository) CreateWithTenant(ctx context.Context, input CreateInput, admit func(ctx context.Context, seats int) error) (User, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return User{}, err
+	}
+	defer tx.Rollback(ctx)
+	if err := ensureTenant(ctx, tx, input.TenantID); err != nil {
+		return User{}, err
+	}
+	if admit != nil {
+		if _, err := tx.Exec(ctx, `SELECT 1 FROM tenants WHERE id = $1 FOR UPDATE`, input.TenantID); err != nil {
+			return User{}, err
+		}
+		current, err := countUsers(ctx, tx, input.TenantID)
+		if err != nil {
+			return User{}, err
+		}
+		if err := admit(ctx, current+1); err != nil {
+			return User{}, err
+		}
+	}
+	u, err := insertUser(ctx, tx, input)
+	if err != nil {
+		return User{}, err
+	}
+	msg, err := outbox.NewMessage(OutboxSource, SubjectUserCreated, "user", u.ID, u)
+	if err != nil {
+		return User{}, err
+	}
+	if err := outbox.Write(ctx, tx, msg); err != nil {
+		return User{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
 		return User{}, err
 	}
-	return r.Create(ctx, input)
+	return u, nil
 }
@@ -0,0 +1,84 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"project_saas/services/user-service/internal/data/migrations"
+	"project_saas/shared/pkg/outbox"
+	"project_saas/shared/pkg/postgres/migrate"
+)
+
+// limitSeats allows up to limit seats per tenant.
+type limitSeats struct{ limit int }
+
+func (s limitSeats) LicensedSeats(ctx context.Context, tenantID string) (int, error) {
+	return s.limit, nil
+}
+
+// TestConcurrentCreatesRespectSeatLimit races more creates than the tenant has seats. It needs a
+// Postgres database it may create the users tables in, given as USERS_TEST_POSTGRES_URL.
+func TestConcurrentCreatesRespectSeatLimit(t *testing.T) {
+	url := os.Getenv("USERS_TEST_POSTGRES_URL")
+	if url == "" {
+		t.Skip("USERS_TEST_POSTGRES_URL not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	if err := migrate.Run(ctx, pool, migrations.Files, "."); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrate.Run(ctx, pool, outbox.Migrations, "migrations"); err != nil {
+		t.Fatal(err)
+	}
+	tenant := fmt.Sprintf("seat-test-%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		pool.Exec(ctx, `DELETE FROM outbox WHERE source = $1 AND payload->>'tenant_id' = $2`, OutboxSource, tenant)
+		pool.Exec(ctx, `DELETE FROM tenants WHERE id = $1`, tenant)
+	})
+
+	const seats, attempts = 3, 10
+	svc := NewService(NewRepository(pool)).WithSeatChecker(limitSeats{limit: seats})
+	var wg sync.WaitGroup
+	errs := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := svc.Create(ctx, CreateInput{TenantID: tenant, Email: fmt.Sprintf("user%d@example.com", i), FullName: "User"})
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	created, refused := 0, 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case errors.Is(err, ErrSeatLimit):
+			refused++
+		default:
+			t.Fatal(err)
+		}
+	}
+	n, err := countUsers(ctx, pool, tenant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created != seats || refused != attempts-seats || n != seats {
+		t.Fatalf("created %d, refused %d, stored %d; want %d seats filled", created, refused, n, seats)
+	}
+}
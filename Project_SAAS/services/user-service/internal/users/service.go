@@ -4,26 +4,44 @@ import (
 	"context"
 	"errors"
 	"strings"
+	"time"
 )
 
 type repository interface {
 	ListByTenant(ctx context.Context, tenantID string) ([]User, error)
-	CreateWithTenant(ctx context.Context, input CreateInput) (User, error)
+	CreateWithTenant(ctx context.Context, input CreateInput, admit func(ctx context.Context, seats int) error) (User, error)
 }
 
+// SeatChecker reports how many seats the tenant's subscription licenses; zero when it has no active
+// subscription.
+type SeatChecker interface {
+	LicensedSeats(ctx context.Context, tenantID string) (int, error)
+}
+
+// seatLookupTimeout bounds the call to the seat checker, which is usually a remote service.
+const seatLookupTimeout = 2 * time.Second
+
 // Service coordinates validation and persistence.
 type Service struct {
-	repo repository
+	repo  repository
+	seats SeatChecker
 }
 
 func NewService(repo repository) *Service {
 	return &Service{repo: repo}
 }
 
+// WithSeatChecker enforces subscription seat limits on Create.
+func (s *Service) WithSeatChecker(c SeatChecker) *Service {
+	s.seats = c
+	return s
+}
+
 var (
 	ErrInvalidTenant = errors.New("tenant_id is required")
 	ErrInvalidEmail  = errors.New("email is required")
 	ErrInvalidName   = errors.New("full_name is required")
+	ErrSeatLimit     = errors.New("subscription has no free seats")
 )
 
 func (s *Service) List(ctx context.Context, tenantID string) ([]User, error) {
@@ -46,5 +64,23 @@ func (s *Service) Create(ctx context.Context, input CreateInput) (User, error) {
 	if input.FullName == "" {
 		return User{}, ErrInvalidName
 	}
-	return s.repo.CreateWithTenant(ctx, input)
+	var admit func(ctx context.Context, seats int) error
+	if s.seats != nil {
+		// The licensed seats are looked up before the repository opens its transaction, so a slow
+		// checker never holds the tenant lock. admit only compares numbers and runs under that lock,
+		// where the count cannot be changed by another create for the same tenant.
+		lookupCtx, cancel := context.WithTimeout(ctx, seatLookupTimeout)
+		licensed, err := s.seats.LicensedSeats(lookupCtx, input.TenantID)
+		cancel()
+		if err != nil {
+			return User{}, err
+		}
+		admit = func(_ context.Context, seats int) error {
+			if seats > licensed {
+				return ErrSeatLimit
+			}
+			return nil
+		}
+	}
+	return s.repo.CreateWithTenant(ctx, input, admit)
 }
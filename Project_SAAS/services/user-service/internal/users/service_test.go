@@ -2,7 +2,11 @@ package users
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 )
 
 type stubRepo struct {
@@ -12,6 +16,7 @@ type stubRepo struct {
 	createdInput CreateInput
 	createUser   User
 	createErr    error
+	count        int
 }
 
 func (s *stubRepo) ListByTenant(ctx context.Context, tenantID string) ([]User, error) {
@@ -19,7 +24,12 @@ func (s *stubRepo) ListByTenant(ctx context.Context, tenantID string) ([]User, e
 	return s.listResult, s.listErr
 }
 
-func (s *stubRepo) CreateWithTenant(ctx context.Context, input CreateInput) (User, error) {
+func (s *stubRepo) CreateWithTenant(ctx context.Context, input CreateInput, admit func(context.Context, int) error) (User, error) {
+	if admit != nil {
+		if err := admit(ctx, s.count+1); err != nil {
+			return User{}, err
+		}
+	}
 	s.createdInput = input
 	if s.createErr != nil {
 		return User{}, s.createErr
@@ -27,6 +37,18 @@ func (s *stubRepo) CreateWithTenant(ctx context.Context, input CreateInput) (Use
 	return s.createUser, nil
 }
 
+type stubSeats struct {
+	tenant   string
+	licensed int
+	calls    int
+}
+
+func (s *stubSeats) LicensedSeats(ctx context.Context, tenantID string) (int, error) {
+	s.tenant = tenantID
+	s.calls++
+	return s.licensed, nil
+}
+
 func TestServiceListValidation(t *testing.T) {
 	svc := NewService(&stubRepo{})
 	if _, err := svc.List(context.Background(), ""); err != ErrInvalidTenant {
@@ -76,3 +98,175 @@ func TestServiceCreateSuccessNormalizesInput(t *testing.T) {
 		t.Fatalf("name not trimmed: %q", repo.createdInput.FullName)
 	}
 }
+
+func TestServiceCreateSeatLimit(t *testing.T) {
+	repo := &stubRepo{count: 4, createUser: User{ID: "u5"}}
+	seats := &stubSeats{licensed: 4}
+	svc := NewService(repo).WithSeatChecker(seats)
+	input := CreateInput{TenantID: " t ", Email: "a@b.com", FullName: "Name"}
+	if _, err := svc.Create(context.Background(), input); err != ErrSeatLimit {
+		t.Fatalf("expected ErrSeatLimit, got %v", err)
+	}
+	if seats.tenant != "t" {
+		t.Fatalf("expected seat lookup for the trimmed tenant, got %q", seats.tenant)
+	}
+	if repo.createdInput.TenantID != "" {
+		t.Fatalf("user should not be created when seats are exhausted")
+	}
+	seats.licensed = 5
+	if _, err := svc.Create(context.Background(), input); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// requestKey marks a test request's context, so fakes can tell concurrent requests apart.
+type requestKey struct{}
+
+func requestID(ctx context.Context) int {
+	id, _ := ctx.Value(requestKey{}).(int)
+	return id
+}
+
+// lockingRepo stands in for the Postgres repository: admits and inserts for a tenant run one at a
+// time under a lock, as they do with the tenant row locked. It records which requests hold the lock.
+type lockingRepo struct {
+	mu     sync.Mutex
+	count  int
+	admits int
+
+	heldMu sync.Mutex
+	held   map[int]bool
+}
+
+func (r *lockingRepo) ListByTenant(ctx context.Context, tenantID string) ([]User, error) {
+	return nil, nil
+}
+
+func (r *lockingRepo) CreateWithTenant(ctx context.Context, input CreateInput, admit func(context.Context, int) error) (User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.setHeld(requestID(ctx), true)
+	defer r.setHeld(requestID(ctx), false)
+	r.admits++
+	if err := admit(ctx, r.count+1); err != nil {
+		return User{}, err
+	}
+	r.count++
+	return User{ID: input.Email, TenantID: input.TenantID}, nil
+}
+
+func (r *lockingRepo) setHeld(id int, held bool) {
+	r.heldMu.Lock()
+	defer r.heldMu.Unlock()
+	if r.held == nil {
+		r.held = make(map[int]bool)
+	}
+	r.held[id] = held
+}
+
+func (r *lockingRepo) holds(id int) bool {
+	r.heldMu.Lock()
+	defer r.heldMu.Unlock()
+	return r.held[id]
+}
+
+// barrierSeats answers no lookup until all expected lookups have arrived, so the test passes only
+// if every request looks its seats up before any of them takes the lock. It records any request that
+// asked while holding the repository lock itself.
+type barrierSeats struct {
+	repo     *lockingRepo
+	licensed int
+	expected int
+
+	mu        sync.Mutex
+	arrived   int
+	all       chan struct{}
+	underLock []int
+}
+
+func newBarrierSeats(repo *lockingRepo, licensed, expected int) *barrierSeats {
+	return &barrierSeats{repo: repo, licensed: licensed, expected: expected, all: make(chan struct{})}
+}
+
+func (s *barrierSeats) LicensedSeats(ctx context.Context, tenantID string) (int, error) {
+	id := requestID(ctx)
+	s.mu.Lock()
+	if s.repo.holds(id) {
+		s.underLock = append(s.underLock, id)
+	}
+	s.arrived++
+	if s.arrived == s.expected {
+		close(s.all)
+	}
+	s.mu.Unlock()
+	select {
+	case <-s.all:
+		return s.licensed, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func TestServiceCreateLooksUpSeatsOutsideTheLock(t *testing.T) {
+	const attempts = 10
+	repo := &lockingRepo{}
+	seats := newBarrierSeats(repo, 3, attempts)
+	svc := NewService(repo).WithSeatChecker(seats)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx := context.WithValue(context.Background(), requestKey{}, i+1)
+			_, err := svc.Create(ctx, CreateInput{TenantID: "t", Email: fmt.Sprintf("u%d@example.com", i), FullName: "User"})
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	created := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case errors.Is(err, ErrSeatLimit):
+		case errors.Is(err, context.DeadlineExceeded):
+			t.Fatal("seat lookups did not all run before the lock was taken")
+		default:
+			t.Fatal(err)
+		}
+	}
+	if created != 3 || repo.count != 3 {
+		t.Fatalf("created %d users (stored %d), want 3", created, repo.count)
+	}
+	if repo.admits != attempts {
+		t.Fatalf("admit ran %d times, want %d", repo.admits, attempts)
+	}
+	if len(seats.underLock) > 0 {
+		t.Fatalf("requests %v called the seat checker while holding the tenant lock", seats.underLock)
+	}
+}
+
+// blockingSeats never answers until its context ends.
+type blockingSeats struct{}
+
+func (blockingSeats) LicensedSeats(ctx context.Context, tenantID string) (int, error) {
+	<-ctx.Done()
+	return 0, ctx.Err()
+}
+
+func TestServiceCreateSeatLookupIsBounded(t *testing.T) {
+	repo := &stubRepo{}
+	svc := NewService(repo).WithSeatChecker(blockingSeats{})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := svc.Create(ctx, CreateInput{TenantID: "t", Email: "a@b.com", FullName: "Name"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the lookup deadline, got %v", err)
+	}
+	if repo.createdInput.TenantID != "" {
+		t.Fatal("the repository should not be reached when the seat lookup fails")
+	}
+}
@@ -0,0 +1,151 @@
+// Command fakegen produces reproducible tenants, users, subscriptions and usage events, either as NDJSON
+// fixtures or seeded straight into Postgres.
+//
+//	go run ./cmd/fakegen -seed 42 -tenants 200 -usage 100000 -out ./fixtures
+//	go run ./cmd/fakegen -seed 42 -tenants 200 -postgres "$POSTGRES_URL"
+//	go run ./cmd/fakegen -seed 42 -tenants 200 -users-postgres "$USERS_URL" -subscriptions-postgres "$SUBSCRIPTIONS_URL"
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"project_saas/shared/pkg/data/fake"
+	"project_saas/shared/pkg/postgres"
+)
+
+func main() {
+	var (
+		seed     = flag.Uint64("seed", fake.DefaultSeed, "random seed; the same seed always produces the same data")
+		tenants  = flag.Int("tenants", 100, "number of tenants")
+		usage    = flag.Int("usage", 10000, "number of usage events (NDJSON output only)")
+		days     = flag.Int("days", 30, "length of the usage window in days")
+		epoch    = flag.String("epoch", "2024-01-01T00:00:00Z", "start of the simulated period (RFC 3339)")
+		outDir   = flag.String("out", "", "directory for tenants/users/plans/subscriptions/usage .ndjson files")
+		pgURL    = flag.String("postgres", "", "Postgres URL to seed when both services share a database (schemas must already be migrated)")
+		usersURL = flag.String("users-postgres", "", "user-service Postgres URL; overrides -postgres for tenants and users")
+		subsURL  = flag.String("subscriptions-postgres", "", "subscription-service Postgres URL; overrides -postgres for plans and subscriptions")
+		maxSeats = flag.Int("max-seats", 2000, "largest tenant size, capped at the largest plan's seats")
+	)
+	flag.Parse()
+	if *usersURL == "" {
+		*usersURL = *pgURL
+	}
+	if *subsURL == "" {
+		*subsURL = *pgURL
+	}
+	if (*usersURL == "") != (*subsURL == "") {
+		fmt.Fprintln(os.Stderr, "fakegen: -users-postgres and -subscriptions-postgres must be set together, or use -postgres")
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *outDir == "" && *usersURL == "" {
+		fmt.Fprintln(os.Stderr, "fakegen: one of -out, -postgres or -users-postgres/-subscriptions-postgres is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+	start, err := time.Parse(time.RFC3339, *epoch)
+	if err != nil {
+		fail(fmt.Errorf("parse -epoch: %w", err))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	gen := fake.New(fake.Config{Seed: *seed, Epoch: start, Days: *days, MaxTenantSeats: *maxSeats})
+	ds := gen.Dataset(*tenants)
+	fmt.Fprintf(os.Stderr, "fakegen: seed=%d tenants=%d users=%d\n", *seed, len(ds.Tenants), len(ds.Users))
+
+	if *outDir != "" {
+		if err := writeFixtures(ctx, gen, ds, *outDir, *usage); err != nil {
+			fail(err)
+		}
+	}
+	if *usersURL != "" {
+		users, err := postgres.Pool(ctx, *usersURL, 2)
+		if err != nil {
+			fail(err)
+		}
+		defer users.Close()
+		subscriptions, err := postgres.Pool(ctx, *subsURL, 2)
+		if err != nil {
+			fail(err)
+		}
+		defer subscriptions.Close()
+		if err := fake.SeedPostgres(ctx, users, subscriptions, ds); err != nil {
+			fail(err)
+		}
+	}
+}
+
+func writeFixtures(ctx context.Context, gen *fake.Generator, ds fake.Dataset, dir string, usage int) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if err := writeNDJSON(filepath.Join(dir, "plans.ndjson"), ds.Plans); err != nil {
+		return err
+	}
+	if err := writeNDJSON(filepath.Join(dir, "tenants.ndjson"), ds.Tenants); err != nil {
+		return err
+	}
+	if err := writeNDJSON(filepath.Join(dir, "users.ndjson"), ds.Users); err != nil {
+		return err
+	}
+	if err := writeNDJSON(filepath.Join(dir, "subscriptions.ndjson"), ds.Subscriptions); err != nil {
+		return err
+	}
+	// Usage can run to millions of records, so each one is encoded as it is generated. Cancelling on
+	// return stops the generator if a write fails part way.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	return createNDJSON(filepath.Join(dir, "usage.ndjson"), func(enc *json.Encoder) error {
+		for r := range gen.StreamUsage(ctx, ds, usage) {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return ctx.Err()
+	})
+}
+
+func writeNDJSON[T any](path string, rows []T) error {
+	return createNDJSON(path, func(enc *json.Encoder) error {
+		for _, row := range rows {
+			if err := enc.Encode(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// createNDJSON creates path and hands write a buffered encoder for it.
+func createNDJSON(path string, write func(enc *json.Encoder) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if err := write(json.NewEncoder(w)); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "fakegen:", err)
+	os.Exit(1)
+}
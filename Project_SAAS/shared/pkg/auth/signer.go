@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Signer mints HS256 tokens that a Validator sharing the same secret accepts.
+type Signer struct {
+	secret []byte
+}
+
+// NewSigner returns a Signer using the provided HMAC secret.
+func NewSigner(secret string) *Signer {
+	return &Signer{secret: []byte(secret)}
+}
+
+// Sign issues a token for claims that expires after ttl.
+func (s *Signer) Sign(claims Claims, ttl time.Duration) (string, error) {
+	if claims.TenantID == "" {
+		return "", errors.New("tenant_id required to sign token")
+	}
+	now := time.Now()
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(ttl))
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, &claims).SignedString(s.secret)
+}
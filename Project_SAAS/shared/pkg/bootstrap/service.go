@@ -2,28 +2,107 @@ package bootstrap
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
 
+	"project_saas/shared/pkg/auth"
 	"project_saas/shared/pkg/config"
+	"project_saas/shared/pkg/grpcx"
 	"project_saas/shared/pkg/httpx"
 	"project_saas/shared/pkg/logger"
 	"project_saas/shared/pkg/observability"
 )
 
+// GRPCRegisterFunc attaches service implementations to the shared gRPC server.
+type GRPCRegisterFunc func(s *grpc.Server, cfg config.ServiceConfig, log *zap.Logger)
+
+// Option customises RunHTTPService.
+type Option func(*options)
+
+type options struct {
+	grpcRegister GRPCRegisterFunc
+	configExt    interface{}
+}
+
+// WithConfig loads the service's extension settings into ext, a pointer to a struct tagged like
+// config.ServiceConfig, before any register func runs.
+func WithConfig(ext interface{}) Option {
+	return func(o *options) {
+		o.configExt = ext
+	}
+}
+
+// WithGRPC also serves gRPC on cfg.GRPCPort, guarded by the same auth.Validator as the HTTP API.
+// register runs after the HTTP register func, so it can reuse dependencies created there.
+func WithGRPC(register GRPCRegisterFunc) Option {
+	return func(o *options) {
+		o.grpcRegister = register
+	}
+}
+
+// RegisterFunc mounts a service's HTTP routes.
+type RegisterFunc func(r chi.Router, cfg config.ServiceConfig, log *zap.Logger)
+
+// Main is the standard service entrypoint. With no arguments it runs the service until SIGINT/SIGTERM;
+// "config print" instead prints the effective configuration, secrets redacted, and exits.
+func Main(serviceName string, register RegisterFunc, opts ...Option) {
+	args := os.Args[1:]
+	if len(args) == 2 && args[0] == "config" && args[1] == "print" {
+		if err := PrintConfig(os.Stdout, serviceName, opts...); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(args) > 0 {
+		fmt.Fprintf(os.Stderr, "usage: %s [config print]\n", serviceName)
+		os.Exit(2)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if err := RunHTTPService(ctx, serviceName, register, opts...); err != nil {
+		panic(err)
+	}
+}
+
+// PrintConfig writes every setting the service would start with, including WithConfig extensions, and
+// where each value came from. Validation errors are returned after the table is printed.
+func PrintConfig(w io.Writer, serviceName string, opts ...Option) error {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	_, settings, err := config.Loader{}.Explain(serviceName, o.configExt)
+	if printErr := config.Print(w, settings); printErr != nil {
+		return printErr
+	}
+	return err
+}
+
 // RunHTTPService wires up config, logging, and HTTP server launch for a service.
-func RunHTTPService(ctx context.Context, serviceName string, register func(r chi.Router, cfg config.ServiceConfig, log *zap.Logger)) error {
-	cfg, err := config.Load(serviceName)
+func RunHTTPService(ctx context.Context, serviceName string, register RegisterFunc, opts ...Option) error {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	cfg, settings, err := config.Loader{}.Explain(serviceName, o.configExt)
 	if err != nil {
 		return err
 	}
-	log, err := logger.New(serviceName)
+	log, err := logger.New(serviceName, cfg.Env)
 	if err != nil {
 		return err
 	}
-	shutdownObs, err := observability.Setup(ctx, serviceName, log)
+	shutdownObs, err := observability.Setup(ctx, cfg, log)
 	if err != nil {
 		return err
 	}
@@ -34,8 +113,38 @@ func RunHTTPService(ctx context.Context, serviceName string, register func(r chi
 			log.Warn("failed to shutdown observability", zap.Error(err))
 		}
 	}()
-	log.Info("starting service", zap.String("env", cfg.Env), zap.String("budget", cfg.ConcurrencyBudget()))
-	return httpx.Run(ctx, cfg, log, func(r chi.Router) {
-		register(r, cfg, log)
+	log.Info("starting service", zap.String("env", cfg.Env), zap.String("budget", cfg.ConcurrencyBudget()), zap.Any("config", settingsMap(settings)))
+	if o.grpcRegister == nil {
+		return httpx.Run(ctx, cfg, log, func(r chi.Router) {
+			register(r, cfg, log)
+		})
+	}
+
+	grpcSrv, healthSrv := grpcx.NewServer(auth.NewValidator(cfg.AuthSecret.Value()), log.Named("grpc"))
+	registered := make(chan struct{})
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		return httpx.Run(gctx, cfg, log, func(r chi.Router) {
+			register(r, cfg, log)
+			o.grpcRegister(grpcSrv, cfg, log)
+			close(registered)
+		})
+	})
+	g.Go(func() error {
+		select {
+		case <-registered:
+		case <-gctx.Done():
+			return nil
+		}
+		return grpcx.Serve(gctx, grpcSrv, healthSrv, cfg.GRPCPort, cfg.ShutdownDrainDelay, log)
 	})
+	return g.Wait()
+}
+
+func settingsMap(settings []config.Setting) map[string]string {
+	out := make(map[string]string, len(settings))
+	for _, s := range settings {
+		out[s.Env] = s.Value
+	}
+	return out
 }
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func envMap(values map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := values[key]
+		return v, ok
+	}
+}
+
+type extConfig struct {
+	UpstreamAddr string `yaml:"upstream_addr" env:"UPSTREAM_ADDR" default:"localhost:9000"`
+	Retries      int    `yaml:"retries" env:"RETRIES" default:"3"`
+}
+
+func (e *extConfig) Validate() error {
+	if e.Retries > 10 {
+		return errors.New("RETRIES: at most 10")
+	}
+	return nil
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoaderLayering(t *testing.T) {
+	file := writeFile(t, "config.yaml", `
+http_port: 8000
+max_workers: 16
+retries: 5
+services:
+  user-service:
+    http_port: 8081
+`)
+	dotenv := writeFile(t, ".env", "MAX_WORKERS=20\nMAX_DB_JOBS=4\n")
+	var ext extConfig
+	cfg, settings, err := Loader{
+		File:      file,
+		DotEnv:    dotenv,
+		LookupEnv: envMap(map[string]string{"MAX_DB_JOBS": "6", "SHUTDOWN_DRAIN_DELAY": "1s"}),
+	}.Explain("user-service", &ext)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.HTTPPort != "8081" {
+		t.Fatalf("service section should override file defaults, got %q", cfg.HTTPPort)
+	}
+	if cfg.MaxWorkers != 20 {
+		t.Fatalf(".env should override the file, got %d", cfg.MaxWorkers)
+	}
+	if cfg.MaxInFlightDBJobs != 6 {
+		t.Fatalf("environment should override .env, got %d", cfg.MaxInFlightDBJobs)
+	}
+	if cfg.GRPCPort != "9090" || cfg.ShutdownDrainDelay != time.Second {
+		t.Fatalf("unexpected defaults: %+v", cfg)
+	}
+	if ext.Retries != 5 || ext.UpstreamAddr != "localhost:9000" {
+		t.Fatalf("extension not loaded: %+v", ext)
+	}
+	sources := map[string]string{}
+	for _, s := range settings {
+		sources[s.Env] = s.Source
+	}
+	want := map[string]string{"HTTP_PORT": SourceFile, "MAX_WORKERS": SourceDotEnv, "MAX_DB_JOBS": SourceEnv, "GRPC_PORT": SourceDefault}
+	for env, source := range want {
+		if sources[env] != source {
+			t.Fatalf("%s: expected source %s, got %s", env, source, sources[env])
+		}
+	}
+}
+
+func TestLoaderRejectsMalformedValues(t *testing.T) {
+	_, err := Loader{File: "missing.yaml", LookupEnv: envMap(map[string]string{"MAX_WORKERS": "lots"})}.Load("svc", nil)
+	if err == nil || !strings.Contains(err.Error(), "read config file") {
+		t.Fatalf("explicit missing file should fail, got %v", err)
+	}
+	_, err = Loader{File: writeFile(t, "c.yaml", "{}"), LookupEnv: envMap(map[string]string{"MAX_WORKERS": "lots"})}.Load("svc", nil)
+	if err == nil || !strings.Contains(err.Error(), "MAX_WORKERS") {
+		t.Fatalf("expected MAX_WORKERS parse error, got %v", err)
+	}
+}
+
+func TestLoaderFileValues(t *testing.T) {
+	file := writeFile(t, "config.yaml", `
+shutdown_drain_delay: 1500ms
+nats_url: ~
+upstream_addr: &addr upstream:9000
+services:
+  svc:
+    retries: 07
+  other:
+    retries: [1, 2]
+`)
+	var ext extConfig
+	cfg, err := Loader{File: file, LookupEnv: envMap(nil)}.Load("svc", &ext)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ShutdownDrainDelay != 1500*time.Millisecond || ext.Retries != 7 || ext.UpstreamAddr != "upstream:9000" {
+		t.Fatalf("file values not taken literally: %+v %+v", cfg, ext)
+	}
+	if cfg.NATSURL == "" {
+		t.Fatal("a null value should leave the default in place")
+	}
+
+	for name, content := range map[string]string{
+		"list":          "max_workers: [1, 2]\n",
+		"mapping":       "postgres_url:\n  host: db\n",
+		"service list":  "services:\n  svc:\n    http_port: [8080]\n",
+		"services list": "services: [svc]\n",
+		"top level":     "- max_workers\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Loader{File: writeFile(t, "c.yaml", content), LookupEnv: envMap(nil)}.Load("svc", &extConfig{})
+			if err == nil || !strings.Contains(err.Error(), "parse config file") {
+				t.Fatalf("expected a parse error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidationRules(t *testing.T) {
+	cases := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"default secret in production", map[string]string{"APP_ENV": "production"}, "default development secret"},
+		{"short secret in production", map[string]string{"APP_ENV": "production", "AUTH_SECRET": "short"}, "at least 32 bytes"},
+		{"unknown env", map[string]string{"APP_ENV": "prod"}, "unknown environment"},
+		{"bad port", map[string]string{"HTTP_PORT": "99999"}, "HTTP_PORT"},
+		{"extension rule", map[string]string{"RETRIES": "11"}, "RETRIES"},
+	}
+	file := writeFile(t, "c.yaml", "{}")
+	for _, tc := range cases {
+		_, err := Loader{File: file, LookupEnv: envMap(tc.env)}.Load("svc", &extConfig{})
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: expected error containing %q, got %v", tc.name, tc.want, err)
+		}
+	}
+	_, err := Loader{File: file, LookupEnv: envMap(map[string]string{
+		"APP_ENV":     "production",
+		"AUTH_SECRET": strings.Repeat("s", 32),
+	})}.Load("svc", nil)
+	if err != nil {
+		t.Fatalf("valid production config rejected: %v", err)
+	}
+}
+
+func TestSecretsRedacted(t *testing.T) {
+	cfg, settings, err := Loader{File: writeFile(t, "c.yaml", "{}"), LookupEnv: envMap(map[string]string{
+		"AUTH_SECRET":  "super-secret-value",
+		"POSTGRES_URL": "postgres://app:hunter2@db:5432/saas",
+	})}.Explain("svc", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out := fmt.Sprintf("%v %+v %#v", cfg, cfg, cfg.AuthSecret); strings.Contains(out, "super-secret-value") {
+		t.Fatalf("secret leaked through fmt: %s", out)
+	}
+	var b strings.Builder
+	if err := Print(&b, settings); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(b.String(), "super-secret-value") || strings.Contains(b.String(), "hunter2") {
+		t.Fatalf("secret leaked through Print:\n%s", b.String())
+	}
+	if cfg.AuthSecret.Value() != "super-secret-value" {
+		t.Fatalf("Value should return plaintext")
+	}
+}
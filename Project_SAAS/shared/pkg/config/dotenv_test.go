@@ -0,0 +1,72 @@
+package config_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"project_saas/shared/pkg/config"
+	"project_saas/shared/pkg/logger"
+	"project_saas/shared/pkg/observability"
+)
+
+// unsetenv removes key from the process environment for the rest of the test.
+func unsetenv(t *testing.T, key string) {
+	t.Helper()
+	t.Setenv(key, "")
+	os.Unsetenv(key)
+}
+
+func TestDotEnvReachesLoggerAndObservability(t *testing.T) {
+	dir := t.TempDir()
+	dotenv := filepath.Join(dir, ".env")
+	if err := os.WriteFile(dotenv, []byte("APP_ENV=staging\nOBSERVABILITY_DISABLED=true\nMAX_WORKERS=20\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(file, []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	unsetenv(t, "APP_ENV")
+	unsetenv(t, "OBSERVABILITY_DISABLED")
+	t.Setenv("MAX_WORKERS", "7")
+
+	cfg, err := config.Loader{File: file, DotEnv: dotenv}.Load("svc", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Env != "staging" || !cfg.ObservabilityDisabled || cfg.MaxWorkers != 7 {
+		t.Fatalf("loaded %+v", cfg)
+	}
+	// .env values stay inside the loader; the process environment is left as it was.
+	if got, ok := os.LookupEnv("APP_ENV"); ok {
+		t.Fatalf("APP_ENV exported to the environment as %q", got)
+	}
+	if got := os.Getenv("MAX_WORKERS"); got != "7" {
+		t.Fatalf("MAX_WORKERS in the environment = %q, want 7", got)
+	}
+
+	// The development logger enables debug; staging must get the production one.
+	log, err := logger.New(cfg.ServiceName, cfg.Env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if log.Core().Enabled(zapcore.DebugLevel) {
+		t.Fatal("logger built with the development config despite APP_ENV=staging in .env")
+	}
+
+	core, logs := observer.New(zap.InfoLevel)
+	shutdown, err := observability.Setup(context.Background(), cfg, zap.New(core))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown(context.Background())
+	if logs.FilterMessage("observability disabled via config").Len() != 1 {
+		t.Fatalf("observability ignored OBSERVABILITY_DISABLED from .env: %v", logs.All())
+	}
+}
@@ -0,0 +1,311 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Sources a setting can come from, lowest precedence first.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceDotEnv  = ".env"
+	SourceEnv     = "env"
+)
+
+// Loader assembles configuration from layered sources. The zero value reads $CONFIG_FILE (or
+// ./config.yaml), ./.env and the process environment; tests override the fields instead of mutating
+// global state. Values from .env are only seen through the loader and never exported to the process
+// environment, so anything that needs them must take them from the loaded config.
+type Loader struct {
+	// File is the YAML file to read. Empty means $CONFIG_FILE, falling back to ./config.yaml when present.
+	File string
+	// DotEnv is the .env file to read. Empty means ./.env when present.
+	DotEnv string
+	// LookupEnv replaces os.LookupEnv.
+	LookupEnv func(key string) (string, bool)
+}
+
+// Setting describes where one effective value came from; values of secrets are already redacted.
+type Setting struct {
+	Key    string
+	Env    string
+	Value  string
+	Source string
+}
+
+// Load fills a ServiceConfig for service and, when ext is a non-nil pointer to a struct tagged like
+// ServiceConfig, the service's extension settings. The YAML file may hold shared keys at the top level
+// and per-service overrides under services.<service>. Parse errors and validation failures (including
+// ext's Validate method, if any) are reported together.
+func (l Loader) Load(service string, ext interface{}) (ServiceConfig, error) {
+	cfg, _, err := l.load(service, ext)
+	return cfg, err
+}
+
+// Explain loads like Load and also reports each setting's effective value and source. Settings are
+// returned even when validation fails so the offending values can be shown.
+func (l Loader) Explain(service string, ext interface{}) (ServiceConfig, []Setting, error) {
+	return l.load(service, ext)
+}
+
+// Print writes settings as an aligned table of environment variable, value and source.
+func Print(w io.Writer, settings []Setting) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ENV\tVALUE\tSOURCE")
+	for _, s := range settings {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Env, s.Value, s.Source)
+	}
+	return tw.Flush()
+}
+
+func (l Loader) load(service string, ext interface{}) (ServiceConfig, []Setting, error) {
+	cfg := ServiceConfig{ServiceName: service}
+	fields, err := collectFields(&cfg)
+	if err != nil {
+		return ServiceConfig{}, nil, err
+	}
+	if ext != nil {
+		extFields, err := collectFields(ext)
+		if err != nil {
+			return ServiceConfig{}, nil, err
+		}
+		fields = append(fields, extFields...)
+	}
+
+	fileValues, err := l.readFile(service)
+	if err != nil {
+		return ServiceConfig{}, nil, err
+	}
+	dotenv, err := l.readDotEnv()
+	if err != nil {
+		return ServiceConfig{}, nil, err
+	}
+	lookup := l.LookupEnv
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+
+	var errs []error
+	settings := make([]Setting, 0, len(fields))
+	for _, f := range fields {
+		raw, source := f.def, SourceDefault
+		if v, ok := fileValues[f.yamlKey]; ok {
+			raw, source = v, SourceFile
+		}
+		if v, ok := dotenv[f.env]; ok {
+			raw, source = v, SourceDotEnv
+		}
+		if v, ok := lookup(f.env); ok {
+			raw, source = v, SourceEnv
+		}
+		if err := f.set(raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s (from %s): %w", f.env, source, err))
+			continue
+		}
+		settings = append(settings, Setting{Key: f.yamlKey, Env: f.env, Value: f.display(), Source: source})
+	}
+	if len(errs) > 0 {
+		return ServiceConfig{}, settings, errors.Join(errs...)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if v, ok := ext.(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return ServiceConfig{}, settings, errors.Join(errs...)
+	}
+	return cfg, settings, nil
+}
+
+func (l Loader) readFile(service string) (map[string]string, error) {
+	path := l.File
+	explicit := path != ""
+	if !explicit {
+		if p, ok := os.LookupEnv("CONFIG_FILE"); ok && p != "" {
+			path, explicit = p, true
+		} else {
+			path = "config.yaml"
+		}
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	values := make(map[string]string)
+	if len(doc.Content) == 0 {
+		return values, nil
+	}
+	var errs []error
+	for key, node := range mapping(doc.Content[0], "", &errs) {
+		if key != "services" {
+			addScalar(values, key, node, &errs)
+			continue
+		}
+		for name, section := range mapping(node, "services", &errs) {
+			if name != service {
+				continue
+			}
+			for k, v := range mapping(section, "services."+name, &errs) {
+				addScalar(values, k, v, &errs)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("parse config file %s: %w", path, errors.Join(errs...))
+	}
+	return values, nil
+}
+
+// mapping returns the entries of a YAML mapping node, recording an error when node is something else.
+// An empty node, such as a key with no value, has no entries.
+func mapping(node *yaml.Node, path string, errs *[]error) map[string]*yaml.Node {
+	node = resolveAlias(node)
+	entries := make(map[string]*yaml.Node)
+	switch {
+	case node.Kind == yaml.ScalarNode && node.Tag == "!!null":
+	case node.Kind != yaml.MappingNode:
+		if path == "" {
+			path = "top level"
+		}
+		*errs = append(*errs, fmt.Errorf("%s: expected a mapping (line %d)", path, node.Line))
+	default:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			entries[node.Content[i].Value] = node.Content[i+1]
+		}
+	}
+	return entries
+}
+
+// addScalar stores the literal text of a scalar value, so 1.50 stays "1.50" and 30s stays "30s".
+// Settings are single values, so lists and mappings are rejected rather than flattened into strings
+// no field could parse; a null value leaves the setting to the lower layers.
+func addScalar(values map[string]string, key string, node *yaml.Node, errs *[]error) {
+	node = resolveAlias(node)
+	switch {
+	case node.Kind != yaml.ScalarNode:
+		*errs = append(*errs, fmt.Errorf("%s: expected a single value, got a list or mapping (line %d)", key, node.Line))
+	case node.Tag != "!!null":
+		values[key] = node.Value
+	}
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+func (l Loader) readDotEnv() (map[string]string, error) {
+	path := l.DotEnv
+	if path == "" {
+		path = ".env"
+		if _, err := os.Stat(path); err != nil {
+			return nil, nil
+		}
+	}
+	values, err := godotenv.Read(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return values, nil
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	secretType   = reflect.TypeOf(Secret(""))
+)
+
+type field struct {
+	value   reflect.Value
+	yamlKey string
+	env     string
+	def     string
+}
+
+func collectFields(target interface{}) ([]field, error) {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("config target must be a pointer to a struct, got %T", target)
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+	var fields []field
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		env := sf.Tag.Get("env")
+		if env == "" || !sf.IsExported() {
+			continue
+		}
+		key := sf.Tag.Get("yaml")
+		if key == "" {
+			key = strings.ToLower(env)
+		}
+		fields = append(fields, field{value: rv.Field(i), yamlKey: key, env: env, def: sf.Tag.Get("default")})
+	}
+	return fields, nil
+}
+
+func (f field) set(raw string) error {
+	switch {
+	case f.value.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		f.value.SetInt(int64(d))
+	case f.value.Kind() == reflect.String:
+		f.value.SetString(raw)
+	case f.value.Kind() == reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		f.value.SetInt(int64(n))
+	case f.value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		f.value.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported config field type %s", f.value.Type())
+	}
+	return nil
+}
+
+func (f field) display() string {
+	if f.value.Type() == secretType {
+		return Secret(f.value.String()).String()
+	}
+	if f.value.Type() == durationType {
+		return time.Duration(f.value.Int()).String()
+	}
+	if f.value.Kind() == reflect.String {
+		return redactURL(f.value.String())
+	}
+	return fmt.Sprint(f.value.Interface())
+}
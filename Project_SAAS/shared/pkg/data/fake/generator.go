@@ -2,70 +2,305 @@ package fake
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/binary"
+	"fmt"
 	"math"
-	"math/big"
+	"math/rand/v2"
+	"strings"
+	"sync"
 	"time"
 )
 
+// DefaultSeed is used by StreamUsage and whenever Config.Seed is zero, so runs are reproducible unless
+// a caller explicitly asks for different data.
+const DefaultSeed = 20240101
+
+// Config controls the shape of generated data. Zero values select the defaults noted per field.
+type Config struct {
+	// Seed fixes every random choice; the same seed always yields the same data. Default DefaultSeed.
+	Seed uint64
+	// Epoch is the start of the simulated period. Default 2024-01-01T00:00:00Z.
+	Epoch time.Time
+	// Days is the length of the usage window after Epoch. Default 30.
+	Days int
+	// MaxTenantSeats caps the Zipfian tenant size distribution. Default 2000; values above the largest
+	// plan's MaxSeats are lowered to it, since no subscription could license a bigger tenant.
+	MaxTenantSeats int
+	// ZipfS is the Zipf skew (> 1); larger values produce more tiny tenants. Default 1.3.
+	ZipfS float64
+	// PeakHour is the UTC hour with the most usage on the diurnal curve. Default 14.
+	PeakHour int
+}
+
 // UsageRecord mimics a metered usage event that billing must aggregate.
 type UsageRecord struct {
-	TenantID  string
-	UserID    string
-	Quantity  int64
-	UnitPrice float64
-	Occurred  time.Time
+	TenantID  string    `json:"tenant_id"`
+	UserID    string    `json:"user_id"`
+	Quantity  int64     `json:"quantity"`
+	UnitPrice float64   `json:"unit_price"`
+	Occurred  time.Time `json:"occurred"`
 }
 
-// StreamUsage emits total synthetic records into the returned channel.
-func StreamUsage(ctx context.Context, total int) <-chan UsageRecord {
-	if total <= 0 {
-		total = 1
+// Tenant is a customer organisation; Seats follows a Zipf distribution.
+type Tenant struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Seats     int       `json:"seats"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// User belongs to a tenant and occupies one seat.
+type User struct {
+	ID        string    `json:"id"`
+	TenantID  string    `json:"tenant_id"`
+	Email     string    `json:"email"`
+	FullName  string    `json:"full_name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Plan mirrors the subscription-service catalog.
+type Plan struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	PriceCents    int    `json:"price_cents"`
+	BillingPeriod string `json:"billing_period"`
+	MaxSeats      int    `json:"max_seats"`
+	// UnitPrice is what one metered usage unit costs on this plan.
+	UnitPrice float64 `json:"unit_price"`
+}
+
+// Subscription assigns a tenant to the smallest plan that fits its seats.
+type Subscription struct {
+	TenantID         string    `json:"tenant_id"`
+	PlanID           string    `json:"plan_id"`
+	Status           string    `json:"status"`
+	Seats            int       `json:"seats"`
+	ActivatedAt      time.Time `json:"activated_at"`
+	CurrentPeriodEnd time.Time `json:"current_period_end"`
+}
+
+// Dataset is a consistent set of tenants with their users and subscriptions.
+type Dataset struct {
+	Plans         []Plan
+	Tenants       []Tenant
+	Users         []User
+	Subscriptions []Subscription
+}
+
+// Plans is the catalog seeded by subscription-service's migrations.
+var Plans = []Plan{
+	{ID: "growth", Name: "Growth", Description: "Up to 500 seats, metered usage billed monthly.", PriceCents: 35000, BillingPeriod: "monthly", MaxSeats: 500, UnitPrice: 0.05},
+	{ID: "enterprise", Name: "Enterprise", Description: "Dedicated concurrency budget with premium support.", PriceCents: 125000, BillingPeriod: "monthly", MaxSeats: 5000, UnitPrice: 0.03},
+}
+
+// Independent random streams, so adding users does not change the usage sequence and vice versa.
+const (
+	streamTenants uint64 = iota + 1
+	streamUsers
+	streamUsage
+)
+
+// Generator produces deterministic synthetic data for load and contract tests.
+type Generator struct {
+	cfg    Config
+	hourly [24]float64
+}
+
+// New returns a Generator for cfg.
+func New(cfg Config) *Generator {
+	if cfg.Seed == 0 {
+		cfg.Seed = DefaultSeed
+	}
+	if cfg.Epoch.IsZero() {
+		cfg.Epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	if cfg.Days <= 0 {
+		cfg.Days = 30
+	}
+	if cfg.MaxTenantSeats <= 0 {
+		cfg.MaxTenantSeats = 2000
+	}
+	cfg.MaxTenantSeats = min(cfg.MaxTenantSeats, Plans[len(Plans)-1].MaxSeats)
+	if cfg.ZipfS <= 1 {
+		cfg.ZipfS = 1.3
+	}
+	if cfg.PeakHour < 0 || cfg.PeakHour > 23 {
+		cfg.PeakHour = 14
+	}
+	return &Generator{cfg: cfg, hourly: diurnalWeights(cfg.PeakHour)}
+}
+
+func (g *Generator) rng(stream uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(g.cfg.Seed, stream))
+}
+
+// Dataset builds n tenants with Zipf-distributed seat counts, one user per occupied seat, and a
+// subscription on the smallest plan that fits.
+func (g *Generator) Dataset(n int) Dataset {
+	ds := Dataset{Plans: Plans}
+	tr := g.rng(streamTenants)
+	ur := g.rng(streamUsers)
+	zipf := rand.NewZipf(tr, g.cfg.ZipfS, 1, uint64(g.cfg.MaxTenantSeats-1))
+	for i := 0; i < n; i++ {
+		seats := 1 + int(zipf.Uint64())
+		created := g.cfg.Epoch.Add(-time.Duration(tr.IntN(365*24)) * time.Hour)
+		tenant := Tenant{
+			ID:        fmt.Sprintf("tenant-%05d", i+1),
+			Name:      companyName(tr),
+			Seats:     seats,
+			CreatedAt: created,
+		}
+		ds.Tenants = append(ds.Tenants, tenant)
+		plan := planFor(seats)
+		ds.Subscriptions = append(ds.Subscriptions, Subscription{
+			TenantID:         tenant.ID,
+			PlanID:           plan.ID,
+			Status:           "active",
+			Seats:            seats,
+			ActivatedAt:      created,
+			CurrentPeriodEnd: g.cfg.Epoch.Add(30 * 24 * time.Hour),
+		})
+		// Tenants rarely fill every licensed seat.
+		occupied := max(1, seats*(60+ur.IntN(41))/100)
+		domain := strings.ToLower(strings.ReplaceAll(tenant.Name, " ", "")) + ".example"
+		for j := 0; j < occupied; j++ {
+			first, last := firstNames[ur.IntN(len(firstNames))], lastNames[ur.IntN(len(lastNames))]
+			ds.Users = append(ds.Users, User{
+				ID:        uuid(ur),
+				TenantID:  tenant.ID,
+				Email:     fmt.Sprintf("%s.%s%d@%s", strings.ToLower(first), strings.ToLower(last), j+1, domain),
+				FullName:  first + " " + last,
+				CreatedAt: created.Add(time.Duration(ur.IntN(30*24)) * time.Hour),
+			})
+		}
 	}
+	return ds
+}
+
+// StreamUsage emits total usage records for ds into the returned channel. Tenants are picked in
+// proportion to their user count, so large tenants dominate usage as they do in production, and
+// timestamps follow the diurnal curve across the configured window.
+func (g *Generator) StreamUsage(ctx context.Context, ds Dataset, total int) <-chan UsageRecord {
 	out := make(chan UsageRecord, 1024)
+	if len(ds.Users) == 0 || total <= 0 {
+		close(out)
+		return out
+	}
+	prices := make(map[string]float64, len(ds.Subscriptions))
+	for _, sub := range ds.Subscriptions {
+		prices[sub.TenantID] = planByID(sub.PlanID).UnitPrice
+	}
+	r := g.rng(streamUsage)
 	go func() {
 		defer close(out)
 		for i := 0; i < total; i++ {
+			// A uniformly chosen user makes tenant selection proportional to tenant size.
+			user := ds.Users[r.IntN(len(ds.Users))]
+			record := UsageRecord{
+				TenantID:  user.TenantID,
+				UserID:    user.ID,
+				Quantity:  1 + int64(r.ExpFloat64()*3),
+				UnitPrice: prices[user.TenantID],
+				Occurred:  g.occurredAt(r),
+			}
 			select {
 			case <-ctx.Done():
 				return
-			case out <- UsageRecord{
-				TenantID:  randomTenant(),
-				UserID:    randomUser(),
-				Quantity:  1 + int64(i%10),
-				UnitPrice: math.Round((0.01+float64(i%5))*100) / 100,
-				Occurred:  time.Now().Add(-time.Duration(i%3600) * time.Second),
-			}:
+			case out <- record:
 			}
 		}
 	}()
 	return out
 }
 
-func randomTenant() string {
-	n, _ := rand.Int(rand.Reader, big.NewInt(9999))
-	return "tenant-" + n.String()
+// defaultUsage is the default-seeded generator and its 1,000 tenant dataset. Building the dataset
+// allocates hundreds of thousands of users, so it is done once per process and then only read.
+var defaultUsage = sync.OnceValues(func() (*Generator, Dataset) {
+	g := New(Config{})
+	return g, g.Dataset(1000)
+})
+
+// StreamUsage emits total records from the default-seeded generator over a 1,000 tenant dataset, so
+// billing runs and benchmarks see the same data every time.
+func StreamUsage(ctx context.Context, total int) <-chan UsageRecord {
+	return usageFrom(ctx, defaultUsage, total)
 }
 
-func randomUser() string {
-	var b [8]byte
-	if _, err := rand.Read(b[:]); err != nil {
-		return "user-0"
+// usageFrom streams total records from the generator and dataset returned by source.
+func usageFrom(ctx context.Context, source func() (*Generator, Dataset), total int) <-chan UsageRecord {
+	if total <= 0 {
+		total = 1
 	}
-	return "user-" + string(base36(int64(binary.BigEndian.Uint64(b[:]))))
+	g, ds := source()
+	return g.StreamUsage(ctx, ds, total)
+}
+
+func (g *Generator) occurredAt(r *rand.Rand) time.Time {
+	day := r.IntN(g.cfg.Days)
+	hour := sampleHour(r, g.hourly)
+	offset := time.Duration(day)*24*time.Hour + time.Duration(hour)*time.Hour + time.Duration(r.IntN(3600))*time.Second
+	return g.cfg.Epoch.Add(offset)
 }
 
-func base36(v int64) []rune {
-	const alphabet = "0123456789abcdefghijklmnopqrstuvwxyz"
-	if v == 0 {
-		return []rune{'0'}
+// diurnalWeights returns a cumulative distribution over UTC hours following a cosine curve that peaks
+// at peak and bottoms out twelve hours later at a tenth of the peak rate.
+func diurnalWeights(peak int) [24]float64 {
+	var cum [24]float64
+	total := 0.0
+	for h := 0; h < 24; h++ {
+		total += 0.55 + 0.45*math.Cos(2*math.Pi*float64(h-peak)/24)
+		cum[h] = total
 	}
-	var out []rune
-	for v > 0 {
-		rem := v % 36
-		out = append([]rune{rune(alphabet[rem])}, out...)
-		v /= 36
+	for h := range cum {
+		cum[h] /= total
 	}
-	return out
+	return cum
+}
+
+func sampleHour(r *rand.Rand, cum [24]float64) int {
+	x := r.Float64()
+	for h, c := range cum {
+		if x < c {
+			return h
+		}
+	}
+	return 23
+}
+
+// planFor returns the smallest plan that fits seats. New caps tenant sizes at the largest plan, so
+// one always does.
+func planFor(seats int) Plan {
+	for _, p := range Plans {
+		if seats <= p.MaxSeats {
+			return p
+		}
+	}
+	return Plans[len(Plans)-1]
+}
+
+func planByID(id string) Plan {
+	for _, p := range Plans {
+		if p.ID == id {
+			return p
+		}
+	}
+	return Plans[0]
+}
+
+func uuid(r *rand.Rand) string {
+	hi, lo := r.Uint64(), r.Uint64()
+	hi = (hi &^ (0xf << 12)) | (0x4 << 12) // version 4
+	lo = (lo &^ (0x3 << 62)) | (0x2 << 62) // RFC 4122 variant
+	return fmt.Sprintf("%08x-%04x-%04x-%04x-%012x", hi>>32, (hi>>16)&0xffff, hi&0xffff, lo>>48, lo&0xffffffffffff)
 }
+
+func companyName(r *rand.Rand) string {
+	return companyPrefixes[r.IntN(len(companyPrefixes))] + " " + companySuffixes[r.IntN(len(companySuffixes))]
+}
+
+var (
+	firstNames      = []string{"Aarav", "Priya", "Rohan", "Ananya", "Vikram", "Meera", "Arjun", "Kavya", "Alex", "Sam", "Jordan", "Taylor", "Chen", "Fatima", "Lucas", "Sofia"}
+	lastNames       = []string{"Sharma", "Iyer", "Reddy", "Nair", "Patel", "Gupta", "Khan", "Singh", "Smith", "Garcia", "Kim", "Mueller", "Rossi", "Silva", "Okafor", "Tanaka"}
+	companyPrefixes = []string{"Acme", "Globex", "Initech", "Umbrella", "Stark", "Wayne", "Hooli", "Vandelay", "Concur", "Zenith", "Nimbus", "Quantum"}
+	companySuffixes = []string{"Labs", "Systems", "Retail", "Logistics", "Health", "Finance", "Media", "Works", "Cloud", "Foods"}
+)
@@ -0,0 +1,138 @@
+package fake
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func collect(g *Generator, ds Dataset, total int) []UsageRecord {
+	var out []UsageRecord
+	for r := range g.StreamUsage(context.Background(), ds, total) {
+		out = append(out, r)
+	}
+	return out
+}
+
+func TestGeneratorDeterministic(t *testing.T) {
+	a, b := New(Config{Seed: 42}), New(Config{Seed: 42})
+	dsA, dsB := a.Dataset(50), b.Dataset(50)
+	if !reflect.DeepEqual(dsA, dsB) {
+		t.Fatalf("same seed produced different datasets")
+	}
+	if !reflect.DeepEqual(collect(a, dsA, 500), collect(b, dsB, 500)) {
+		t.Fatalf("same seed produced different usage")
+	}
+	other := New(Config{Seed: 43}).Dataset(50)
+	if reflect.DeepEqual(dsA.Users, other.Users) {
+		t.Fatalf("different seeds produced identical users")
+	}
+}
+
+func TestDatasetConsistent(t *testing.T) {
+	for name, cfg := range map[string]Config{
+		"default": {},
+		// Far above the largest plan, so tenant sizes must be capped for every subscription to fit.
+		"oversized tenants": {MaxTenantSeats: 50000, ZipfS: 1.01},
+	} {
+		t.Run(name, func(t *testing.T) {
+			checkDataset(t, New(cfg).Dataset(200))
+		})
+	}
+}
+
+func checkDataset(t *testing.T, ds Dataset) {
+	t.Helper()
+	tenants := map[string]Tenant{}
+	for _, tn := range ds.Tenants {
+		tenants[tn.ID] = tn
+	}
+	users := map[string]int{}
+	emails := map[string]bool{}
+	for _, u := range ds.Users {
+		if _, ok := tenants[u.TenantID]; !ok {
+			t.Fatalf("user %s references unknown tenant %s", u.ID, u.TenantID)
+		}
+		key := u.TenantID + "/" + u.Email
+		if emails[key] {
+			t.Fatalf("duplicate email %s", key)
+		}
+		emails[key] = true
+		users[u.TenantID]++
+	}
+	for _, sub := range ds.Subscriptions {
+		if users[sub.TenantID] > sub.Seats {
+			t.Fatalf("%s: %d users exceed %d seats", sub.TenantID, users[sub.TenantID], sub.Seats)
+		}
+		if sub.Seats > planByID(sub.PlanID).MaxSeats {
+			t.Fatalf("%s: %d seats do not fit plan %s", sub.TenantID, sub.Seats, sub.PlanID)
+		}
+	}
+}
+
+func TestTenantSizesAreSkewed(t *testing.T) {
+	ds := New(Config{}).Dataset(1000)
+	seats := make([]int, 0, len(ds.Tenants))
+	total := 0
+	for _, tn := range ds.Tenants {
+		seats = append(seats, tn.Seats)
+		total += tn.Seats
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(seats)))
+	if median := seats[len(seats)/2]; median > 10 {
+		t.Fatalf("expected most tenants to be small, median seats %d", median)
+	}
+	top := 0
+	for _, s := range seats[:len(seats)/10] {
+		top += s
+	}
+	if top*2 < total {
+		t.Fatalf("expected the largest 10%% of tenants to hold most seats, got %d of %d", top, total)
+	}
+}
+
+func TestUsageFollowsDiurnalCurve(t *testing.T) {
+	g := New(Config{PeakHour: 14})
+	var hours [24]int
+	for _, r := range collect(g, g.Dataset(100), 20000) {
+		hours[r.Occurred.Hour()]++
+	}
+	if peak, trough := hours[14], hours[2]; peak < 4*trough {
+		t.Fatalf("expected peak hour to dominate the trough, got %d vs %d", peak, trough)
+	}
+}
+
+func TestStreamUsageReusesDefaultDataset(t *testing.T) {
+	g, ds := defaultUsage()
+	if _, again := defaultUsage(); &again.Users[0] != &ds.Users[0] {
+		t.Fatalf("default dataset was rebuilt")
+	}
+
+	builds := 0
+	source := func() (*Generator, Dataset) {
+		builds++
+		return g, ds
+	}
+	read := func(stream <-chan UsageRecord) []UsageRecord {
+		var out []UsageRecord
+		for r := range stream {
+			out = append(out, r)
+		}
+		return out
+	}
+	first := read(usageFrom(context.Background(), source, 200))
+	if !reflect.DeepEqual(read(usageFrom(context.Background(), source, 200)), first) {
+		t.Fatalf("repeated calls produced different usage")
+	}
+	if builds != 2 {
+		t.Fatalf("usageFrom did not take its dataset from source")
+	}
+	if !reflect.DeepEqual(read(StreamUsage(context.Background(), 200)), first) {
+		t.Fatalf("StreamUsage differs from the shared default dataset")
+	}
+	fresh := New(Config{})
+	if want := collect(fresh, fresh.Dataset(1000), 200); !reflect.DeepEqual(first, want) {
+		t.Fatalf("cached stream differs from a freshly generated one")
+	}
+}
@@ -0,0 +1,67 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// seedChunk is how many rows SeedPostgres writes per transaction, so a large dataset neither holds one
+// long transaction open nor queues every statement in memory at once.
+const seedChunk = 1000
+
+// SeedPostgres writes ds into the user-service schema through users and the subscription-service
+// schema through subscriptions; both must already be migrated and may be the same pool when the
+// services share a database. Rows are committed in chunks and rows that exist are left untouched, so
+// seeding the same dataset twice is a no-op and a seed that stopped part way can simply be rerun.
+func SeedPostgres(ctx context.Context, users, subscriptions *pgxpool.Pool, ds Dataset) error {
+	if err := seedRows(ctx, users, "tenants", ds.Tenants,
+		`INSERT INTO tenants (id, name, created_at) VALUES ($1, $2, $3) ON CONFLICT (id) DO NOTHING`,
+		func(t Tenant) []any { return []any{t.ID, t.Name, t.CreatedAt} }); err != nil {
+		return err
+	}
+	if err := seedRows(ctx, users, "users", ds.Users,
+		`INSERT INTO users (id, tenant_id, email, full_name, created_at) VALUES ($1, $2, $3, $4, $5) ON CONFLICT DO NOTHING`,
+		func(u User) []any { return []any{u.ID, u.TenantID, u.Email, u.FullName, u.CreatedAt} }); err != nil {
+		return err
+	}
+	if err := seedRows(ctx, subscriptions, "plans", ds.Plans,
+		`INSERT INTO plans (id, name, description, price_cents, billing_period, max_seats) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (id) DO NOTHING`,
+		func(p Plan) []any {
+			return []any{p.ID, p.Name, p.Description, p.PriceCents, p.BillingPeriod, p.MaxSeats}
+		}); err != nil {
+		return err
+	}
+	return seedRows(ctx, subscriptions, "tenant_subscriptions", ds.Subscriptions,
+		`INSERT INTO tenant_subscriptions (tenant_id, plan_id, seats, status, activated_at, current_period_end) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (tenant_id) DO NOTHING`,
+		func(s Subscription) []any {
+			return []any{s.TenantID, s.PlanID, s.Seats, s.Status, s.ActivatedAt, s.CurrentPeriodEnd}
+		})
+}
+
+// seedRows inserts rows with query, one transaction per seedChunk rows.
+func seedRows[T any](ctx context.Context, pool *pgxpool.Pool, table string, rows []T, query string, args func(T) []any) error {
+	for start := 0; start < len(rows); start += seedChunk {
+		chunk := rows[start:min(start+seedChunk, len(rows))]
+		err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+			batch := &pgx.Batch{}
+			for _, row := range chunk {
+				batch.Queue(query, args(row)...)
+			}
+			results := tx.SendBatch(ctx, batch)
+			for i := range chunk {
+				if _, err := results.Exec(); err != nil {
+					results.Close()
+					return fmt.Errorf("seed %s row %d: %w", table, start+i, err)
+				}
+			}
+			return results.Close()
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
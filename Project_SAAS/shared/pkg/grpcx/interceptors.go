@@ -0,0 +1,121 @@
+package grpcx
+
+import (
+	"context"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"project_saas/shared/pkg/auth"
+)
+
+const healthServicePrefix = "/grpc.health.v1.Health/"
+
+// UnaryRecoverer converts handler panics into codes.Internal instead of crashing the process.
+func UnaryRecoverer(log *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				log.Error("grpc panic", zap.String("method", info.FullMethod), zap.Any("panic", p), zap.ByteString("stack", debug.Stack()))
+				err = status.Error(codes.Internal, "internal error")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamRecoverer is the streaming counterpart of UnaryRecoverer.
+func StreamRecoverer(log *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				log.Error("grpc panic", zap.String("method", info.FullMethod), zap.Any("panic", p), zap.ByteString("stack", debug.Stack()))
+				err = status.Error(codes.Internal, "internal error")
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// UnaryLogger emits one structured log line per RPC, mirroring httpx.RequestLogger.
+func UnaryLogger(log *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		log.Info("grpc_request",
+			zap.String("method", info.FullMethod),
+			zap.String("code", status.Code(err).String()),
+			zap.Duration("duration", time.Since(start)),
+		)
+		return resp, err
+	}
+}
+
+// UnaryAuth validates the bearer token in the "authorization" metadata with the same auth.Validator the
+// HTTP middleware uses and stores the claims in the context. Health checks are exempt.
+func UnaryAuth(validator *auth.Validator, log *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if strings.HasPrefix(info.FullMethod, healthServicePrefix) {
+			return handler(ctx, req)
+		}
+		ctx, err := authenticate(ctx, validator, log)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuth is the streaming counterpart of UnaryAuth.
+func StreamAuth(validator *auth.Validator, log *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if strings.HasPrefix(info.FullMethod, healthServicePrefix) {
+			return handler(srv, ss)
+		}
+		ctx, err := authenticate(ss.Context(), validator, log)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func authenticate(ctx context.Context, validator *auth.Validator, log *zap.Logger) (context.Context, error) {
+	var header string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			header = values[0]
+		}
+	}
+	claims, err := validator.Parse(header)
+	if err != nil {
+		log.Warn("grpc auth failed", zap.Error(err))
+		return ctx, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return auth.WithClaims(ctx, claims), nil
+}
+
+type authedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authedStream) Context() context.Context { return s.ctx }
+
+// AuthorizeTenant rejects calls whose token was issued for a different tenant than the one requested.
+func AuthorizeTenant(ctx context.Context, tenantID string) error {
+	claims, ok := auth.ClaimsFromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, auth.ErrMissingToken.Error())
+	}
+	if claims.TenantID != strings.TrimSpace(tenantID) {
+		return status.Error(codes.PermissionDenied, "token not valid for tenant")
+	}
+	return nil
+}
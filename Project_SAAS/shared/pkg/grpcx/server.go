@@ -0,0 +1,94 @@
+package grpcx
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"project_saas/shared/pkg/auth"
+)
+
+// NewServer returns a gRPC server with recovery, request logging, bearer auth and OpenTelemetry tracing,
+// plus the standard grpc.health.v1 service.
+func NewServer(validator *auth.Validator, log *zap.Logger) (*grpc.Server, *health.Server) {
+	srv := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(
+			UnaryRecoverer(log),
+			UnaryLogger(log),
+			UnaryAuth(validator, log),
+		),
+		grpc.ChainStreamInterceptor(
+			StreamRecoverer(log),
+			StreamAuth(validator, log),
+		),
+	)
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(srv, hs)
+	return srv, hs
+}
+
+// stopTimeout bounds GracefulStop; RPCs still running after it, such as long-lived streams, are cut off.
+// It matches the HTTP server's shutdown budget.
+const stopTimeout = 10 * time.Second
+
+// Serve listens on port until ctx is cancelled. It then reports NOT_SERVING on the health service for
+// drainDelay while still accepting RPCs, so clients and load balancers move away first, and finally
+// drains in-flight RPCs with GracefulStop for at most stopTimeout before forcing the rest closed.
+func Serve(ctx context.Context, srv *grpc.Server, hs *health.Server, port string, drainDelay time.Duration, log *zap.Logger) error {
+	lis, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		return err
+	}
+	return serve(ctx, srv, hs, lis, drainDelay, stopTimeout, log)
+}
+
+func serve(ctx context.Context, srv *grpc.Server, hs *health.Server, lis net.Listener, drainDelay, stopTimeout time.Duration, log *zap.Logger) error {
+	errCh := make(chan error, 1)
+	go func() {
+		log.Info("grpc server starting", zap.String("addr", lis.Addr().String()))
+		errCh <- srv.Serve(lis)
+	}()
+
+	select {
+	case <-ctx.Done():
+	case err := <-errCh:
+		if err == grpc.ErrServerStopped {
+			return nil
+		}
+		return err
+	}
+
+	hs.Shutdown()
+	log.Info("grpc server draining", zap.Duration("delay", drainDelay))
+	drain := time.NewTimer(drainDelay)
+	select {
+	case <-drain.C:
+	case err := <-errCh:
+		drain.Stop()
+		return err
+	}
+
+	log.Info("grpc server shutting down")
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+	deadline := time.NewTimer(stopTimeout)
+	defer deadline.Stop()
+	select {
+	case <-stopped:
+	case <-deadline.C:
+		log.Warn("grpc graceful stop timed out, closing remaining connections", zap.Duration("timeout", stopTimeout))
+		srv.Stop()
+		<-stopped
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package grpcx
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type testServer struct {
+	cancel context.CancelFunc
+	done   chan error
+	client healthpb.HealthClient
+}
+
+func startServer(t *testing.T, drainDelay, stopTimeout time.Duration) *testServer {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(srv, hs)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ts := &testServer{cancel: cancel, done: make(chan error, 1)}
+	go func() { ts.done <- serve(ctx, srv, hs, lis, drainDelay, stopTimeout, zap.NewNop()) }()
+	t.Cleanup(func() {
+		cancel()
+		srv.Stop()
+	})
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	ts.client = healthpb.NewHealthClient(conn)
+	return ts
+}
+
+func (ts *testServer) status(t *testing.T) healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	resp, err := ts.client.Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("health check: %v", err)
+	}
+	return resp.Status
+}
+
+func (ts *testServer) wait(t *testing.T, within time.Duration) {
+	t.Helper()
+	select {
+	case err := <-ts.done:
+		if err != nil {
+			t.Fatalf("serve: %v", err)
+		}
+	case <-time.After(within):
+		t.Fatalf("server still running %s after shutdown began", within)
+	}
+}
+
+func TestServeReportsNotServingWhileDraining(t *testing.T) {
+	ts := startServer(t, 300*time.Millisecond, time.Second)
+	if got := ts.status(t); got != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("before shutdown: %s, want SERVING", got)
+	}
+
+	ts.cancel()
+	time.Sleep(50 * time.Millisecond)
+	// The server keeps answering during the drain, but tells callers to go elsewhere.
+	if got := ts.status(t); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("during drain: %s, want NOT_SERVING", got)
+	}
+	select {
+	case err := <-ts.done:
+		t.Fatalf("serve returned %v before the drain delay elapsed", err)
+	default:
+	}
+	ts.wait(t, time.Second)
+}
+
+func TestServeForcesStopAfterTimeout(t *testing.T) {
+	ts := startServer(t, 0, 200*time.Millisecond)
+
+	// An open Watch stream never finishes on its own, so GracefulStop alone would block forever.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := ts.client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	ts.cancel()
+	ts.wait(t, 2*time.Second)
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Fatalf("serve returned after %s, before the stop timeout", elapsed)
+	}
+	// Health goes NOT_SERVING first, then the forced stop breaks the stream.
+	for {
+		if _, err := stream.Recv(); err != nil {
+			break
+		}
+	}
+}
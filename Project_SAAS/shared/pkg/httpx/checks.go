@@ -0,0 +1,107 @@
+package httpx
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nats-io/nats.go"
+)
+
+// PostgresCheck pings the pool.
+func PostgresCheck(pool *pgxpool.Pool) CheckFunc {
+	return func(ctx context.Context) error {
+		return pool.Ping(ctx)
+	}
+}
+
+// NATSCheck fails unless the connection is currently established.
+func NATSCheck(nc *nats.Conn) CheckFunc {
+	return func(context.Context) error {
+		if status := nc.Status(); status != nats.CONNECTED {
+			return fmt.Errorf("nats connection %s", status)
+		}
+		return nil
+	}
+}
+
+// RedisCheck sends a RESP PING to the server behind a redis:// URL and expects +PONG. AUTH is sent first
+// when the URL carries a password, with the username as well when one is given (Redis 6 ACLs).
+func RedisCheck(redisURL string) CheckFunc {
+	return func(ctx context.Context) error {
+		u, err := url.Parse(redisURL)
+		if err != nil {
+			return fmt.Errorf("parse redis url: %w", err)
+		}
+		host := u.Host
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "6379")
+		}
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", host)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		if deadline, ok := ctx.Deadline(); ok {
+			_ = conn.SetDeadline(deadline)
+		}
+		rd := bufio.NewReader(conn)
+		if pass, ok := u.User.Password(); ok {
+			args := []string{"AUTH", pass}
+			if user := u.User.Username(); user != "" {
+				args = []string{"AUTH", user, pass}
+			}
+			if err := redisCommand(conn, rd, "+OK", args...); err != nil {
+				return err
+			}
+		}
+		return redisCommand(conn, rd, "+PONG", "PING")
+	}
+}
+
+func redisCommand(conn net.Conn, rd *bufio.Reader, want string, args ...string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := conn.Write([]byte(b.String())); err != nil {
+		return err
+	}
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if got := strings.TrimSpace(line); got != want {
+		return fmt.Errorf("redis %s: unexpected reply %q", args[0], got)
+	}
+	return nil
+}
+
+// HTTPCheck issues a GET against a downstream service (typically its /readyz) and expects a 2xx.
+func HTTPCheck(client *http.Client, target string) CheckFunc {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("%s returned %d", target, resp.StatusCode)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,198 @@
+package httpx
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeRedis answers RESP commands on a loopback listener: PING with +PONG, AUTH with +OK when the
+// arguments match wantAuth and an error otherwise. Every command it reads is recorded.
+type fakeRedis struct {
+	addr     string
+	wantAuth []string
+	commands chan []string
+}
+
+func startFakeRedis(t *testing.T, wantAuth ...string) *fakeRedis {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { lis.Close() })
+	f := &fakeRedis{addr: lis.Addr().String(), wantAuth: wantAuth, commands: make(chan []string, 16)}
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	rd := bufio.NewReader(conn)
+	for {
+		args, err := readRESPArray(rd)
+		if err != nil {
+			return
+		}
+		f.commands <- args
+		switch strings.ToUpper(args[0]) {
+		case "PING":
+			conn.Write([]byte("+PONG\r\n"))
+		case "AUTH":
+			if strings.Join(args[1:], " ") == strings.Join(f.wantAuth, " ") {
+				conn.Write([]byte("+OK\r\n"))
+			} else {
+				conn.Write([]byte("-WRONGPASS invalid username-password pair\r\n"))
+			}
+		default:
+			conn.Write([]byte("-ERR unknown command\r\n"))
+		}
+	}
+}
+
+func readRESPArray(rd *bufio.Reader) ([]string, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		if _, err := rd.ReadString('\n'); err != nil { // $<len>
+			return nil, err
+		}
+		arg, err := rd.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, strings.TrimSuffix(arg, "\r\n"))
+	}
+	return args, nil
+}
+
+func TestRedisCheck(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	plain := startFakeRedis(t)
+	if err := RedisCheck("redis://" + plain.addr)(ctx); err != nil {
+		t.Fatalf("ping without auth: %v", err)
+	}
+	if got := <-plain.commands; got[0] != "PING" {
+		t.Fatalf("expected PING first without credentials, got %v", got)
+	}
+
+	acl := startFakeRedis(t, "app", "s3cret")
+	if err := RedisCheck("redis://app:s3cret@" + acl.addr)(ctx); err != nil {
+		t.Fatalf("ping with ACL auth: %v", err)
+	}
+	if err := RedisCheck("redis://app:wrong@" + acl.addr)(ctx); err == nil {
+		t.Fatal("expected a rejected AUTH to fail the check")
+	}
+
+	password := startFakeRedis(t, "s3cret")
+	if err := RedisCheck("redis://:s3cret@" + password.addr)(ctx); err != nil {
+		t.Fatalf("ping with password-only auth: %v", err)
+	}
+}
+
+func TestRedisCheckUnreachable(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := RedisCheck("redis://" + addr)(ctx); err == nil {
+		t.Fatal("expected a closed port to fail the check")
+	}
+}
+
+func TestRedisCheckHonoursDeadline(t *testing.T) {
+	// A listener that accepts but never replies.
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	accepted := make(chan net.Conn, 1)
+	t.Cleanup(func() {
+		lis.Close()
+		if conn, ok := <-accepted; ok {
+			conn.Close()
+		}
+	})
+	go func() {
+		defer close(accepted)
+		if conn, err := lis.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := RedisCheck("redis://" + lis.Addr().String())(ctx); err == nil {
+		t.Fatal("expected a silent server to fail the check")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("check ignored the context deadline, took %s", elapsed)
+	}
+}
+
+func TestHTTPCheck(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/readyz" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	check := HTTPCheck(srv.Client(), srv.URL+"/readyz")
+	ctx := context.Background()
+	if err := check(ctx); err != nil {
+		t.Fatalf("2xx should pass: %v", err)
+	}
+	status = http.StatusServiceUnavailable
+	if err := check(ctx); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Fatalf("expected a 503 failure, got %v", err)
+	}
+
+	if err := HTTPCheck(nil, srv.URL+"/readyz")(ctx); err == nil {
+		t.Fatal("nil client should fall back to the default client and still see the 503")
+	}
+}
+
+func TestHTTPCheckHonoursContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := HTTPCheck(srv.Client(), srv.URL)(ctx); err == nil {
+		t.Fatal("expected the context deadline to fail the check")
+	}
+}
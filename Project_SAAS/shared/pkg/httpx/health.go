@@ -0,0 +1,231 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	statusOK       = "ok"
+	statusFail     = "fail"
+	statusDraining = "draining"
+)
+
+// CheckFunc probes a single dependency and returns nil when it is usable.
+type CheckFunc func(ctx context.Context) error
+
+// CheckOption tunes a registered check.
+type CheckOption func(*check)
+
+// WithTimeout bounds how long a single probe may take (default 1s).
+func WithTimeout(d time.Duration) CheckOption {
+	return func(c *check) { c.timeout = d }
+}
+
+// WithCacheTTL reuses the last result for d so frequent probes do not hammer the dependency (default 2s).
+func WithCacheTTL(d time.Duration) CheckOption {
+	return func(c *check) { c.ttl = d }
+}
+
+// Optional reports the check in /readyz without letting its failure mark the service unready.
+func Optional() CheckOption {
+	return func(c *check) { c.optional = true }
+}
+
+// CheckResult is the per-dependency entry in the readiness report.
+type CheckResult struct {
+	Status     string    `json:"status"`
+	Optional   bool      `json:"optional,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+// Report is the JSON body served by /readyz.
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type check struct {
+	name     string
+	fn       CheckFunc
+	timeout  time.Duration
+	ttl      time.Duration
+	optional bool
+
+	// mu guards the fields below only; the check itself runs without it.
+	mu     sync.Mutex
+	last   CheckResult
+	cached bool
+	flight *flight
+}
+
+// flight is a probe in progress. Concurrent callers wait for it instead of probing again.
+type flight struct {
+	done chan struct{}
+	res  CheckResult
+	// shared is false when the probe failed because its caller went away; the result says nothing
+	// about the dependency, so waiters probe again and it is not cached.
+	shared bool
+}
+
+func (c *check) run(ctx context.Context) CheckResult {
+	for {
+		c.mu.Lock()
+		if c.cached && time.Since(c.last.CheckedAt) < c.ttl {
+			res := c.last
+			c.mu.Unlock()
+			return res
+		}
+		f := c.flight
+		if f == nil {
+			f = &flight{done: make(chan struct{})}
+			c.flight = f
+			c.mu.Unlock()
+			return c.lead(ctx, f)
+		}
+		c.mu.Unlock()
+		select {
+		case <-f.done:
+			if f.shared {
+				return f.res
+			}
+		case <-ctx.Done():
+			return c.result(time.Now(), ctx.Err())
+		}
+	}
+}
+
+// lead runs the probe for f and publishes its result to waiting callers. A failure is cached only when
+// it was not caused by ctx ending, such as a client disconnecting mid-probe.
+func (c *check) lead(ctx context.Context, f *flight) CheckResult {
+	defer func() {
+		c.mu.Lock()
+		c.flight = nil
+		c.mu.Unlock()
+		close(f.done)
+	}()
+	probeCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	start := time.Now()
+	err := c.fn(probeCtx)
+	res := c.result(start, err)
+	shared := err == nil || ctx.Err() == nil
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if shared {
+		c.last, c.cached = res, true
+	}
+	f.res, f.shared = res, shared
+	return res
+}
+
+func (c *check) result(start time.Time, err error) CheckResult {
+	res := CheckResult{
+		Status:     statusOK,
+		Optional:   c.optional,
+		DurationMS: time.Since(start).Milliseconds(),
+		CheckedAt:  start,
+	}
+	if err != nil {
+		res.Status = statusFail
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// Health backs the /livez and /readyz endpoints served by Run.
+type Health struct {
+	mu       sync.RWMutex
+	checks   []*check
+	draining atomic.Bool
+}
+
+// NewHealth returns an empty Health with no registered checks.
+func NewHealth() *Health {
+	return &Health{}
+}
+
+var defaultHealth = NewHealth()
+
+// RegisterCheck adds a readiness check to the Health served by Run.
+func RegisterCheck(name string, fn CheckFunc, opts ...CheckOption) {
+	defaultHealth.Register(name, fn, opts...)
+}
+
+// Register adds a readiness check. Registering the same name twice replaces the earlier check.
+func (h *Health) Register(name string, fn CheckFunc, opts ...CheckOption) {
+	c := &check{name: name, fn: fn, timeout: time.Second, ttl: 2 * time.Second}
+	for _, opt := range opts {
+		opt(c)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, existing := range h.checks {
+		if existing.name == name {
+			h.checks[i] = c
+			return
+		}
+	}
+	h.checks = append(h.checks, c)
+}
+
+// Drain marks the service unready so load balancers stop routing to it ahead of shutdown.
+func (h *Health) Drain() {
+	h.draining.Store(true)
+}
+
+// Ready runs every check concurrently and summarises the result.
+func (h *Health) Ready(ctx context.Context) Report {
+	if h.draining.Load() {
+		return Report{Status: statusDraining}
+	}
+	h.mu.RLock()
+	checks := append([]*check(nil), h.checks...)
+	h.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			results[i] = c.run(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{Status: statusOK, Checks: make(map[string]CheckResult, len(checks))}
+	for i, c := range checks {
+		report.Checks[c.name] = results[i]
+		if results[i].Status != statusOK && !c.optional {
+			report.Status = statusFail
+		}
+	}
+	return report
+}
+
+// LiveHandler reports that the process is up and serving; it never consults dependencies, so a database
+// outage does not get the pod restarted.
+func (h *Health) LiveHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, Report{Status: statusOK})
+	}
+}
+
+// ReadyHandler serves the readiness report, answering 503 unless every required check passes.
+func (h *Health) ReadyHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := h.Ready(r.Context())
+		code := http.StatusOK
+		if report.Status != statusOK {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, report)
+	}
+}
@@ -0,0 +1,137 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHealthReadyReport(t *testing.T) {
+	h := NewHealth()
+	h.Register("db", func(context.Context) error { return nil })
+	h.Register("cache", func(context.Context) error { return errors.New("down") }, Optional())
+
+	report := h.Ready(context.Background())
+	if report.Status != statusOK {
+		t.Fatalf("optional failure should not fail readiness, got %+v", report)
+	}
+	if report.Checks["cache"].Status != statusFail || report.Checks["cache"].Error != "down" {
+		t.Fatalf("expected cache failure in breakdown, got %+v", report.Checks["cache"])
+	}
+
+	h.Register("db", func(context.Context) error { return errors.New("refused") })
+	rec := httptest.NewRecorder()
+	h.ReadyHandler()(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 when a required check fails, got %d", rec.Code)
+	}
+}
+
+func TestHealthCheckTimeoutAndCache(t *testing.T) {
+	h := NewHealth()
+	calls := 0
+	h.Register("slow", func(ctx context.Context) error {
+		calls++
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithTimeout(10*time.Millisecond), WithCacheTTL(time.Minute))
+
+	first := h.Ready(context.Background())
+	if first.Checks["slow"].Status != statusFail {
+		t.Fatalf("expected timeout to fail the check, got %+v", first.Checks["slow"])
+	}
+	h.Ready(context.Background())
+	if calls != 1 {
+		t.Fatalf("expected cached result on second probe, got %d calls", calls)
+	}
+}
+
+func TestHealthCheckDoesNotCacheCallerCancellation(t *testing.T) {
+	h := NewHealth()
+	calls := 0
+	h.Register("db", func(ctx context.Context) error {
+		calls++
+		return ctx.Err()
+	}, WithCacheTTL(time.Minute))
+
+	gone, cancel := context.WithCancel(context.Background())
+	cancel()
+	if got := h.Ready(gone).Checks["db"]; got.Status != statusFail {
+		t.Fatalf("expected the cancelled probe to fail, got %+v", got)
+	}
+	if got := h.Ready(context.Background()).Checks["db"]; got.Status != statusOK {
+		t.Fatalf("a disconnected client's failure was cached: %+v", got)
+	}
+	if calls != 2 {
+		t.Fatalf("expected the dependency to be probed again, got %d calls", calls)
+	}
+}
+
+func TestHealthConcurrentProbesShareOneCheck(t *testing.T) {
+	h := NewHealth()
+	var calls atomic.Int32
+	started, release := make(chan struct{}), make(chan struct{})
+	h.Register("db", func(ctx context.Context) error {
+		calls.Add(1)
+		close(started)
+		<-release
+		return nil
+	}, WithTimeout(time.Minute), WithCacheTTL(time.Minute))
+
+	const probes = 5
+	var wg sync.WaitGroup
+	reports := make([]Report, probes)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		reports[0] = h.Ready(context.Background())
+	}()
+	<-started
+
+	// A probe with its own short deadline is not queued behind the slow check.
+	short, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if got := h.Ready(short).Checks["db"]; got.Status != statusFail {
+		t.Fatalf("expected the impatient probe to give up, got %+v", got)
+	}
+
+	for i := 1; i < probes; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			reports[i] = h.Ready(context.Background())
+		}(i)
+	}
+	close(release)
+	wg.Wait()
+	for i, r := range reports {
+		if r.Status != statusOK {
+			t.Fatalf("probe %d: %+v", i, r)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected one dependency call, got %d", n)
+	}
+}
+
+func TestHealthDrain(t *testing.T) {
+	h := NewHealth()
+	h.Register("db", func(context.Context) error { return nil })
+	h.Drain()
+
+	rec := httptest.NewRecorder()
+	h.ReadyHandler()(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 while draining, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	h.LiveHandler()(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("liveness should stay ok while draining, got %d", rec.Code)
+	}
+}
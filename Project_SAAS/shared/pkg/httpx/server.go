@@ -2,8 +2,10 @@ package httpx
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -15,7 +17,37 @@ import (
 	"project_saas/shared/pkg/config"
 )
 
-// Run spins up an HTTP server with graceful shutdown.
+var (
+	shutdownMu    sync.Mutex
+	shutdownHooks []func(ctx context.Context) error
+)
+
+// OnShutdown registers fn to run once Run's server has stopped, so that background work started by a
+// register func (outbox relays, broker connections) stops with the service. Hooks run in reverse order
+// of registration and share a 10s deadline.
+func OnShutdown(fn func(ctx context.Context) error) {
+	shutdownMu.Lock()
+	defer shutdownMu.Unlock()
+	shutdownHooks = append(shutdownHooks, fn)
+}
+
+func runShutdownHooks(log *zap.Logger) {
+	shutdownMu.Lock()
+	hooks := shutdownHooks
+	shutdownHooks = nil
+	shutdownMu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := hooks[i](ctx); err != nil {
+			log.Warn("shutdown hook failed", zap.Error(err))
+		}
+	}
+}
+
+// Run spins up an HTTP server with graceful shutdown. It serves /livez and /readyz (with /health kept as
+// an alias of /readyz) from the checks added via RegisterCheck. On shutdown readiness flips to
+// "draining" for cfg.ShutdownDrainDelay before the listener closes, so load balancers stop routing first.
 func Run(ctx context.Context, cfg config.ServiceConfig, log *zap.Logger, register func(r chi.Router)) error {
 	if register == nil {
 		return fmt.Errorf("register func required")
@@ -27,7 +59,11 @@ func Run(ctx context.Context, cfg config.ServiceConfig, log *zap.Logger, registe
 	r.Use(RequestLogger(log))
 	r.Use(otelchi.Middleware(cfg.ServiceName, otelchi.WithChiRoutes(r)))
 	r.Handle("/metrics", promhttp.Handler())
+	r.Get("/livez", defaultHealth.LiveHandler())
+	r.Get("/readyz", defaultHealth.ReadyHandler())
+	r.Get("/health", defaultHealth.ReadyHandler())
 	register(r)
+	defer runShutdownHooks(log)
 
 	srv := &http.Server{
 		Addr:              ":" + cfg.HTTPPort,
@@ -45,6 +81,13 @@ func Run(ctx context.Context, cfg config.ServiceConfig, log *zap.Logger, registe
 
 	select {
 	case <-ctx.Done():
+		defaultHealth.Drain()
+		log.Info("http server draining", zap.Duration("delay", cfg.ShutdownDrainDelay))
+		select {
+		case <-time.After(cfg.ShutdownDrainDelay):
+		case err := <-errCh:
+			return err
+		}
 		log.Info("http server shutting down")
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -56,3 +99,9 @@ func Run(ctx context.Context, cfg config.ServiceConfig, log *zap.Logger, registe
 		return err
 	}
 }
+
+func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(payload)
+}
@@ -1,17 +1,16 @@
 package logger
 
 import (
-	"os"
-
 	"go.uber.org/zap"
 )
 
-// New creates a structured zap logger with sane defaults for local development.
-func New(service string) (*zap.Logger, error) {
+// New creates a structured zap logger for service; env is the loaded APP_ENV, and "development" gets
+// zap's human-readable development config.
+func New(service, env string) (*zap.Logger, error) {
 	cfg := zap.NewProductionConfig()
 	cfg.OutputPaths = []string{"stdout"}
 	cfg.ErrorOutputPaths = []string{"stderr"}
-	if os.Getenv("APP_ENV") == "development" {
+	if env == "development" {
 		cfg = zap.NewDevelopmentConfig()
 	}
 	cfg.InitialFields = map[string]interface{}{
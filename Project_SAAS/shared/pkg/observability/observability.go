@@ -2,7 +2,6 @@ package observability
 
 import (
 	"context"
-	"os"
 	"strings"
 	"time"
 
@@ -15,19 +14,21 @@ import (
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
 	"go.uber.org/zap"
+
+	"project_saas/shared/pkg/config"
 )
 
 // ShutdownFunc releases telemetry exporters.
 type ShutdownFunc func(ctx context.Context) error
 
-// Setup initializes OpenTelemetry tracing and propagation.
-func Setup(ctx context.Context, service string, log *zap.Logger) (ShutdownFunc, error) {
-	if strings.EqualFold(os.Getenv("OBSERVABILITY_DISABLED"), "true") {
-		log.Info("observability disabled via env flag")
+// Setup initializes OpenTelemetry tracing and propagation for cfg.ServiceName.
+func Setup(ctx context.Context, cfg config.ServiceConfig, log *zap.Logger) (ShutdownFunc, error) {
+	if cfg.ObservabilityDisabled {
+		log.Info("observability disabled via config")
 		return func(context.Context) error { return nil }, nil
 	}
 
-	exporter, exporterName, err := buildExporter(ctx)
+	exporter, exporterName, err := buildExporter(ctx, cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -38,7 +39,7 @@ func Setup(ctx context.Context, service string, log *zap.Logger) (ShutdownFunc,
 		resource.WithProcess(),
 		resource.WithHost(),
 		resource.WithAttributes(
-			semconv.ServiceName(service),
+			semconv.ServiceName(cfg.ServiceName),
 		),
 	)
 	if err != nil {
@@ -58,11 +59,11 @@ func Setup(ctx context.Context, service string, log *zap.Logger) (ShutdownFunc,
 	return tp.Shutdown, nil
 }
 
-func buildExporter(ctx context.Context) (sdktrace.SpanExporter, string, error) {
-	endpoint := strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"))
+func buildExporter(ctx context.Context, cfg config.ServiceConfig) (sdktrace.SpanExporter, string, error) {
+	endpoint := strings.TrimSpace(cfg.OTLPEndpoint)
 	if endpoint != "" {
 		normalized, insecure := normalizeEndpoint(endpoint)
-		path := strings.TrimPrefix(strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")), "/")
+		path := strings.TrimPrefix(strings.TrimSpace(cfg.OTLPTracesPath), "/")
 		if path == "" {
 			path = "v1/traces"
 		}
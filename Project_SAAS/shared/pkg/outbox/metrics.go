@@ -0,0 +1,30 @@
+package outbox
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	pendingMessages = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "outbox_pending_messages",
+		Help: "Outbox rows not yet published to the broker, by source.",
+	}, []string{"source"})
+	lagSeconds = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "outbox_lag_seconds",
+		Help: "Age of the oldest unpublished outbox row, by source.",
+	}, []string{"source"})
+	publishDelay = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "outbox_publish_delay_seconds",
+		Help:    "Time between an outbox row being committed and it being published.",
+		Buckets: prometheus.ExponentialBuckets(0.01, 2, 14),
+	})
+	published = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "outbox_published_total",
+		Help: "Outbox messages published, by subject.",
+	}, []string{"subject"})
+	publishFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "outbox_publish_failures_total",
+		Help: "Outbox publish attempts rejected by the broker, by subject.",
+	}, []string{"subject"})
+)
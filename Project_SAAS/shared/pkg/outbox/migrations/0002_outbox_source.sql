@@ -0,0 +1,13 @@
+-- Services share the outbox table; each relay only publishes the rows its own service wrote.
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT '';
+-- A row that fails to publish backs off instead of blocking the rows behind it.
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+-- Rows written before source existed belong to the service owning their subject's stream.
+UPDATE outbox SET source = CASE split_part(subject, '.', 1)
+    WHEN 'users' THEN 'user-service'
+    WHEN 'subscriptions' THEN 'subscription-service'
+    ELSE source END
+WHERE source = '' AND published_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS outbox_pending_source_idx ON outbox (source, id) WHERE published_at IS NULL;
@@ -0,0 +1,62 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+)
+
+// JetStreamPublisher publishes outbox messages to a NATS JetStream stream. The outbox row id is sent as
+// Nats-Msg-Id so the stream's duplicate window absorbs redeliveries after a relay crash.
+type JetStreamPublisher struct {
+	js       nats.JetStreamContext
+	stream   string
+	subjects []string
+
+	mu      sync.Mutex
+	ensured bool
+}
+
+// NewJetStreamPublisher binds to nc and lazily creates stream (capturing subjects) on first publish.
+func NewJetStreamPublisher(nc *nats.Conn, stream string, subjects ...string) (*JetStreamPublisher, error) {
+	js, err := nc.JetStream()
+	if err != nil {
+		return nil, err
+	}
+	return &JetStreamPublisher{js: js, stream: stream, subjects: subjects}, nil
+}
+
+// Publish waits for the JetStream ack before returning.
+func (p *JetStreamPublisher) Publish(ctx context.Context, msg Message) error {
+	if err := p.ensureStream(); err != nil {
+		return err
+	}
+	out := nats.NewMsg(msg.Subject)
+	out.Data = msg.Payload
+	out.Header.Set(nats.MsgIdHdr, strconv.FormatInt(msg.ID, 10))
+	out.Header.Set("Aggregate-Type", msg.AggregateType)
+	out.Header.Set("Aggregate-Id", msg.AggregateID)
+	_, err := p.js.PublishMsg(out, nats.Context(ctx))
+	return err
+}
+
+func (p *JetStreamPublisher) ensureStream() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ensured {
+		return nil
+	}
+	_, err := p.js.StreamInfo(p.stream)
+	if errors.Is(err, nats.ErrStreamNotFound) {
+		_, err = p.js.AddStream(&nats.StreamConfig{Name: p.stream, Subjects: p.subjects})
+	}
+	if err != nil {
+		return fmt.Errorf("ensure stream %s: %w", p.stream, err)
+	}
+	p.ensured = true
+	return nil
+}
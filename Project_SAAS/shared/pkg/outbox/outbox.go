@@ -0,0 +1,64 @@
+package outbox
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Migrations holds the outbox table schema; apply it with migrate.Run(ctx, pool, outbox.Migrations, "migrations").
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
+
+// Message is an event recorded in the same transaction as the business change that produced it.
+type Message struct {
+	ID int64
+	// Source names the service that wrote the message; only that service's relay publishes it.
+	Source        string
+	AggregateType string
+	AggregateID   string
+	Subject       string
+	Payload       []byte
+	CreatedAt     time.Time
+	// Attempts counts failed publishes so far.
+	Attempts int
+}
+
+// NewMessage marshals payload to JSON and returns a message from source destined for subject.
+func NewMessage(source, subject, aggregateType, aggregateID string, payload interface{}) (Message, error) {
+	if source == "" {
+		return Message{}, fmt.Errorf("outbox source required")
+	}
+	if subject == "" {
+		return Message{}, fmt.Errorf("outbox subject required")
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Message{}, fmt.Errorf("marshal outbox payload: %w", err)
+	}
+	return Message{
+		Source:        source,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Subject:       subject,
+		Payload:       data,
+	}, nil
+}
+
+// Write stores msg inside tx so it commits or rolls back together with the caller's own writes.
+func Write(ctx context.Context, tx pgx.Tx, msg Message) error {
+	if msg.Source == "" {
+		return fmt.Errorf("write outbox message: source required")
+	}
+	_, err := tx.Exec(ctx, `INSERT INTO outbox (source, aggregate_type, aggregate_id, subject, payload) VALUES ($1, $2, $3, $4, $5)`,
+		msg.Source, msg.AggregateType, msg.AggregateID, msg.Subject, msg.Payload)
+	if err != nil {
+		return fmt.Errorf("write outbox message: %w", err)
+	}
+	return nil
+}
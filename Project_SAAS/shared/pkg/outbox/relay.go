@@ -0,0 +1,171 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Publisher delivers a message to the broker. It must only return nil once the broker has accepted the message.
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// RelayConfig tunes how aggressively the relay drains the outbox.
+type RelayConfig struct {
+	BatchSize    int
+	PollInterval time.Duration
+	// RetryBackoff is the wait after a message's first failed publish; it doubles with each further
+	// failure up to MaxBackoff.
+	RetryBackoff time.Duration
+	MaxBackoff   time.Duration
+}
+
+// Relay polls the outbox and forwards source's pending messages to a Publisher with at-least-once
+// delivery.
+type Relay struct {
+	store  Store
+	source string
+	pub    Publisher
+	log    *zap.Logger
+	cfg    RelayConfig
+}
+
+// NewRelay builds a Relay for the messages source writes, filling in defaults for zero config values.
+func NewRelay(store Store, source string, pub Publisher, log *zap.Logger, cfg RelayConfig) *Relay {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 5 * time.Minute
+	}
+	return &Relay{store: store, source: source, pub: pub, log: log.Named("outbox-relay").With(zap.String("source", source)), cfg: cfg}
+}
+
+// Start runs the relay in the background. The returned stop func cancels it and waits, bounded by
+// ctx, for the batch in flight to finish.
+func (r *Relay) Start() (stop func(ctx context.Context) error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.Run(ctx)
+	}()
+	return func(ctx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("stop outbox relay: %w", ctx.Err())
+		}
+	}
+}
+
+// Run drains the outbox until ctx is cancelled. A full batch is followed immediately by the next one so
+// a backlog clears without waiting for the poll interval.
+func (r *Relay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		claimed, err := r.Flush(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.log.Warn("outbox flush failed", zap.Error(err))
+		}
+		if err := r.observeBacklog(ctx); err != nil && ctx.Err() == nil {
+			r.log.Warn("outbox backlog probe failed", zap.Error(err))
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err == nil && claimed == r.cfg.BatchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Flush publishes one batch of due messages in id order, marks the published ones sent and returns how
+// many were claimed. A message that fails to publish backs off and the rest of the batch carries on,
+// except for later messages of the same aggregate, which wait for it so that they are not delivered
+// ahead of it. The publish errors are returned together.
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	batch, msgs, err := r.store.Claim(ctx, r.source, r.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	defer batch.Rollback(ctx)
+	if len(msgs) == 0 {
+		return 0, nil
+	}
+
+	type aggregate struct{ typ, id string }
+	blocked := make(map[aggregate]bool)
+	sent := make([]int64, 0, len(msgs))
+	var publishErrs []error
+	for _, msg := range msgs {
+		agg := aggregate{msg.AggregateType, msg.AggregateID}
+		if blocked[agg] {
+			continue
+		}
+		if err := r.pub.Publish(ctx, msg); err != nil {
+			blocked[agg] = true
+			publishErr := fmt.Errorf("publish outbox message %d: %w", msg.ID, err)
+			publishErrs = append(publishErrs, publishErr)
+			publishFailures.WithLabelValues(msg.Subject).Inc()
+			if err := batch.MarkFailed(ctx, msg.ID, publishErr.Error(), r.backoff(msg.Attempts+1)); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		sent = append(sent, msg.ID)
+		published.WithLabelValues(msg.Subject).Inc()
+		publishDelay.Observe(time.Since(msg.CreatedAt).Seconds())
+	}
+
+	if len(sent) > 0 {
+		if err := batch.MarkSent(ctx, sent); err != nil {
+			return 0, err
+		}
+	}
+	if err := batch.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return len(msgs), errors.Join(publishErrs...)
+}
+
+// backoff returns the wait after a message's attempts-th failure.
+func (r *Relay) backoff(attempts int) time.Duration {
+	d := r.cfg.RetryBackoff
+	for i := 1; i < attempts && d < r.cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, r.cfg.MaxBackoff)
+}
+
+func (r *Relay) observeBacklog(ctx context.Context) error {
+	pending, oldest, err := r.store.Backlog(ctx, r.source)
+	if err != nil {
+		return err
+	}
+	pendingMessages.WithLabelValues(r.source).Set(float64(pending))
+	if oldest == nil {
+		lagSeconds.WithLabelValues(r.source).Set(0)
+		return nil
+	}
+	lagSeconds.WithLabelValues(r.source).Set(time.Since(*oldest).Seconds())
+	return nil
+}
@@ -0,0 +1,293 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+)
+
+// memStore is an in-memory Store with the same claim rules as PostgresStore and a clock the test moves.
+type memStore struct {
+	mu   sync.Mutex
+	now  time.Time
+	rows []*memRow
+}
+
+type memRow struct {
+	msg         Message
+	publishedAt *time.Time
+	nextAttempt time.Time
+	lastError   string
+}
+
+func newMemStore() *memStore {
+	return &memStore{now: time.Now()}
+}
+
+func (s *memStore) add(source, aggregateID, subject string, age time.Duration) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := int64(len(s.rows) + 1)
+	s.rows = append(s.rows, &memRow{
+		msg:         Message{ID: id, Source: source, AggregateType: "user", AggregateID: aggregateID, Subject: subject, CreatedAt: s.now.Add(-age)},
+		nextAttempt: s.now,
+	})
+	return id
+}
+
+func (s *memStore) advance(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = s.now.Add(d)
+}
+
+func (s *memStore) row(id int64) memRow {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.rows[id-1]
+}
+
+func (s *memStore) Claim(_ context.Context, source string, limit int) (Batch, []Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	backingOff := make(map[string]bool)
+	var out []Message
+	for _, r := range s.rows {
+		if r.msg.Source != source || r.publishedAt != nil {
+			continue
+		}
+		key := r.msg.AggregateType + "/" + r.msg.AggregateID
+		if r.nextAttempt.After(s.now) {
+			backingOff[key] = true
+			continue
+		}
+		if backingOff[key] || len(out) == limit {
+			continue
+		}
+		out = append(out, r.msg)
+	}
+	return &memBatch{store: s}, out, nil
+}
+
+func (s *memStore) Backlog(_ context.Context, source string) (int64, *time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var pending int64
+	var oldest *time.Time
+	for _, r := range s.rows {
+		if r.msg.Source == source && r.publishedAt == nil {
+			pending++
+			if oldest == nil || r.msg.CreatedAt.Before(*oldest) {
+				created := r.msg.CreatedAt
+				oldest = &created
+			}
+		}
+	}
+	return pending, oldest, nil
+}
+
+// memBatch buffers its changes until Commit, like the transaction behind a postgresBatch.
+type memBatch struct {
+	store   *memStore
+	pending []func(now time.Time)
+}
+
+func (b *memBatch) MarkSent(_ context.Context, ids []int64) error {
+	b.pending = append(b.pending, func(now time.Time) {
+		for _, id := range ids {
+			r := b.store.rows[id-1]
+			r.publishedAt, r.lastError = &now, ""
+		}
+	})
+	return nil
+}
+
+func (b *memBatch) MarkFailed(_ context.Context, id int64, reason string, retryIn time.Duration) error {
+	b.pending = append(b.pending, func(now time.Time) {
+		r := b.store.rows[id-1]
+		r.msg.Attempts++
+		r.lastError, r.nextAttempt = reason, now.Add(retryIn)
+	})
+	return nil
+}
+
+func (b *memBatch) Commit(context.Context) error {
+	b.store.mu.Lock()
+	defer b.store.mu.Unlock()
+	for _, apply := range b.pending {
+		apply(b.store.now)
+	}
+	b.pending = nil
+	return nil
+}
+
+func (b *memBatch) Rollback(context.Context) error {
+	b.pending = nil
+	return nil
+}
+
+// recordingPublisher records published message IDs and fails those listed in failing.
+type recordingPublisher struct {
+	mu      sync.Mutex
+	sent    []int64
+	failing map[int64]bool
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, msg Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failing[msg.ID] {
+		return errors.New("broker unavailable")
+	}
+	p.sent = append(p.sent, msg.ID)
+	return nil
+}
+
+func (p *recordingPublisher) published() []int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.sent)
+}
+
+func gaugeValue(t *testing.T, g prometheus.Gauge) float64 {
+	t.Helper()
+	var m dto.Metric
+	if err := g.Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetGauge().GetValue()
+}
+
+func TestRelayPublishesOwnSourceAndMarksSent(t *testing.T) {
+	store := newMemStore()
+	own1 := store.add("user-service", "u1", "users.created", 0)
+	other := store.add("subscription-service", "t1", "subscriptions.activated", 0)
+	own2 := store.add("user-service", "u2", "users.created", 0)
+	pub := &recordingPublisher{}
+	relay := NewRelay(store, "user-service", pub, zap.NewNop(), RelayConfig{})
+
+	n, err := relay.Flush(context.Background())
+	if err != nil || n != 2 {
+		t.Fatalf("flush: claimed %d, err %v", n, err)
+	}
+	if got := pub.published(); !slices.Equal(got, []int64{own1, own2}) {
+		t.Fatalf("published %v, want only user-service rows in id order", got)
+	}
+	if store.row(own1).publishedAt == nil || store.row(own2).publishedAt == nil {
+		t.Fatal("published rows not marked sent")
+	}
+	if store.row(other).publishedAt != nil {
+		t.Fatal("another service's row was marked sent")
+	}
+	if n, err := relay.Flush(context.Background()); n != 0 || err != nil {
+		t.Fatalf("second flush: claimed %d, err %v", n, err)
+	}
+}
+
+func TestRelaySkipsPastFailingMessage(t *testing.T) {
+	store := newMemStore()
+	poison := store.add("user-service", "u1", "users.created", 0)
+	sameAggregate := store.add("user-service", "u1", "users.updated", 0)
+	independent := store.add("user-service", "u2", "users.created", 0)
+	pub := &recordingPublisher{failing: map[int64]bool{poison: true}}
+	relay := NewRelay(store, "user-service", pub, zap.NewNop(), RelayConfig{RetryBackoff: time.Second, MaxBackoff: 3 * time.Second})
+
+	if _, err := relay.Flush(context.Background()); err == nil {
+		t.Fatal("expected the publish failure to be reported")
+	}
+	if got := pub.published(); !slices.Equal(got, []int64{independent}) {
+		t.Fatalf("published %v: the failure must not hold back other aggregates, and must hold back its own", got)
+	}
+	row := store.row(poison)
+	if row.msg.Attempts != 1 || row.lastError == "" || !row.nextAttempt.Equal(store.now.Add(time.Second)) {
+		t.Fatalf("failed row = %+v, want one attempt backing off 1s", row)
+	}
+
+	// Backing off: nothing of u1 is due, not even the later message.
+	if n, _ := relay.Flush(context.Background()); n != 0 {
+		t.Fatalf("claimed %d messages during the backoff", n)
+	}
+
+	// Each further failure doubles the backoff, up to MaxBackoff.
+	for _, want := range []time.Duration{2 * time.Second, 3 * time.Second, 3 * time.Second} {
+		store.advance(time.Hour)
+		relay.Flush(context.Background())
+		if row := store.row(poison); !row.nextAttempt.Equal(store.now.Add(want)) {
+			t.Fatalf("attempt %d backs off until %v, want %v", row.msg.Attempts, row.nextAttempt.Sub(store.now), want)
+		}
+	}
+
+	pub.mu.Lock()
+	pub.failing = nil
+	pub.mu.Unlock()
+	store.advance(time.Hour)
+	if _, err := relay.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := pub.published(); !slices.Equal(got, []int64{independent, poison, sameAggregate}) {
+		t.Fatalf("published %v, want the retried message before the one queued behind it", got)
+	}
+	if row := store.row(poison); row.publishedAt == nil || row.lastError != "" {
+		t.Fatalf("retried row = %+v", row)
+	}
+}
+
+func TestRelayBacklogMetrics(t *testing.T) {
+	store := newMemStore()
+	store.add("lag-test", "u1", "users.created", 90*time.Second)
+	store.add("lag-test", "u2", "users.created", 30*time.Second)
+	store.add("other-lag-test", "t1", "subscriptions.activated", time.Hour)
+	relay := NewRelay(store, "lag-test", &recordingPublisher{}, zap.NewNop(), RelayConfig{})
+
+	if err := relay.observeBacklog(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := gaugeValue(t, pendingMessages.WithLabelValues("lag-test")); got != 2 {
+		t.Fatalf("pending = %v, want 2", got)
+	}
+	if got := gaugeValue(t, lagSeconds.WithLabelValues("lag-test")); got < 90 || got > 95 {
+		t.Fatalf("lag = %vs, want about 90s: the oldest own row, not the other source's", got)
+	}
+
+	if _, err := relay.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	relay.observeBacklog(context.Background())
+	if pending, lag := gaugeValue(t, pendingMessages.WithLabelValues("lag-test")), gaugeValue(t, lagSeconds.WithLabelValues("lag-test")); pending != 0 || lag != 0 {
+		t.Fatalf("after flushing: pending %v, lag %v", pending, lag)
+	}
+}
+
+func TestRelayStartStop(t *testing.T) {
+	store := newMemStore()
+	id := store.add("user-service", "u1", "users.created", 0)
+	pub := &recordingPublisher{}
+	stop := NewRelay(store, "user-service", pub, zap.NewNop(), RelayConfig{PollInterval: 10 * time.Millisecond}).Start()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(pub.published()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := pub.published(); !slices.Equal(got, []int64{id}) {
+		t.Fatalf("published %v", got)
+	}
+
+	// Rows written after the stop stay pending.
+	store.add("user-service", "u2", "users.created", 0)
+	time.Sleep(30 * time.Millisecond)
+	if got := pub.published(); len(got) != 1 {
+		t.Fatalf("relay kept publishing after stop: %v", got)
+	}
+}
@@ -0,0 +1,197 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Store is the relay's view of the outbox table.
+type Store interface {
+	// Claim locks up to limit due messages written by source, in id order. Messages of one aggregate
+	// are claimed from its oldest unpublished one onwards, stopping at the first that is backing off,
+	// and only by one claimer at a time, so no message is ever delivered ahead of an earlier one of the
+	// same aggregate.
+	Claim(ctx context.Context, source string, limit int) (Batch, []Message, error)
+	// Backlog reports how many of source's messages are unpublished and when the oldest was written.
+	Backlog(ctx context.Context, source string) (pending int64, oldest *time.Time, err error)
+}
+
+// Batch records the outcome of publishing claimed messages. Nothing is saved until Commit.
+type Batch interface {
+	MarkSent(ctx context.Context, ids []int64) error
+	// MarkFailed counts a failed attempt and makes the message due again after retryIn.
+	MarkFailed(ctx context.Context, id int64, reason string, retryIn time.Duration) error
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+// PostgresStore keeps the outbox in the table created by Migrations. Several relays may share it: each
+// claim takes a transaction-scoped advisory lock per aggregate, so an aggregate's messages are
+// published by one relay at a time while different aggregates are spread across relays.
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresStore returns a Store backed by pool.
+func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{pool: pool}
+}
+
+func (s *PostgresStore) Claim(ctx context.Context, source string, limit int) (Batch, []Message, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("begin outbox tx: %w", err)
+	}
+	batch, err := claim(ctx, tx, source, limit)
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, nil, fmt.Errorf("claim outbox batch: %w", err)
+	}
+	return postgresBatch{tx: tx}, batch, nil
+}
+
+// claim runs in three statements. The first lists the aggregates with the oldest unpublished
+// messages, leaving out those whose oldest message is backing off. The second takes an advisory lock on each that no other relay holds; the locks last until
+// tx ends. The third reads the locked aggregates' unpublished messages with a snapshot taken after
+// the locks, so it sees everything the previous holder committed. A message is only returned if it
+// and every earlier message of its aggregate are due.
+func claim(ctx context.Context, tx pgx.Tx, source string, limit int) ([]Message, error) {
+	var types, ids []string
+	rows, err := tx.Query(ctx, `
+SELECT aggregate_type, aggregate_id
+FROM (
+	SELECT DISTINCT ON (aggregate_type, aggregate_id) id, aggregate_type, aggregate_id, next_attempt_at
+	FROM outbox
+	WHERE source = $1 AND published_at IS NULL
+	ORDER BY aggregate_type, aggregate_id, id
+) heads
+WHERE next_attempt_at <= NOW()
+ORDER BY id
+LIMIT $2
+`, source, limit)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var typ, id string
+		if err := rows.Scan(&typ, &id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		types, ids = append(types, typ), append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(types) == 0 {
+		return nil, nil
+	}
+
+	rows, err = tx.Query(ctx, `
+SELECT a.aggregate_type, a.aggregate_id
+FROM unnest($2::text[], $3::text[]) AS a(aggregate_type, aggregate_id)
+WHERE pg_try_advisory_xact_lock(hashtextextended($1::text || '/' || a.aggregate_type || '/' || a.aggregate_id, 0))
+`, source, types, ids)
+	if err != nil {
+		return nil, err
+	}
+	types, ids = types[:0], ids[:0]
+	for rows.Next() {
+		var typ, id string
+		if err := rows.Scan(&typ, &id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		types, ids = append(types, typ), append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(types) == 0 {
+		return nil, nil
+	}
+
+	rows, err = tx.Query(ctx, `
+SELECT o.id, o.source, o.aggregate_type, o.aggregate_id, o.subject, o.payload, o.created_at, o.attempts,
+	o.next_attempt_at <= NOW()
+FROM outbox o
+JOIN unnest($2::text[], $3::text[]) AS a(aggregate_type, aggregate_id)
+	ON o.aggregate_type = a.aggregate_type AND o.aggregate_id = a.aggregate_id
+WHERE o.source = $1 AND o.published_at IS NULL
+ORDER BY o.id
+LIMIT $4
+FOR UPDATE OF o
+`, source, types, ids, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	type aggregate struct{ typ, id string }
+	waiting := make(map[aggregate]bool)
+	var batch []Message
+	for rows.Next() {
+		var msg Message
+		var due bool
+		if err := rows.Scan(&msg.ID, &msg.Source, &msg.AggregateType, &msg.AggregateID, &msg.Subject, &msg.Payload, &msg.CreatedAt, &msg.Attempts, &due); err != nil {
+			return nil, err
+		}
+		agg := aggregate{msg.AggregateType, msg.AggregateID}
+		if !due {
+			// Everything after a message that is backing off waits for it.
+			waiting[agg] = true
+		}
+		if waiting[agg] {
+			continue
+		}
+		batch = append(batch, msg)
+	}
+	return batch, rows.Err()
+}
+
+func (s *PostgresStore) Backlog(ctx context.Context, source string) (int64, *time.Time, error) {
+	var pending int64
+	var oldest *time.Time
+	err := s.pool.QueryRow(ctx, `SELECT COUNT(*), MIN(created_at) FROM outbox WHERE source = $1 AND published_at IS NULL`, source).Scan(&pending, &oldest)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil, err
+	}
+	return pending, oldest, nil
+}
+
+type postgresBatch struct {
+	tx pgx.Tx
+}
+
+func (b postgresBatch) MarkSent(ctx context.Context, ids []int64) error {
+	if _, err := b.tx.Exec(ctx, `UPDATE outbox SET published_at = NOW(), attempts = attempts + 1, last_error = NULL WHERE id = ANY($1)`, ids); err != nil {
+		return fmt.Errorf("mark outbox messages sent: %w", err)
+	}
+	return nil
+}
+
+func (b postgresBatch) MarkFailed(ctx context.Context, id int64, reason string, retryIn time.Duration) error {
+	_, err := b.tx.Exec(ctx, `UPDATE outbox SET attempts = attempts + 1, last_error = $2, next_attempt_at = NOW() + make_interval(secs => $3) WHERE id = $1`,
+		id, reason, retryIn.Seconds())
+	if err != nil {
+		return fmt.Errorf("record outbox failure: %w", err)
+	}
+	return nil
+}
+
+func (b postgresBatch) Commit(ctx context.Context) error {
+	if err := b.tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit outbox tx: %w", err)
+	}
+	return nil
+}
+
+func (b postgresBatch) Rollback(ctx context.Context) error {
+	return b.tx.Rollback(ctx)
+}
@@ -0,0 +1,201 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"project_saas/shared/pkg/postgres/migrate"
+)
+
+// openTestTable connects to OUTBOX_TEST_POSTGRES_URL, skipping the test when it is not set, and
+// returns the pool with two sources unique to this run and a function that commits a message.
+func openTestTable(t *testing.T) (pool *pgxpool.Pool, own, other string, write func(source, aggregateID, subject string) int64) {
+	t.Helper()
+	url := os.Getenv("OUTBOX_TEST_POSTGRES_URL")
+	if url == "" {
+		t.Skip("OUTBOX_TEST_POSTGRES_URL not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	if err := migrate.Run(ctx, pool, Migrations, "migrations"); err != nil {
+		t.Fatal(err)
+	}
+	// Sources unique to this run keep it apart from other rows in the table.
+	own, other = fmt.Sprintf("test-%d", time.Now().UnixNano()), fmt.Sprintf("test-other-%d", time.Now().UnixNano())
+	t.Cleanup(func() { pool.Exec(ctx, `DELETE FROM outbox WHERE source = ANY($1)`, []string{own, other}) })
+
+	write = func(source, aggregateID, subject string) int64 {
+		t.Helper()
+		msg, err := NewMessage(source, subject, "user", aggregateID, map[string]string{"id": aggregateID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx, err := pool.Begin(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tx.Rollback(ctx)
+		if err := Write(ctx, tx, msg); err != nil {
+			t.Fatal(err)
+		}
+		var id int64
+		if err := tx.QueryRow(ctx, `SELECT currval(pg_get_serial_sequence('outbox', 'id'))`).Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	return pool, own, other, write
+}
+
+func ids(msgs []Message) []int64 {
+	out := make([]int64, 0, len(msgs))
+	for _, m := range msgs {
+		out = append(out, m.ID)
+	}
+	return out
+}
+
+// TestPostgresStore runs the relay against a real outbox table. It needs a Postgres database it may
+// create the outbox table in, given as OUTBOX_TEST_POSTGRES_URL.
+func TestPostgresStore(t *testing.T) {
+	ctx := context.Background()
+	pool, own, other, write := openTestTable(t)
+	poison := write(own, "u1", "users.created")
+	behind := write(own, "u1", "users.updated")
+	otherRow := write(other, "t1", "subscriptions.activated")
+	independent := write(own, "u2", "users.created")
+
+	store := NewPostgresStore(pool)
+	pub := &recordingPublisher{failing: map[int64]bool{poison: true}}
+	relay := NewRelay(store, own, pub, zap.NewNop(), RelayConfig{RetryBackoff: time.Hour})
+	if _, err := relay.Flush(ctx); err == nil {
+		t.Fatal("expected the publish failure to be reported")
+	}
+	if got := pub.published(); !slices.Equal(got, []int64{independent}) {
+		t.Fatalf("published %v, want %v", got, []int64{independent})
+	}
+
+	var attempts int
+	var lastError *string
+	var backingOff bool
+	if err := pool.QueryRow(ctx, `SELECT attempts, last_error, next_attempt_at > NOW() + interval '50 minutes' FROM outbox WHERE id = $1`, poison).
+		Scan(&attempts, &lastError, &backingOff); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 1 || lastError == nil || !backingOff {
+		t.Fatalf("failed row: attempts %d, last_error %v, backing off %v", attempts, lastError, backingOff)
+	}
+	var sent bool
+	if err := pool.QueryRow(ctx, `SELECT published_at IS NOT NULL FROM outbox WHERE id = $1`, independent).Scan(&sent); err != nil || !sent {
+		t.Fatalf("published row not marked sent (err %v)", err)
+	}
+
+	// The row behind the failing one, and the other source's row, are not claimed.
+	batch, msgs, err := store.Claim(ctx, own, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch.Rollback(ctx)
+	if len(msgs) != 0 {
+		t.Fatalf("claimed %d messages while %d backs off", len(msgs), poison)
+	}
+	pending, oldest, err := store.Backlog(ctx, own)
+	if err != nil || pending != 2 || oldest == nil {
+		t.Fatalf("backlog: %d pending, oldest %v, err %v", pending, oldest, err)
+	}
+
+	if _, err := pool.Exec(ctx, `UPDATE outbox SET next_attempt_at = NOW() WHERE id = $1`, poison); err != nil {
+		t.Fatal(err)
+	}
+	pub.failing = nil
+	if _, err := relay.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := pub.published(); !slices.Equal(got, []int64{independent, poison, behind}) {
+		t.Fatalf("published %v after the retry", got)
+	}
+	var unpublished bool
+	if err := pool.QueryRow(ctx, `SELECT published_at IS NULL FROM outbox WHERE id = $1`, otherRow).Scan(&unpublished); err != nil || !unpublished {
+		t.Fatalf("another source's row was published (err %v)", err)
+	}
+}
+
+// TestPostgresStoreClaimsAggregateOnce has two relays claim at the same time. While the first holds
+// an aggregate, the second must not take any of its messages, not even ones the first did not claim.
+func TestPostgresStoreClaimsAggregateOnce(t *testing.T) {
+	ctx := context.Background()
+	pool, own, _, write := openTestTable(t)
+	first := write(own, "u1", "users.created")
+	second := write(own, "u1", "users.updated")
+	third := write(own, "u1", "users.updated")
+	independent := write(own, "u2", "users.created")
+	store := NewPostgresStore(pool)
+
+	// Relay A claims only u1's first message.
+	batchA, msgsA, err := store.Claim(ctx, own, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer batchA.Rollback(ctx)
+	if got := ids(msgsA); !slices.Equal(got, []int64{first}) {
+		t.Fatalf("relay A claimed %v, want %v", got, []int64{first})
+	}
+
+	// Relay B skips the whole aggregate A holds and takes the independent one.
+	batchB, msgsB, err := store.Claim(ctx, own, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(msgsB); !slices.Equal(got, []int64{independent}) {
+		t.Fatalf("relay B claimed %v while A holds u1, want %v", got, []int64{independent})
+	}
+	if err := batchB.Rollback(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once A has published and committed, B continues with u1 in order.
+	if err := batchA.MarkSent(ctx, []int64{first}); err != nil {
+		t.Fatal(err)
+	}
+	if err := batchA.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	batchB, msgsB, err = store.Claim(ctx, own, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer batchB.Rollback(ctx)
+	if got := ids(msgsB); !slices.Equal(got, []int64{second, third, independent}) {
+		t.Fatalf("relay B claimed %v after A committed, want %v", got, []int64{second, third, independent})
+	}
+
+	// A failure recorded by B holds back the rest of the aggregate for the next claimer too.
+	if err := batchB.MarkFailed(ctx, second, "broker down", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := batchB.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	batchC, msgsC, err := store.Claim(ctx, own, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer batchC.Rollback(ctx)
+	if got := ids(msgsC); !slices.Equal(got, []int64{independent}) {
+		t.Fatalf("claimed %v while %d backs off, want only %v", got, second, []int64{independent})
+	}
+}
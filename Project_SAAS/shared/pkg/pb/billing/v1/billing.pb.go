@@ -0,0 +1,222 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: billing/v1/billing.proto
+
+package billingv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type RunBillingRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	TenantId string                 `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	// records defaults to 1,000,000 when zero.
+	Records       int32 `protobuf:"varint,2,opt,name=records,proto3" json:"records,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunBillingRequest) Reset() {
+	*x = RunBillingRequest{}
+	mi := &file_billing_v1_billing_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunBillingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunBillingRequest) ProtoMessage() {}
+
+func (x *RunBillingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_billing_v1_billing_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunBillingRequest.ProtoReflect.Descriptor instead.
+func (*RunBillingRequest) Descriptor() ([]byte, []int) {
+	return file_billing_v1_billing_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RunBillingRequest) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *RunBillingRequest) GetRecords() int32 {
+	if x != nil {
+		return x.Records
+	}
+	return 0
+}
+
+type RunBillingResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TenantId      string                 `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	Processed     int64                  `protobuf:"varint,2,opt,name=processed,proto3" json:"processed,omitempty"`
+	OpsPerSecond  float64                `protobuf:"fixed64,3,opt,name=ops_per_second,json=opsPerSecond,proto3" json:"ops_per_second,omitempty"`
+	DurationMs    int64                  `protobuf:"varint,4,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	Budget        string                 `protobuf:"bytes,5,opt,name=budget,proto3" json:"budget,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunBillingResponse) Reset() {
+	*x = RunBillingResponse{}
+	mi := &file_billing_v1_billing_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunBillingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunBillingResponse) ProtoMessage() {}
+
+func (x *RunBillingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_billing_v1_billing_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunBillingResponse.ProtoReflect.Descriptor instead.
+func (*RunBillingResponse) Descriptor() ([]byte, []int) {
+	return file_billing_v1_billing_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RunBillingResponse) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *RunBillingResponse) GetProcessed() int64 {
+	if x != nil {
+		return x.Processed
+	}
+	return 0
+}
+
+func (x *RunBillingResponse) GetOpsPerSecond() float64 {
+	if x != nil {
+		return x.OpsPerSecond
+	}
+	return 0
+}
+
+func (x *RunBillingResponse) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *RunBillingResponse) GetBudget() string {
+	if x != nil {
+		return x.Budget
+	}
+	return ""
+}
+
+var File_billing_v1_billing_proto protoreflect.FileDescriptor
+
+const file_billing_v1_billing_proto_rawDesc = "" +
+	"\n" +
+	"\x18billing/v1/billing.proto\x12\n" +
+	"billing.v1\"J\n" +
+	"\x11RunBillingRequest\x12\x1b\n" +
+	"\ttenant_id\x18\x01 \x01(\tR\btenantId\x12\x18\n" +
+	"\arecords\x18\x02 \x01(\x05R\arecords\"\xae\x01\n" +
+	"\x12RunBillingResponse\x12\x1b\n" +
+	"\ttenant_id\x18\x01 \x01(\tR\btenantId\x12\x1c\n" +
+	"\tprocessed\x18\x02 \x01(\x03R\tprocessed\x12$\n" +
+	"\x0eops_per_second\x18\x03 \x01(\x01R\fopsPerSecond\x12\x1f\n" +
+	"\vduration_ms\x18\x04 \x01(\x03R\n" +
+	"durationMs\x12\x16\n" +
+	"\x06budget\x18\x05 \x01(\tR\x06budget2]\n" +
+	"\x0eBillingService\x12K\n" +
+	"\n" +
+	"RunBilling\x12\x1d.billing.v1.RunBillingRequest\x1a\x1e.billing.v1.RunBillingResponseB1Z/project_saas/shared/pkg/pb/billing/v1;billingv1b\x06proto3"
+
+var (
+	file_billing_v1_billing_proto_rawDescOnce sync.Once
+	file_billing_v1_billing_proto_rawDescData []byte
+)
+
+func file_billing_v1_billing_proto_rawDescGZIP() []byte {
+	file_billing_v1_billing_proto_rawDescOnce.Do(func() {
+		file_billing_v1_billing_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_billing_v1_billing_proto_rawDesc), len(file_billing_v1_billing_proto_rawDesc)))
+	})
+	return file_billing_v1_billing_proto_rawDescData
+}
+
+var file_billing_v1_billing_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_billing_v1_billing_proto_goTypes = []any{
+	(*RunBillingRequest)(nil),  // 0: billing.v1.RunBillingRequest
+	(*RunBillingResponse)(nil), // 1: billing.v1.RunBillingResponse
+}
+var file_billing_v1_billing_proto_depIdxs = []int32{
+	0, // 0: billing.v1.BillingService.RunBilling:input_type -> billing.v1.RunBillingRequest
+	1, // 1: billing.v1.BillingService.RunBilling:output_type -> billing.v1.RunBillingResponse
+	1, // [1:2] is the sub-list for method output_type
+	0, // [0:1] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_billing_v1_billing_proto_init() }
+func file_billing_v1_billing_proto_init() {
+	if File_billing_v1_billing_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_billing_v1_billing_proto_rawDesc), len(file_billing_v1_billing_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_billing_v1_billing_proto_goTypes,
+		DependencyIndexes: file_billing_v1_billing_proto_depIdxs,
+		MessageInfos:      file_billing_v1_billing_proto_msgTypes,
+	}.Build()
+	File_billing_v1_billing_proto = out.File
+	file_billing_v1_billing_proto_goTypes = nil
+	file_billing_v1_billing_proto_depIdxs = nil
+}
@@ -1,12 +1,20 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"subcription/internal/billing/dunning"
 	"subcription/internal/billing/handler"
 	"subcription/internal/billing/repository"
 	"subcription/internal/billing/service"
 	"subcription/internal/platform/payment"
+	"sync"
+	"syscall"
+	"time"
 )
 
 /*
@@ -17,9 +25,14 @@ curl -X POST http://localhost:8080/subscribe \
     "user_id": "550e8400-e29b-41d4-a716-446655440000",
     "plan_id": "basic_plan",
     "amount": 999,
-    "payment_method": "card"
+    "currency": "INR",
+    "payment_method": "pm_card_visa"
   }'
 
+curl -X POST http://localhost:8080/subscriptions/<subscription-id>/cancel
+curl -X POST http://localhost:8080/payments/<payment-id>/refund
+curl http://localhost:8080/users/550e8400-e29b-41d4-a716-446655440000/payments
+
 
 */
 
@@ -37,12 +50,39 @@ func main() {
 	billingService := service.NewBillingService(repo, gateway)
 	billingHandler := handler.NewBillingHandler(billingService)
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Renews subscriptions and retries failed renewal charges until shutdown.
+	dunningCtx, stopDunning := context.WithCancel(ctx)
+	dunningEngine := dunning.NewEngine(repo, gateway, dunning.SystemClock, dunning.DefaultConfig(), dunning.LogNotifier{})
+	var dunningDone sync.WaitGroup
+	dunningDone.Add(1)
+	go func() {
+		defer dunningDone.Done()
+		dunningEngine.Run(dunningCtx, time.Minute)
+	}()
+
 	mux := http.NewServeMux()
-	mux.HandleFunc("/subscribe", billingHandler.Subscribe)
+	billingHandler.Register(mux)
+	srv := &http.Server{Addr: ":8080", Handler: mux}
 
-	log.Println("Starting server on :8080")
-	if err := http.ListenAndServe(":8080", mux); err != nil {
-		log.Fatalf("could not start server: %v\n", err)
-	}
+	go func() {
+		log.Println("Starting server on :8080")
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("could not start server: %v\n", err)
+		}
+	}()
 
+	<-ctx.Done()
+	log.Println("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("graceful shutdown failed: %v", err)
+	}
+	// Run returns once any renewal pass in progress has finished, so no charge is left half recorded.
+	stopDunning()
+	dunningDone.Wait()
+	log.Println("Server stopped")
 }
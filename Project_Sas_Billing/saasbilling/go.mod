@@ -2,4 +2,4 @@ module subcription
 
 go 1.25.5
 
-require github.com/google/uuid v1.6.0 // indirect
+require github.com/google/uuid v1.6.0
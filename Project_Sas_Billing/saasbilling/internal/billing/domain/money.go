@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultCurrency is used when a subscription request does not name one. Amounts are always in the
+// currency's minor unit (paise for INR).
+const DefaultCurrency = "INR"
+
+// ErrInvalidTransition is returned when a status change does not apply to the current status.
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// NormalizeCurrency returns the upper-case ISO 4217 code for currency, or DefaultCurrency when it is
+// empty. The same code is stored on the subscription and the payment and sent to the gateway.
+func NormalizeCurrency(currency string) (string, error) {
+	c := strings.ToUpper(strings.TrimSpace(currency))
+	if c == "" {
+		return DefaultCurrency, nil
+	}
+	if len(c) != 3 {
+		return "", fmt.Errorf("currency %q is not a 3-letter ISO code", currency)
+	}
+	for _, r := range c {
+		if r < 'A' || r > 'Z' {
+			return "", fmt.Errorf("currency %q is not a 3-letter ISO code", currency)
+		}
+	}
+	return c, nil
+}
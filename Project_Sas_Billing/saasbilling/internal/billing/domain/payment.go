@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,6 +14,9 @@ const (
 	PaymentCompleted PaymentStatus = "completed"
 	PaymentFailed    PaymentStatus = "failed"
 	PaymentRefunded  PaymentStatus = "refunded"
+	// PaymentRefunding: a refund was claimed and sent to the gateway but its outcome is not recorded
+	// yet. Only one caller can move a payment here, so a charge is never refunded twice.
+	PaymentRefunding PaymentStatus = "refunding"
 )
 
 type Payment struct {
@@ -21,9 +25,70 @@ type Payment struct {
 	SubscriptionID uuid.UUID
 	Amount         int64
 	Currency       string
+	PaymentMethod  string
+	// IdempotencyKey identifies the charge this payment records; it is sent to the gateway so that
+	// repeating the charge after a crash does not take the money twice. Empty for untracked payments.
+	IdempotencyKey string
 	Status         PaymentStatus
 	GatewayRef     string
+	FailureReason  string
 	PaymentDate    time.Time
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
+	// Version counts stored updates; like Subscription.Version it stops a stale copy from overwriting
+	// a concurrent change.
+	Version   int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Complete records a successful charge; ref is the gateway's charge ID, needed for refunds.
+func (p *Payment) Complete(ref string, now time.Time) error {
+	if p.Status != PaymentInitiated {
+		return fmt.Errorf("%w: cannot complete a %s payment", ErrInvalidTransition, p.Status)
+	}
+	p.Status = PaymentCompleted
+	p.GatewayRef = ref
+	p.PaymentDate = now
+	p.UpdatedAt = now
+	return nil
+}
+
+// Fail records a declined or errored charge.
+func (p *Payment) Fail(reason string, now time.Time) error {
+	if p.Status != PaymentInitiated {
+		return fmt.Errorf("%w: cannot fail a %s payment", ErrInvalidTransition, p.Status)
+	}
+	p.Status = PaymentFailed
+	p.FailureReason = reason
+	p.UpdatedAt = now
+	return nil
+}
+
+// BeginRefund claims a completed payment for refunding.
+func (p *Payment) BeginRefund(now time.Time) error {
+	if p.Status != PaymentCompleted {
+		return fmt.Errorf("%w: cannot refund a %s payment", ErrInvalidTransition, p.Status)
+	}
+	p.Status = PaymentRefunding
+	p.UpdatedAt = now
+	return nil
+}
+
+// AbortRefund returns a payment whose refund the gateway refused to completed.
+func (p *Payment) AbortRefund(now time.Time) error {
+	if p.Status != PaymentRefunding {
+		return fmt.Errorf("%w: cannot abort the refund of a %s payment", ErrInvalidTransition, p.Status)
+	}
+	p.Status = PaymentCompleted
+	p.UpdatedAt = now
+	return nil
+}
+
+// Refund records that a completed or refunding payment was returned in full.
+func (p *Payment) Refund(now time.Time) error {
+	if p.Status != PaymentCompleted && p.Status != PaymentRefunding {
+		return fmt.Errorf("%w: cannot refund a %s payment", ErrInvalidTransition, p.Status)
+	}
+	p.Status = PaymentRefunded
+	p.UpdatedAt = now
+	return nil
 }
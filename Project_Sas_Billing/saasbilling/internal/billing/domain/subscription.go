@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,14 +14,113 @@ const (
 	StatusActive    SubscriptionStatus = "active"
 	StatusCancelled SubscriptionStatus = "canceled"
 	StatusExpired   SubscriptionStatus = "expired"
+	// StatusGracePeriod: a renewal charge failed and is being retried; the subscriber keeps access.
+	StatusGracePeriod SubscriptionStatus = "grace_period"
+	// StatusPastDue: the grace period ended without a successful retry; access is suspended while the
+	// remaining retries run.
+	StatusPastDue SubscriptionStatus = "past_due"
+	// StatusFailed: the first charge did not go through, so the subscription never started. It is kept,
+	// rather than deleted, so the failed payment still links to it.
+	StatusFailed SubscriptionStatus = "failed"
 )
 
+// BillingPeriod is how long one successful charge keeps a subscription active.
+const BillingPeriod = 30 * 24 * time.Hour
+
 type Subscription struct {
-	ID        uuid.UUID
-	UserID    uuid.UUID
-	PlanID    string
-	Amount    int64
-	Status    SubscriptionStatus
+	ID               uuid.UUID
+	UserID           uuid.UUID
+	PlanID           string
+	Amount           int64
+	Currency         string
+	PaymentMethod    string
+	Status           SubscriptionStatus
+	CurrentPeriodEnd time.Time
+	// Dunning state, zero unless a renewal charge has failed.
+	FailedAttempts int
+	FirstFailureAt time.Time
+	NextRetryAt    time.Time
+	// Version counts stored updates. An update is only accepted against the version it was read at, so
+	// a writer holding a stale copy cannot overwrite a concurrent change such as a cancellation.
+	Version   int
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
+
+// InDunning reports whether a failed renewal charge is being retried.
+func (s *Subscription) InDunning() bool {
+	return s.Status == StatusGracePeriod || s.Status == StatusPastDue
+}
+
+// Activate marks a pending subscription as paid for until periodEnd.
+func (s *Subscription) Activate(now, periodEnd time.Time) error {
+	if s.Status != StatusPending {
+		return fmt.Errorf("%w: cannot activate a %s subscription", ErrInvalidTransition, s.Status)
+	}
+	s.Status = StatusActive
+	s.CurrentPeriodEnd = periodEnd
+	s.UpdatedAt = now
+	return nil
+}
+
+// Fail ends a pending subscription whose first charge did not go through.
+func (s *Subscription) Fail(now time.Time) error {
+	if s.Status != StatusPending {
+		return fmt.Errorf("%w: cannot fail a %s subscription", ErrInvalidTransition, s.Status)
+	}
+	s.Status = StatusFailed
+	s.UpdatedAt = now
+	return nil
+}
+
+// Renew records a successful renewal charge, extending the subscription to periodEnd and clearing
+// any dunning state.
+func (s *Subscription) Renew(now, periodEnd time.Time) error {
+	if s.Status != StatusActive && !s.InDunning() {
+		return fmt.Errorf("%w: cannot renew a %s subscription", ErrInvalidTransition, s.Status)
+	}
+	s.Status = StatusActive
+	s.CurrentPeriodEnd = periodEnd
+	s.FailedAttempts = 0
+	s.FirstFailureAt = time.Time{}
+	s.NextRetryAt = time.Time{}
+	s.UpdatedAt = now
+	return nil
+}
+
+// RecordChargeFailure counts a failed renewal charge and schedules the next retry. The first failure
+// moves an active subscription into the grace period.
+func (s *Subscription) RecordChargeFailure(now, nextRetryAt time.Time) error {
+	switch {
+	case s.Status == StatusActive:
+		s.Status = StatusGracePeriod
+		s.FirstFailureAt = now
+	case !s.InDunning():
+		return fmt.Errorf("%w: cannot record a charge failure for a %s subscription", ErrInvalidTransition, s.Status)
+	}
+	s.FailedAttempts++
+	s.NextRetryAt = nextRetryAt
+	s.UpdatedAt = now
+	return nil
+}
+
+// MarkPastDue ends the grace period.
+func (s *Subscription) MarkPastDue(now time.Time) error {
+	if s.Status != StatusGracePeriod {
+		return fmt.Errorf("%w: cannot mark a %s subscription past due", ErrInvalidTransition, s.Status)
+	}
+	s.Status = StatusPastDue
+	s.UpdatedAt = now
+	return nil
+}
+
+// Cancel ends a subscription that has not already ended.
+func (s *Subscription) Cancel(now time.Time) error {
+	if s.Status != StatusPending && s.Status != StatusActive && !s.InDunning() {
+		return fmt.Errorf("%w: cannot cancel a %s subscription", ErrInvalidTransition, s.Status)
+	}
+	s.Status = StatusCancelled
+	s.NextRetryAt = time.Time{}
+	s.UpdatedAt = now
+	return nil
+}
@@ -0,0 +1,326 @@
+// Package dunning renews subscriptions at the end of each billing period and retries failed renewal
+// charges on a schedule, moving the subscription through the grace period and past due before
+// cancelling it after the final failure.
+package dunning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"subcription/internal/billing/domain"
+	"subcription/internal/billing/repository"
+	"subcription/internal/platform/payment"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Clock supplies the current time; tests inject a fake to run a dunning cycle deterministically.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function to Clock.
+type ClockFunc func() time.Time
+
+func (f ClockFunc) Now() time.Time { return f() }
+
+// SystemClock reads the wall clock.
+var SystemClock Clock = ClockFunc(time.Now)
+
+// Config controls the retry policy.
+type Config struct {
+	// RetrySchedule lists when to retry a failed renewal, measured from the first failure. The
+	// subscription is cancelled when the last retry fails.
+	RetrySchedule []time.Duration
+	// GracePeriod is how long after the first failure the subscriber keeps access. After it the
+	// subscription is past due until a retry succeeds or the retries run out.
+	GracePeriod time.Duration
+}
+
+// DefaultConfig retries 1, 3, 5 and 7 days after the first failure with a 3 day grace period.
+func DefaultConfig() Config {
+	day := 24 * time.Hour
+	return Config{
+		RetrySchedule: []time.Duration{1 * day, 3 * day, 5 * day, 7 * day},
+		GracePeriod:   3 * day,
+	}
+}
+
+// EventKind names a dunning step.
+type EventKind string
+
+const (
+	// EventRenewed: a renewal was charged on the first attempt.
+	EventRenewed EventKind = "renewed"
+	// EventChargeFailed: a renewal or retry failed. NextRetryAt is zero after the final attempt.
+	EventChargeFailed EventKind = "charge_failed"
+	// EventPastDue: the grace period ended without a successful retry.
+	EventPastDue EventKind = "past_due"
+	// EventRecovered: a retry succeeded and the subscription is active again.
+	EventRecovered EventKind = "recovered"
+	// EventCancelled: the final retry failed and the subscription was cancelled.
+	EventCancelled EventKind = "cancelled"
+)
+
+// Event describes one step for notifications, such as emailing the subscriber to update their card.
+type Event struct {
+	Kind         EventKind
+	Subscription domain.Subscription
+	// Payment is the charge attempt behind the step; nil for EventPastDue.
+	Payment *domain.Payment
+	// Attempt is the 1-based number of failed charges so far, 0 on success.
+	Attempt     int
+	NextRetryAt time.Time
+	At          time.Time
+}
+
+// Notifier receives every dunning step. Notify should not block; the engine calls it inline.
+type Notifier interface {
+	Notify(Event)
+}
+
+// NotifierFunc adapts a function to Notifier.
+type NotifierFunc func(Event)
+
+func (f NotifierFunc) Notify(e Event) { f(e) }
+
+// LogNotifier writes each step to the standard logger.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(e Event) {
+	log.Printf("dunning: %s subscription=%s user=%s attempt=%d next_retry=%s",
+		e.Kind, e.Subscription.ID, e.Subscription.UserID, e.Attempt, formatTime(e.NextRetryAt))
+}
+
+type Engine struct {
+	repo     repository.BillingRepository
+	gateway  payment.Gatway
+	clock    Clock
+	config   Config
+	notifier Notifier
+}
+
+// NewEngine builds an engine. A nil clock uses SystemClock and a nil notifier drops events.
+func NewEngine(repo repository.BillingRepository, gateway payment.Gatway, clock Clock, config Config, notifier Notifier) *Engine {
+	if clock == nil {
+		clock = SystemClock
+	}
+	if notifier == nil {
+		notifier = NotifierFunc(func(Event) {})
+	}
+	return &Engine{repo: repo, gateway: gateway, clock: clock, config: config, notifier: notifier}
+}
+
+// Run calls RunOnce every interval until ctx is cancelled, logging failures.
+func (e *Engine) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := e.RunOnce(); err != nil {
+			log.Printf("dunning: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce processes every subscription that is due: active ones whose period has ended are renewed,
+// grace periods that have run out become past due, and due retries are attempted. One subscription
+// failing does not stop the others; their errors are joined.
+func (e *Engine) RunOnce() error {
+	subs, err := e.repo.ListSubscriptionsByStatus(domain.StatusActive, domain.StatusGracePeriod, domain.StatusPastDue)
+	if err != nil {
+		return fmt.Errorf("list subscriptions: %w", err)
+	}
+	var errs []error
+	for _, sub := range subs {
+		if err := e.process(sub.ID); err != nil {
+			errs = append(errs, fmt.Errorf("subscription %s: %w", sub.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// process re-reads the subscription so that a change made since the listing, such as a cancellation,
+// is seen before charging. Every write is checked against the version read here.
+func (e *Engine) process(id uuid.UUID) error {
+	sub, err := e.repo.GetSubscription(id)
+	if errors.Is(err, repository.ErrSubscriptionNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	now := e.clock.Now()
+	if sub.Status == domain.StatusGracePeriod && !now.Before(sub.FirstFailureAt.Add(e.config.GracePeriod)) {
+		if err := sub.MarkPastDue(now); err != nil {
+			return err
+		}
+		if err := e.repo.UpdateSubscription(sub); err != nil {
+			return err
+		}
+		e.notify(EventPastDue, sub, nil, sub.FailedAttempts, now)
+	}
+
+	switch {
+	case sub.Status == domain.StatusActive && !now.Before(sub.CurrentPeriodEnd):
+		return e.charge(sub, now)
+	case sub.InDunning() && !now.Before(sub.NextRetryAt):
+		return e.charge(sub, now)
+	}
+	return nil
+}
+
+// chargeKey identifies one charge attempt: the renewal of the period ending at CurrentPeriodEnd and,
+// during dunning, the retry number.
+func chargeKey(sub *domain.Subscription) string {
+	return fmt.Sprintf("renewal:%s:%d:%d", sub.ID, sub.CurrentPeriodEnd.Unix(), sub.FailedAttempts+1)
+}
+
+// charge attempts the renewal and records the outcome on the subscription.
+//
+// The attempt is keyed by chargeKey, so a run that stopped part way, or whose subscription update
+// lost a race, is finished by the next run without charging again: a recorded outcome is reused and
+// an unrecorded charge is repeated under the same gateway idempotency key.
+func (e *Engine) charge(sub *domain.Subscription, now time.Time) error {
+	retrying := sub.InDunning()
+	key := chargeKey(sub)
+	pay, err := e.repo.GetPaymentByIdempotencyKey(key)
+	if errors.Is(err, repository.ErrPaymentNotFound) {
+		pay = &domain.Payment{
+			ID:             uuid.New(),
+			UserID:         sub.UserID,
+			SubscriptionID: sub.ID,
+			Amount:         sub.Amount,
+			Currency:       sub.Currency,
+			PaymentMethod:  sub.PaymentMethod,
+			IdempotencyKey: key,
+			Status:         domain.PaymentInitiated,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
+		err = e.repo.CreatePayment(pay)
+	}
+	if err != nil {
+		return err
+	}
+
+	if pay.Status == domain.PaymentInitiated {
+		description := fmt.Sprintf("Subscription %s (%s) renewal", sub.ID, sub.PlanID)
+		resp, chargeErr := e.gateway.Charge(pay.Amount, pay.Currency, pay.PaymentMethod, description, key)
+		if reason := payment.FailureReason(resp, chargeErr); reason != "" {
+			_ = pay.Fail(reason, now)
+		} else {
+			_ = pay.Complete(resp.RefID, now)
+		}
+		if err := e.repo.UpdatePayment(pay); err != nil {
+			return err
+		}
+	}
+	switch pay.Status {
+	case domain.PaymentFailed:
+		return e.recordFailure(sub, pay, now)
+	case domain.PaymentCompleted:
+	default:
+		return fmt.Errorf("renewal payment %s is %s", pay.ID, pay.Status)
+	}
+
+	// On-time renewals keep the billing anchor; a recovered subscription starts a new full period.
+	periodEnd := sub.CurrentPeriodEnd.Add(domain.BillingPeriod)
+	if retrying || periodEnd.Before(now) {
+		periodEnd = now.Add(domain.BillingPeriod)
+	}
+	if err := sub.Renew(now, periodEnd); err != nil {
+		return err
+	}
+	if err := e.repo.UpdateSubscription(sub); err != nil {
+		if errors.Is(err, repository.ErrSubscriptionConflict) {
+			return e.refundIfEnded(sub.ID, pay, now, err)
+		}
+		return err
+	}
+	kind := EventRenewed
+	if retrying {
+		kind = EventRecovered
+	}
+	e.notify(kind, sub, pay, 0, now)
+	return nil
+}
+
+// refundIfEnded handles a renewal charge whose subscription changed before the renewal was saved. If
+// it was cancelled meanwhile the charge is refunded; otherwise conflict is returned and the next run
+// records the renewal from the completed payment.
+func (e *Engine) refundIfEnded(id uuid.UUID, pay *domain.Payment, now time.Time, conflict error) error {
+	sub, err := e.repo.GetSubscription(id)
+	if err != nil {
+		return errors.Join(conflict, err)
+	}
+	if sub.Status == domain.StatusActive || sub.InDunning() {
+		return conflict
+	}
+	resp, refundErr := e.gateway.Refund(pay.GatewayRef, pay.Amount)
+	if reason := payment.FailureReason(resp, refundErr); reason != "" {
+		return fmt.Errorf("refund renewal charge %s of %s subscription: %s", pay.GatewayRef, sub.Status, reason)
+	}
+	_ = pay.Refund(now)
+	return e.repo.UpdatePayment(pay)
+}
+
+// recordFailure schedules the next retry, or cancels the subscription when the schedule is used up.
+func (e *Engine) recordFailure(sub *domain.Subscription, pay *domain.Payment, now time.Time) error {
+	firstFailure := sub.FirstFailureAt
+	if !sub.InDunning() {
+		firstFailure = now
+	}
+	attempt := sub.FailedAttempts + 1
+
+	if attempt > len(e.config.RetrySchedule) {
+		sub.FailedAttempts = attempt
+		if err := sub.Cancel(now); err != nil {
+			return err
+		}
+		if err := e.repo.UpdateSubscription(sub); err != nil {
+			return err
+		}
+		e.notify(EventChargeFailed, sub, pay, attempt, now)
+		e.notify(EventCancelled, sub, pay, attempt, now)
+		return nil
+	}
+
+	nextRetry := firstFailure.Add(e.config.RetrySchedule[attempt-1])
+	if err := sub.RecordChargeFailure(now, nextRetry); err != nil {
+		return err
+	}
+	if e.config.GracePeriod <= 0 {
+		_ = sub.MarkPastDue(now)
+	}
+	if err := e.repo.UpdateSubscription(sub); err != nil {
+		return err
+	}
+	e.notify(EventChargeFailed, sub, pay, attempt, now)
+	if sub.Status == domain.StatusPastDue && attempt == 1 {
+		e.notify(EventPastDue, sub, nil, attempt, now)
+	}
+	return nil
+}
+
+func (e *Engine) notify(kind EventKind, sub *domain.Subscription, pay *domain.Payment, attempt int, at time.Time) {
+	evt := Event{Kind: kind, Subscription: *sub, Attempt: attempt, NextRetryAt: sub.NextRetryAt, At: at}
+	if pay != nil {
+		copied := *pay
+		evt.Payment = &copied
+	}
+	e.notifier.Notify(evt)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
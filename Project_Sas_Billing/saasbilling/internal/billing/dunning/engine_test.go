@@ -0,0 +1,317 @@
+package dunning
+
+import (
+	"errors"
+	"slices"
+	"subcription/internal/billing/domain"
+	"subcription/internal/billing/repository"
+	"subcription/internal/billing/service"
+	"subcription/internal/platform/payment"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const day = 24 * time.Hour
+
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time          { return c.now }
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+type recorder struct{ events []Event }
+
+func (r *recorder) Notify(e Event) { r.events = append(r.events, e) }
+
+func (r *recorder) kinds() []EventKind {
+	out := make([]EventKind, 0, len(r.events))
+	for _, e := range r.events {
+		out = append(out, e.Kind)
+	}
+	return out
+}
+
+// setup creates an active subscription and an engine whose clock stands at the end of its first
+// billing period.
+func setup(t *testing.T) (*Engine, repository.BillingRepository, *payment.StripeMock, *fakeClock, *recorder, uuid.UUID) {
+	t.Helper()
+	repo := repository.NewInMemoryBillingRepository()
+	mock := &payment.StripeMock{}
+	sub, _, err := service.NewBillingService(repo, mock).CreateSubscription(service.CreateSubscriptionInput{
+		UserID:        uuid.New(),
+		PlanID:        "basic_plan",
+		Amount:        999,
+		PaymentMethod: "pm_card_visa",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &fakeClock{now: sub.CurrentPeriodEnd}
+	rec := &recorder{}
+	return NewEngine(repo, mock, clock, DefaultConfig(), rec), repo, mock, clock, rec, sub.ID
+}
+
+// step is one engine run: advance the clock, run, and check the subscription.
+type step struct {
+	advance      time.Duration
+	gatewayFails bool
+	wantStatus   domain.SubscriptionStatus
+	wantEvents   []EventKind
+}
+
+func runSteps(t *testing.T, steps []step) (repository.BillingRepository, uuid.UUID, *recorder) {
+	t.Helper()
+	engine, repo, mock, clock, rec, id := setup(t)
+	for i, s := range steps {
+		clock.Advance(s.advance)
+		mock.ShouldFail = s.gatewayFails
+		before := len(rec.events)
+		if err := engine.RunOnce(); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		sub, err := repo.GetSubscription(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sub.Status != s.wantStatus {
+			t.Fatalf("step %d (+%s): status %s, want %s", i, s.advance, sub.Status, s.wantStatus)
+		}
+		got := rec.kinds()[before:]
+		if !slices.Equal(got, s.wantEvents) {
+			t.Fatalf("step %d (+%s): events %v, want %v", i, s.advance, got, s.wantEvents)
+		}
+	}
+	return repo, id, rec
+}
+
+func TestDunningCycle(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []step
+		check func(t *testing.T, repo repository.BillingRepository, id uuid.UUID, rec *recorder)
+	}{
+		{
+			name: "renews on time",
+			steps: []step{
+				{advance: 0, wantStatus: domain.StatusActive, wantEvents: []EventKind{EventRenewed}},
+				{advance: day, wantStatus: domain.StatusActive},
+			},
+			check: func(t *testing.T, repo repository.BillingRepository, id uuid.UUID, rec *recorder) {
+				sub, _ := repo.GetSubscription(id)
+				if want := rec.events[0].At.Add(domain.BillingPeriod); !sub.CurrentPeriodEnd.Equal(want) {
+					t.Errorf("period end %s, want %s", sub.CurrentPeriodEnd, want)
+				}
+			},
+		},
+		{
+			name: "full cycle ends in cancellation",
+			steps: []step{
+				{advance: 0, gatewayFails: true, wantStatus: domain.StatusGracePeriod, wantEvents: []EventKind{EventChargeFailed}},
+				{advance: 12 * time.Hour, gatewayFails: true, wantStatus: domain.StatusGracePeriod},
+				{advance: 12 * time.Hour, gatewayFails: true, wantStatus: domain.StatusGracePeriod, wantEvents: []EventKind{EventChargeFailed}},
+				{advance: 2 * day, gatewayFails: true, wantStatus: domain.StatusPastDue, wantEvents: []EventKind{EventPastDue, EventChargeFailed}},
+				{advance: 2 * day, gatewayFails: true, wantStatus: domain.StatusPastDue, wantEvents: []EventKind{EventChargeFailed}},
+				{advance: day, gatewayFails: true, wantStatus: domain.StatusPastDue},
+				{advance: day, gatewayFails: true, wantStatus: domain.StatusCancelled, wantEvents: []EventKind{EventChargeFailed, EventCancelled}},
+				{advance: 30 * day, wantStatus: domain.StatusCancelled},
+			},
+			check: func(t *testing.T, repo repository.BillingRepository, id uuid.UUID, rec *recorder) {
+				payments, _ := repo.ListPaymentsBySubscription(id)
+				// The initial charge, the failed renewal and four retries.
+				if len(payments) != 6 {
+					t.Fatalf("payments = %d, want 6", len(payments))
+				}
+				for _, p := range payments[1:] {
+					if p.Status != domain.PaymentFailed {
+						t.Errorf("payment %s is %s", p.ID, p.Status)
+					}
+				}
+				wantRetry := []time.Duration{1 * day, 3 * day, 5 * day, 7 * day}
+				first := rec.events[0].At
+				var failures []Event
+				for _, e := range rec.events {
+					if e.Kind == EventChargeFailed {
+						failures = append(failures, e)
+					}
+				}
+				for i, want := range wantRetry {
+					if got := failures[i].NextRetryAt.Sub(first); got != want {
+						t.Errorf("failure %d scheduled retry at +%s, want +%s", i+1, got, want)
+					}
+				}
+				if last := failures[len(failures)-1]; !last.NextRetryAt.IsZero() || last.Attempt != 5 {
+					t.Errorf("final failure = attempt %d, next retry %s", last.Attempt, last.NextRetryAt)
+				}
+			},
+		},
+		{
+			name: "retry recovers during grace period",
+			steps: []step{
+				{advance: 0, gatewayFails: true, wantStatus: domain.StatusGracePeriod, wantEvents: []EventKind{EventChargeFailed}},
+				{advance: day, wantStatus: domain.StatusActive, wantEvents: []EventKind{EventRecovered}},
+				{advance: 7 * day, wantStatus: domain.StatusActive},
+			},
+			check: func(t *testing.T, repo repository.BillingRepository, id uuid.UUID, rec *recorder) {
+				sub, _ := repo.GetSubscription(id)
+				if sub.FailedAttempts != 0 || !sub.NextRetryAt.IsZero() || !sub.FirstFailureAt.IsZero() {
+					t.Errorf("dunning state not cleared: %+v", sub)
+				}
+				if want := rec.events[1].At.Add(domain.BillingPeriod); !sub.CurrentPeriodEnd.Equal(want) {
+					t.Errorf("period end %s, want %s", sub.CurrentPeriodEnd, want)
+				}
+			},
+		},
+		{
+			name: "retry recovers after going past due",
+			steps: []step{
+				{advance: 0, gatewayFails: true, wantStatus: domain.StatusGracePeriod, wantEvents: []EventKind{EventChargeFailed}},
+				{advance: day, gatewayFails: true, wantStatus: domain.StatusGracePeriod, wantEvents: []EventKind{EventChargeFailed}},
+				{advance: 2 * day, wantStatus: domain.StatusActive, wantEvents: []EventKind{EventPastDue, EventRecovered}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, id, rec := runSteps(t, tt.steps)
+			if tt.check != nil {
+				tt.check(t, repo, id, rec)
+			}
+		})
+	}
+}
+
+func TestManualCancelStopsRetries(t *testing.T) {
+	engine, repo, mock, clock, rec, id := setup(t)
+	mock.ShouldFail = true
+	if err := engine.RunOnce(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.NewBillingService(repo, mock).CancelSubscription(id); err != nil {
+		t.Fatal(err)
+	}
+	before := len(rec.events)
+	clock.Advance(10 * day)
+	if err := engine.RunOnce(); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.events) != before {
+		t.Fatalf("engine kept dunning a cancelled subscription: %v", rec.kinds()[before:])
+	}
+}
+
+// hookGateway runs beforeCharge ahead of every charge, to change the subscription mid-renewal.
+type hookGateway struct {
+	*payment.StripeMock
+	beforeCharge func()
+}
+
+func (g *hookGateway) Charge(amount int64, currency, source, description, idempotencyKey string) (*payment.GatewayResponse, error) {
+	if g.beforeCharge != nil {
+		g.beforeCharge()
+	}
+	return g.StripeMock.Charge(amount, currency, source, description, idempotencyKey)
+}
+
+func TestCancelDuringRenewalWins(t *testing.T) {
+	tests := []struct {
+		name        string
+		declined    bool
+		wantErr     error
+		wantPayment domain.PaymentStatus
+	}{
+		// The charge went through for a subscription that no longer exists, so it is returned.
+		{name: "charge succeeds", wantPayment: domain.PaymentRefunded},
+		{name: "charge declined", declined: true, wantErr: repository.ErrSubscriptionConflict, wantPayment: domain.PaymentFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, repo, mock, clock, rec, id := setup(t)
+			mock.ShouldFail = tt.declined
+			svc := service.NewBillingService(repo, mock)
+			gateway := &hookGateway{StripeMock: mock, beforeCharge: func() {
+				if _, err := svc.CancelSubscription(id); err != nil {
+					t.Fatal(err)
+				}
+			}}
+			engine := NewEngine(repo, gateway, clock, DefaultConfig(), rec)
+
+			if err := engine.RunOnce(); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			gateway.beforeCharge = nil
+			clock.Advance(10 * day)
+			if err := engine.RunOnce(); err != nil {
+				t.Fatal(err)
+			}
+
+			sub, _ := repo.GetSubscription(id)
+			if sub.Status != domain.StatusCancelled {
+				t.Fatalf("status = %s: the engine overwrote the cancellation", sub.Status)
+			}
+			if len(rec.events) != 0 {
+				t.Fatalf("events %v for a cancelled subscription", rec.kinds())
+			}
+			payments, _ := repo.ListPaymentsBySubscription(id)
+			if len(payments) != 2 || payments[1].Status != tt.wantPayment {
+				t.Fatalf("renewal payments = %d, last %s, want %s", len(payments)-1, payments[len(payments)-1].Status, tt.wantPayment)
+			}
+		})
+	}
+}
+
+// flakyRepo fails the first call to the named update after a successful charge.
+type flakyRepo struct {
+	repository.BillingRepository
+	failing string
+}
+
+func (r *flakyRepo) UpdatePayment(p *domain.Payment) error {
+	if r.failing == "UpdatePayment" && p.Status == domain.PaymentCompleted {
+		r.failing = ""
+		return errors.New("database unavailable")
+	}
+	return r.BillingRepository.UpdatePayment(p)
+}
+
+func (r *flakyRepo) UpdateSubscription(s *domain.Subscription) error {
+	if r.failing == "UpdateSubscription" && s.Status == domain.StatusActive {
+		r.failing = ""
+		return errors.New("database unavailable")
+	}
+	return r.BillingRepository.UpdateSubscription(s)
+}
+
+func TestInterruptedRenewalDoesNotChargeTwice(t *testing.T) {
+	for _, failing := range []string{"UpdatePayment", "UpdateSubscription"} {
+		t.Run(failing, func(t *testing.T) {
+			_, inner, mock, clock, rec, id := setup(t)
+			repo := &flakyRepo{BillingRepository: inner, failing: failing}
+			engine := NewEngine(repo, mock, clock, DefaultConfig(), rec)
+
+			if err := engine.RunOnce(); err == nil {
+				t.Fatal("expected the storage error")
+			}
+			clock.Advance(time.Minute)
+			if err := engine.RunOnce(); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := mock.Charges(); got != 2 {
+				t.Fatalf("gateway charges = %d, want the first period and one renewal", got)
+			}
+			payments, _ := repo.ListPaymentsBySubscription(id)
+			if len(payments) != 2 || payments[1].Status != domain.PaymentCompleted {
+				t.Fatalf("payments = %+v", payments)
+			}
+			sub, _ := repo.GetSubscription(id)
+			if sub.Status != domain.StatusActive || !sub.CurrentPeriodEnd.After(clock.Now()) {
+				t.Fatalf("subscription not renewed: %+v", sub)
+			}
+			if !slices.Equal(rec.kinds(), []EventKind{EventRenewed}) {
+				t.Fatalf("events %v", rec.kinds())
+			}
+		})
+	}
+}
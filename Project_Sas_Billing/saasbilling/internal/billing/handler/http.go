@@ -2,14 +2,17 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"subcription/internal/billing/domain"
+	"subcription/internal/billing/repository"
 	"subcription/internal/billing/service"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type BillingHandler struct {
-
 	service *service.BillingService
 }
 
@@ -19,30 +22,233 @@ func NewBillingHandler(s *service.BillingService) *BillingHandler {
 	}
 }
 
+// Register attaches the billing routes to mux.
+func (h *BillingHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /subscribe", h.Subscribe)
+	mux.HandleFunc("GET /subscriptions/{id}", h.GetSubscription)
+	mux.HandleFunc("POST /subscriptions/{id}/cancel", h.CancelSubscription)
+	mux.HandleFunc("GET /subscriptions/{id}/payments", h.SubscriptionPayments)
+	mux.HandleFunc("GET /users/{id}/payments", h.UserPayments)
+	mux.HandleFunc("POST /payments/{id}/refund", h.RefundPayment)
+}
+
 type SubscribeRequest struct {
-	UserID    uuid.UUID `json:"user_id"`
-	PlanID    string `json:"plan_id"`
-	Amount    int64 `json:"amount"`
-	PaymentMethod string `json:"payment_method"`
+	UserID        uuid.UUID `json:"user_id"`
+	PlanID        string    `json:"plan_id"`
+	Amount        int64     `json:"amount"`
+	Currency      string    `json:"currency"`
+	PaymentMethod string    `json:"payment_method"`
+}
+
+type SubscriptionResponse struct {
+	ID               uuid.UUID  `json:"id"`
+	UserID           uuid.UUID  `json:"user_id"`
+	PlanID           string     `json:"plan_id"`
+	Amount           int64      `json:"amount"`
+	Currency         string     `json:"currency"`
+	Status           string     `json:"status"`
+	CurrentPeriodEnd time.Time  `json:"current_period_end"`
+	FailedAttempts   int        `json:"failed_attempts,omitempty"`
+	NextRetryAt      *time.Time `json:"next_retry_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+type PaymentResponse struct {
+	ID             uuid.UUID  `json:"id"`
+	UserID         uuid.UUID  `json:"user_id"`
+	SubscriptionID uuid.UUID  `json:"subscription_id"`
+	Amount         int64      `json:"amount"`
+	Currency       string     `json:"currency"`
+	PaymentMethod  string     `json:"payment_method"`
+	Status         string     `json:"status"`
+	GatewayRef     string     `json:"gateway_ref,omitempty"`
+	FailureReason  string     `json:"failure_reason,omitempty"`
+	PaymentDate    *time.Time `json:"payment_date,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type SubscribeResponse struct {
+	Subscription *SubscriptionResponse `json:"subscription,omitempty"`
+	Payment      *PaymentResponse      `json:"payment,omitempty"`
+	Error        string                `json:"error,omitempty"`
 }
 
 func (h *BillingHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
 	var req SubscribeRequest
-	
+
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request payload", http.StatusBadRequest)
 		return
 	}
 
-	err := h.service.CreateSubscription(req.UserID, req.PlanID, req.Amount)
+	sub, pay, err := h.service.CreateSubscription(service.CreateSubscriptionInput{
+		UserID:        req.UserID,
+		PlanID:        req.PlanID,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		PaymentMethod: req.PaymentMethod,
+	})
+	if errors.Is(err, service.ErrPaymentFailed) {
+		// The failed payment is returned so the client can show the reason and find it in the history.
+		writeJSON(w, http.StatusPaymentRequired, SubscribeResponse{Payment: toPaymentResponse(pay), Error: err.Error()})
+		return
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, SubscribeResponse{
+		Subscription: toSubscriptionResponse(sub),
+		Payment:      toPaymentResponse(pay),
+	})
+}
+
+func (h *BillingHandler) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	sub, err := h.service.GetSubscription(id)
 	if err != nil {
-		http.Error(w, "Failed to create subscription", http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
-	
-	w.WriteHeader(http.StatusCreated)
-	w.Write([]byte("Subscription created successfully"))
+	writeJSON(w, http.StatusOK, toSubscriptionResponse(sub))
 }
 
+func (h *BillingHandler) CancelSubscription(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	sub, err := h.service.CancelSubscription(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toSubscriptionResponse(sub))
+}
 
+func (h *BillingHandler) SubscriptionPayments(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	payments, err := h.service.SubscriptionPayments(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toPaymentResponses(payments))
+}
 
+func (h *BillingHandler) UserPayments(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	payments, err := h.service.UserPayments(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toPaymentResponses(payments))
+}
+
+func (h *BillingHandler) RefundPayment(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	pay, err := h.service.RefundPayment(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toPaymentResponse(pay))
+}
+
+func pathID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidRequest):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrSubscriptionNotFound), errors.Is(err, repository.ErrPaymentNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidTransition), errors.Is(err, repository.ErrSubscriptionConflict),
+		errors.Is(err, repository.ErrPaymentConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrRefundFailed):
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func toSubscriptionResponse(s *domain.Subscription) *SubscriptionResponse {
+	if s == nil {
+		return nil
+	}
+	resp := &SubscriptionResponse{
+		ID:               s.ID,
+		UserID:           s.UserID,
+		PlanID:           s.PlanID,
+		Amount:           s.Amount,
+		Currency:         s.Currency,
+		Status:           string(s.Status),
+		CurrentPeriodEnd: s.CurrentPeriodEnd,
+		FailedAttempts:   s.FailedAttempts,
+		CreatedAt:        s.CreatedAt,
+		UpdatedAt:        s.UpdatedAt,
+	}
+	if !s.NextRetryAt.IsZero() {
+		resp.NextRetryAt = &s.NextRetryAt
+	}
+	return resp
+}
+
+func toPaymentResponse(p *domain.Payment) *PaymentResponse {
+	if p == nil {
+		return nil
+	}
+	resp := &PaymentResponse{
+		ID:             p.ID,
+		UserID:         p.UserID,
+		SubscriptionID: p.SubscriptionID,
+		Amount:         p.Amount,
+		Currency:       p.Currency,
+		PaymentMethod:  p.PaymentMethod,
+		Status:         string(p.Status),
+		GatewayRef:     p.GatewayRef,
+		FailureReason:  p.FailureReason,
+		CreatedAt:      p.CreatedAt,
+	}
+	if !p.PaymentDate.IsZero() {
+		resp.PaymentDate = &p.PaymentDate
+	}
+	return resp
+}
+
+func toPaymentResponses(payments []*domain.Payment) []*PaymentResponse {
+	out := make([]*PaymentResponse, 0, len(payments))
+	for _, p := range payments {
+		out = append(out, toPaymentResponse(p))
+	}
+	return out
+}
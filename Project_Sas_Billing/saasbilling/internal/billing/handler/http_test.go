@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"subcription/internal/billing/domain"
+	"subcription/internal/billing/repository"
+	"subcription/internal/billing/service"
+	"subcription/internal/platform/payment"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// activationFailsRepo cannot store subscription updates, so CreateSubscription refunds its charge.
+type activationFailsRepo struct {
+	repository.BillingRepository
+}
+
+func (r activationFailsRepo) UpdateSubscription(sub *domain.Subscription) error {
+	if sub.Status == domain.StatusActive {
+		return errors.New("database unavailable")
+	}
+	return r.BillingRepository.UpdateSubscription(sub)
+}
+
+func TestRefundPaymentTwiceIsRejected(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup returns a payment to refund over HTTP and how many refund requests should succeed.
+		setup func(t *testing.T, repo repository.BillingRepository, gateway payment.Gatway) (uuid.UUID, int)
+	}{
+		{
+			name: "completed payment",
+			setup: func(t *testing.T, repo repository.BillingRepository, gateway payment.Gatway) (uuid.UUID, int) {
+				_, pay, err := service.NewBillingService(repo, gateway).CreateSubscription(subscribeInput())
+				if err != nil {
+					t.Fatal(err)
+				}
+				return pay.ID, 1
+			},
+		},
+		{
+			name: "already refunded after a failed activation",
+			setup: func(t *testing.T, repo repository.BillingRepository, gateway payment.Gatway) (uuid.UUID, int) {
+				in := subscribeInput()
+				if _, _, err := service.NewBillingService(activationFailsRepo{repo}, gateway).CreateSubscription(in); err == nil {
+					t.Fatal("expected the activation to fail")
+				}
+				payments, _ := repo.ListPaymentsByUser(in.UserID)
+				if len(payments) != 1 {
+					t.Fatalf("payments = %d", len(payments))
+				}
+				return payments[0].ID, 0
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewInMemoryBillingRepository()
+			gateway := &countingGateway{Gatway: &payment.StripeMock{}}
+			id, wantOK := tt.setup(t, repo, gateway)
+			refundsBefore := gateway.refunds
+
+			mux := http.NewServeMux()
+			NewBillingHandler(service.NewBillingService(repo, gateway)).Register(mux)
+			for i := 0; i < 2; i++ {
+				rec := httptest.NewRecorder()
+				mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/payments/"+id.String()+"/refund", nil))
+				want := http.StatusConflict
+				if i < wantOK {
+					want = http.StatusOK
+				}
+				if rec.Code != want {
+					t.Fatalf("refund request %d: status %d, want %d (%s)", i+1, rec.Code, want, rec.Body)
+				}
+			}
+			if got := gateway.refunds - refundsBefore; got != wantOK {
+				t.Fatalf("gateway refunds over HTTP = %d, want %d", got, wantOK)
+			}
+			stored, _ := repo.GetPayment(id)
+			if stored.Status != domain.PaymentRefunded {
+				t.Fatalf("payment status = %s", stored.Status)
+			}
+		})
+	}
+}
+
+func TestFailedChargeStaysInSubscriptionPayments(t *testing.T) {
+	repo := repository.NewInMemoryBillingRepository()
+	svc := service.NewBillingService(repo, &payment.StripeMock{ShouldFail: true})
+	in := subscribeInput()
+	_, pay, err := svc.CreateSubscription(in)
+	if !errors.Is(err, service.ErrPaymentFailed) {
+		t.Fatalf("err = %v, want ErrPaymentFailed", err)
+	}
+
+	mux := http.NewServeMux()
+	NewBillingHandler(svc).Register(mux)
+	for _, path := range []string{
+		"/subscriptions/" + pay.SubscriptionID.String(),
+		"/subscriptions/" + pay.SubscriptionID.String() + "/payments",
+		"/users/" + in.UserID.String() + "/payments",
+	} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: status %d (%s)", path, rec.Code, rec.Body)
+		}
+		if !strings.Contains(rec.Body.String(), pay.SubscriptionID.String()) {
+			t.Fatalf("GET %s does not mention the failed subscription: %s", path, rec.Body)
+		}
+	}
+}
+
+type countingGateway struct {
+	payment.Gatway
+	refunds int
+}
+
+func (g *countingGateway) Refund(chargeID string, amount int64) (*payment.GatewayResponse, error) {
+	g.refunds++
+	return g.Gatway.Refund(chargeID, amount)
+}
+
+func subscribeInput() service.CreateSubscriptionInput {
+	return service.CreateSubscriptionInput{
+		UserID:        uuid.New(),
+		PlanID:        "basic_plan",
+		Amount:        999,
+		PaymentMethod: "pm_card_visa",
+	}
+}
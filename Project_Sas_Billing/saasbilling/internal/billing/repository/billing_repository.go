@@ -1,21 +1,44 @@
 package repository
 
 import (
+	"errors"
+	"slices"
+	"sort"
 	"subcription/internal/billing/domain"
-	"time"
+	"sync"
 
 	"github.com/google/uuid"
 )
 
-type BillingRepository interface {
-	CreateSubscription(userID uuid.UUID, planID string, amount int64) error
-	CreatePayment(payment *domain.Payment) error
-
-	//CreateSubscription(sub *domain.Subsciption) error
-	//UpdateSubscription(id string , status domain.SubscriptionStatus) error
+var (
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+	ErrPaymentNotFound      = errors.New("payment not found")
+	// ErrSubscriptionConflict means the subscription changed after it was read; re-read and retry.
+	ErrSubscriptionConflict = errors.New("subscription was changed concurrently")
+	// ErrPaymentConflict means the payment changed after it was read, e.g. another refund claimed it.
+	ErrPaymentConflict = errors.New("payment was changed concurrently")
+	// ErrDuplicatePayment means a payment with the same idempotency key is already recorded.
+	ErrDuplicatePayment = errors.New("payment already recorded")
+)
 
-	//UpdatePaymentStatus(id string, status domain.PaymentStatus,ref string) error
+type BillingRepository interface {
+	CreateSubscription(sub *domain.Subscription) error
+	// UpdateSubscription stores sub if the stored copy is still at sub.Version, and advances
+	// sub.Version. It returns ErrSubscriptionConflict when someone else updated it first.
+	UpdateSubscription(sub *domain.Subscription) error
+	GetSubscription(id uuid.UUID) (*domain.Subscription, error)
+	ListSubscriptionsByStatus(statuses ...domain.SubscriptionStatus) ([]*domain.Subscription, error)
 
+	// CreatePayment returns ErrDuplicatePayment when the payment's idempotency key is already taken.
+	CreatePayment(payment *domain.Payment) error
+	// UpdatePayment stores payment if the stored copy is still at payment.Version, and advances
+	// payment.Version. It returns ErrPaymentConflict when someone else updated it first.
+	UpdatePayment(payment *domain.Payment) error
+	GetPayment(id uuid.UUID) (*domain.Payment, error)
+	GetPaymentByIdempotencyKey(key string) (*domain.Payment, error)
+	// ListPaymentsByUser and ListPaymentsBySubscription return payments oldest first.
+	ListPaymentsByUser(userID uuid.UUID) ([]*domain.Payment, error)
+	ListPaymentsBySubscription(subscriptionID uuid.UUID) ([]*domain.Payment, error)
 }
 
 // NewInMemoryBillingRepository creates an in-memory implementation of BillingRepository
@@ -23,29 +46,136 @@ func NewInMemoryBillingRepository() BillingRepository {
 	return &inMemoryBillingRepository{
 		subscriptions: make(map[uuid.UUID]*domain.Subscription),
 		payments:      make(map[uuid.UUID]*domain.Payment),
+		paymentsByKey: make(map[string]uuid.UUID),
 	}
 }
 
+// inMemoryBillingRepository stores copies so callers only change stored records through Update.
 type inMemoryBillingRepository struct {
+	mu            sync.RWMutex
 	subscriptions map[uuid.UUID]*domain.Subscription
 	payments      map[uuid.UUID]*domain.Payment
+	paymentsByKey map[string]uuid.UUID
+}
+
+func (r *inMemoryBillingRepository) CreateSubscription(sub *domain.Subscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *sub
+	r.subscriptions[sub.ID] = &stored
+	return nil
 }
 
-func (r *inMemoryBillingRepository) CreateSubscription(userID uuid.UUID, planID string, amount int64) error {
-	sub := &domain.Subscription{
-		ID:        uuid.New(),
-		UserID:    userID,
-		PlanID:    planID,
-		Amount:    amount,
-		Status:    domain.StatusPending,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+func (r *inMemoryBillingRepository) UpdateSubscription(sub *domain.Subscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.subscriptions[sub.ID]
+	if !ok {
+		return ErrSubscriptionNotFound
 	}
-	r.subscriptions[sub.ID] = sub
+	if current.Version != sub.Version {
+		return ErrSubscriptionConflict
+	}
+	sub.Version++
+	stored := *sub
+	r.subscriptions[sub.ID] = &stored
 	return nil
 }
 
+func (r *inMemoryBillingRepository) GetSubscription(id uuid.UUID) (*domain.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	sub, ok := r.subscriptions[id]
+	if !ok {
+		return nil, ErrSubscriptionNotFound
+	}
+	out := *sub
+	return &out, nil
+}
+
+func (r *inMemoryBillingRepository) ListSubscriptionsByStatus(statuses ...domain.SubscriptionStatus) ([]*domain.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*domain.Subscription, 0)
+	for _, sub := range r.subscriptions {
+		if slices.Contains(statuses, sub.Status) {
+			copied := *sub
+			out = append(out, &copied)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
 func (r *inMemoryBillingRepository) CreatePayment(payment *domain.Payment) error {
-	r.payments[payment.ID] = payment
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if payment.IdempotencyKey != "" {
+		if _, ok := r.paymentsByKey[payment.IdempotencyKey]; ok {
+			return ErrDuplicatePayment
+		}
+		r.paymentsByKey[payment.IdempotencyKey] = payment.ID
+	}
+	stored := *payment
+	r.payments[payment.ID] = &stored
 	return nil
 }
+
+func (r *inMemoryBillingRepository) UpdatePayment(payment *domain.Payment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.payments[payment.ID]
+	if !ok {
+		return ErrPaymentNotFound
+	}
+	if current.Version != payment.Version {
+		return ErrPaymentConflict
+	}
+	payment.Version++
+	stored := *payment
+	r.payments[payment.ID] = &stored
+	return nil
+}
+
+func (r *inMemoryBillingRepository) GetPayment(id uuid.UUID) (*domain.Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	payment, ok := r.payments[id]
+	if !ok {
+		return nil, ErrPaymentNotFound
+	}
+	out := *payment
+	return &out, nil
+}
+
+func (r *inMemoryBillingRepository) GetPaymentByIdempotencyKey(key string) (*domain.Payment, error) {
+	r.mu.RLock()
+	id, ok := r.paymentsByKey[key]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, ErrPaymentNotFound
+	}
+	return r.GetPayment(id)
+}
+
+func (r *inMemoryBillingRepository) ListPaymentsByUser(userID uuid.UUID) ([]*domain.Payment, error) {
+	return r.listPayments(func(p *domain.Payment) bool { return p.UserID == userID }), nil
+}
+
+func (r *inMemoryBillingRepository) ListPaymentsBySubscription(subscriptionID uuid.UUID) ([]*domain.Payment, error) {
+	return r.listPayments(func(p *domain.Payment) bool { return p.SubscriptionID == subscriptionID }), nil
+}
+
+func (r *inMemoryBillingRepository) listPayments(match func(*domain.Payment) bool) []*domain.Payment {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*domain.Payment, 0)
+	for _, p := range r.payments {
+		if match(p) {
+			copied := *p
+			out = append(out, &copied)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
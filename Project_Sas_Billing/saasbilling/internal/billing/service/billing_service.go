@@ -1,6 +1,9 @@
 package service
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"subcription/internal/billing/domain"
 	"subcription/internal/billing/repository"
 	"subcription/internal/platform/payment"
@@ -9,53 +12,228 @@ import (
 	"github.com/google/uuid"
 )
 
+var (
+	ErrInvalidRequest = errors.New("invalid request")
+	// ErrPaymentFailed means the gateway declined or could not process the charge.
+	ErrPaymentFailed = errors.New("payment failed")
+	ErrRefundFailed  = errors.New("refund failed")
+)
+
+// maxUpdateAttempts bounds how often an update that lost a race is retried on a fresh copy.
+const maxUpdateAttempts = 3
+
 type BillingService struct {
 	repo    repository.BillingRepository
 	gateway payment.Gatway
+	now     func() time.Time
 }
 
 func NewBillingService(repo repository.BillingRepository, gateway payment.Gatway) *BillingService {
 	return &BillingService{
 		repo:    repo,
 		gateway: gateway,
+		now:     time.Now,
 	}
 }
 
-func (s *BillingService) CreateSubscription(userID uuid.UUID, planID string, amount int64) error {
+// CreateSubscriptionInput describes a new subscription. Amount is in the currency's minor unit and
+// PaymentMethod is the gateway source to charge (a card token or saved payment method ID).
+type CreateSubscriptionInput struct {
+	UserID        uuid.UUID
+	PlanID        string
+	Amount        int64
+	Currency      string
+	PaymentMethod string
+}
 
-	subscription := &domain.Subscription{
-		ID:        uuid.New(),
-		UserID:    userID,
-		PlanID:    planID,
-		Amount:    amount,
-		Status:    "active",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+func (in CreateSubscriptionInput) validate() error {
+	var problems []string
+	if in.UserID == uuid.Nil {
+		problems = append(problems, "user_id is required")
 	}
-
-	if err := s.repo.CreateSubscription(subscription.UserID, subscription.PlanID, subscription.Amount); err != nil {
-		return err
+	if strings.TrimSpace(in.PlanID) == "" {
+		problems = append(problems, "plan_id is required")
 	}
+	if in.Amount <= 0 {
+		problems = append(problems, "amount must be positive")
+	}
+	if strings.TrimSpace(in.PaymentMethod) == "" {
+		problems = append(problems, "payment_method is required")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidRequest, strings.Join(problems, ", "))
+	}
+	return nil
+}
 
-	resp, err := s.gateway.Charge(amount, "usd", "src_mocked_12345", "Subscription Charge")
+// CreateSubscription stores a pending subscription, charges the first period and then either
+// activates the subscription or rolls it back.
+//
+// When the charge fails the subscription is kept as failed, so the failed payment in the user's
+// payment history still belongs to it; the error wraps ErrPaymentFailed and the returned payment
+// explains why. When the charge succeeds but the result cannot be stored, the charge is refunded.
+func (s *BillingService) CreateSubscription(in CreateSubscriptionInput) (*domain.Subscription, *domain.Payment, error) {
+	if err := in.validate(); err != nil {
+		return nil, nil, err
+	}
+	currency, err := domain.NormalizeCurrency(in.Currency)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
 
-	if err != nil || !resp.Success {
-		return err
+	now := s.now()
+	subscription := &domain.Subscription{
+		ID:            uuid.New(),
+		UserID:        in.UserID,
+		PlanID:        in.PlanID,
+		Amount:        in.Amount,
+		Currency:      currency,
+		PaymentMethod: in.PaymentMethod,
+		Status:        domain.StatusPending,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := s.repo.CreateSubscription(subscription); err != nil {
+		return nil, nil, err
 	}
 
-	payment := &domain.Payment{
+	pay := &domain.Payment{
 		ID:             uuid.New(),
+		UserID:         in.UserID,
 		SubscriptionID: subscription.ID,
-		Amount:         amount,
-		Currency:       "INR",
-		Status:         "completed",
-		CreatedAt:      time.Now(),
+		Amount:         in.Amount,
+		Currency:       currency,
+		PaymentMethod:  in.PaymentMethod,
+		IdempotencyKey: "subscribe:" + subscription.ID.String(),
+		Status:         domain.PaymentInitiated,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.repo.CreatePayment(pay); err != nil {
+		return nil, nil, s.rollback(subscription, err)
 	}
 
-	if err := s.repo.CreatePayment(payment); err != nil {
-		return err
+	description := fmt.Sprintf("Subscription %s (%s)", subscription.ID, in.PlanID)
+	resp, chargeErr := s.gateway.Charge(in.Amount, currency, in.PaymentMethod, description, pay.IdempotencyKey)
+	if reason := payment.FailureReason(resp, chargeErr); reason != "" {
+		_ = pay.Fail(reason, s.now())
+		declined := fmt.Errorf("%w: %s", ErrPaymentFailed, reason)
+		if err := s.repo.UpdatePayment(pay); err != nil {
+			declined = errors.Join(declined, fmt.Errorf("record failed payment %s: %w", pay.ID, err))
+		}
+		return nil, pay, s.rollback(subscription, declined)
 	}
 
-	return nil
+	paidAt := s.now()
+	_ = pay.Complete(resp.RefID, paidAt)
+	_ = subscription.Activate(paidAt, paidAt.Add(domain.BillingPeriod))
+	if err := s.repo.UpdatePayment(pay); err != nil {
+		return nil, nil, s.refundAfterFailure(subscription, pay, err)
+	}
+	if err := s.repo.UpdateSubscription(subscription); err != nil {
+		return nil, nil, s.refundAfterFailure(subscription, pay, err)
+	}
+	return subscription, pay, nil
+}
 
+// rollback marks a subscription whose first charge did not go through as failed, returning cause. It
+// works on a fresh copy because the caller's may already have been activated in memory.
+func (s *BillingService) rollback(sub *domain.Subscription, cause error) error {
+	stored, err := s.repo.GetSubscription(sub.ID)
+	if err == nil {
+		if err = stored.Fail(s.now()); err == nil {
+			err = s.repo.UpdateSubscription(stored)
+		}
+	}
+	if err != nil {
+		return errors.Join(cause, fmt.Errorf("roll back subscription %s: %w", sub.ID, err))
+	}
+	return cause
+}
+
+// refundAfterFailure returns a charge that succeeded for a subscription that could not be saved, and
+// records the payment as refunded so that it cannot be refunded again.
+func (s *BillingService) refundAfterFailure(sub *domain.Subscription, pay *domain.Payment, cause error) error {
+	resp, err := s.gateway.Refund(pay.GatewayRef, pay.Amount)
+	if reason := payment.FailureReason(resp, err); reason != "" {
+		return errors.Join(cause, fmt.Errorf("%w for charge %s: %s", ErrRefundFailed, pay.GatewayRef, reason))
+	}
+	_ = pay.Refund(s.now())
+	if err := s.repo.UpdatePayment(pay); err != nil {
+		cause = errors.Join(cause, fmt.Errorf("record refund of payment %s: %w", pay.ID, err))
+	}
+	return s.rollback(sub, cause)
+}
+
+// GetSubscription returns a subscription or repository.ErrSubscriptionNotFound.
+func (s *BillingService) GetSubscription(id uuid.UUID) (*domain.Subscription, error) {
+	return s.repo.GetSubscription(id)
+}
+
+// CancelSubscription cancels a subscription that has not already ended, stopping any dunning retries.
+// It does not refund past payments; use RefundPayment for that. When the subscription changes while
+// it is being cancelled, e.g. by a renewal, the cancellation is retried on the new state.
+func (s *BillingService) CancelSubscription(id uuid.UUID) (*domain.Subscription, error) {
+	for attempt := 1; ; attempt++ {
+		sub, err := s.repo.GetSubscription(id)
+		if err != nil {
+			return nil, err
+		}
+		if err := sub.Cancel(s.now()); err != nil {
+			return nil, err
+		}
+		err = s.repo.UpdateSubscription(sub)
+		if errors.Is(err, repository.ErrSubscriptionConflict) && attempt < maxUpdateAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return sub, nil
+	}
+}
+
+// RefundPayment refunds a completed payment in full through the gateway.
+//
+// The payment is first claimed by storing it as refunding, which only one of several concurrent
+// callers can do; the others get domain.ErrInvalidTransition or repository.ErrPaymentConflict and
+// never reach the gateway. If the gateway refuses, the payment goes back to completed.
+func (s *BillingService) RefundPayment(id uuid.UUID) (*domain.Payment, error) {
+	pay, err := s.repo.GetPayment(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := pay.BeginRefund(s.now()); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdatePayment(pay); err != nil {
+		return nil, err
+	}
+	resp, refundErr := s.gateway.Refund(pay.GatewayRef, pay.Amount)
+	if reason := payment.FailureReason(resp, refundErr); reason != "" {
+		refused := fmt.Errorf("%w: %s", ErrRefundFailed, reason)
+		_ = pay.AbortRefund(s.now())
+		if err := s.repo.UpdatePayment(pay); err != nil {
+			refused = errors.Join(refused, fmt.Errorf("release payment %s: %w", pay.ID, err))
+		}
+		return nil, refused
+	}
+	_ = pay.Refund(s.now())
+	if err := s.repo.UpdatePayment(pay); err != nil {
+		return nil, err
+	}
+	return pay, nil
+}
+
+// UserPayments returns every payment attempt by a user, including failed ones, oldest first.
+func (s *BillingService) UserPayments(userID uuid.UUID) ([]*domain.Payment, error) {
+	return s.repo.ListPaymentsByUser(userID)
+}
+
+// SubscriptionPayments returns the payments made for a subscription, oldest first.
+func (s *BillingService) SubscriptionPayments(id uuid.UUID) ([]*domain.Payment, error) {
+	if _, err := s.repo.GetSubscription(id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListPaymentsBySubscription(id)
 }
@@ -0,0 +1,360 @@
+package service
+
+import (
+	"errors"
+	"subcription/internal/billing/domain"
+	"subcription/internal/billing/repository"
+	"subcription/internal/platform/payment"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// recordingGateway wraps a gateway and remembers the arguments of the last charge.
+type recordingGateway struct {
+	payment.Gatway
+	declineWithoutError bool
+	currency, source    string
+	refunds             int
+}
+
+func (g *recordingGateway) Charge(amount int64, currency, source, description, idempotencyKey string) (*payment.GatewayResponse, error) {
+	g.currency, g.source = currency, source
+	if g.declineWithoutError {
+		return &payment.GatewayResponse{Success: false, ErrorMessage: "card declined"}, nil
+	}
+	return g.Gatway.Charge(amount, currency, source, description, idempotencyKey)
+}
+
+func (g *recordingGateway) Refund(chargeID string, amount int64) (*payment.GatewayResponse, error) {
+	g.refunds++
+	return g.Gatway.Refund(chargeID, amount)
+}
+
+func validInput() CreateSubscriptionInput {
+	return CreateSubscriptionInput{
+		UserID:        uuid.New(),
+		PlanID:        "basic_plan",
+		Amount:        999,
+		PaymentMethod: "pm_card_visa",
+	}
+}
+
+func TestCreateSubscription(t *testing.T) {
+	tests := []struct {
+		name           string
+		gateway        *recordingGateway
+		input          func(in *CreateSubscriptionInput)
+		wantErr        error
+		wantSub        domain.SubscriptionStatus
+		wantPayment    domain.PaymentStatus
+		wantCurrency   string
+		wantGatewayHit bool
+	}{
+		{
+			name:           "charge succeeds",
+			gateway:        &recordingGateway{Gatway: &payment.StripeMock{}},
+			wantSub:        domain.StatusActive,
+			wantPayment:    domain.PaymentCompleted,
+			wantCurrency:   domain.DefaultCurrency,
+			wantGatewayHit: true,
+		},
+		{
+			name:           "currency is normalized once for all records",
+			gateway:        &recordingGateway{Gatway: &payment.StripeMock{}},
+			input:          func(in *CreateSubscriptionInput) { in.Currency = " usd " },
+			wantSub:        domain.StatusActive,
+			wantPayment:    domain.PaymentCompleted,
+			wantCurrency:   "USD",
+			wantGatewayHit: true,
+		},
+		{
+			name:           "gateway error rolls back",
+			gateway:        &recordingGateway{Gatway: &payment.StripeMock{ShouldFail: true}},
+			wantErr:        ErrPaymentFailed,
+			wantPayment:    domain.PaymentFailed,
+			wantCurrency:   domain.DefaultCurrency,
+			wantGatewayHit: true,
+		},
+		{
+			name:           "decline without error rolls back",
+			gateway:        &recordingGateway{Gatway: &payment.StripeMock{}, declineWithoutError: true},
+			wantErr:        ErrPaymentFailed,
+			wantPayment:    domain.PaymentFailed,
+			wantCurrency:   domain.DefaultCurrency,
+			wantGatewayHit: true,
+		},
+		{
+			name:    "missing payment method",
+			gateway: &recordingGateway{Gatway: &payment.StripeMock{}},
+			input:   func(in *CreateSubscriptionInput) { in.PaymentMethod = "" },
+			wantErr: ErrInvalidRequest,
+		},
+		{
+			name:    "non-positive amount",
+			gateway: &recordingGateway{Gatway: &payment.StripeMock{}},
+			input:   func(in *CreateSubscriptionInput) { in.Amount = 0 },
+			wantErr: ErrInvalidRequest,
+		},
+		{
+			name:    "bad currency",
+			gateway: &recordingGateway{Gatway: &payment.StripeMock{}},
+			input:   func(in *CreateSubscriptionInput) { in.Currency = "rupees" },
+			wantErr: ErrInvalidRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewInMemoryBillingRepository()
+			svc := NewBillingService(repo, tt.gateway)
+			in := validInput()
+			if tt.input != nil {
+				tt.input(&in)
+			}
+
+			sub, pay, err := svc.CreateSubscription(in)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if hit := tt.gateway.source != ""; hit != tt.wantGatewayHit {
+				t.Fatalf("gateway charged = %v, want %v", hit, tt.wantGatewayHit)
+			}
+			if tt.wantGatewayHit && tt.gateway.source != in.PaymentMethod {
+				t.Errorf("charged source %q, want %q", tt.gateway.source, in.PaymentMethod)
+			}
+
+			history, _ := repo.ListPaymentsByUser(in.UserID)
+			if tt.wantPayment == "" {
+				if len(history) != 0 {
+					t.Fatalf("recorded %d payments for a rejected request", len(history))
+				}
+				return
+			}
+			if pay == nil || pay.Status != tt.wantPayment {
+				t.Fatalf("payment = %+v, want status %s", pay, tt.wantPayment)
+			}
+			if len(history) != 1 || history[0].Status != tt.wantPayment {
+				t.Fatalf("payment history = %+v", history)
+			}
+			if pay.Currency != tt.wantCurrency || tt.gateway.currency != tt.wantCurrency {
+				t.Errorf("payment currency %q, charged %q, want %q", pay.Currency, tt.gateway.currency, tt.wantCurrency)
+			}
+
+			if tt.wantSub == "" {
+				if sub != nil {
+					t.Fatalf("returned subscription %+v after a failed charge", sub)
+				}
+				if stored, err := repo.GetSubscription(pay.SubscriptionID); err != nil || stored.Status != domain.StatusFailed {
+					t.Fatalf("pending subscription was not marked failed: %+v, %v", stored, err)
+				}
+				if pay.FailureReason == "" {
+					t.Error("failed payment has no reason")
+				}
+				return
+			}
+			stored, err := repo.GetSubscription(sub.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Status != tt.wantSub || stored.Currency != tt.wantCurrency {
+				t.Errorf("stored subscription = %+v", stored)
+			}
+			if pay.GatewayRef == "" || pay.SubscriptionID != sub.ID {
+				t.Errorf("payment not linked to charge and subscription: %+v", pay)
+			}
+		})
+	}
+}
+
+func TestCancelSubscription(t *testing.T) {
+	tests := []struct {
+		name    string
+		cancels int
+		wantErr error
+	}{
+		{name: "active subscription", cancels: 1},
+		{name: "already cancelled", cancels: 2, wantErr: domain.ErrInvalidTransition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewBillingService(repository.NewInMemoryBillingRepository(), &payment.StripeMock{})
+			sub, _, err := svc.CreateSubscription(validInput())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < tt.cancels; i++ {
+				_, err = svc.CancelSubscription(sub.ID)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			stored, _ := svc.GetSubscription(sub.ID)
+			if stored.Status != domain.StatusCancelled {
+				t.Fatalf("status = %s", stored.Status)
+			}
+		})
+	}
+}
+
+func TestRefundPayment(t *testing.T) {
+	tests := []struct {
+		name       string
+		gatewayOK  bool
+		refunds    int
+		wantErr    error
+		wantStatus domain.PaymentStatus
+	}{
+		{name: "completed payment", gatewayOK: true, refunds: 1, wantStatus: domain.PaymentRefunded},
+		{name: "refunded twice", gatewayOK: true, refunds: 2, wantErr: domain.ErrInvalidTransition, wantStatus: domain.PaymentRefunded},
+		{name: "gateway refuses", gatewayOK: false, refunds: 1, wantErr: ErrRefundFailed, wantStatus: domain.PaymentCompleted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &payment.StripeMock{}
+			repo := repository.NewInMemoryBillingRepository()
+			svc := NewBillingService(repo, mock)
+			_, pay, err := svc.CreateSubscription(validInput())
+			if err != nil {
+				t.Fatal(err)
+			}
+			mock.ShouldFail = !tt.gatewayOK
+			for i := 0; i < tt.refunds; i++ {
+				_, err = svc.RefundPayment(pay.ID)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			stored, _ := repo.GetPayment(pay.ID)
+			if stored.Status != tt.wantStatus {
+				t.Fatalf("status = %s, want %s", stored.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRefundAfterFailedActivation(t *testing.T) {
+	gateway := &recordingGateway{Gatway: &payment.StripeMock{}}
+	repo := &failingUpdateRepo{BillingRepository: repository.NewInMemoryBillingRepository()}
+	svc := NewBillingService(repo, gateway)
+	in := validInput()
+
+	_, _, err := svc.CreateSubscription(in)
+	if err == nil {
+		t.Fatal("expected the storage error")
+	}
+	if gateway.refunds != 1 {
+		t.Fatalf("refunds = %d, want 1", gateway.refunds)
+	}
+	payments, _ := repo.ListPaymentsByUser(in.UserID)
+	if len(payments) != 1 || payments[0].Status != domain.PaymentRefunded {
+		t.Fatalf("payments = %+v, want the one refunded charge", payments)
+	}
+	if stored, err := repo.GetSubscription(payments[0].SubscriptionID); err != nil || stored.Status != domain.StatusFailed {
+		t.Fatalf("subscription not rolled back: %+v, %v", stored, err)
+	}
+}
+
+// failingUpdateRepo cannot activate subscriptions.
+type failingUpdateRepo struct {
+	repository.BillingRepository
+}
+
+func (r *failingUpdateRepo) UpdateSubscription(sub *domain.Subscription) error {
+	if sub.Status == domain.StatusActive {
+		return errors.New("database unavailable")
+	}
+	return r.BillingRepository.UpdateSubscription(sub)
+}
+
+// failedPaymentRepo cannot record failed payments.
+type failedPaymentRepo struct {
+	repository.BillingRepository
+}
+
+func (r *failedPaymentRepo) UpdatePayment(p *domain.Payment) error {
+	if p.Status == domain.PaymentFailed {
+		return errors.New("database unavailable")
+	}
+	return r.BillingRepository.UpdatePayment(p)
+}
+
+func TestDeclineReportedWhenFailedPaymentCannotBeStored(t *testing.T) {
+	repo := &failedPaymentRepo{BillingRepository: repository.NewInMemoryBillingRepository()}
+	svc := NewBillingService(repo, &payment.StripeMock{ShouldFail: true})
+
+	_, pay, err := svc.CreateSubscription(validInput())
+	if !errors.Is(err, ErrPaymentFailed) {
+		t.Fatalf("err = %v, want ErrPaymentFailed", err)
+	}
+	if pay == nil || pay.Status != domain.PaymentFailed {
+		t.Fatalf("payment = %+v, want the failed payment", pay)
+	}
+	if stored, err := repo.GetSubscription(pay.SubscriptionID); err != nil || stored.Status != domain.StatusFailed {
+		t.Fatalf("subscription = %+v, %v, want failed", stored, err)
+	}
+}
+
+// slowRefundGateway holds every refund until release is closed, so concurrent refunds overlap.
+type slowRefundGateway struct {
+	payment.Gatway
+	release chan struct{}
+	refunds atomic.Int32
+}
+
+func (g *slowRefundGateway) Refund(chargeID string, amount int64) (*payment.GatewayResponse, error) {
+	g.refunds.Add(1)
+	<-g.release
+	return g.Gatway.Refund(chargeID, amount)
+}
+
+func TestConcurrentRefundsReachGatewayOnce(t *testing.T) {
+	gateway := &slowRefundGateway{Gatway: &payment.StripeMock{}, release: make(chan struct{})}
+	repo := repository.NewInMemoryBillingRepository()
+	svc := NewBillingService(repo, gateway)
+	_, pay, err := svc.CreateSubscription(validInput())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const callers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.RefundPayment(pay.ID)
+			errs <- err
+		}()
+	}
+	// Let the losers fail fast while the winner is still inside the gateway.
+	time.Sleep(50 * time.Millisecond)
+	close(gateway.release)
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, domain.ErrInvalidTransition), errors.Is(err, repository.ErrPaymentConflict):
+		default:
+			t.Fatalf("unexpected refund error: %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("%d refunds succeeded, want 1", succeeded)
+	}
+	if got := gateway.refunds.Load(); got != 1 {
+		t.Fatalf("gateway refunds = %d, want 1", got)
+	}
+	stored, _ := repo.GetPayment(pay.ID)
+	if stored.Status != domain.PaymentRefunded {
+		t.Fatalf("status = %s, want refunded", stored.Status)
+	}
+}
@@ -1,6 +1,10 @@
 package payment
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
 
 type GatewayResponse struct {
 	Success      bool
@@ -9,15 +13,44 @@ type GatewayResponse struct {
 }
 
 type Gatway interface {
-	Charge(amount int64, currency, source, description string) (*GatewayResponse, error)
+	// Charge takes amount from source. Repeating a charge with the same idempotencyKey returns the
+	// original charge instead of taking the money again.
+	Charge(amount int64, currency, source, description, idempotencyKey string) (*GatewayResponse, error)
 	Refund(chargeID string, amount int64) (*GatewayResponse, error)
 }
 
+// FailureReason returns why a gateway call failed, or "" if it succeeded. A call fails when it
+// returns an error or a response without Success.
+func FailureReason(resp *GatewayResponse, err error) string {
+	switch {
+	case err != nil:
+		return err.Error()
+	case resp == nil:
+		return "no response from payment gateway"
+	case !resp.Success:
+		if resp.ErrorMessage != "" {
+			return resp.ErrorMessage
+		}
+		return "declined by payment gateway"
+	}
+	return ""
+}
+
 type StripeMock struct {
 	ShouldFail bool
+
+	mu       sync.Mutex
+	charges  map[string]*GatewayResponse
+	refunded map[string]bool
 }
 
-func (s *StripeMock) Charge(amount int64, currency, source, description string) (*GatewayResponse, error) {
+func (s *StripeMock) Charge(amount int64, currency, source, description, idempotencyKey string) (*GatewayResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if resp, ok := s.charges[idempotencyKey]; ok {
+		copied := *resp
+		return &copied, nil
+	}
 	if s.ShouldFail {
 		return &GatewayResponse{
 			Success:      false,
@@ -25,14 +58,38 @@ func (s *StripeMock) Charge(amount int64, currency, source, description string)
 		}, errors.New("charge failed")
 	}
 
-	return &GatewayResponse{
+	if s.charges == nil {
+		s.charges = make(map[string]*GatewayResponse)
+	}
+	resp := &GatewayResponse{
 		Success: true,
-		RefID:   "ch_mocked_12345",
-	}, nil
+		RefID:   fmt.Sprintf("ch_mocked_%d", len(s.charges)+1),
+	}
+	if idempotencyKey == "" {
+		idempotencyKey = "unkeyed:" + resp.RefID
+	}
+	s.charges[idempotencyKey] = resp
+	copied := *resp
+	return &copied, nil
 }
 
-func (s *StripeMock) Refund(chargeID string, amount int64) (*GatewayResponse, error) {
+// Charges returns how many distinct charges succeeded.
+func (s *StripeMock) Charges() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.charges)
+}
 
+// Refund returns a charge in full. Like a real gateway it refuses to refund the same charge twice.
+func (s *StripeMock) Refund(chargeID string, amount int64) (*GatewayResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.refunded[chargeID] {
+		return &GatewayResponse{
+			Success:      false,
+			ErrorMessage: "Charge already refunded",
+		}, fmt.Errorf("charge %s already refunded", chargeID)
+	}
 	if s.ShouldFail {
 		return &GatewayResponse{
 			Success:      false,
@@ -40,8 +97,12 @@ func (s *StripeMock) Refund(chargeID string, amount int64) (*GatewayResponse, er
 		}, errors.New("refund failed")
 	}
 
+	if s.refunded == nil {
+		s.refunded = make(map[string]bool)
+	}
+	s.refunded[chargeID] = true
 	return &GatewayResponse{
 		Success: true,
-		RefID:   "re_mocked_12345",
+		RefID:   fmt.Sprintf("re_mocked_%d", len(s.refunded)),
 	}, nil
 }
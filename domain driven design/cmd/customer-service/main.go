@@ -4,13 +4,17 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/helrachar/banking/internal/api"
 	appcustomer "github.com/helrachar/banking/internal/application/customer"
 	"github.com/helrachar/banking/internal/config"
+	"github.com/helrachar/banking/internal/domain"
+	"github.com/helrachar/banking/internal/infrastructure/eventlog"
 	"github.com/helrachar/banking/internal/infrastructure/memory"
+	"github.com/helrachar/banking/internal/infrastructure/sqlstore"
 	"github.com/helrachar/banking/internal/logging"
 	"github.com/helrachar/banking/internal/server"
 )
@@ -19,8 +23,18 @@ func main() {
 	cfg := config.Load()
 	logger := logging.New(cfg.Env)
 
-	repo := memory.NewCustomerRepository()
-	service := appcustomer.NewService(repo)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	repo, closeRepo, err := openRepository(ctx, cfg.Storage)
+	if err != nil {
+		logger.Error("open customer repository failed", "driver", cfg.Storage.Driver, "error", err)
+		os.Exit(1)
+	}
+	defer closeRepo()
+	logger.Info("customer repository ready", "driver", cfg.Storage.Driver)
+
+	service := appcustomer.NewService(repo, eventlog.NewPublisher(logger))
 	handler := api.NewCustomerHandler(service, logger)
 
 	mux := http.NewServeMux()
@@ -34,9 +48,6 @@ func main() {
 
 	httpServer := server.NewHTTPServer(cfg.HTTP, decoratedHandler)
 
-	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	defer stop()
-
 	go func() {
 		logger.Info("http server starting", "port", cfg.HTTP.Port)
 		if err := httpServer.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -55,3 +66,15 @@ func main() {
 		logger.Info("server stopped gracefully")
 	}
 }
+
+// openRepository returns the repository selected by cfg and a func releasing it.
+func openRepository(ctx context.Context, cfg config.StorageConfig) (domain.CustomerRepository, func(), error) {
+	if cfg.Driver == "memory" {
+		return memory.NewCustomerRepository(), func() {}, nil
+	}
+	repo, err := sqlstore.Open(ctx, cfg.Driver, cfg.DSN)
+	if err != nil {
+		return nil, nil, err
+	}
+	return repo, func() { _ = repo.Close() }, nil
+}
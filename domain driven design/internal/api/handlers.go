@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -52,31 +53,69 @@ func (h *CustomerHandler) routeCustomers(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// routeCustomerByID serves /customers/{id} and the KYC commands under /customers/{id}/kyc/.
 func (h *CustomerHandler) routeCustomerByID(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		respondError(w, r, http.StatusMethodNotAllowed, "method not allowed", nil)
-		return
-	}
-	id := strings.TrimPrefix(r.URL.Path, "/customers/")
+	id, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/customers/"), "/")
 	if id == "" {
 		respondError(w, r, http.StatusNotFound, "customer not found", nil)
 		return
 	}
-	h.getCustomer(w, r, domain.CustomerID(id))
+	customerID := domain.CustomerID(id)
+
+	if rest == "" {
+		switch r.Method {
+		case http.MethodGet:
+			h.getCustomer(w, r, customerID)
+		case http.MethodPatch:
+			h.updateProfile(w, r, customerID)
+		default:
+			respondError(w, r, http.StatusMethodNotAllowed, "method not allowed", nil)
+		}
+		return
+	}
+
+	action, ok := strings.CutPrefix(rest, "kyc/")
+	handle, known := h.kycActions()[action]
+	if !ok || !known {
+		respondError(w, r, http.StatusNotFound, "not found", nil)
+		return
+	}
+	if r.Method != http.MethodPost {
+		respondError(w, r, http.StatusMethodNotAllowed, "method not allowed", nil)
+		return
+	}
+	handle(w, r, customerID)
 }
 
 type createCustomerRequest struct {
 	FullName string `json:"fullName"`
 	Email    string `json:"email"`
 	PAN      string `json:"pan"`
+	Phone    string `json:"phone"`
 }
 
 type customerResponse struct {
-	ID        string `json:"id"`
-	FullName  string `json:"fullName"`
-	Email     string `json:"email"`
-	PAN       string `json:"pan"`
-	CreatedAt string `json:"createdAt"`
+	ID        string             `json:"id"`
+	FullName  string             `json:"fullName"`
+	Email     string             `json:"email"`
+	PAN       string             `json:"pan"`
+	Phone     string             `json:"phone,omitempty"`
+	KYCStatus string             `json:"kycStatus"`
+	KYCReason string             `json:"kycReason,omitempty"`
+	Documents []documentResponse `json:"documents"`
+	Version   int                `json:"version"`
+	CreatedAt string             `json:"createdAt"`
+	UpdatedAt string             `json:"updatedAt"`
+}
+
+type documentResponse struct {
+	Type      string `json:"type"`
+	Reference string `json:"reference"`
+}
+
+type customerPageResponse struct {
+	Items      []customerResponse `json:"items"`
+	NextCursor string             `json:"nextCursor,omitempty"`
 }
 
 type errorResponse struct {
@@ -97,15 +136,9 @@ func (h *CustomerHandler) createCustomer(w http.ResponseWriter, r *http.Request)
 	defer cancel()
 
 	id := domain.CustomerID(uuid.NewString())
-	customer, err := h.service.RegisterCustomer(ctx, id, req.FullName, req.Email, req.PAN)
+	customer, err := h.service.RegisterCustomer(ctx, id, req.FullName, req.Email, req.PAN, req.Phone)
 	if err != nil {
-		var validationErr *domain.ValidationError
-		if errors.As(err, &validationErr) {
-			respondError(w, r, http.StatusBadRequest, "validation failed", validationErr.Fields())
-			return
-		}
-		respondError(w, r, http.StatusInternalServerError, "unable to create customer", nil)
-		h.logger.Error("register customer failed", "error", err, "request_id", server.RequestIDFromContext(r.Context()))
+		h.respondCommandError(w, r, err, "register customer")
 		return
 	}
 
@@ -131,30 +164,91 @@ func (h *CustomerHandler) getCustomer(w http.ResponseWriter, r *http.Request, id
 }
 
 func (h *CustomerHandler) listCustomers(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseCustomerFilter(r)
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "validation failed", err.Fields())
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
 	defer cancel()
 
-	customers, err := h.service.ListCustomers(ctx)
-	if err != nil {
+	page, listErr := h.service.ListCustomers(ctx, filter)
+	if listErr != nil {
+		var validationErr *domain.ValidationError
+		if errors.As(listErr, &validationErr) {
+			respondError(w, r, http.StatusBadRequest, "validation failed", validationErr.Fields())
+			return
+		}
 		respondError(w, r, http.StatusInternalServerError, "unable to list customers", nil)
-		h.logger.Error("list customers failed", "error", err, "request_id", server.RequestIDFromContext(r.Context()))
+		h.logger.Error("list customers failed", "error", listErr, "request_id", server.RequestIDFromContext(r.Context()))
 		return
 	}
 
-	resp := make([]customerResponse, 0, len(customers))
-	for _, c := range customers {
-		resp = append(resp, mapCustomer(c))
+	resp := customerPageResponse{Items: make([]customerResponse, 0, len(page.Customers)), NextCursor: page.NextCursor}
+	for _, c := range page.Customers {
+		resp.Items = append(resp.Items, mapCustomer(c))
 	}
 	respondJSON(w, http.StatusOK, resp)
 }
 
+// parseCustomerFilter reads email, name, kycStatus, createdAfter, createdBefore (RFC 3339), limit and cursor
+// from the query string.
+func parseCustomerFilter(r *http.Request) (domain.CustomerFilter, *domain.ValidationError) {
+	q := r.URL.Query()
+	errs := domain.NewValidationError()
+	filter := domain.CustomerFilter{
+		Email:        q.Get("email"),
+		NameContains: q.Get("name"),
+		Cursor:       q.Get("cursor"),
+	}
+	if v := q.Get("kycStatus"); v != "" {
+		status, err := domain.ParseKYCStatus(v)
+		if err != nil {
+			errs.Add("kycStatus", "is not a known KYC status")
+		}
+		filter.KYCStatus = status
+	}
+	for key, target := range map[string]*time.Time{"createdAfter": &filter.CreatedAfter, "createdBefore": &filter.CreatedBefore} {
+		if v := q.Get(key); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				errs.Add(key, "must be an RFC 3339 timestamp")
+				continue
+			}
+			*target = t
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			errs.Add("limit", "must be a positive integer")
+		}
+		filter.Limit = limit
+	}
+	if errs.HasErrors() {
+		return domain.CustomerFilter{}, errs
+	}
+	return filter, nil
+}
+
 func mapCustomer(c *domain.Customer) customerResponse {
+	docs := make([]documentResponse, 0, len(c.Documents()))
+	for _, d := range c.Documents() {
+		docs = append(docs, documentResponse{Type: string(d.Type), Reference: d.Reference})
+	}
 	return customerResponse{
 		ID:        string(c.ID()),
 		FullName:  c.FullName(),
-		Email:     c.Email(),
-		PAN:       c.PAN(),
+		Email:     c.Email().String(),
+		PAN:       c.PAN().String(),
+		Phone:     c.Phone().String(),
+		KYCStatus: string(c.KYCStatus()),
+		KYCReason: c.KYCReason(),
+		Documents: docs,
+		Version:   c.Version(),
 		CreatedAt: c.CreatedAt().Format(time.RFC3339),
+		UpdatedAt: c.UpdatedAt().Format(time.RFC3339),
 	}
 }
 
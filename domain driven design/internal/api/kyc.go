@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/helrachar/banking/internal/domain"
+	"github.com/helrachar/banking/internal/server"
+)
+
+type customerCommand func(w http.ResponseWriter, r *http.Request, id domain.CustomerID)
+
+// kycActions maps the last path segment of /customers/{id}/kyc/{action} to its handler.
+func (h *CustomerHandler) kycActions() map[string]customerCommand {
+	return map[string]customerCommand{
+		"documents": h.submitDocuments,
+		"verify":    h.verifyKYC,
+		"reject":    h.rejectKYC,
+		"suspend":   h.suspendCustomer,
+		"reinstate": h.reinstateCustomer,
+	}
+}
+
+// updateProfileRequest carries the version the client last read; omitted fields are left unchanged.
+type updateProfileRequest struct {
+	Version  int     `json:"version"`
+	FullName *string `json:"fullName"`
+	Email    *string `json:"email"`
+	Phone    *string `json:"phone"`
+}
+
+type submitDocumentsRequest struct {
+	Documents []documentResponse `json:"documents"`
+}
+
+type reviewRequest struct {
+	Reviewer string `json:"reviewer"`
+	Reason   string `json:"reason"`
+}
+
+func (h *CustomerHandler) updateProfile(w http.ResponseWriter, r *http.Request, id domain.CustomerID) {
+	var req updateProfileRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+	if req.Version <= 0 {
+		respondError(w, r, http.StatusBadRequest, "validation failed", map[string]string{"version": "is required"})
+		return
+	}
+	update := domain.ProfileUpdate{FullName: req.FullName, Email: req.Email, Phone: req.Phone}
+	h.runCommand(w, r, "update profile", func(ctx context.Context) (*domain.Customer, error) {
+		return h.service.UpdateProfile(ctx, id, req.Version, update)
+	})
+}
+
+func (h *CustomerHandler) submitDocuments(w http.ResponseWriter, r *http.Request, id domain.CustomerID) {
+	var req submitDocumentsRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+	docs := make([]domain.KYCDocument, 0, len(req.Documents))
+	for _, d := range req.Documents {
+		docs = append(docs, domain.KYCDocument{Type: domain.DocumentType(d.Type), Reference: d.Reference})
+	}
+	h.runCommand(w, r, "submit kyc documents", func(ctx context.Context) (*domain.Customer, error) {
+		return h.service.SubmitKYCDocuments(ctx, id, docs)
+	})
+}
+
+func (h *CustomerHandler) verifyKYC(w http.ResponseWriter, r *http.Request, id domain.CustomerID) {
+	var req reviewRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+	h.runCommand(w, r, "verify kyc", func(ctx context.Context) (*domain.Customer, error) {
+		return h.service.VerifyKYC(ctx, id, req.Reviewer)
+	})
+}
+
+func (h *CustomerHandler) rejectKYC(w http.ResponseWriter, r *http.Request, id domain.CustomerID) {
+	var req reviewRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+	h.runCommand(w, r, "reject kyc", func(ctx context.Context) (*domain.Customer, error) {
+		return h.service.RejectKYC(ctx, id, req.Reviewer, req.Reason)
+	})
+}
+
+func (h *CustomerHandler) suspendCustomer(w http.ResponseWriter, r *http.Request, id domain.CustomerID) {
+	var req reviewRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+	h.runCommand(w, r, "suspend customer", func(ctx context.Context) (*domain.Customer, error) {
+		return h.service.SuspendCustomer(ctx, id, req.Reason)
+	})
+}
+
+func (h *CustomerHandler) reinstateCustomer(w http.ResponseWriter, r *http.Request, id domain.CustomerID) {
+	h.runCommand(w, r, "reinstate customer", func(ctx context.Context) (*domain.Customer, error) {
+		return h.service.ReinstateCustomer(ctx, id)
+	})
+}
+
+func (h *CustomerHandler) decode(w http.ResponseWriter, r *http.Request, dst any) bool {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		h.logger.Warn("invalid payload", "error", err, "request_id", server.RequestIDFromContext(r.Context()))
+		respondError(w, r, http.StatusBadRequest, "invalid payload", nil)
+		return false
+	}
+	return true
+}
+
+// runCommand executes a customer command and responds with the updated customer.
+func (h *CustomerHandler) runCommand(w http.ResponseWriter, r *http.Request, action string, command func(context.Context) (*domain.Customer, error)) {
+	ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+	defer cancel()
+
+	customer, err := command(ctx)
+	if err != nil {
+		h.respondCommandError(w, r, err, action)
+		return
+	}
+	respondJSON(w, http.StatusOK, mapCustomer(customer))
+}
+
+// respondCommandError maps domain errors to status codes: validation failures are 400, unknown
+// customers 404, and anything that conflicts with the customer's current state 409.
+func (h *CustomerHandler) respondCommandError(w http.ResponseWriter, r *http.Request, err error, action string) {
+	var validationErr *domain.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		respondError(w, r, http.StatusBadRequest, "validation failed", validationErr.Fields())
+	case errors.Is(err, domain.ErrNotFound):
+		respondError(w, r, http.StatusNotFound, "customer not found", nil)
+	case errors.Is(err, domain.ErrDuplicateEmail):
+		respondError(w, r, http.StatusConflict, "customer already exists", map[string]string{"email": "is already registered"})
+	case errors.Is(err, domain.ErrDuplicatePAN):
+		respondError(w, r, http.StatusConflict, "customer already exists", map[string]string{"pan": "is already registered"})
+	case errors.Is(err, domain.ErrVersionConflict):
+		respondError(w, r, http.StatusConflict, "customer was modified; reload and retry", nil)
+	case errors.Is(err, domain.ErrInvalidKYCTransition), errors.Is(err, domain.ErrCustomerSuspended):
+		respondError(w, r, http.StatusConflict, err.Error(), nil)
+	default:
+		respondError(w, r, http.StatusInternalServerError, "unable to "+action, nil)
+		h.logger.Error(action+" failed", "error", err, "request_id", server.RequestIDFromContext(r.Context()))
+	}
+}
@@ -10,24 +10,29 @@ import (
 
 // Service orchestrates customer use-cases within the application layer.
 type Service struct {
-	repo domain.CustomerRepository
-	now  func() time.Time
+	repo      domain.CustomerRepository
+	publisher domain.EventPublisher
+	now       func() time.Time
 }
 
-// NewService wires dependencies for the customer application service.
-func NewService(repo domain.CustomerRepository) *Service {
-	return &Service{repo: repo, now: func() time.Time { return time.Now().UTC() }}
+// NewService wires dependencies for the customer application service. A nil publisher drops events.
+func NewService(repo domain.CustomerRepository, publisher domain.EventPublisher) *Service {
+	if publisher == nil {
+		publisher = discardPublisher{}
+	}
+	return &Service{repo: repo, publisher: publisher, now: func() time.Time { return time.Now().UTC() }}
 }
 
 // RegisterCustomer validates and persists a new customer aggregate.
-func (s *Service) RegisterCustomer(ctx context.Context, id domain.CustomerID, fullName, email, pan string) (*domain.Customer, error) {
-	customer, err := domain.NewCustomer(id, fullName, email, pan, s.now())
+func (s *Service) RegisterCustomer(ctx context.Context, id domain.CustomerID, fullName, email, pan, phone string) (*domain.Customer, error) {
+	customer, err := domain.NewCustomer(id, fullName, email, pan, phone, s.now())
 	if err != nil {
 		return nil, err
 	}
 	if err := s.repo.Save(ctx, customer); err != nil {
 		return nil, fmt.Errorf("save customer: %w", err)
 	}
+	s.publisher.Publish(ctx, customer.PullEvents())
 	return customer, nil
 }
 
@@ -40,11 +45,81 @@ func (s *Service) FetchCustomer(ctx context.Context, id domain.CustomerID) (*dom
 	return customer, nil
 }
 
-// ListCustomers returns all aggregates (demo scope).
-func (s *Service) ListCustomers(ctx context.Context) ([]*domain.Customer, error) {
-	customers, err := s.repo.List(ctx)
+// ListCustomers returns one page of customers matching filter.
+func (s *Service) ListCustomers(ctx context.Context, filter domain.CustomerFilter) (domain.CustomerPage, error) {
+	page, err := s.repo.List(ctx, filter.Normalize())
+	if err != nil {
+		return domain.CustomerPage{}, fmt.Errorf("list customers: %w", err)
+	}
+	return page, nil
+}
+
+// UpdateProfile changes the customer's profile if it is still at expectedVersion, the version the
+// caller last read; otherwise it returns domain.ErrVersionConflict.
+func (s *Service) UpdateProfile(ctx context.Context, id domain.CustomerID, expectedVersion int, update domain.ProfileUpdate) (*domain.Customer, error) {
+	return s.mutate(ctx, id, func(c *domain.Customer) error {
+		if c.Version() != expectedVersion {
+			return fmt.Errorf("%w: customer is at version %d, not %d", domain.ErrVersionConflict, c.Version(), expectedVersion)
+		}
+		return c.UpdateProfile(update, s.now())
+	})
+}
+
+// SubmitKYCDocuments starts a KYC review with docs.
+func (s *Service) SubmitKYCDocuments(ctx context.Context, id domain.CustomerID, docs []domain.KYCDocument) (*domain.Customer, error) {
+	return s.mutate(ctx, id, func(c *domain.Customer) error {
+		return c.SubmitKYCDocuments(docs, s.now())
+	})
+}
+
+// VerifyKYC approves the customer's submitted documents.
+func (s *Service) VerifyKYC(ctx context.Context, id domain.CustomerID, reviewer string) (*domain.Customer, error) {
+	return s.mutate(ctx, id, func(c *domain.Customer) error {
+		return c.VerifyKYC(reviewer, s.now())
+	})
+}
+
+// RejectKYC rejects the customer's submitted documents.
+func (s *Service) RejectKYC(ctx context.Context, id domain.CustomerID, reviewer, reason string) (*domain.Customer, error) {
+	return s.mutate(ctx, id, func(c *domain.Customer) error {
+		return c.RejectKYC(reviewer, reason, s.now())
+	})
+}
+
+// SuspendCustomer blocks a verified customer.
+func (s *Service) SuspendCustomer(ctx context.Context, id domain.CustomerID, reason string) (*domain.Customer, error) {
+	return s.mutate(ctx, id, func(c *domain.Customer) error {
+		return c.Suspend(reason, s.now())
+	})
+}
+
+// ReinstateCustomer lifts a suspension.
+func (s *Service) ReinstateCustomer(ctx context.Context, id domain.CustomerID) (*domain.Customer, error) {
+	return s.mutate(ctx, id, func(c *domain.Customer) error {
+		return c.Reinstate(s.now())
+	})
+}
+
+// mutate loads the customer, applies change, saves it and publishes the events the change recorded.
+// Events are only published once the save succeeded.
+func (s *Service) mutate(ctx context.Context, id domain.CustomerID, change func(*domain.Customer) error) (*domain.Customer, error) {
+	customer, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("list customers: %w", err)
+		return nil, fmt.Errorf("get customer: %w", err)
+	}
+	if err := change(customer); err != nil {
+		return nil, err
 	}
-	return customers, nil
+	if customer.Version() == customer.PersistedVersion() {
+		return customer, nil
+	}
+	if err := s.repo.Save(ctx, customer); err != nil {
+		return nil, fmt.Errorf("save customer: %w", err)
+	}
+	s.publisher.Publish(ctx, customer.PullEvents())
+	return customer, nil
 }
+
+type discardPublisher struct{}
+
+func (discardPublisher) Publish(context.Context, []domain.Event) {}
@@ -12,6 +12,15 @@ type Config struct {
 	AppName string
 	Env     string
 	HTTP    HTTPConfig
+	Storage StorageConfig
+}
+
+// StorageConfig selects the customer repository.
+type StorageConfig struct {
+	// Driver is "memory", "sqlite" (local default) or "postgres".
+	Driver string
+	// DSN is the connection string; empty uses customers.db for sqlite.
+	DSN string
 }
 
 // HTTPConfig controls HTTP server behavior.
@@ -35,6 +44,10 @@ func Load() Config {
 			IdleTimeout:     durationFromEnv("HTTP_IDLE_TIMEOUT", 60*time.Second),
 			ShutdownTimeout: durationFromEnv("HTTP_SHUTDOWN_TIMEOUT", 10*time.Second),
 		},
+		Storage: StorageConfig{
+			Driver: envOrDefault("STORAGE_DRIVER", "sqlite"),
+			DSN:    os.Getenv("DATABASE_URL"),
+		},
 	}
 }
 
@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"fmt"
+	"slices"
 	"strings"
 	"time"
 )
@@ -8,21 +10,29 @@ import (
 // CustomerID represents the globally unique identifier for a customer aggregate.
 type CustomerID string
 
-// Customer models the core attributes HelraChar requires for onboarding.
+// Customer models the core attributes HelraChar requires for onboarding and the customer's KYC
+// lifecycle. Every change bumps Version and records a domain event; repositories use the version the
+// aggregate was loaded at to reject concurrent updates.
 type Customer struct {
-	id        CustomerID
-	fullName  string
-	email     string
-	pan       string
-	createdAt time.Time
+	id               CustomerID
+	fullName         string
+	email            Email
+	pan              PAN
+	phone            Phone
+	kycStatus        KYCStatus
+	kycReason        string
+	documents        []KYCDocument
+	version          int
+	persistedVersion int
+	createdAt        time.Time
+	updatedAt        time.Time
+	events           []Event
 }
 
-// NewCustomer enforces invariant checks before a customer aggregate is created.
-func NewCustomer(id CustomerID, fullName, email, pan string, createdAt time.Time) (*Customer, error) {
+// NewCustomer enforces invariant checks before a customer aggregate is created. phone is optional.
+func NewCustomer(id CustomerID, fullName, email, pan, phone string, createdAt time.Time) (*Customer, error) {
 	errs := NewValidationError()
 	name := strings.TrimSpace(fullName)
-	mail := strings.TrimSpace(email)
-	panValue := strings.TrimSpace(pan)
 
 	if id == "" {
 		errs.Add("id", "cannot be empty")
@@ -30,11 +40,19 @@ func NewCustomer(id CustomerID, fullName, email, pan string, createdAt time.Time
 	if name == "" {
 		errs.Add("fullName", "is required")
 	}
-	if !strings.Contains(mail, "@") {
-		errs.Add("email", "must contain '@'")
+	mail, err := ParseEmail(email)
+	if err != nil {
+		errs.Add("email", err.Error())
 	}
-	if len(panValue) != 10 {
-		errs.Add("pan", "must be 10 characters")
+	panValue, err := ParsePAN(pan)
+	if err != nil {
+		errs.Add("pan", err.Error())
+	}
+	var phoneValue Phone
+	if strings.TrimSpace(phone) != "" {
+		if phoneValue, err = ParsePhone(phone); err != nil {
+			errs.Add("phone", err.Error())
+		}
 	}
 	if errs.HasErrors() {
 		return nil, errs
@@ -43,13 +61,72 @@ func NewCustomer(id CustomerID, fullName, email, pan string, createdAt time.Time
 		createdAt = time.Now().UTC()
 	}
 
-	return &Customer{
+	c := &Customer{
 		id:        id,
 		fullName:  name,
-		email:     strings.ToLower(mail),
-		pan:       strings.ToUpper(panValue),
+		email:     mail,
+		pan:       panValue,
+		phone:     phoneValue,
+		kycStatus: KYCRegistered,
+		version:   1,
 		createdAt: createdAt,
-	}, nil
+		updatedAt: createdAt,
+	}
+	c.record(CustomerRegistered{EventMeta: c.eventMeta(), Email: mail, PAN: panValue})
+	return c, nil
+}
+
+// CustomerState is the persisted form of a customer, used by repositories to store and rehydrate the
+// aggregate without going through the onboarding rules.
+type CustomerState struct {
+	ID        CustomerID
+	FullName  string
+	Email     Email
+	PAN       PAN
+	Phone     Phone
+	KYCStatus KYCStatus
+	KYCReason string
+	Documents []KYCDocument
+	Version   int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// RehydrateCustomer rebuilds an aggregate from persisted state. Repositories use it instead of
+// NewCustomer because stored data was validated when it was written and must load even if the
+// onboarding rules have since become stricter.
+func RehydrateCustomer(s CustomerState) *Customer {
+	return &Customer{
+		id:               s.ID,
+		fullName:         s.FullName,
+		email:            s.Email,
+		pan:              s.PAN,
+		phone:            s.Phone,
+		kycStatus:        s.KYCStatus,
+		kycReason:        s.KYCReason,
+		documents:        append([]KYCDocument(nil), s.Documents...),
+		version:          s.Version,
+		persistedVersion: s.Version,
+		createdAt:        s.CreatedAt,
+		updatedAt:        s.UpdatedAt,
+	}
+}
+
+// State captures the aggregate for persistence.
+func (c *Customer) State() CustomerState {
+	return CustomerState{
+		ID:        c.id,
+		FullName:  c.fullName,
+		Email:     c.email,
+		PAN:       c.pan,
+		Phone:     c.phone,
+		KYCStatus: c.kycStatus,
+		KYCReason: c.kycReason,
+		Documents: append([]KYCDocument(nil), c.documents...),
+		Version:   c.version,
+		CreatedAt: c.createdAt,
+		UpdatedAt: c.updatedAt,
+	}
 }
 
 // ID exposes the aggregate identifier.
@@ -57,22 +134,247 @@ func (c *Customer) ID() CustomerID {
 	return c.id
 }
 
-// FullName returns the immutable full name captured at onboarding.
+// FullName returns the customer's current full name.
 func (c *Customer) FullName() string {
 	return c.fullName
 }
 
 // Email returns the normalized email address.
-func (c *Customer) Email() string {
+func (c *Customer) Email() Email {
 	return c.email
 }
 
-// PAN returns the 10 character Permanent Account Number reference.
-func (c *Customer) PAN() string {
+// PAN returns the Permanent Account Number captured at onboarding; it never changes.
+func (c *Customer) PAN() PAN {
 	return c.pan
 }
 
+// Phone returns the E.164 phone number, empty when none was given.
+func (c *Customer) Phone() Phone {
+	return c.phone
+}
+
+// KYCStatus returns the customer's position in the KYC lifecycle.
+func (c *Customer) KYCStatus() KYCStatus {
+	return c.kycStatus
+}
+
+// KYCReason explains the latest rejection or suspension; it is cleared by the next transition.
+func (c *Customer) KYCReason() string {
+	return c.kycReason
+}
+
+// Documents returns the documents submitted for the current KYC review.
+func (c *Customer) Documents() []KYCDocument {
+	return append([]KYCDocument(nil), c.documents...)
+}
+
+// Version counts the changes made to the customer, starting at 1 on registration.
+func (c *Customer) Version() int {
+	return c.version
+}
+
+// PersistedVersion is the version the aggregate was loaded or last saved at, 0 for a new customer.
+// Repositories only overwrite a stored customer still at this version.
+func (c *Customer) PersistedVersion() int {
+	return c.persistedVersion
+}
+
+// MarkPersisted is called by repositories after a successful save.
+func (c *Customer) MarkPersisted() {
+	c.persistedVersion = c.version
+}
+
 // CreatedAt returns the timestamp when the aggregate was persisted.
 func (c *Customer) CreatedAt() time.Time {
 	return c.createdAt
 }
+
+// UpdatedAt returns the time of the latest change.
+func (c *Customer) UpdatedAt() time.Time {
+	return c.updatedAt
+}
+
+// PullEvents returns the events recorded since the last call and forgets them.
+func (c *Customer) PullEvents() []Event {
+	events := c.events
+	c.events = nil
+	return events
+}
+
+// ProfileUpdate lists the profile fields to change; nil fields are left as they are. An empty Phone
+// removes the number.
+type ProfileUpdate struct {
+	FullName *string
+	Email    *string
+	Phone    *string
+}
+
+// UpdateProfile applies update as a single new version. Suspended customers cannot change their
+// profile.
+func (c *Customer) UpdateProfile(update ProfileUpdate, at time.Time) error {
+	if c.kycStatus == KYCSuspended {
+		return ErrCustomerSuspended
+	}
+	errs := NewValidationError()
+	var changes []ProfileChange
+
+	if update.FullName != nil {
+		name := strings.TrimSpace(*update.FullName)
+		switch {
+		case name == "":
+			errs.Add("fullName", "is required")
+		case name != c.fullName:
+			changes = append(changes, ProfileChange{Field: "fullName", From: c.fullName, To: name})
+		}
+	}
+	if update.Email != nil {
+		mail, err := ParseEmail(*update.Email)
+		if err != nil {
+			errs.Add("email", err.Error())
+		} else if mail != c.email {
+			changes = append(changes, ProfileChange{Field: "email", From: c.email.String(), To: mail.String()})
+		}
+	}
+	if update.Phone != nil {
+		var phone Phone
+		var err error
+		if strings.TrimSpace(*update.Phone) != "" {
+			phone, err = ParsePhone(*update.Phone)
+		}
+		if err != nil {
+			errs.Add("phone", err.Error())
+		} else if phone != c.phone {
+			changes = append(changes, ProfileChange{Field: "phone", From: c.phone.String(), To: phone.String()})
+		}
+	}
+	if errs.HasErrors() {
+		return errs
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	for _, change := range changes {
+		switch change.Field {
+		case "fullName":
+			c.fullName = change.To
+		case "email":
+			c.email = Email(change.To)
+		case "phone":
+			c.phone = Phone(change.To)
+		}
+	}
+	c.bump(at)
+	c.record(CustomerProfileUpdated{EventMeta: c.eventMeta(), Changes: changes})
+	return nil
+}
+
+// SubmitKYCDocuments starts a KYC review. It is allowed after registration and after a rejection.
+func (c *Customer) SubmitKYCDocuments(docs []KYCDocument, at time.Time) error {
+	if err := c.transition(KYCDocumentsSubmitted, "submit documents for"); err != nil {
+		return err
+	}
+	if errs := validateDocuments(docs); errs.HasErrors() {
+		return errs
+	}
+	c.documents = append([]KYCDocument(nil), docs...)
+	c.moveTo(KYCDocumentsSubmitted, "", at)
+	c.record(CustomerKYCDocumentsSubmitted{EventMeta: c.eventMeta(), Documents: c.Documents()})
+	return nil
+}
+
+// VerifyKYC approves the submitted documents.
+func (c *Customer) VerifyKYC(reviewer string, at time.Time) error {
+	if err := c.transition(KYCVerified, "verify", KYCDocumentsSubmitted); err != nil {
+		return err
+	}
+	reviewer = strings.TrimSpace(reviewer)
+	if reviewer == "" {
+		return requiredField("reviewer")
+	}
+	c.moveTo(KYCVerified, "", at)
+	c.record(CustomerKYCVerified{EventMeta: c.eventMeta(), Reviewer: reviewer})
+	return nil
+}
+
+// RejectKYC turns the submitted documents down; the customer may submit again.
+func (c *Customer) RejectKYC(reviewer, reason string, at time.Time) error {
+	if err := c.transition(KYCRejected, "reject"); err != nil {
+		return err
+	}
+	reviewer, reason = strings.TrimSpace(reviewer), strings.TrimSpace(reason)
+	errs := NewValidationError()
+	if reviewer == "" {
+		errs.Add("reviewer", "is required")
+	}
+	if reason == "" {
+		errs.Add("reason", "is required")
+	}
+	if errs.HasErrors() {
+		return errs
+	}
+	c.moveTo(KYCRejected, reason, at)
+	c.record(CustomerKYCRejected{EventMeta: c.eventMeta(), Reviewer: reviewer, Reason: reason})
+	return nil
+}
+
+// Suspend blocks a verified customer.
+func (c *Customer) Suspend(reason string, at time.Time) error {
+	if err := c.transition(KYCSuspended, "suspend"); err != nil {
+		return err
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return requiredField("reason")
+	}
+	c.moveTo(KYCSuspended, reason, at)
+	c.record(CustomerSuspended{EventMeta: c.eventMeta(), Reason: reason})
+	return nil
+}
+
+// Reinstate lifts a suspension, returning the customer to verified.
+func (c *Customer) Reinstate(at time.Time) error {
+	if err := c.transition(KYCVerified, "reinstate", KYCSuspended); err != nil {
+		return err
+	}
+	c.moveTo(KYCVerified, "", at)
+	c.record(CustomerReinstated{EventMeta: c.eventMeta()})
+	return nil
+}
+
+// transition checks that the lifecycle allows moving to next. Commands that share a target state, like
+// VerifyKYC and Reinstate, also name the states they start from.
+func (c *Customer) transition(next KYCStatus, action string, from ...KYCStatus) error {
+	if !c.kycStatus.CanTransitionTo(next) || (len(from) > 0 && !slices.Contains(from, c.kycStatus)) {
+		return fmt.Errorf("%w: cannot %s a customer whose KYC is %s", ErrInvalidKYCTransition, action, c.kycStatus)
+	}
+	return nil
+}
+
+func (c *Customer) moveTo(status KYCStatus, reason string, at time.Time) {
+	c.kycStatus = status
+	c.kycReason = reason
+	c.bump(at)
+}
+
+func (c *Customer) bump(at time.Time) {
+	if at.IsZero() {
+		at = time.Now().UTC()
+	}
+	c.version++
+	c.updatedAt = at
+}
+
+func (c *Customer) eventMeta() EventMeta {
+	return EventMeta{CustomerID: c.id, Version: c.version, At: c.updatedAt}
+}
+
+func (c *Customer) record(evt Event) {
+	c.events = append(c.events, evt)
+}
+
+func requiredField(field string) *ValidationError {
+	errs := NewValidationError()
+	errs.Add(field, "is required")
+	return errs
+}
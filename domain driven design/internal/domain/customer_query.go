@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Page size bounds for customer listings.
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+// CustomerFilter narrows a customer listing. Zero values match everything. Results are ordered by
+// creation time then ID, and Cursor continues after the last customer of the previous page.
+type CustomerFilter struct {
+	// Email matches the normalized address exactly.
+	Email string
+	// NameContains matches a case-insensitive substring of the full name.
+	NameContains  string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// KYCStatus matches customers currently in that status.
+	KYCStatus KYCStatus
+	Limit     int
+	Cursor    string
+}
+
+// CustomerPage is one page of a listing; NextCursor is empty on the last page.
+type CustomerPage struct {
+	Customers  []*Customer
+	NextCursor string
+}
+
+// Normalize applies the page size bounds and the same normalization NewCustomer uses for email, so
+// repositories can compare stored values directly.
+func (f CustomerFilter) Normalize() CustomerFilter {
+	f.Email = strings.ToLower(strings.TrimSpace(f.Email))
+	f.NameContains = strings.TrimSpace(f.NameContains)
+	switch {
+	case f.Limit <= 0:
+		f.Limit = DefaultPageSize
+	case f.Limit > MaxPageSize:
+		f.Limit = MaxPageSize
+	}
+	return f
+}
+
+// PageCursor is the position after which the next page starts.
+type PageCursor struct {
+	CreatedAt time.Time
+	ID        CustomerID
+}
+
+// EncodeCursor returns the opaque cursor for the page following c.
+func EncodeCursor(c *Customer) string {
+	raw := strconv.FormatInt(c.CreatedAt().UnixNano(), 10) + ":" + string(c.ID())
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses a cursor produced by EncodeCursor. An empty cursor yields the zero PageCursor.
+func DecodeCursor(cursor string) (PageCursor, error) {
+	if cursor == "" {
+		return PageCursor{}, nil
+	}
+	invalid := NewValidationError()
+	invalid.Add("cursor", "is not a valid page cursor")
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return PageCursor{}, invalid
+	}
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok || id == "" {
+		return PageCursor{}, invalid
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return PageCursor{}, invalid
+	}
+	return PageCursor{CreatedAt: time.Unix(0, n).UTC(), ID: CustomerID(id)}, nil
+}
+
+// After reports whether c sorts after the cursor position.
+func (p PageCursor) After(c *Customer) bool {
+	if !c.CreatedAt().Equal(p.CreatedAt) {
+		return c.CreatedAt().After(p.CreatedAt)
+	}
+	return c.ID() > p.ID
+}
+
+// Matches reports whether c satisfies the filter's predicates (not its paging). It expects a
+// normalized filter.
+func (f CustomerFilter) Matches(c *Customer) bool {
+	if f.Email != "" && string(c.Email()) != f.Email {
+		return false
+	}
+	if f.NameContains != "" && !strings.Contains(strings.ToLower(c.FullName()), strings.ToLower(f.NameContains)) {
+		return false
+	}
+	if f.KYCStatus != "" && c.KYCStatus() != f.KYCStatus {
+		return false
+	}
+	if !f.CreatedAfter.IsZero() && !c.CreatedAt().After(f.CreatedAfter) {
+		return false
+	}
+	if !f.CreatedBefore.IsZero() && !c.CreatedAt().Before(f.CreatedBefore) {
+		return false
+	}
+	return true
+}
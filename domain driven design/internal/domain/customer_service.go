@@ -6,11 +6,13 @@ import (
 	"time"
 )
 
-// CustomerRepository abstracts the persistence for the aggregate.
+// CustomerRepository abstracts the persistence for the aggregate. Save returns ErrDuplicateEmail or
+// ErrDuplicatePAN when another customer already holds the normalized value, and ErrVersionConflict
+// when the stored customer is no longer at the customer's PersistedVersion.
 type CustomerRepository interface {
 	Save(ctx context.Context, customer *Customer) error
 	GetByID(ctx context.Context, id CustomerID) (*Customer, error)
-	List(ctx context.Context) ([]*Customer, error)
+	List(ctx context.Context, filter CustomerFilter) (CustomerPage, error)
 }
 
 // CustomerService orchestrates simple use-cases around the aggregate.
@@ -24,8 +26,8 @@ func NewCustomerService(repo CustomerRepository) *CustomerService {
 }
 
 // RegisterCustomer creates the customer aggregate and persists it.
-func (s *CustomerService) RegisterCustomer(ctx context.Context, id CustomerID, fullName, email, pan string) (*Customer, error) {
-	customer, err := NewCustomer(id, fullName, email, pan, time.Now().UTC())
+func (s *CustomerService) RegisterCustomer(ctx context.Context, id CustomerID, fullName, email, pan, phone string) (*Customer, error) {
+	customer, err := NewCustomer(id, fullName, email, pan, phone, time.Now().UTC())
 	if err != nil {
 		return nil, err
 	}
@@ -44,11 +46,11 @@ func (s *CustomerService) FetchCustomer(ctx context.Context, id CustomerID) (*Cu
 	return customer, nil
 }
 
-// ListCustomers is a simple query operation for demo purposes.
-func (s *CustomerService) ListCustomers(ctx context.Context) ([]*Customer, error) {
-	customers, err := s.repo.List(ctx)
+// ListCustomers returns one page of customers matching filter.
+func (s *CustomerService) ListCustomers(ctx context.Context, filter CustomerFilter) (CustomerPage, error) {
+	page, err := s.repo.List(ctx, filter.Normalize())
 	if err != nil {
-		return nil, fmt.Errorf("list customers: %w", err)
+		return CustomerPage{}, fmt.Errorf("list customers: %w", err)
 	}
-	return customers, nil
+	return page, nil
 }
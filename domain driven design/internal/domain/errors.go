@@ -4,3 +4,15 @@ import "errors"
 
 // ErrNotFound signals repository lookups that miss.
 var ErrNotFound = errors.New("entity not found")
+
+// Uniqueness violations reported by repositories when another customer already holds the value.
+var (
+	ErrDuplicateEmail = errors.New("email already registered")
+	ErrDuplicatePAN   = errors.New("pan already registered")
+)
+
+// ErrVersionConflict is returned when a customer changed since it was loaded.
+var ErrVersionConflict = errors.New("customer was modified concurrently")
+
+// ErrCustomerSuspended is returned for profile changes while the customer is suspended.
+var ErrCustomerSuspended = errors.New("customer is suspended")
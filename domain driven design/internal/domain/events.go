@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Event is something that happened to a customer aggregate.
+type Event interface {
+	EventName() string
+	AggregateID() CustomerID
+	OccurredAt() time.Time
+}
+
+// EventPublisher hands committed events to the rest of the system. Publishing happens after the
+// aggregate is saved and is best effort: implementations deal with their own delivery failures.
+type EventPublisher interface {
+	Publish(ctx context.Context, events []Event)
+}
+
+// EventMeta is embedded in every customer event. Version is the aggregate version the event produced.
+type EventMeta struct {
+	CustomerID CustomerID
+	Version    int
+	At         time.Time
+}
+
+// AggregateID returns the customer the event belongs to.
+func (m EventMeta) AggregateID() CustomerID { return m.CustomerID }
+
+// OccurredAt returns when the change was made.
+func (m EventMeta) OccurredAt() time.Time { return m.At }
+
+// CustomerRegistered is recorded by NewCustomer.
+type CustomerRegistered struct {
+	EventMeta
+	Email Email
+	PAN   PAN
+}
+
+// EventName implements Event.
+func (CustomerRegistered) EventName() string { return "customer.registered" }
+
+// ProfileChange is one field changed by a profile update.
+type ProfileChange struct {
+	Field string
+	From  string
+	To    string
+}
+
+// CustomerProfileUpdated records the fields UpdateProfile changed.
+type CustomerProfileUpdated struct {
+	EventMeta
+	Changes []ProfileChange
+}
+
+// EventName implements Event.
+func (CustomerProfileUpdated) EventName() string { return "customer.profile_updated" }
+
+// CustomerKYCDocumentsSubmitted starts (or restarts, after a rejection) the KYC review.
+type CustomerKYCDocumentsSubmitted struct {
+	EventMeta
+	Documents []KYCDocument
+}
+
+// EventName implements Event.
+func (CustomerKYCDocumentsSubmitted) EventName() string { return "customer.kyc.documents_submitted" }
+
+// CustomerKYCVerified marks the customer as verified by Reviewer.
+type CustomerKYCVerified struct {
+	EventMeta
+	Reviewer string
+}
+
+// EventName implements Event.
+func (CustomerKYCVerified) EventName() string { return "customer.kyc.verified" }
+
+// CustomerKYCRejected records why Reviewer rejected the submitted documents.
+type CustomerKYCRejected struct {
+	EventMeta
+	Reviewer string
+	Reason   string
+}
+
+// EventName implements Event.
+func (CustomerKYCRejected) EventName() string { return "customer.kyc.rejected" }
+
+// CustomerSuspended blocks a verified customer.
+type CustomerSuspended struct {
+	EventMeta
+	Reason string
+}
+
+// EventName implements Event.
+func (CustomerSuspended) EventName() string { return "customer.suspended" }
+
+// CustomerReinstated lifts a suspension.
+type CustomerReinstated struct {
+	EventMeta
+}
+
+// EventName implements Event.
+func (CustomerReinstated) EventName() string { return "customer.reinstated" }
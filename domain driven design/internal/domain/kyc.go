@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KYCStatus is where a customer stands in the know-your-customer process.
+type KYCStatus string
+
+const (
+	KYCRegistered         KYCStatus = "registered"
+	KYCDocumentsSubmitted KYCStatus = "documents_submitted"
+	KYCVerified           KYCStatus = "verified"
+	KYCRejected           KYCStatus = "rejected"
+	KYCSuspended          KYCStatus = "suspended"
+)
+
+// kycTransitions lists the states each state may move to. Rejected customers may resubmit, and
+// suspension is only lifted back to verified.
+var kycTransitions = map[KYCStatus][]KYCStatus{
+	KYCRegistered:         {KYCDocumentsSubmitted},
+	KYCDocumentsSubmitted: {KYCVerified, KYCRejected},
+	KYCRejected:           {KYCDocumentsSubmitted},
+	KYCVerified:           {KYCSuspended},
+	KYCSuspended:          {KYCVerified},
+}
+
+// ParseKYCStatus validates a status name.
+func ParseKYCStatus(value string) (KYCStatus, error) {
+	status := KYCStatus(strings.TrimSpace(value))
+	if _, ok := kycTransitions[status]; !ok {
+		return "", fmt.Errorf("unknown KYC status %q", value)
+	}
+	return status, nil
+}
+
+// CanTransitionTo reports whether the lifecycle allows moving from s to next.
+func (s KYCStatus) CanTransitionTo(next KYCStatus) bool {
+	for _, allowed := range kycTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrInvalidKYCTransition is returned when a KYC command does not apply to the current status.
+var ErrInvalidKYCTransition = errors.New("invalid KYC transition")
+
+// DocumentType names an identity document accepted for KYC.
+type DocumentType string
+
+const (
+	DocumentPANCard        DocumentType = "pan_card"
+	DocumentAadhaar        DocumentType = "aadhaar"
+	DocumentPassport       DocumentType = "passport"
+	DocumentVoterID        DocumentType = "voter_id"
+	DocumentDrivingLicence DocumentType = "driving_licence"
+)
+
+var documentTypes = map[DocumentType]bool{
+	DocumentPANCard:        true,
+	DocumentAadhaar:        true,
+	DocumentPassport:       true,
+	DocumentVoterID:        true,
+	DocumentDrivingLicence: true,
+}
+
+// KYCDocument references an uploaded identity document; the file itself lives outside the domain.
+type KYCDocument struct {
+	Type      DocumentType
+	Reference string
+}
+
+// validateDocuments requires at least one document, known types, a reference for each and no type
+// submitted twice.
+func validateDocuments(docs []KYCDocument) *ValidationError {
+	errs := NewValidationError()
+	if len(docs) == 0 {
+		errs.Add("documents", "at least one document is required")
+		return errs
+	}
+	seen := make(map[DocumentType]bool, len(docs))
+	for i, doc := range docs {
+		field := fmt.Sprintf("documents[%d]", i)
+		switch {
+		case !documentTypes[doc.Type]:
+			errs.Add(field+".type", "is not a supported document type")
+		case strings.TrimSpace(doc.Reference) == "":
+			errs.Add(field+".reference", "is required")
+		case seen[doc.Type]:
+			errs.Add(field+".type", "was already submitted")
+		}
+		seen[doc.Type] = true
+	}
+	return errs
+}
@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestKYCTransitions(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	commands := []struct {
+		name      string
+		run       func(c *Customer) error
+		wantEvent string
+	}{
+		{"submit", func(c *Customer) error {
+			return c.SubmitKYCDocuments([]KYCDocument{{Type: DocumentPANCard, Reference: "s3://kyc/pan.pdf"}}, at)
+		}, "customer.kyc.documents_submitted"},
+		{"verify", func(c *Customer) error { return c.VerifyKYC("reviewer-1", at) }, "customer.kyc.verified"},
+		{"reject", func(c *Customer) error { return c.RejectKYC("reviewer-1", "blurry scan", at) }, "customer.kyc.rejected"},
+		{"suspend", func(c *Customer) error { return c.Suspend("fraud alert", at) }, "customer.suspended"},
+		{"reinstate", func(c *Customer) error { return c.Reinstate(at) }, "customer.reinstated"},
+	}
+	// allowed maps each status to the commands it accepts and the status each one leads to; every
+	// other command must be refused.
+	allowed := map[KYCStatus]map[string]KYCStatus{
+		KYCRegistered:         {"submit": KYCDocumentsSubmitted},
+		KYCDocumentsSubmitted: {"verify": KYCVerified, "reject": KYCRejected},
+		KYCRejected:           {"submit": KYCDocumentsSubmitted},
+		KYCVerified:           {"suspend": KYCSuspended},
+		KYCSuspended:          {"reinstate": KYCVerified},
+	}
+
+	for from, next := range allowed {
+		for _, cmd := range commands {
+			want, ok := next[cmd.name]
+			t.Run(string(from)+"/"+cmd.name, func(t *testing.T) {
+				c := RehydrateCustomer(CustomerState{ID: "c1", FullName: "Ada Lovelace", KYCStatus: from, KYCReason: "earlier", Version: 3})
+				err := cmd.run(c)
+				events := c.PullEvents()
+				if !ok {
+					if !errors.Is(err, ErrInvalidKYCTransition) {
+						t.Fatalf("err = %v, want ErrInvalidKYCTransition", err)
+					}
+					if c.KYCStatus() != from || c.Version() != 3 || c.KYCReason() != "earlier" || len(events) != 0 {
+						t.Fatalf("refused command changed the customer: status %s, version %d, reason %q, %d events",
+							c.KYCStatus(), c.Version(), c.KYCReason(), len(events))
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if c.KYCStatus() != want || c.Version() != 4 || !c.UpdatedAt().Equal(at) {
+					t.Fatalf("status %s, version %d, updated %s; want %s at version 4", c.KYCStatus(), c.Version(), c.UpdatedAt(), want)
+				}
+				if len(events) != 1 || events[0].EventName() != cmd.wantEvent {
+					t.Fatalf("events = %v, want one %s", events, cmd.wantEvent)
+				}
+			})
+		}
+	}
+}
+
+func TestKYCCommandValidation(t *testing.T) {
+	at := time.Now()
+	tests := []struct {
+		name  string
+		from  KYCStatus
+		run   func(c *Customer) error
+		field string
+	}{
+		{"no documents", KYCRegistered, func(c *Customer) error { return c.SubmitKYCDocuments(nil, at) }, "documents"},
+		{"unknown document type", KYCRegistered, func(c *Customer) error {
+			return c.SubmitKYCDocuments([]KYCDocument{{Type: "library_card", Reference: "x"}}, at)
+		}, "documents[0].type"},
+		{"document without reference", KYCRegistered, func(c *Customer) error {
+			return c.SubmitKYCDocuments([]KYCDocument{{Type: DocumentAadhaar, Reference: " "}}, at)
+		}, "documents[0].reference"},
+		{"document submitted twice", KYCRejected, func(c *Customer) error {
+			return c.SubmitKYCDocuments([]KYCDocument{{Type: DocumentAadhaar, Reference: "a"}, {Type: DocumentAadhaar, Reference: "b"}}, at)
+		}, "documents[1].type"},
+		{"verify without reviewer", KYCDocumentsSubmitted, func(c *Customer) error { return c.VerifyKYC(" ", at) }, "reviewer"},
+		{"reject without reason", KYCDocumentsSubmitted, func(c *Customer) error { return c.RejectKYC("reviewer-1", "", at) }, "reason"},
+		{"suspend without reason", KYCVerified, func(c *Customer) error { return c.Suspend("", at) }, "reason"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := RehydrateCustomer(CustomerState{ID: "c1", KYCStatus: tt.from, Version: 1})
+			var verr *ValidationError
+			if err := tt.run(c); !errors.As(err, &verr) {
+				t.Fatalf("err = %v, want a validation error", err)
+			}
+			if _, ok := verr.Fields()[tt.field]; !ok {
+				t.Fatalf("fields = %v, want %s", verr.Fields(), tt.field)
+			}
+			if c.KYCStatus() != tt.from || c.Version() != 1 || len(c.PullEvents()) != 0 {
+				t.Fatal("invalid command changed the customer")
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"errors"
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+// PAN is a validated Permanent Account Number such as ABCPL1234F: three letters, a holder-type letter,
+// the holder's name initial, a four digit sequence and a check letter.
+//
+// The Income Tax Department does not publish the algorithm behind the check letter, so ParsePAN
+// enforces the published structure instead: a known holder type and a non-zero sequence.
+type PAN string
+
+var panPattern = regexp.MustCompile(`^[A-Z]{5}[0-9]{4}[A-Z]$`)
+
+// panHolderTypes maps the fourth character of a PAN to the kind of holder it was issued to.
+var panHolderTypes = map[byte]string{
+	'A': "association of persons",
+	'B': "body of individuals",
+	'C': "company",
+	'F': "firm",
+	'G': "government",
+	'H': "hindu undivided family",
+	'J': "artificial juridical person",
+	'L': "local authority",
+	'P': "individual",
+	'T': "trust",
+}
+
+// ParsePAN normalizes value to upper case and checks the PAN structure.
+func ParsePAN(value string) (PAN, error) {
+	v := strings.ToUpper(strings.TrimSpace(value))
+	if !panPattern.MatchString(v) {
+		return "", errors.New("must be 5 letters, 4 digits and a letter (e.g. ABCPL1234F)")
+	}
+	if _, ok := panHolderTypes[v[3]]; !ok {
+		return "", errors.New("fourth character is not a valid holder type")
+	}
+	if v[5:9] == "0000" {
+		return "", errors.New("sequence number cannot be 0000")
+	}
+	return PAN(v), nil
+}
+
+// HolderType describes who the PAN was issued to, e.g. "individual" or "company".
+func (p PAN) HolderType() string {
+	if len(p) != 10 {
+		return ""
+	}
+	return panHolderTypes[p[3]]
+}
+
+// IsIndividual reports whether the PAN belongs to a person.
+func (p PAN) IsIndividual() bool {
+	return len(p) == 10 && p[3] == 'P'
+}
+
+func (p PAN) String() string { return string(p) }
+
+// Email is a normalized (lower-cased) single mailbox address.
+type Email string
+
+// ParseEmail accepts a bare address such as ada@example.com; display names and lists are rejected.
+func ParseEmail(value string) (Email, error) {
+	v := strings.TrimSpace(value)
+	if len(v) > 254 {
+		return "", errors.New("must be at most 254 characters")
+	}
+	addr, err := mail.ParseAddress(v)
+	if err != nil || addr.Name != "" || addr.Address != v {
+		return "", errors.New("must be a valid email address")
+	}
+	_, host, _ := strings.Cut(v, "@")
+	if !strings.Contains(host, ".") || strings.HasPrefix(host, ".") || strings.HasSuffix(host, ".") {
+		return "", errors.New("must include a valid domain")
+	}
+	return Email(strings.ToLower(v)), nil
+}
+
+func (e Email) String() string { return string(e) }
+
+// Phone is a number in E.164 form, e.g. +919876543210.
+type Phone string
+
+// ParsePhone strips spaces, dots, dashes and parentheses and requires an international number: a
+// leading + followed by 8 to 15 digits, the first of which is a non-zero country code digit.
+func ParsePhone(value string) (Phone, error) {
+	v := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '.', '-', '(', ')':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(value))
+	if !strings.HasPrefix(v, "+") {
+		return "", errors.New("must start with + and a country code")
+	}
+	digits := v[1:]
+	if len(digits) < 8 || len(digits) > 15 {
+		return "", errors.New("must have 8 to 15 digits")
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return "", errors.New("must contain only digits after the +")
+		}
+	}
+	if digits[0] == '0' {
+		return "", errors.New("country code cannot start with 0")
+	}
+	return Phone(v), nil
+}
+
+func (p Phone) String() string { return string(p) }
@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePAN(t *testing.T) {
+	tests := []struct {
+		in, want string
+		holder   string
+		wantErr  bool
+	}{
+		{in: "ABCPL1234F", want: "ABCPL1234F", holder: "individual"},
+		{in: " abcpl1234f ", want: "ABCPL1234F", holder: "individual"},
+		{in: "ABCCK5678A", want: "ABCCK5678A", holder: "company"},
+		{in: "ZZZTZ9999F", want: "ZZZTZ9999F", holder: "trust"},
+		{in: "AAAGA0001Z", want: "AAAGA0001Z", holder: "government"},
+		{in: "ABCPL1234", wantErr: true},
+		{in: "ABCPL1234FF", wantErr: true},
+		{in: "ABC1L1234F", wantErr: true},
+		{in: "ABCPL12A4F", wantErr: true},
+		{in: "ABCPL12345", wantErr: true},
+		{in: "ABCXL1234F", wantErr: true}, // unknown holder type
+		{in: "ABCPL0000F", wantErr: true}, // zero sequence
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParsePAN(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParsePAN(%q) = %q, want an error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != tt.want || got.HolderType() != tt.holder {
+				t.Fatalf("ParsePAN(%q) = %q (%s), want %q (%s)", tt.in, got, got.HolderType(), tt.want, tt.holder)
+			}
+		})
+	}
+}
+
+func TestParseEmail(t *testing.T) {
+	tests := []struct {
+		in, want string
+		wantErr  bool
+	}{
+		{in: "ada@example.com", want: "ada@example.com"},
+		{in: "  Ada.Lovelace@Example.COM ", want: "ada.lovelace@example.com"},
+		{in: "ada+kyc@mail.example.in", want: "ada+kyc@mail.example.in"},
+		{in: "", wantErr: true},
+		{in: "ada", wantErr: true},
+		{in: "ada@localhost", wantErr: true},
+		{in: "ada@.example.com", wantErr: true},
+		{in: "ada@example.", wantErr: true},
+		{in: "Ada <ada@example.com>", wantErr: true},
+		{in: "ada@example.com, bob@example.com", wantErr: true},
+		{in: "ada@" + strings.Repeat("x", 247) + ".com", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseEmail(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseEmail(%q) = %q, want an error", tt.in, got)
+				}
+				return
+			}
+			if err != nil || got.String() != tt.want {
+				t.Fatalf("ParseEmail(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePhone(t *testing.T) {
+	tests := []struct {
+		in, want string
+		wantErr  bool
+	}{
+		{in: "+919876543210", want: "+919876543210"},
+		{in: "+91 98765 43210", want: "+919876543210"},
+		{in: "+1 (415) 555-0100", want: "+14155550100"},
+		{in: "+44.20.7946.0958", want: "+442079460958"},
+		{in: "+1234567", wantErr: true},
+		{in: "+1234567890123456", wantErr: true},
+		{in: "9876543210", wantErr: true},
+		{in: "+0 9876543210", wantErr: true},
+		{in: "+91 98765 4321O", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParsePhone(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParsePhone(%q) = %q, want an error", tt.in, got)
+				}
+				return
+			}
+			if err != nil || got.String() != tt.want {
+				t.Fatalf("ParsePhone(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewCustomerPANIsIndependentOfName(t *testing.T) {
+	for _, name := range []string{"Ada Lovelace", "Grace Hopper", "Madonna"} {
+		if _, err := NewCustomer("c1", name, "ada@example.com", "ABCPL1234F", "", time.Time{}); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+}
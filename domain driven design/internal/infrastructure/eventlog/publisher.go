@@ -0,0 +1,31 @@
+package eventlog
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/helrachar/banking/internal/domain"
+)
+
+// Publisher writes committed customer events to the structured log. It stands in for a message
+// broker until one is introduced; swapping it only requires another domain.EventPublisher.
+type Publisher struct {
+	logger *slog.Logger
+}
+
+// NewPublisher logs events through logger.
+func NewPublisher(logger *slog.Logger) *Publisher {
+	return &Publisher{logger: logger}
+}
+
+// Publish logs each event with its name, customer and time, plus the event payload.
+func (p *Publisher) Publish(ctx context.Context, events []domain.Event) {
+	for _, evt := range events {
+		p.logger.InfoContext(ctx, "domain event",
+			"event", evt.EventName(),
+			"customer_id", evt.AggregateID(),
+			"occurred_at", evt.OccurredAt(),
+			"payload", evt,
+		)
+	}
+}
@@ -2,27 +2,46 @@ package memory
 
 import (
 	"context"
+	"sort"
 	"sync"
 
 	"github.com/helrachar/banking/internal/domain"
 )
 
-// CustomerRepository is an in-memory repository useful for local demos.
+// CustomerRepository is an in-memory repository useful for local demos. It stores state snapshots
+// rather than aggregates so callers cannot change stored customers without saving them.
 type CustomerRepository struct {
 	mu        sync.RWMutex
-	customers map[domain.CustomerID]*domain.Customer
+	customers map[domain.CustomerID]domain.CustomerState
 }
 
 // NewCustomerRepository constructs the in-memory map store.
 func NewCustomerRepository() *CustomerRepository {
-	return &CustomerRepository{customers: make(map[domain.CustomerID]*domain.Customer)}
+	return &CustomerRepository{customers: make(map[domain.CustomerID]domain.CustomerState)}
 }
 
-// Save persists or replaces the aggregate.
+// Save persists or replaces the aggregate, rejecting an email or PAN held by another customer and a
+// customer that changed since it was loaded.
 func (r *CustomerRepository) Save(_ context.Context, customer *domain.Customer) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.customers[customer.ID()] = customer
+	for id, other := range r.customers {
+		if id == customer.ID() {
+			continue
+		}
+		if other.Email == customer.Email() {
+			return domain.ErrDuplicateEmail
+		}
+		if other.PAN == customer.PAN() {
+			return domain.ErrDuplicatePAN
+		}
+	}
+	stored, exists := r.customers[customer.ID()]
+	if exists && stored.Version != customer.PersistedVersion() || !exists && customer.PersistedVersion() != 0 {
+		return domain.ErrVersionConflict
+	}
+	r.customers[customer.ID()] = customer.State()
+	customer.MarkPersisted()
 	return nil
 }
 
@@ -30,20 +49,41 @@ func (r *CustomerRepository) Save(_ context.Context, customer *domain.Customer)
 func (r *CustomerRepository) GetByID(_ context.Context, id domain.CustomerID) (*domain.Customer, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	customer, ok := r.customers[id]
+	state, ok := r.customers[id]
 	if !ok {
 		return nil, domain.ErrNotFound
 	}
-	return customer, nil
+	return domain.RehydrateCustomer(state), nil
 }
 
-// List returns a snapshot slice of all aggregates.
-func (r *CustomerRepository) List(_ context.Context) ([]*domain.Customer, error) {
+// List returns one page of matching aggregates ordered by creation time.
+func (r *CustomerRepository) List(_ context.Context, filter domain.CustomerFilter) (domain.CustomerPage, error) {
+	filter = filter.Normalize()
+	cursor, err := domain.DecodeCursor(filter.Cursor)
+	if err != nil {
+		return domain.CustomerPage{}, err
+	}
+
 	r.mu.RLock()
-	defer r.mu.RUnlock()
-	results := make([]*domain.Customer, 0, len(r.customers))
-	for _, c := range r.customers {
-		results = append(results, c)
+	matches := make([]*domain.Customer, 0)
+	for _, state := range r.customers {
+		c := domain.RehydrateCustomer(state)
+		if filter.Matches(c) && (filter.Cursor == "" || cursor.After(c)) {
+			matches = append(matches, c)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		if !matches[i].CreatedAt().Equal(matches[j].CreatedAt()) {
+			return matches[i].CreatedAt().Before(matches[j].CreatedAt())
+		}
+		return matches[i].ID() < matches[j].ID()
+	})
+	page := domain.CustomerPage{Customers: matches}
+	if len(matches) > filter.Limit {
+		page.Customers = matches[:filter.Limit]
+		page.NextCursor = domain.EncodeCursor(page.Customers[filter.Limit-1])
 	}
-	return results, nil
+	return page, nil
 }
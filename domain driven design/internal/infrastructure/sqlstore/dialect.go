@@ -0,0 +1,135 @@
+package sqlstore
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"github.com/helrachar/banking/internal/domain"
+)
+
+// sqliteTimeLayout is fixed width so created_at sorts correctly as text. Microseconds match the
+// precision Postgres keeps, so both stores produce identical page cursors.
+const sqliteTimeLayout = "2006-01-02T15:04:05.000000Z"
+
+// dialect holds the differences between the supported databases; queries are written with ?
+// placeholders and rebound per dialect.
+type dialect struct {
+	name string
+	// driver is the database/sql driver name.
+	driver string
+	// lockMigrations serializes migrations across processes inside the migration transaction.
+	lockMigrations   string
+	migrationsTable  string
+	numberedArgs     bool
+	encodeTime       func(time.Time) any
+	decodeTime       func(any) (time.Time, error)
+	uniqueConstraint func(error) (string, bool)
+}
+
+var sqliteDialect = dialect{
+	name:   "sqlite",
+	driver: "sqlite",
+	migrationsTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TEXT NOT NULL
+	)`,
+	encodeTime: func(t time.Time) any { return t.UTC().Format(sqliteTimeLayout) },
+	decodeTime: func(v any) (time.Time, error) {
+		s, ok := v.(string)
+		if !ok {
+			return time.Time{}, fmt.Errorf("timestamp: unexpected %T", v)
+		}
+		return time.Parse(sqliteTimeLayout, s)
+	},
+	uniqueConstraint: func(err error) (string, bool) {
+		var sqlErr *sqlite.Error
+		if !errors.As(err, &sqlErr) || sqlErr.Code() != sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+			return "", false
+		}
+		// SQLite names the column rather than the index:
+		// "constraint failed: UNIQUE constraint failed: customers.email (2067)".
+		_, column, _ := strings.Cut(sqlErr.Error(), "UNIQUE constraint failed: ")
+		column, _, _ = strings.Cut(column, " ")
+		return column, true
+	},
+}
+
+var postgresDialect = dialect{
+	name:           "postgres",
+	driver:         "pgx",
+	lockMigrations: `SELECT pg_advisory_xact_lock(hashtext('schema_migrations'))`,
+	migrationsTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT        NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL
+	)`,
+	numberedArgs: true,
+	encodeTime:   func(t time.Time) any { return t.UTC().Truncate(time.Microsecond) },
+	decodeTime: func(v any) (time.Time, error) {
+		t, ok := v.(time.Time)
+		if !ok {
+			return time.Time{}, fmt.Errorf("timestamp: unexpected %T", v)
+		}
+		return t.UTC(), nil
+	},
+	uniqueConstraint: func(err error) (string, bool) {
+		var pgErr *pgconn.PgError
+		if !errors.As(err, &pgErr) || pgErr.Code != "23505" {
+			return "", false
+		}
+		return pgErr.ConstraintName, true
+	},
+}
+
+func dialectFor(driver string) (dialect, error) {
+	switch driver {
+	case "sqlite":
+		return sqliteDialect, nil
+	case "postgres":
+		return postgresDialect, nil
+	default:
+		return dialect{}, fmt.Errorf("unsupported storage driver %q (want sqlite or postgres)", driver)
+	}
+}
+
+// rebind rewrites ? placeholders as $1, $2, ... for dialects that number them.
+func (d dialect) rebind(query string) string {
+	if !d.numberedArgs {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// domainError maps unique violations on the customer indexes to domain errors.
+func (d dialect) domainError(err error) error {
+	constraint, ok := d.uniqueConstraint(err)
+	if !ok {
+		return err
+	}
+	switch constraint {
+	case "customers_email_unique", "customers.email":
+		return domain.ErrDuplicateEmail
+	case "customers_pan_unique", "customers.pan":
+		return domain.ErrDuplicatePAN
+	default:
+		return err
+	}
+}
@@ -0,0 +1,12 @@
+ALTER TABLE customers
+    ADD COLUMN phone         TEXT        NOT NULL DEFAULT '',
+    ADD COLUMN kyc_status    TEXT        NOT NULL DEFAULT 'registered',
+    ADD COLUMN kyc_reason    TEXT        NOT NULL DEFAULT '',
+    ADD COLUMN kyc_documents JSONB       NOT NULL DEFAULT '[]',
+    ADD COLUMN version       INTEGER     NOT NULL DEFAULT 1,
+    ADD COLUMN updated_at    TIMESTAMPTZ;
+
+UPDATE customers SET updated_at = created_at;
+ALTER TABLE customers ALTER COLUMN updated_at SET NOT NULL;
+
+CREATE INDEX customers_kyc_status_idx ON customers (kyc_status, created_at, id);
@@ -0,0 +1,11 @@
+CREATE TABLE customers (
+    id         TEXT PRIMARY KEY,
+    full_name  TEXT NOT NULL,
+    email      TEXT NOT NULL,
+    pan        TEXT NOT NULL,
+    created_at TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX customers_email_unique ON customers (email);
+CREATE UNIQUE INDEX customers_pan_unique ON customers (pan);
+CREATE INDEX customers_created_idx ON customers (created_at, id);
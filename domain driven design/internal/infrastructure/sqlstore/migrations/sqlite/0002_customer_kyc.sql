@@ -0,0 +1,10 @@
+ALTER TABLE customers ADD COLUMN phone TEXT NOT NULL DEFAULT '';
+ALTER TABLE customers ADD COLUMN kyc_status TEXT NOT NULL DEFAULT 'registered';
+ALTER TABLE customers ADD COLUMN kyc_reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE customers ADD COLUMN kyc_documents TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE customers ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE customers ADD COLUMN updated_at TEXT;
+
+UPDATE customers SET updated_at = created_at;
+
+CREATE INDEX customers_kyc_status_idx ON customers (kyc_status, created_at, id);
@@ -0,0 +1,250 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // registers the "pgx" driver
+	_ "modernc.org/sqlite"             // registers the "sqlite" driver
+
+	"github.com/helrachar/banking/internal/domain"
+)
+
+// DefaultSQLiteDSN is used when the sqlite driver is selected without a DSN.
+const DefaultSQLiteDSN = "file:customers.db?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+
+// CustomerRepository persists customers in SQLite (local use) or Postgres (production). Unique
+// indexes on the normalized email and PAN are the source of truth for uniqueness, so concurrent
+// registrations cannot both succeed.
+type CustomerRepository struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+// Open connects with driver ("sqlite" or "postgres") and applies pending migrations.
+func Open(ctx context.Context, driver, dsn string) (*CustomerRepository, error) {
+	d, err := dialectFor(driver)
+	if err != nil {
+		return nil, err
+	}
+	if dsn == "" && driver == "sqlite" {
+		dsn = DefaultSQLiteDSN
+	}
+	db, err := sql.Open(d.driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", driver, err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("connect %s: %w", driver, err)
+	}
+	if err := migrate(ctx, db, d); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate %s: %w", driver, err)
+	}
+	return &CustomerRepository{db: db, dialect: d}, nil
+}
+
+// Close releases the connection pool.
+func (r *CustomerRepository) Close() error {
+	return r.db.Close()
+}
+
+// Save inserts a new aggregate or updates a loaded one. Updates only apply while the stored version is
+// still the one the aggregate was loaded at; otherwise Save returns ErrVersionConflict. The creation
+// time is never rewritten.
+func (r *CustomerRepository) Save(ctx context.Context, customer *domain.Customer) error {
+	state := customer.State()
+	documents, err := encodeDocuments(state.Documents)
+	if err != nil {
+		return err
+	}
+	if customer.PersistedVersion() == 0 {
+		_, err = r.db.ExecContext(ctx, r.dialect.rebind(`
+			INSERT INTO customers (id, full_name, email, pan, phone, kyc_status, kyc_reason, kyc_documents,
+				version, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+			string(state.ID), state.FullName, string(state.Email), string(state.PAN), string(state.Phone),
+			string(state.KYCStatus), state.KYCReason, documents, state.Version,
+			r.dialect.encodeTime(state.CreatedAt), r.dialect.encodeTime(state.UpdatedAt))
+		if err != nil {
+			return r.dialect.domainError(err)
+		}
+		customer.MarkPersisted()
+		return nil
+	}
+
+	res, err := r.db.ExecContext(ctx, r.dialect.rebind(`
+		UPDATE customers SET full_name = ?, email = ?, phone = ?, kyc_status = ?, kyc_reason = ?,
+			kyc_documents = ?, version = ?, updated_at = ?
+		WHERE id = ? AND version = ?`),
+		state.FullName, string(state.Email), string(state.Phone), string(state.KYCStatus), state.KYCReason,
+		documents, state.Version, r.dialect.encodeTime(state.UpdatedAt),
+		string(state.ID), customer.PersistedVersion())
+	if err != nil {
+		return r.dialect.domainError(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrVersionConflict
+	}
+	customer.MarkPersisted()
+	return nil
+}
+
+// GetByID fetches an aggregate or yields ErrNotFound.
+func (r *CustomerRepository) GetByID(ctx context.Context, id domain.CustomerID) (*domain.Customer, error) {
+	row := r.db.QueryRowContext(ctx, r.dialect.rebind(
+		`SELECT `+customerColumns+` FROM customers WHERE id = ?`), string(id))
+	customer, err := r.scan(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrNotFound
+	}
+	return customer, err
+}
+
+// List returns one page of matching aggregates ordered by creation time, using the (created_at, id)
+// index as a keyset so deep pages cost the same as the first.
+func (r *CustomerRepository) List(ctx context.Context, filter domain.CustomerFilter) (domain.CustomerPage, error) {
+	filter = filter.Normalize()
+	cursor, err := domain.DecodeCursor(filter.Cursor)
+	if err != nil {
+		return domain.CustomerPage{}, err
+	}
+
+	var (
+		where []string
+		args  []any
+	)
+	if filter.Email != "" {
+		where = append(where, "email = ?")
+		args = append(args, filter.Email)
+	}
+	if filter.NameContains != "" {
+		where = append(where, `LOWER(full_name) LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(strings.ToLower(filter.NameContains))+"%")
+	}
+	if filter.KYCStatus != "" {
+		where = append(where, "kyc_status = ?")
+		args = append(args, string(filter.KYCStatus))
+	}
+	if !filter.CreatedAfter.IsZero() {
+		where = append(where, "created_at > ?")
+		args = append(args, r.dialect.encodeTime(filter.CreatedAfter))
+	}
+	if !filter.CreatedBefore.IsZero() {
+		where = append(where, "created_at < ?")
+		args = append(args, r.dialect.encodeTime(filter.CreatedBefore))
+	}
+	if filter.Cursor != "" {
+		at := r.dialect.encodeTime(cursor.CreatedAt)
+		where = append(where, "(created_at > ? OR (created_at = ? AND id > ?))")
+		args = append(args, at, at, string(cursor.ID))
+	}
+	query := `SELECT ` + customerColumns + ` FROM customers`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY created_at, id LIMIT ?"
+	args = append(args, filter.Limit+1)
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return domain.CustomerPage{}, err
+	}
+	defer rows.Close()
+	customers := make([]*domain.Customer, 0, filter.Limit+1)
+	for rows.Next() {
+		customer, err := r.scan(rows)
+		if err != nil {
+			return domain.CustomerPage{}, err
+		}
+		customers = append(customers, customer)
+	}
+	if err := rows.Err(); err != nil {
+		return domain.CustomerPage{}, err
+	}
+
+	page := domain.CustomerPage{Customers: customers}
+	if len(customers) > filter.Limit {
+		page.Customers = customers[:filter.Limit]
+		page.NextCursor = domain.EncodeCursor(page.Customers[filter.Limit-1])
+	}
+	return page, nil
+}
+
+const customerColumns = `id, full_name, email, pan, phone, kyc_status, kyc_reason, kyc_documents, version,
+	created_at, updated_at`
+
+func (r *CustomerRepository) scan(row interface{ Scan(...any) error }) (*domain.Customer, error) {
+	var (
+		state                    domain.CustomerState
+		id, fullName, email, pan string
+		phone, kycStatus         string
+		documents                []byte
+		createdAt, updatedAt     any
+	)
+	if err := row.Scan(&id, &fullName, &email, &pan, &phone, &kycStatus, &state.KYCReason, &documents,
+		&state.Version, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+	var err error
+	if state.CreatedAt, err = r.dialect.decodeTime(createdAt); err != nil {
+		return nil, fmt.Errorf("customer %s: %w", id, err)
+	}
+	if state.UpdatedAt, err = r.dialect.decodeTime(updatedAt); err != nil {
+		return nil, fmt.Errorf("customer %s: %w", id, err)
+	}
+	if state.Documents, err = decodeDocuments(documents); err != nil {
+		return nil, fmt.Errorf("customer %s: %w", id, err)
+	}
+	state.ID = domain.CustomerID(id)
+	state.FullName = fullName
+	state.Email = domain.Email(email)
+	state.PAN = domain.PAN(pan)
+	state.Phone = domain.Phone(phone)
+	state.KYCStatus = domain.KYCStatus(kycStatus)
+	return domain.RehydrateCustomer(state), nil
+}
+
+// storedDocument is the JSON layout of kyc_documents; it is kept separate from the domain type so
+// renaming a Go field cannot change stored data.
+type storedDocument struct {
+	Type      string `json:"type"`
+	Reference string `json:"reference"`
+}
+
+func encodeDocuments(docs []domain.KYCDocument) (string, error) {
+	stored := make([]storedDocument, len(docs))
+	for i, doc := range docs {
+		stored[i] = storedDocument{Type: string(doc.Type), Reference: doc.Reference}
+	}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return "", fmt.Errorf("encode kyc documents: %w", err)
+	}
+	return string(data), nil
+}
+
+func decodeDocuments(data []byte) ([]domain.KYCDocument, error) {
+	var stored []storedDocument
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("decode kyc documents: %w", err)
+	}
+	docs := make([]domain.KYCDocument, len(stored))
+	for i, doc := range stored {
+		docs[i] = domain.KYCDocument{Type: domain.DocumentType(doc.Type), Reference: doc.Reference}
+	}
+	return docs, nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,304 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/helrachar/banking/internal/domain"
+)
+
+var testPANs = []string{"ABCPL0001Y", "ABCPL0002Z", "ABCPL0003A", "ABCPL0004B", "ABCPL0005C", "ABCPL0006D", "ABCPL0007E", "ABCPL0008F"}
+
+func testDSN(t *testing.T) string {
+	return "file:" + filepath.Join(t.TempDir(), "customers.db") + "?_pragma=busy_timeout(5000)"
+}
+
+func openTest(t *testing.T) *CustomerRepository {
+	t.Helper()
+	repo, err := Open(context.Background(), "sqlite", testDSN(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+func newCustomer(t *testing.T, id, name, email, pan string, createdAt time.Time) *domain.Customer {
+	t.Helper()
+	c, err := domain.NewCustomer(domain.CustomerID(id), name, email, pan, "", createdAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestSaveRejectsDuplicates(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		email string
+		pan   string
+		want  error
+	}{
+		{name: "same email in another case", email: "ADA@example.com", pan: testPANs[1], want: domain.ErrDuplicateEmail},
+		{name: "same pan in lower case", email: "grace@example.com", pan: "abcpl0001y", want: domain.ErrDuplicatePAN},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := openTest(t)
+			ctx := context.Background()
+			if err := repo.Save(ctx, newCustomer(t, "c1", "Ada Lovelace", "ada@example.com", testPANs[0], at)); err != nil {
+				t.Fatal(err)
+			}
+			err := repo.Save(ctx, newCustomer(t, "c2", "Grace Hopper", tt.email, tt.pan, at))
+			if !errors.Is(err, tt.want) || errors.Is(err, domain.ErrNotFound) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if _, err := repo.GetByID(ctx, "c2"); !errors.Is(err, domain.ErrNotFound) {
+				t.Fatalf("rejected customer was stored: %v", err)
+			}
+		})
+	}
+}
+
+func TestSaveRejectsStaleVersion(t *testing.T) {
+	repo := openTest(t)
+	ctx := context.Background()
+	if err := repo.Save(ctx, newCustomer(t, "c1", "Ada Lovelace", "ada@example.com", testPANs[0], time.Now())); err != nil {
+		t.Fatal(err)
+	}
+	first, _ := repo.GetByID(ctx, "c1")
+	second, _ := repo.GetByID(ctx, "c1")
+	name := "Ada King"
+	if err := first.UpdateProfile(domain.ProfileUpdate{FullName: &name}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Save(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	email := "ada.king@example.com"
+	if err := second.UpdateProfile(domain.ProfileUpdate{Email: &email}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Save(ctx, second); !errors.Is(err, domain.ErrVersionConflict) {
+		t.Fatalf("err = %v, want ErrVersionConflict", err)
+	}
+	stored, _ := repo.GetByID(ctx, "c1")
+	if stored.FullName() != name || stored.Email() != "ada@example.com" || stored.Version() != 2 {
+		t.Fatalf("stored = %+v", stored.State())
+	}
+}
+
+func TestMigrations(t *testing.T) {
+	ctx := context.Background()
+	dsn := testDSN(t)
+	all, err := loadMigrations(sqliteDialect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) < 2 || all[0].version != 1 {
+		t.Fatalf("migrations = %+v", all)
+	}
+
+	// A database created before the KYC columns existed, holding one customer.
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		sqliteDialect.migrationsTable,
+		all[0].sql,
+		`INSERT INTO schema_migrations (version, name, applied_at) VALUES (1, '` + all[0].name + `', '2024-01-01T00:00:00.000000Z')`,
+		`INSERT INTO customers (id, full_name, email, pan, created_at) VALUES ('old', 'Ada Lovelace', 'ada@example.com', 'ABCPL0001Y', '2024-01-01T00:00:00.000000Z')`,
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	// Opening twice applies the pending migrations once.
+	for i := 0; i < 2; i++ {
+		repo, err := Open(ctx, "sqlite", dsn)
+		if err != nil {
+			t.Fatalf("open %d: %v", i+1, err)
+		}
+		var versions []int
+		rows, err := repo.db.QueryContext(ctx, `SELECT version FROM schema_migrations ORDER BY version`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for rows.Next() {
+			var v int
+			rows.Scan(&v)
+			versions = append(versions, v)
+		}
+		rows.Close()
+		want := make([]int, 0, len(all))
+		for _, m := range all {
+			want = append(want, m.version)
+		}
+		if !slices.Equal(versions, want) {
+			t.Fatalf("open %d: applied %v, want %v", i+1, versions, want)
+		}
+
+		old, err := repo.GetByID(ctx, "old")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if old.KYCStatus() != domain.KYCRegistered || old.Version() != 1 || !old.UpdatedAt().Equal(old.CreatedAt()) || len(old.Documents()) != 0 {
+			t.Fatalf("backfilled customer = %+v", old.State())
+		}
+		repo.Close()
+	}
+}
+
+func TestMigrationFailureLeavesSchemaUntouched(t *testing.T) {
+	ctx := context.Background()
+	dsn := testDSN(t)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// A conflicting table makes the first migration fail.
+	if _, err := db.ExecContext(ctx, `CREATE TABLE customers (id TEXT PRIMARY KEY)`); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrate(ctx, db, sqliteDialect); err == nil {
+		t.Fatal("expected the migration to fail")
+	}
+	var tables int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE name = 'schema_migrations'`).Scan(&tables); err != nil {
+		t.Fatal(err)
+	}
+	if tables != 0 {
+		t.Fatal("a failed migration left schema_migrations behind")
+	}
+}
+
+// seed stores customers c1..c6. c2 and c3 share a creation time so that a page can end between them.
+func seed(t *testing.T, repo *CustomerRepository) time.Time {
+	t.Helper()
+	base := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	created := []time.Duration{0, time.Hour, time.Hour, 2 * time.Hour, 3 * time.Hour, 4 * time.Hour}
+	names := []string{"Ada Lovelace", "Grace Hopper", "Alan Turing", "Edsger Dijkstra", "Barbara 100%_Liskov", "Donald Knuth"}
+	for i, offset := range created {
+		c := newCustomer(t, fmt.Sprintf("c%d", i+1), names[i], fmt.Sprintf("user%d@example.com", i+1), testPANs[i], base.Add(offset))
+		if i == 3 {
+			if err := c.SubmitKYCDocuments([]domain.KYCDocument{{Type: domain.DocumentAadhaar, Reference: "ref"}}, base.Add(offset)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := repo.Save(context.Background(), c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return base
+}
+
+func ids(page domain.CustomerPage) []string {
+	out := make([]string, 0, len(page.Customers))
+	for _, c := range page.Customers {
+		out = append(out, string(c.ID()))
+	}
+	return out
+}
+
+func TestListPagination(t *testing.T) {
+	repo := openTest(t)
+	seed(t, repo)
+	ctx := context.Background()
+
+	tests := []struct {
+		limit     int
+		wantPages [][]string
+	}{
+		// The first page ends between c2 and c3, which share a creation time.
+		{limit: 2, wantPages: [][]string{{"c1", "c2"}, {"c3", "c4"}, {"c5", "c6"}}},
+		{limit: 4, wantPages: [][]string{{"c1", "c2", "c3", "c4"}, {"c5", "c6"}}},
+		// A page exactly as large as the result has no next cursor.
+		{limit: 6, wantPages: [][]string{{"c1", "c2", "c3", "c4", "c5", "c6"}}},
+		{limit: 100, wantPages: [][]string{{"c1", "c2", "c3", "c4", "c5", "c6"}}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("limit %d", tt.limit), func(t *testing.T) {
+			var got [][]string
+			cursor := ""
+			for {
+				page, err := repo.List(ctx, domain.CustomerFilter{Limit: tt.limit, Cursor: cursor})
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, ids(page))
+				if page.NextCursor == "" {
+					break
+				}
+				if len(got) > len(tt.wantPages) {
+					t.Fatalf("pages %v keep going", got)
+				}
+				cursor = page.NextCursor
+			}
+			if !slices.EqualFunc(got, tt.wantPages, slices.Equal[[]string]) {
+				t.Fatalf("pages = %v, want %v", got, tt.wantPages)
+			}
+		})
+	}
+
+	if _, err := repo.List(ctx, domain.CustomerFilter{Cursor: "not-a-cursor"}); err == nil {
+		t.Fatal("expected an invalid cursor to be rejected")
+	}
+}
+
+func TestListFilters(t *testing.T) {
+	repo := openTest(t)
+	base := seed(t, repo)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		filter domain.CustomerFilter
+		want   []string
+	}{
+		{name: "email is normalized", filter: domain.CustomerFilter{Email: "  USER3@Example.com "}, want: []string{"c3"}},
+		{name: "name substring ignores case", filter: domain.CustomerFilter{NameContains: "TUR"}, want: []string{"c3"}},
+		{name: "like wildcards are literal", filter: domain.CustomerFilter{NameContains: "%_"}, want: []string{"c5"}},
+		{name: "underscore alone is literal", filter: domain.CustomerFilter{NameContains: "_"}, want: []string{"c5"}},
+		{name: "kyc status", filter: domain.CustomerFilter{KYCStatus: domain.KYCDocumentsSubmitted}, want: []string{"c4"}},
+		{name: "created after is exclusive", filter: domain.CustomerFilter{CreatedAfter: base.Add(time.Hour)}, want: []string{"c4", "c5", "c6"}},
+		{name: "created before is exclusive", filter: domain.CustomerFilter{CreatedBefore: base.Add(time.Hour)}, want: []string{"c1"}},
+		{name: "created window", filter: domain.CustomerFilter{CreatedAfter: base, CreatedBefore: base.Add(3 * time.Hour)}, want: []string{"c2", "c3", "c4"}},
+		{name: "filters combine", filter: domain.CustomerFilter{NameContains: "a", KYCStatus: domain.KYCRegistered, CreatedBefore: base.Add(2 * time.Hour)}, want: []string{"c1", "c2", "c3"}},
+		{name: "no match", filter: domain.CustomerFilter{Email: "nobody@example.com"}, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := repo.List(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ids(page); !slices.Equal(got, tt.want) || page.NextCursor != "" {
+				t.Fatalf("got %v (next %q), want %v", got, page.NextCursor, tt.want)
+			}
+		})
+	}
+
+	// A cursor keeps the filter's window: paging through registered customers skips c4.
+	first, err := repo.List(ctx, domain.CustomerFilter{KYCStatus: domain.KYCRegistered, Limit: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rest, err := repo.List(ctx, domain.CustomerFilter{KYCStatus: domain.KYCRegistered, Limit: 3, Cursor: first.NextCursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := append(ids(first), ids(rest)...); !slices.Equal(got, []string{"c1", "c2", "c3", "c5", "c6"}) || rest.NextCursor != "" {
+		t.Fatalf("filtered pages = %v", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -14,16 +15,37 @@ import (
 	"github.com/venkatesh/order-service/internal/eventbus"
 	"github.com/venkatesh/order-service/internal/infrastructure"
 	"github.com/venkatesh/order-service/internal/readmodel"
+	"github.com/venkatesh/order-service/internal/saga"
 )
 
 func main() {
-	store := infrastructure.NewInMemoryStore()
-	bus := eventbus.New(4)
+	storage := openStorage()
+	defer storage.close()
+	store := storage.events
+	bus := eventbus.New(4, storage.deadLetters)
 	projection := readmodel.NewOrdersProjection()
-	bus.Subscribe(eventbus.WildcardEvent, projection.Handle)
+	customers := readmodel.NewCustomerTotalsProjection()
+	runner := readmodel.NewRunner(store, storage.checkpoints, 500, projection, customers)
+	bus.Subscribe("projections", eventbus.WildcardEvent, runner.Handle)
 
-	service := app.NewOrderService(store, bus)
-	httpServer := api.NewServer(service, projection)
+	// Projections resume from their saved checkpoints and replay only the events recorded since.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runner.Run(ctx, 2*time.Second)
+
+	snapshotEvery, err := strconv.Atoi(envOr("SNAPSHOT_EVERY", "50"))
+	if err != nil {
+		log.Fatalf("invalid SNAPSHOT_EVERY: %v", err)
+	}
+	service := app.NewOrderService(store, bus, app.WithSnapshots(store, snapshotEvery))
+
+	var fulfilment *saga.Manager
+	if envOr("FULFILMENT_SAGA", "on") == "on" {
+		fulfilment = newFulfilmentSaga(service, storage.sagas)
+		bus.Subscribe("fulfilment-saga", eventbus.WildcardEvent, fulfilment.Handle)
+		go fulfilment.Run(ctx)
+	}
+	httpServer := api.NewServer(service, projection, customers, runner, bus, fulfilment)
 
 	srv := &http.Server{
 		Addr:         ":8080",
@@ -41,6 +63,102 @@ func main() {
 	}()
 
 	waitForShutdown(srv)
+
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer drainCancel()
+	if err := bus.Close(drainCtx); err != nil {
+		log.Printf("event bus did not drain: %v", err)
+	}
+	if err := runner.SaveCheckpoints(drainCtx); err != nil {
+		log.Printf("projections: %v", err)
+	}
+}
+
+type eventStore interface {
+	app.EventStore
+	app.EventStreamReader
+	app.SnapshotStore
+}
+
+type storage struct {
+	events      eventStore
+	deadLetters eventbus.DeadLetterStore
+	sagas       saga.Store
+	checkpoints readmodel.CheckpointStore
+	close       func()
+}
+
+// openStorage picks the store from EVENT_STORE: "sqlite" (default, file at EVENT_STORE_PATH) or
+// "memory" for throwaway demos. Dead letters, saga state and projection checkpoints are kept alongside
+// the events.
+func openStorage() storage {
+	kind := envOr("EVENT_STORE", "sqlite")
+	if kind == "memory" {
+		log.Println("event store: in-memory (orders are lost on restart)")
+		return storage{
+			events:      infrastructure.NewInMemoryStore(),
+			deadLetters: eventbus.NewMemoryDeadLetters(),
+			sagas:       saga.NewMemoryStore(),
+			checkpoints: readmodel.NewMemoryCheckpoints(),
+			close:       func() {},
+		}
+	}
+	if kind != "sqlite" {
+		log.Fatalf("unknown EVENT_STORE %q (want sqlite or memory)", kind)
+	}
+	path := envOr("EVENT_STORE_PATH", "orders.db")
+	store, err := infrastructure.NewSQLiteStore(context.Background(), path, infrastructure.DefaultRegistry())
+	if err != nil {
+		log.Fatalf("event store: %v", err)
+	}
+	log.Printf("event store: sqlite %s", path)
+	return storage{
+		events:      store,
+		deadLetters: store.DeadLetters(),
+		sagas:       store.Sagas(),
+		checkpoints: store.Checkpoints(),
+		close: func() {
+			if err := store.Close(); err != nil {
+				log.Printf("close event store: %v", err)
+			}
+		},
+	}
+}
+
+// newFulfilmentSaga drives orders through simulated adapters. PAYMENT_LIMIT_CENTS declines larger
+// payments, SKUs prefixed "oos-" are out of stock, and SIMULATED_LATENCY above SAGA_STEP_TIMEOUT makes
+// every step time out.
+func newFulfilmentSaga(service *app.OrderService, store saga.Store) *saga.Manager {
+	limit, err := strconv.ParseInt(envOr("PAYMENT_LIMIT_CENTS", "1000000"), 10, 64)
+	if err != nil {
+		log.Fatalf("invalid PAYMENT_LIMIT_CENTS: %v", err)
+	}
+	shipAttempts, err := strconv.Atoi(envOr("SAGA_SHIP_ATTEMPTS", "3"))
+	if err != nil {
+		log.Fatalf("invalid SAGA_SHIP_ATTEMPTS: %v", err)
+	}
+	latency := envDuration("SIMULATED_LATENCY", 50*time.Millisecond)
+	return saga.NewManager(service, store,
+		&saga.SimulatedPayments{LimitCents: limit, Latency: latency},
+		&saga.SimulatedInventory{Latency: latency},
+		&saga.SimulatedShipping{Carrier: "UPS", Latency: latency},
+		saga.Config{StepTimeout: envDuration("SAGA_STEP_TIMEOUT", 2*time.Second), ShipAttempts: shipAttempts},
+	)
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(envOr(key, fallback.String()))
+	if err != nil {
+		log.Fatalf("invalid %s: %v", key, err)
+	}
+	return d
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
 }
 
 func waitForShutdown(srv *http.Server) {
@@ -1,25 +1,38 @@
 package api
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 
 	"github.com/venkatesh/order-service/internal/app"
 	"github.com/venkatesh/order-service/internal/domain"
+	"github.com/venkatesh/order-service/internal/eventbus"
 	"github.com/venkatesh/order-service/internal/readmodel"
+	"github.com/venkatesh/order-service/internal/saga"
 )
 
 // Server wires HTTP handlers to the application service.
 type Server struct {
-	svc   *app.OrderService
-	views *readmodel.OrdersProjection
+	svc         *app.OrderService
+	views       *readmodel.OrdersProjection
+	customers   *readmodel.CustomerTotalsProjection
+	projections *readmodel.Runner
+	bus         *eventbus.Bus
+	sagas       *saga.Manager
 }
 
-// NewServer builds an HTTP server wrapper.
-func NewServer(svc *app.OrderService, views *readmodel.OrdersProjection) *Server {
-	return &Server{svc: svc, views: views}
+// NewServer builds an HTTP server wrapper. sagas may be nil when the fulfilment saga is disabled.
+func NewServer(svc *app.OrderService, views *readmodel.OrdersProjection, customers *readmodel.CustomerTotalsProjection, projections *readmodel.Runner, bus *eventbus.Bus, sagas *saga.Manager) *Server {
+	return &Server{svc: svc, views: views, customers: customers, projections: projections, bus: bus, sagas: sagas}
 }
 
 // Router returns mux with all endpoints registered.
@@ -38,6 +51,18 @@ func (s *Server) Router() http.Handler {
 	r.HandleFunc("/orders/{id}/reserve", s.reserveInventory).Methods(http.MethodPost)
 	r.HandleFunc("/orders/{id}/ship", s.shipOrder).Methods(http.MethodPost)
 	r.HandleFunc("/orders/{id}/cancel", s.cancelOrder).Methods(http.MethodPost)
+	r.HandleFunc("/orders/{id}/snapshot", s.snapshotOrder).Methods(http.MethodPost)
+	r.HandleFunc("/orders/{id}/saga", s.getSaga).Methods(http.MethodGet)
+	r.HandleFunc("/orders/{id}/history", s.orderHistory).Methods(http.MethodGet)
+	r.HandleFunc("/customers", s.listCustomers).Methods(http.MethodGet)
+	r.HandleFunc("/customers/{id}", s.getCustomer).Methods(http.MethodGet)
+
+	r.HandleFunc("/admin/projections", s.projectionStatus).Methods(http.MethodGet)
+	r.HandleFunc("/admin/projections/{name}/rebuild", s.rebuildProjection).Methods(http.MethodPost)
+	r.HandleFunc("/admin/subscribers", s.subscriberStats).Methods(http.MethodGet)
+	r.HandleFunc("/admin/dead-letters", s.listDeadLetters).Methods(http.MethodGet)
+	r.HandleFunc("/admin/dead-letters/{id}/replay", s.replayDeadLetter).Methods(http.MethodPost)
+	r.HandleFunc("/admin/dead-letters/{id}", s.discardDeadLetter).Methods(http.MethodDelete)
 	return r
 }
 
@@ -71,11 +96,18 @@ func (s *Server) placeOrder(w http.ResponseWriter, r *http.Request) {
 		respondErr(w, http.StatusBadRequest, err)
 		return
 	}
-	cmd := app.PlaceOrder{OrderID: req.OrderID, CustomerID: req.CustomerID, Items: req.Items}
-	if err := s.svc.HandlePlaceOrder(r.Context(), cmd); err != nil {
+	meta, err := commandMeta(w, r)
+	if err != nil {
 		respondErr(w, http.StatusBadRequest, err)
 		return
 	}
+	cmd := app.PlaceOrder{Meta: meta, OrderID: req.OrderID, CustomerID: req.CustomerID, Items: req.Items}
+	res, err := s.svc.HandlePlaceOrder(r.Context(), cmd)
+	if err != nil {
+		respondErr(w, commandStatus(err), err)
+		return
+	}
+	setResultHeaders(w, res)
 	respondOK(w, map[string]string{"order_id": req.OrderID})
 }
 
@@ -85,15 +117,23 @@ func (s *Server) authorizePayment(w http.ResponseWriter, r *http.Request) {
 		respondErr(w, http.StatusBadRequest, err)
 		return
 	}
+	meta, err := commandMeta(w, r)
+	if err != nil {
+		respondErr(w, http.StatusBadRequest, err)
+		return
+	}
 	cmd := app.AuthorizePayment{
+		Meta:      meta,
 		OrderID:   mux.Vars(r)["id"],
 		PaymentID: req.PaymentID,
 		Amount:    req.Amount,
 	}
-	if err := s.svc.HandleAuthorizePayment(r.Context(), cmd); err != nil {
-		respondErr(w, http.StatusBadRequest, err)
+	res, err := s.svc.HandleAuthorizePayment(r.Context(), cmd)
+	if err != nil {
+		respondErr(w, commandStatus(err), err)
 		return
 	}
+	setResultHeaders(w, res)
 	respondAccepted(w)
 }
 
@@ -103,11 +143,18 @@ func (s *Server) reserveInventory(w http.ResponseWriter, r *http.Request) {
 		respondErr(w, http.StatusBadRequest, err)
 		return
 	}
-	cmd := app.ReserveInventory{OrderID: mux.Vars(r)["id"], ReservationID: req.ReservationID}
-	if err := s.svc.HandleReserveInventory(r.Context(), cmd); err != nil {
+	meta, err := commandMeta(w, r)
+	if err != nil {
 		respondErr(w, http.StatusBadRequest, err)
 		return
 	}
+	cmd := app.ReserveInventory{Meta: meta, OrderID: mux.Vars(r)["id"], ReservationID: req.ReservationID}
+	res, err := s.svc.HandleReserveInventory(r.Context(), cmd)
+	if err != nil {
+		respondErr(w, commandStatus(err), err)
+		return
+	}
+	setResultHeaders(w, res)
 	respondAccepted(w)
 }
 
@@ -117,11 +164,18 @@ func (s *Server) shipOrder(w http.ResponseWriter, r *http.Request) {
 		respondErr(w, http.StatusBadRequest, err)
 		return
 	}
-	cmd := app.ShipOrder{OrderID: mux.Vars(r)["id"], TrackingNumber: req.TrackingNumber, Carrier: req.Carrier}
-	if err := s.svc.HandleShipOrder(r.Context(), cmd); err != nil {
+	meta, err := commandMeta(w, r)
+	if err != nil {
 		respondErr(w, http.StatusBadRequest, err)
 		return
 	}
+	cmd := app.ShipOrder{Meta: meta, OrderID: mux.Vars(r)["id"], TrackingNumber: req.TrackingNumber, Carrier: req.Carrier}
+	res, err := s.svc.HandleShipOrder(r.Context(), cmd)
+	if err != nil {
+		respondErr(w, commandStatus(err), err)
+		return
+	}
+	setResultHeaders(w, res)
 	respondAccepted(w)
 }
 
@@ -131,28 +185,170 @@ func (s *Server) cancelOrder(w http.ResponseWriter, r *http.Request) {
 		respondErr(w, http.StatusBadRequest, err)
 		return
 	}
-	cmd := app.CancelOrder{OrderID: mux.Vars(r)["id"], Reason: req.Reason}
-	if err := s.svc.HandleCancelOrder(r.Context(), cmd); err != nil {
+	meta, err := commandMeta(w, r)
+	if err != nil {
 		respondErr(w, http.StatusBadRequest, err)
 		return
 	}
+	cmd := app.CancelOrder{Meta: meta, OrderID: mux.Vars(r)["id"], Reason: req.Reason}
+	res, err := s.svc.HandleCancelOrder(r.Context(), cmd)
+	if err != nil {
+		respondErr(w, commandStatus(err), err)
+		return
+	}
+	setResultHeaders(w, res)
 	respondAccepted(w)
 }
 
+func (s *Server) snapshotOrder(w http.ResponseWriter, r *http.Request) {
+	version, err := s.svc.TakeSnapshot(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		respondErr(w, commandStatus(err), err)
+		return
+	}
+	respondOK(w, map[string]int{"version": version})
+}
+
 func (s *Server) listOrders(w http.ResponseWriter, r *http.Request) {
 	respondOK(w, s.views.List())
 }
 
 func (s *Server) getOrder(w http.ResponseWriter, r *http.Request) {
 	orderID := mux.Vars(r)["id"]
+	if r.URL.Query().Has("asOf") {
+		s.getOrderAsOf(w, r, orderID)
+		return
+	}
 	view, ok := s.views.Get(orderID)
 	if !ok {
 		http.NotFound(w, r)
 		return
 	}
+	// The view may lag the event store; If-Match is checked against the aggregate, so the ETag must
+	// come from it too.
+	order, err := s.svc.LoadOrder(r.Context(), orderID)
+	if err != nil {
+		respondErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("ETag", etag(order.Version()))
 	respondOK(w, view)
 }
 
+// getOrderAsOf rebuilds the aggregate from the event store; asOf is either a version number or an
+// RFC 3339 timestamp compared with the time each event was recorded.
+func (s *Server) getOrderAsOf(w http.ResponseWriter, r *http.Request, orderID string) {
+	raw := r.URL.Query().Get("asOf")
+	var asOf app.AsOf
+	if version, err := strconv.Atoi(raw); err == nil && version > 0 {
+		asOf.Version = version
+	} else if at, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+		asOf.Time = at
+	} else {
+		respondErr(w, http.StatusBadRequest, fmt.Errorf("asOf must be a version or an RFC 3339 timestamp, got %q", raw))
+		return
+	}
+	order, err := s.svc.OrderAt(r.Context(), orderID, asOf)
+	if err != nil {
+		respondErr(w, commandStatus(err), err)
+		return
+	}
+	w.Header().Set("ETag", etag(order.Version()))
+	respondOK(w, order.Snapshot())
+}
+
+func (s *Server) orderHistory(w http.ResponseWriter, r *http.Request) {
+	history, err := s.svc.History(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		respondErr(w, commandStatus(err), err)
+		return
+	}
+	respondOK(w, history)
+}
+
+func (s *Server) getSaga(w http.ResponseWriter, r *http.Request) {
+	if s.sagas == nil {
+		http.NotFound(w, r)
+		return
+	}
+	state, ok, err := s.sagas.Get(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		respondErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	respondOK(w, state)
+}
+
+func (s *Server) listCustomers(w http.ResponseWriter, r *http.Request) {
+	respondOK(w, s.customers.List())
+}
+
+func (s *Server) getCustomer(w http.ResponseWriter, r *http.Request) {
+	totals, ok := s.customers.Get(mux.Vars(r)["id"])
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	respondOK(w, totals)
+}
+
+func (s *Server) projectionStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := s.projections.Status(r.Context())
+	if err != nil {
+		respondErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	respondOK(w, status)
+}
+
+func (s *Server) rebuildProjection(w http.ResponseWriter, r *http.Request) {
+	if err := s.projections.Rebuild(r.Context(), mux.Vars(r)["name"]); err != nil {
+		respondErr(w, http.StatusBadRequest, err)
+		return
+	}
+	s.projectionStatus(w, r)
+}
+
+func (s *Server) subscriberStats(w http.ResponseWriter, _ *http.Request) {
+	respondOK(w, s.bus.Stats())
+}
+
+func (s *Server) listDeadLetters(w http.ResponseWriter, r *http.Request) {
+	letters, err := s.bus.DeadLetters(r.Context())
+	if err != nil {
+		respondErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	respondOK(w, letters)
+}
+
+func (s *Server) replayDeadLetter(w http.ResponseWriter, r *http.Request) {
+	if err := s.bus.Replay(r.Context(), mux.Vars(r)["id"]); err != nil {
+		respondErr(w, deadLetterStatus(err), err)
+		return
+	}
+	respondAccepted(w)
+}
+
+func (s *Server) discardDeadLetter(w http.ResponseWriter, r *http.Request) {
+	if err := s.bus.Discard(r.Context(), mux.Vars(r)["id"]); err != nil {
+		respondErr(w, deadLetterStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func deadLetterStatus(err error) int {
+	if errors.Is(err, eventbus.ErrDeadLetterNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
+
 func respondAccepted(w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusAccepted)
@@ -165,6 +361,63 @@ func respondOK(w http.ResponseWriter, payload any) {
 	_ = json.NewEncoder(w).Encode(payload)
 }
 
+// commandMeta reads the Idempotency-Key header, the If-Match precondition (the order version as
+// returned in ETag) and the audit headers. The request ID, generated when the client sent none, becomes
+// the causation ID and is echoed back so callers can find their request in the order history.
+func commandMeta(w http.ResponseWriter, r *http.Request) (app.Meta, error) {
+	requestID := r.Header.Get("X-Request-ID")
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+	w.Header().Set("X-Request-ID", requestID)
+	meta := app.Meta{
+		IdempotencyKey: r.Header.Get("Idempotency-Key"),
+		CorrelationID:  r.Header.Get("X-Correlation-ID"),
+		CausationID:    requestID,
+		UserID:         r.Header.Get("X-User-ID"),
+	}
+	if match := r.Header.Get("If-Match"); match != "" {
+		version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(match, "W/"), `"`))
+		if err != nil || version <= 0 {
+			return app.Meta{}, fmt.Errorf("If-Match must be an order version, got %q", match)
+		}
+		meta.ExpectedVersion = version
+	}
+	return meta, nil
+}
+
+// setResultHeaders exposes the order version after a command as its ETag and flags replayed duplicates.
+func setResultHeaders(w http.ResponseWriter, res app.Result) {
+	w.Header().Set("ETag", etag(res.Version))
+	if res.Duplicate {
+		w.Header().Set("Idempotent-Replayed", "true")
+	}
+}
+
+func newRequestID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return "req-" + hex.EncodeToString(b[:])
+}
+
+func etag(version int) string { return `"` + strconv.Itoa(version) + `"` }
+
+// commandStatus maps command failures to HTTP codes; lost optimistic-concurrency races are retryable.
+func commandStatus(err error) int {
+	switch {
+	case errors.Is(err, app.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, app.ErrConcurrencyConflict):
+		return http.StatusConflict
+	case errors.Is(err, app.ErrIdempotencyKeyReused):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, app.ErrOrderNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusBadRequest
+	}
+}
+
 func respondErr(w http.ResponseWriter, code int, err error) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
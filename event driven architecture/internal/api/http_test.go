@@ -0,0 +1,183 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/venkatesh/order-service/internal/app"
+	"github.com/venkatesh/order-service/internal/domain"
+	"github.com/venkatesh/order-service/internal/infrastructure"
+	"github.com/venkatesh/order-service/internal/readmodel"
+)
+
+type nopPublisher struct{}
+
+func (nopPublisher) Publish(context.Context, []domain.Event) error { return nil }
+
+// testServer wires the API to an in-memory store. Nothing is published, so the read models only move
+// when the test calls catchUp, which lets a test hold the projection behind the event store.
+type testServer struct {
+	handler http.Handler
+	runner  *readmodel.Runner
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	store := infrastructure.NewInMemoryStore()
+	views := readmodel.NewOrdersProjection()
+	customers := readmodel.NewCustomerTotalsProjection()
+	runner := readmodel.NewRunner(store, nil, 100, views, customers)
+	svc := app.NewOrderService(store, nopPublisher{})
+	return &testServer{handler: NewServer(svc, views, customers, runner, nil, nil).Router(), runner: runner}
+}
+
+func (s *testServer) catchUp(t *testing.T) {
+	t.Helper()
+	if err := s.runner.CatchUp(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (s *testServer) do(t *testing.T, method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+const placeBody = `{"order_id":"o-1","customer_id":"c-1","items":[{"SKU":"sku-1","Quantity":2,"UnitPriceCents":500}]}`
+
+func (s *testServer) place(t *testing.T) {
+	t.Helper()
+	if rec := s.do(t, http.MethodPost, "/orders", placeBody, nil); rec.Code != http.StatusOK {
+		t.Fatalf("place order: %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestDuplicateIdempotencyKeyIsReplayed(t *testing.T) {
+	s := newTestServer(t)
+	s.place(t)
+	key := map[string]string{"Idempotency-Key": "pay-once"}
+	body := `{"payment_id":"pay-1","amount_cents":1000}`
+
+	first := s.do(t, http.MethodPost, "/orders/o-1/payment", body, key)
+	if first.Code != http.StatusAccepted || first.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("first: %d replayed=%q", first.Code, first.Header().Get("Idempotent-Replayed"))
+	}
+	again := s.do(t, http.MethodPost, "/orders/o-1/payment", body, key)
+	if again.Code != http.StatusAccepted || again.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("retry: %d replayed=%q", again.Code, again.Header().Get("Idempotent-Replayed"))
+	}
+	if got, want := again.Header().Get("ETag"), first.Header().Get("ETag"); got != want {
+		t.Fatalf("retry ETag = %s, want %s", got, want)
+	}
+
+	reused := s.do(t, http.MethodPost, "/orders/o-1/payment", `{"payment_id":"pay-2","amount_cents":1000}`, key)
+	if reused.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("reused key with other payload: %d %s, want 422", reused.Code, reused.Body)
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	s := newTestServer(t)
+	s.place(t)
+	body := `{"payment_id":"pay-1","amount_cents":1000}`
+
+	tests := []struct {
+		name    string
+		ifMatch string
+		want    int
+	}{
+		{"malformed", "abc", http.StatusBadRequest},
+		{"stale", `"2"`, http.StatusPreconditionFailed},
+		{"current", `"1"`, http.StatusAccepted},
+		{"weak tag of the old version", `W/"1"`, http.StatusPreconditionFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := s.do(t, http.MethodPost, "/orders/o-1/payment", body, map[string]string{"If-Match": tt.ifMatch})
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d %s, want %d", rec.Code, rec.Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetOrderETagIsTheEventStoreVersion(t *testing.T) {
+	s := newTestServer(t)
+	s.place(t)
+	s.catchUp(t)
+	if rec := s.do(t, http.MethodPost, "/orders/o-1/payment", `{"payment_id":"pay-1","amount_cents":1000}`, nil); rec.Code != http.StatusAccepted {
+		t.Fatalf("authorize: %d %s", rec.Code, rec.Body)
+	}
+
+	// The projection has not seen the payment yet.
+	rec := s.do(t, http.MethodGet, "/orders/o-1", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get: %d %s", rec.Code, rec.Body)
+	}
+	etag := rec.Header().Get("ETag")
+	if etag != `"2"` {
+		t.Fatalf("ETag = %s, want \"2\"", etag)
+	}
+	// The ETag is accepted as a precondition even though the view still shows version 1.
+	reserve := s.do(t, http.MethodPost, "/orders/o-1/reserve", `{"reservation_id":"res-1"}`, map[string]string{"If-Match": etag})
+	if reserve.Code != http.StatusAccepted {
+		t.Fatalf("reserve with If-Match %s: %d %s", etag, reserve.Code, reserve.Body)
+	}
+}
+
+func TestCommandStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{&app.ConflictError{AggregateID: "o-1", Expected: 1, Actual: 2}, http.StatusConflict},
+		{app.ErrPreconditionFailed, http.StatusPreconditionFailed},
+		{app.ErrOrderNotFound, http.StatusNotFound},
+		{app.ErrIdempotencyKeyReused, http.StatusUnprocessableEntity},
+		{domain.ErrInvalidTransition, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if got := commandStatus(tt.err); got != tt.want {
+			t.Errorf("commandStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestGetOrderAsOf(t *testing.T) {
+	s := newTestServer(t)
+	s.place(t)
+	if rec := s.do(t, http.MethodPost, "/orders/o-1/payment", `{"payment_id":"pay-1","amount_cents":1000}`, nil); rec.Code != http.StatusAccepted {
+		t.Fatalf("authorize: %d %s", rec.Code, rec.Body)
+	}
+
+	tests := []struct {
+		asOf     string
+		wantCode int
+		wantETag string
+	}{
+		{"1", http.StatusOK, `"1"`},
+		{"2", http.StatusOK, `"2"`},
+		{"3", http.StatusNotFound, ""},
+		{"2000-01-01T00:00:00Z", http.StatusNotFound, ""},
+		{"2999-01-01T00:00:00%2B05:30", http.StatusOK, `"2"`},
+		{"yesterday", http.StatusBadRequest, ""},
+		{"0", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.asOf, func(t *testing.T) {
+			rec := s.do(t, http.MethodGet, "/orders/o-1?asOf="+tt.asOf, "", nil)
+			if rec.Code != tt.wantCode || rec.Header().Get("ETag") != tt.wantETag {
+				t.Fatalf("status %d ETag %s, want %d %s (%s)", rec.Code, rec.Header().Get("ETag"), tt.wantCode, tt.wantETag, rec.Body)
+			}
+		})
+	}
+}
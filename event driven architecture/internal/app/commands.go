@@ -1,9 +1,44 @@
 package app
 
-import "github.com/venkatesh/order-service/internal/domain"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/venkatesh/order-service/internal/domain"
+)
+
+// Meta carries delivery concerns shared by every command.
+type Meta struct {
+	// IdempotencyKey is recorded with the resulting events together with a hash of the command;
+	// repeating the same command with a key already on the stream returns the original outcome instead
+	// of executing again, and reusing the key for a different command fails.
+	IdempotencyKey string
+	// ExpectedVersion, when non-zero, requires the order to be at exactly this version (HTTP If-Match).
+	// Commands with a precondition are never retried automatically.
+	ExpectedVersion int
+	// CorrelationID groups every command of one business flow. When empty, commands on an existing
+	// order continue the correlation the order was placed with.
+	CorrelationID string
+	// CausationID names what triggered the command: an HTTP request ID, or "<order>@<version>" for the
+	// event a process manager reacted to.
+	CausationID string
+	// UserID is the acting user or system component.
+	UserID string
+}
+
+// Result is the outcome of a command.
+type Result struct {
+	// Version is the order version after the command.
+	Version int
+	// Duplicate is set when the idempotency key had already been processed.
+	Duplicate bool
+}
 
 // PlaceOrder carries user intent to create a new order aggregate.
 type PlaceOrder struct {
+	Meta
 	OrderID    string
 	CustomerID string
 	Items      []domain.LineItem
@@ -11,6 +46,7 @@ type PlaceOrder struct {
 
 // AuthorizePayment is triggered by payment service callback.
 type AuthorizePayment struct {
+	Meta
 	OrderID   string
 	PaymentID string
 	Amount    int64
@@ -18,12 +54,14 @@ type AuthorizePayment struct {
 
 // ReserveInventory represents the inventory locking step.
 type ReserveInventory struct {
+	Meta
 	OrderID       string
 	ReservationID string
 }
 
 // ShipOrder finalizes fulfillment.
 type ShipOrder struct {
+	Meta
 	OrderID        string
 	TrackingNumber string
 	Carrier        string
@@ -31,6 +69,24 @@ type ShipOrder struct {
 
 // CancelOrder compensates sagas when downstream failures occur.
 type CancelOrder struct {
+	Meta
 	OrderID string
 	Reason  string
 }
+
+// fingerprint hashes a command's type and payload, leaving out Meta: the same request retried with a
+// fresh request ID or a different If-Match is still the same command.
+func fingerprint(cmd any) (string, error) {
+	payload, err := json.Marshal(cmd)
+	if err != nil {
+		return "", fmt.Errorf("fingerprint %T: %w", cmd, err)
+	}
+	sum := sha256.Sum256(append([]byte(fmt.Sprintf("%T:", cmd)), payload...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (c PlaceOrder) fingerprint() (string, error)       { c.Meta = Meta{}; return fingerprint(c) }
+func (c AuthorizePayment) fingerprint() (string, error) { c.Meta = Meta{}; return fingerprint(c) }
+func (c ReserveInventory) fingerprint() (string, error) { c.Meta = Meta{}; return fingerprint(c) }
+func (c ShipOrder) fingerprint() (string, error)        { c.Meta = Meta{}; return fingerprint(c) }
+func (c CancelOrder) fingerprint() (string, error)      { c.Meta = Meta{}; return fingerprint(c) }
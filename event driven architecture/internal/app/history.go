@@ -0,0 +1,108 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/venkatesh/order-service/internal/domain"
+)
+
+// HistoryEntry is one event of an order's audit trail with the state change it produced.
+type HistoryEntry struct {
+	Position   int64         `json:"position"`
+	Version    int           `json:"version"`
+	Type       string        `json:"type"`
+	Schema     int           `json:"schema"`
+	OccurredAt time.Time     `json:"occurred_at"`
+	RecordedAt time.Time     `json:"recorded_at"`
+	Metadata   Metadata      `json:"metadata"`
+	Event      domain.Event  `json:"event"`
+	Changes    []FieldChange `json:"changes"`
+}
+
+// FieldChange is an aggregate field whose value an event changed.
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// AsOf selects a point in an order's history: the state after Version when it is set, otherwise the
+// state from the events recorded at or before Time.
+type AsOf struct {
+	Version int
+	Time    time.Time
+}
+
+// History replays an order's stream and returns every event with its metadata and the fields it
+// changed. It reads the store, not the projections, so it is never behind.
+func (s *OrderService) History(ctx context.Context, orderID string) ([]HistoryEntry, error) {
+	records, err := s.store.ReadStream(ctx, orderID, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrOrderNotFound, orderID)
+	}
+	order := domain.NewOrder(orderID)
+	entries := make([]HistoryEntry, 0, len(records))
+	for _, rec := range records {
+		before := order.Snapshot()
+		order.Apply(rec.Event)
+		entries = append(entries, HistoryEntry{
+			Position:   rec.Position,
+			Version:    rec.Version,
+			Type:       rec.Event.EventName(),
+			Schema:     rec.Event.SchemaVersion(),
+			OccurredAt: rec.Event.OccurredAt(),
+			RecordedAt: rec.RecordedAt,
+			Metadata:   rec.Metadata,
+			Event:      rec.Event,
+			Changes:    diffSnapshots(before, order.Snapshot()),
+		})
+	}
+	return entries, nil
+}
+
+// OrderAt rebuilds an order as it stood at asOf by replaying its stream from the start; snapshots only
+// describe the latest state, so they cannot be used here.
+func (s *OrderService) OrderAt(ctx context.Context, orderID string, asOf AsOf) (*domain.Order, error) {
+	records, err := s.store.ReadStream(ctx, orderID, 0, asOf.Version)
+	if err != nil {
+		return nil, err
+	}
+	order := domain.NewOrder(orderID)
+	for _, rec := range records {
+		if asOf.Version == 0 && rec.RecordedAt.After(asOf.Time) {
+			break
+		}
+		order.Apply(rec.Event)
+	}
+	if order.Version() == 0 {
+		return nil, fmt.Errorf("%w: %s did not exist yet", ErrOrderNotFound, orderID)
+	}
+	if asOf.Version > order.Version() {
+		return nil, fmt.Errorf("%w: %s has no version %d", ErrOrderNotFound, orderID, asOf.Version)
+	}
+	return order, nil
+}
+
+// diffSnapshots lists the fields that differ between two states of the same order. ID and Version are
+// left out: the first never changes and the second changes with every event.
+func diffSnapshots(before, after domain.OrderSnapshot) []FieldChange {
+	changes := make([]FieldChange, 0)
+	bv, av := reflect.ValueOf(before), reflect.ValueOf(after)
+	for i := 0; i < bv.NumField(); i++ {
+		name := bv.Type().Field(i).Name
+		if name == "ID" || name == "Version" {
+			continue
+		}
+		from, to := bv.Field(i).Interface(), av.Field(i).Interface()
+		if !reflect.DeepEqual(from, to) {
+			changes = append(changes, FieldChange{Field: name, From: from, To: to})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,207 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/venkatesh/order-service/internal/app"
+	"github.com/venkatesh/order-service/internal/domain"
+)
+
+// recordedOrder runs the fulfilled lifecycle and returns the stream as recorded together with the
+// order's state after each version (states[v-1] is the state at version v).
+func recordedOrder(t *testing.T, store snapshottingStore) (*app.OrderService, []app.RecordedEvent, []domain.OrderSnapshot) {
+	t.Helper()
+	ctx := context.Background()
+	svc := app.NewOrderService(store, nopPublisher{})
+	var states []domain.OrderSnapshot
+	for _, step := range lifecycles["fulfilled"] {
+		// Keep recording times apart so every version has its own instant.
+		time.Sleep(2 * time.Millisecond)
+		if err := step(ctx, svc); err != nil {
+			t.Fatal(err)
+		}
+		order, err := svc.LoadOrder(ctx, "o-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		states = append(states, order.Snapshot())
+	}
+	records, err := store.ReadStream(ctx, "o-1", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return svc, records, states
+}
+
+func TestOrderAtVersion(t *testing.T) {
+	ctx := context.Background()
+	for name, open := range snapshotStores(t) {
+		t.Run(name, func(t *testing.T) {
+			svc, _, states := recordedOrder(t, open())
+			for v := 1; v <= len(states); v++ {
+				order, err := svc.OrderAt(ctx, "o-1", app.AsOf{Version: v})
+				if err != nil {
+					t.Fatalf("version %d: %v", v, err)
+				}
+				if got := order.Snapshot(); !reflect.DeepEqual(got, states[v-1]) {
+					t.Fatalf("version %d:\n got  %+v\n want %+v", v, got, states[v-1])
+				}
+			}
+			if _, err := svc.OrderAt(ctx, "o-1", app.AsOf{Version: len(states) + 1}); !errors.Is(err, app.ErrOrderNotFound) {
+				t.Fatalf("version past the head: err = %v, want ErrOrderNotFound", err)
+			}
+			if _, err := svc.OrderAt(ctx, "o-2", app.AsOf{Version: 1}); !errors.Is(err, app.ErrOrderNotFound) {
+				t.Fatalf("unknown order: err = %v, want ErrOrderNotFound", err)
+			}
+		})
+	}
+}
+
+func TestOrderAtTime(t *testing.T) {
+	ctx := context.Background()
+	for name, open := range snapshotStores(t) {
+		t.Run(name, func(t *testing.T) {
+			svc, records, states := recordedOrder(t, open())
+			for i, rec := range records {
+				// An event recorded exactly at asOf is included...
+				order, err := svc.OrderAt(ctx, "o-1", app.AsOf{Time: rec.RecordedAt})
+				if err != nil {
+					t.Fatalf("at version %d's recording time: %v", rec.Version, err)
+				}
+				if got := order.Snapshot(); !reflect.DeepEqual(got, states[i]) {
+					t.Fatalf("at version %d's recording time:\n got  %+v\n want %+v", rec.Version, got, states[i])
+				}
+				// ...and one nanosecond earlier it is not.
+				order, err = svc.OrderAt(ctx, "o-1", app.AsOf{Time: rec.RecordedAt.Add(-time.Nanosecond)})
+				if i == 0 {
+					if !errors.Is(err, app.ErrOrderNotFound) {
+						t.Fatalf("before the first event: err = %v, want ErrOrderNotFound", err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := order.Snapshot(); !reflect.DeepEqual(got, states[i-1]) {
+					t.Fatalf("just before version %d:\n got  %+v\n want %+v", rec.Version, got, states[i-1])
+				}
+			}
+
+			last := records[len(records)-1].RecordedAt
+			// The same instant in another zone selects the same state.
+			order, err := svc.OrderAt(ctx, "o-1", app.AsOf{Time: last.In(time.FixedZone("IST", 5*3600+1800))})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if order.Version() != len(states) {
+				t.Fatalf("head in another zone: version %d, want %d", order.Version(), len(states))
+			}
+			order, err = svc.OrderAt(ctx, "o-1", app.AsOf{Time: last.Add(time.Hour)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := order.Snapshot(); !reflect.DeepEqual(got, states[len(states)-1]) {
+				t.Fatalf("after the last event:\n got  %+v\n want %+v", got, states[len(states)-1])
+			}
+		})
+	}
+}
+
+func TestOrderAtTimeIncludesWholeAppend(t *testing.T) {
+	ctx := context.Background()
+	for name, open := range snapshotStores(t) {
+		t.Run(name, func(t *testing.T) {
+			store := open()
+			at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+			events := []domain.Event{
+				domain.OrderPlaced{
+					BaseEvent:  domain.BaseEvent{Name: "OrderPlaced", EntityID: "o-1", Timestamp: at},
+					CustomerID: "cust-1",
+					Items:      []domain.LineItem{{SKU: "sku-1", Quantity: 1, UnitPriceCents: 1000}},
+					TotalCents: 1000,
+				},
+				domain.OrderCancelled{BaseEvent: domain.BaseEvent{Name: "OrderCancelled", EntityID: "o-1", Timestamp: at}, Reason: "fraud"},
+			}
+			if err := store.Append(ctx, "o-1", 0, events, app.Metadata{}); err != nil {
+				t.Fatal(err)
+			}
+			records, err := store.ReadStream(ctx, "o-1", 0, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Events written by one append share their recording time, so no point in time splits them.
+			order, err := app.NewOrderService(store, nopPublisher{}).OrderAt(ctx, "o-1", app.AsOf{Time: records[0].RecordedAt})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if order.Version() != 2 || order.Status() != domain.OrderStatusCancelled {
+				t.Fatalf("at the append's time: version %d status %s, want 2 cancelled", order.Version(), order.Status())
+			}
+		})
+	}
+}
+
+func TestHistory(t *testing.T) {
+	ctx := context.Background()
+	store := snapshotStores(t)["sqlite"]()
+	svc := app.NewOrderService(store, nopPublisher{})
+	if _, err := svc.HandlePlaceOrder(ctx, app.PlaceOrder{
+		Meta:       app.Meta{IdempotencyKey: "place-1", CausationID: "req-1", UserID: "u-1"},
+		OrderID:    "o-1",
+		CustomerID: "cust-1",
+		Items:      []domain.LineItem{{SKU: "sku-1", Quantity: 2, UnitPriceCents: 500}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, step := range lifecycles["fulfilled"][1:] {
+		if err := step(ctx, svc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	history, err := svc.History(ctx, "o-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		typ     string
+		changes []string
+	}{
+		{"OrderPlaced", []string{"CustomerID", "Items", "Status", "TotalCents"}},
+		{"PaymentAuthorized", []string{"PaymentAuthorized"}},
+		{"InventoryReserved", []string{"Status", "InventoryReserved"}},
+		{"OrderShipped", []string{"Status"}},
+	}
+	if len(history) != len(want) {
+		t.Fatalf("history has %d entries, want %d", len(history), len(want))
+	}
+	for i, entry := range history {
+		var fields []string
+		for _, c := range entry.Changes {
+			fields = append(fields, c.Field)
+		}
+		if entry.Version != i+1 || entry.Type != want[i].typ || !reflect.DeepEqual(fields, want[i].changes) {
+			t.Fatalf("entry %d = version %d %s changing %v, want version %d %s changing %v",
+				i, entry.Version, entry.Type, fields, i+1, want[i].typ, want[i].changes)
+		}
+	}
+	placed := history[0].Metadata
+	if placed.CausationID != "req-1" || placed.CorrelationID != "req-1" || placed.UserID != "u-1" || placed.IdempotencyKey != "place-1" {
+		t.Fatalf("placement metadata = %+v", placed)
+	}
+	// Later commands without a correlation ID join the one the order was placed with.
+	if got := history[3].Metadata.CorrelationID; got != "req-1" {
+		t.Fatalf("shipment correlation = %q, want req-1", got)
+	}
+	if status := history[3].Changes[0]; status.From != domain.OrderStatusReserved || status.To != domain.OrderStatusShipped {
+		t.Fatalf("shipment change = %+v", status)
+	}
+
+	if _, err := svc.History(ctx, "o-2"); !errors.Is(err, app.ErrOrderNotFound) {
+		t.Fatalf("unknown order: err = %v, want ErrOrderNotFound", err)
+	}
+}
@@ -2,7 +2,10 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"time"
 
 	"github.com/venkatesh/order-service/internal/domain"
 )
@@ -10,7 +13,73 @@ import (
 // EventStore persists and rehydrates aggregates via event sourcing.
 type EventStore interface {
 	Load(ctx context.Context, aggregateID string) ([]domain.Event, error)
-	Append(ctx context.Context, aggregateID string, expectedVersion int, events []domain.Event) error
+	// LoadFrom returns the stream's events with a version greater than afterVersion.
+	LoadFrom(ctx context.Context, aggregateID string, afterVersion int) ([]domain.Event, error)
+	// Append writes events with meta attached to each; a stream not at expectedVersion yields a
+	// *ConflictError.
+	Append(ctx context.Context, aggregateID string, expectedVersion int, events []domain.Event, meta Metadata) error
+	// FindIdempotencyKey returns the stream version reached by the command that recorded key, and the
+	// metadata it was recorded with.
+	FindIdempotencyKey(ctx context.Context, aggregateID, key string) (version int, meta Metadata, found bool, err error)
+	// ReadStream returns up to limit recorded events of one stream after afterVersion (limit <= 0 means
+	// all of them), in version order.
+	ReadStream(ctx context.Context, aggregateID string, afterVersion, limit int) ([]RecordedEvent, error)
+}
+
+// Metadata is stored with every event a command produced.
+type Metadata struct {
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	// PayloadHash fingerprints the command that used IdempotencyKey, so a key reused for a different
+	// request is rejected instead of replaying an unrelated outcome.
+	PayloadHash   string `json:"payload_hash,omitempty"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+	CausationID   string `json:"causation_id,omitempty"`
+	UserID        string `json:"user_id,omitempty"`
+}
+
+// Command failures callers can branch on.
+var (
+	// ErrConcurrencyConflict matches every *ConflictError via errors.Is.
+	ErrConcurrencyConflict = errors.New("concurrency conflict")
+	// ErrPreconditionFailed is returned when Meta.ExpectedVersion does not match the order.
+	ErrPreconditionFailed = errors.New("precondition failed")
+	// ErrOrderNotFound is returned by commands on an order that was never placed.
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrIdempotencyKeyReused is returned when an idempotency key already on the stream was recorded
+	// for a different command or payload.
+	ErrIdempotencyKeyReused = errors.New("idempotency key reused with a different request")
+)
+
+// ConflictError reports an append that lost an optimistic-concurrency race.
+type ConflictError struct {
+	AggregateID string
+	Expected    int
+	Actual      int
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("concurrency conflict for aggregate %s: expected version %d, stream is at %d", e.AggregateID, e.Expected, e.Actual)
+}
+
+// Is makes errors.Is(err, ErrConcurrencyConflict) true.
+func (e *ConflictError) Is(target error) bool { return target == ErrConcurrencyConflict }
+
+// RecordedEvent is an event as persisted: its position in the global log and version within its stream.
+type RecordedEvent struct {
+	Position   int64
+	StreamID   string
+	Version    int
+	Event      domain.Event
+	Metadata   Metadata
+	RecordedAt time.Time
+}
+
+// EventStreamReader reads the global log in position order so projections can catch up.
+type EventStreamReader interface {
+	// ReadAll returns up to limit events with a position greater than after.
+	ReadAll(ctx context.Context, after int64, limit int) ([]RecordedEvent, error)
+	// LastPosition returns the position of the newest event, 0 when the log is empty.
+	LastPosition(ctx context.Context) (int64, error)
 }
 
 // Publisher fan-outs domain events to interested projections/sagas.
@@ -20,115 +89,223 @@ type Publisher interface {
 
 // OrderService wires command handlers to persistence + messaging.
 type OrderService struct {
-	store     EventStore
-	publisher Publisher
+	store         EventStore
+	publisher     Publisher
+	snapshots     SnapshotStore
+	snapshotEvery int
+	upcasters     map[int]SnapshotUpcaster
 }
 
 // NewOrderService composes an application service instance.
-func NewOrderService(store EventStore, publisher Publisher) *OrderService {
-	return &OrderService{store: store, publisher: publisher}
+func NewOrderService(store EventStore, publisher Publisher, opts ...Option) *OrderService {
+	s := &OrderService{store: store, publisher: publisher, upcasters: make(map[int]SnapshotUpcaster)}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
+// maxConflictRetries bounds the reload-and-retry loop for commutative commands.
+const maxConflictRetries = 3
+
+// commandKind tells execute whether the order must be new and whether a lost concurrency race may be
+// retried on the reloaded order.
+type commandKind int
+
+const (
+	// createsOrder requires a new order; a conflict means another request placed it first.
+	createsOrder commandKind = iota
+	// changesOrder requires an existing order. Its outcome depends on the state the caller saw, so a
+	// lost race is returned to the caller rather than retried.
+	changesOrder
+	// commutesOrder requires an existing order and means the same thing whatever landed concurrently,
+	// so a lost race is retried against the reloaded order.
+	commutesOrder
+)
+
 // HandlePlaceOrder executes the aggregate logic for PlaceOrder.
-func (s *OrderService) HandlePlaceOrder(ctx context.Context, cmd PlaceOrder) error {
-	if cmd.OrderID == "" {
-		return fmt.Errorf("order id is required")
-	}
-	order := domain.NewOrder(cmd.OrderID)
-	expected := order.Version()
-	events, err := order.HandlePlaceOrder(cmd.CustomerID, cmd.Items)
-	if err != nil {
-		return err
-	}
-	return s.persistAndPublish(ctx, order, expected, events)
+func (s *OrderService) HandlePlaceOrder(ctx context.Context, cmd PlaceOrder) (Result, error) {
+	return s.execute(ctx, cmd.OrderID, cmd.Meta, createsOrder, cmd.fingerprint, func(order *domain.Order) ([]domain.Event, error) {
+		return order.HandlePlaceOrder(cmd.CustomerID, cmd.Items)
+	})
 }
 
 // HandleAuthorizePayment rehydrates aggregate then delegates to domain logic.
-func (s *OrderService) HandleAuthorizePayment(ctx context.Context, cmd AuthorizePayment) error {
-	order, err := s.loadOrder(ctx, cmd.OrderID)
-	if err != nil {
-		return err
-	}
-	expected := order.Version()
-	events, err := order.HandleAuthorizePayment(cmd.PaymentID, cmd.Amount)
-	if err != nil {
-		return err
-	}
-	return s.persistAndPublish(ctx, order, expected, events)
+func (s *OrderService) HandleAuthorizePayment(ctx context.Context, cmd AuthorizePayment) (Result, error) {
+	return s.execute(ctx, cmd.OrderID, cmd.Meta, changesOrder, cmd.fingerprint, func(order *domain.Order) ([]domain.Event, error) {
+		return order.HandleAuthorizePayment(cmd.PaymentID, cmd.Amount)
+	})
 }
 
 // HandleReserveInventory ensures saga progression after payment.
-func (s *OrderService) HandleReserveInventory(ctx context.Context, cmd ReserveInventory) error {
-	order, err := s.loadOrder(ctx, cmd.OrderID)
+func (s *OrderService) HandleReserveInventory(ctx context.Context, cmd ReserveInventory) (Result, error) {
+	return s.execute(ctx, cmd.OrderID, cmd.Meta, changesOrder, cmd.fingerprint, func(order *domain.Order) ([]domain.Event, error) {
+		return order.HandleReserveInventory(cmd.ReservationID)
+	})
+}
+
+// HandleShipOrder finalizes lifecycle.
+func (s *OrderService) HandleShipOrder(ctx context.Context, cmd ShipOrder) (Result, error) {
+	return s.execute(ctx, cmd.OrderID, cmd.Meta, changesOrder, cmd.fingerprint, func(order *domain.Order) ([]domain.Event, error) {
+		return order.HandleShipOrder(cmd.TrackingNumber, cmd.Carrier)
+	})
+}
+
+// HandleCancelOrder compensates when downstream services fail. Cancelling is commutative: whatever
+// landed concurrently, the domain either still allows the cancel or rejects it on the fresh state.
+func (s *OrderService) HandleCancelOrder(ctx context.Context, cmd CancelOrder) (Result, error) {
+	return s.execute(ctx, cmd.OrderID, cmd.Meta, commutesOrder, cmd.fingerprint, func(order *domain.Order) ([]domain.Event, error) {
+		return order.HandleCancel(cmd.Reason)
+	})
+}
+
+// execute runs decide against the current order and appends its events. A duplicate idempotency key
+// short-circuits to the recorded outcome when fingerprint matches the recorded command, and fails
+// with ErrIdempotencyKeyReused when it does not. Only commutative commands without an ExpectedVersion are
+// retried after a lost concurrency race: the order is reloaded and the domain re-validates against the
+// fresh state. Every other conflict is returned to the caller.
+func (s *OrderService) execute(ctx context.Context, orderID string, meta Meta, kind commandKind, fingerprint func() (string, error), decide func(*domain.Order) ([]domain.Event, error)) (Result, error) {
+	if orderID == "" {
+		return Result{}, fmt.Errorf("order id is required")
+	}
+	payloadHash, err := fingerprint()
 	if err != nil {
-		return err
+		return Result{}, err
 	}
-	expected := order.Version()
-	events, err := order.HandleReserveInventory(cmd.ReservationID)
+	if res, ok, err := s.findDuplicate(ctx, orderID, meta.IdempotencyKey, payloadHash); err != nil || ok {
+		return res, err
+	}
+	metadata, err := s.metadata(ctx, orderID, meta)
 	if err != nil {
-		return err
+		return Result{}, err
+	}
+	if metadata.IdempotencyKey != "" {
+		metadata.PayloadHash = payloadHash
+	}
+	creates := kind == createsOrder
+	attempts := 1
+	if kind == commutesOrder && meta.ExpectedVersion == 0 {
+		attempts = maxConflictRetries
+	}
+	for attempt := 1; ; attempt++ {
+		order, err := s.loadOrder(ctx, orderID)
+		if err != nil {
+			return Result{}, err
+		}
+		if !creates && order.Version() == 0 {
+			return Result{}, fmt.Errorf("%w: %s", ErrOrderNotFound, orderID)
+		}
+		if meta.ExpectedVersion != 0 && order.Version() != meta.ExpectedVersion {
+			return Result{}, fmt.Errorf("%w: order %s is at version %d, not %d", ErrPreconditionFailed, orderID, order.Version(), meta.ExpectedVersion)
+		}
+		expected := order.Version()
+		events, err := decide(order)
+		if err != nil {
+			return Result{}, err
+		}
+		if len(events) == 0 {
+			return Result{Version: expected}, nil
+		}
+		err = s.persistAndPublish(ctx, order, expected, events, metadata)
+		if err == nil {
+			return Result{Version: order.Version()}, nil
+		}
+		if !errors.Is(err, ErrConcurrencyConflict) {
+			return Result{}, err
+		}
+		// The race may have been lost to a concurrent duplicate of this very command.
+		if res, ok, findErr := s.findDuplicate(ctx, orderID, meta.IdempotencyKey, payloadHash); findErr != nil || ok {
+			return res, findErr
+		}
+		if attempt >= attempts {
+			return Result{}, err
+		}
+		select {
+		case <-ctx.Done():
+			return Result{}, ctx.Err()
+		case <-time.After(time.Duration(attempt*10) * time.Millisecond):
+		}
 	}
-	return s.persistAndPublish(ctx, order, expected, events)
 }
 
-// HandleShipOrder finalizes lifecycle.
-func (s *OrderService) HandleShipOrder(ctx context.Context, cmd ShipOrder) error {
-	order, err := s.loadOrder(ctx, cmd.OrderID)
-	if err != nil {
-		return err
+// metadata builds the audit metadata for a command. Without an explicit correlation ID the command
+// joins the order's existing correlation, falling back to its own causation for a new order.
+func (s *OrderService) metadata(ctx context.Context, orderID string, meta Meta) (Metadata, error) {
+	md := Metadata{
+		IdempotencyKey: meta.IdempotencyKey,
+		CorrelationID:  meta.CorrelationID,
+		CausationID:    meta.CausationID,
+		UserID:         meta.UserID,
+	}
+	if md.CorrelationID != "" {
+		return md, nil
 	}
-	expected := order.Version()
-	events, err := order.HandleShipOrder(cmd.TrackingNumber, cmd.Carrier)
+	first, err := s.store.ReadStream(ctx, orderID, 0, 1)
 	if err != nil {
-		return err
+		return Metadata{}, err
+	}
+	if len(first) > 0 && first[0].Metadata.CorrelationID != "" {
+		md.CorrelationID = first[0].Metadata.CorrelationID
+	} else {
+		md.CorrelationID = md.CausationID
 	}
-	return s.persistAndPublish(ctx, order, expected, events)
+	return md, nil
 }
 
-// HandleCancelOrder compensates when downstream services fail.
-func (s *OrderService) HandleCancelOrder(ctx context.Context, cmd CancelOrder) error {
-	order, err := s.loadOrder(ctx, cmd.OrderID)
-	if err != nil {
-		return err
+// findDuplicate looks key up on the order's stream. Events recorded before payload hashes were stored
+// carry none and are accepted as duplicates.
+func (s *OrderService) findDuplicate(ctx context.Context, orderID, key, payloadHash string) (Result, bool, error) {
+	if key == "" {
+		return Result{}, false, nil
 	}
-	expected := order.Version()
-	events, err := order.HandleCancel(cmd.Reason)
-	if err != nil {
-		return err
+	version, recorded, ok, err := s.store.FindIdempotencyKey(ctx, orderID, key)
+	if err != nil || !ok {
+		return Result{}, false, err
 	}
-	if len(events) == 0 {
-		return nil
+	if recorded.PayloadHash != "" && recorded.PayloadHash != payloadHash {
+		return Result{}, false, fmt.Errorf("%w: key %q on order %s", ErrIdempotencyKeyReused, key, orderID)
 	}
-	return s.persistAndPublish(ctx, order, expected, events)
+	return Result{Version: version, Duplicate: true}, true, nil
+}
+
+// LoadOrder rehydrates an order for read-only inspection; Version 0 means it does not exist.
+func (s *OrderService) LoadOrder(ctx context.Context, orderID string) (*domain.Order, error) {
+	return s.loadOrder(ctx, orderID)
 }
 
 func (s *OrderService) loadOrder(ctx context.Context, orderID string) (*domain.Order, error) {
 	if orderID == "" {
 		return nil, fmt.Errorf("order id is required")
 	}
-	events, err := s.store.Load(ctx, orderID)
+	order := s.restoreSnapshot(ctx, orderID)
+	if order == nil {
+		order = domain.NewOrder(orderID)
+	}
+	events, err := s.store.LoadFrom(ctx, orderID, order.Version())
 	if err != nil {
 		return nil, err
 	}
-	order := domain.NewOrder(orderID)
 	for _, evt := range events {
 		order.Apply(evt)
 	}
 	return order, nil
 }
 
-func (s *OrderService) persistAndPublish(ctx context.Context, order *domain.Order, expected int, events []domain.Event) error {
+func (s *OrderService) persistAndPublish(ctx context.Context, order *domain.Order, expected int, events []domain.Event, meta Metadata) error {
 	if len(events) == 0 {
 		return nil
 	}
-	if err := s.store.Append(ctx, order.ID(), expected, events); err != nil {
+	if err := s.store.Append(ctx, order.ID(), expected, events, meta); err != nil {
 		return err
 	}
 	for _, evt := range events {
 		order.Apply(evt)
 	}
+	s.maybeSnapshot(ctx, order, expected)
+	// The events are committed, so the command has succeeded. Failing it now would invite a retry of a
+	// command that already took effect; the projection runner and sagas catch up from the store instead.
 	if err := s.publisher.Publish(ctx, events); err != nil {
-		return err
+		log.Printf("order %s: %d events stored but not published: %v", order.ID(), len(events), err)
 	}
 	return nil
 }
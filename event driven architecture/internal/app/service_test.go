@@ -0,0 +1,200 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/venkatesh/order-service/internal/app"
+	"github.com/venkatesh/order-service/internal/domain"
+	"github.com/venkatesh/order-service/internal/infrastructure"
+)
+
+type nopPublisher struct{}
+
+func (nopPublisher) Publish(context.Context, []domain.Event) error { return nil }
+
+// racingStore loses the next conflicts appends to a concurrent writer, as if another command had
+// landed between the load and the append.
+type racingStore struct {
+	*infrastructure.InMemoryStore
+	mu        sync.Mutex
+	conflicts int
+	appends   int
+}
+
+func (s *racingStore) Append(ctx context.Context, aggregateID string, expected int, events []domain.Event, meta app.Metadata) error {
+	s.mu.Lock()
+	s.appends++
+	lose := s.conflicts > 0
+	if lose {
+		s.conflicts--
+	}
+	s.mu.Unlock()
+	if lose {
+		return &app.ConflictError{AggregateID: aggregateID, Expected: expected, Actual: expected + 1}
+	}
+	return s.InMemoryStore.Append(ctx, aggregateID, expected, events, meta)
+}
+
+func (s *racingStore) loseNext(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conflicts, s.appends = n, 0
+}
+
+func newService(t *testing.T) (*app.OrderService, *racingStore) {
+	t.Helper()
+	store := &racingStore{InMemoryStore: infrastructure.NewInMemoryStore()}
+	return app.NewOrderService(store, nopPublisher{}), store
+}
+
+func placeOrder(t *testing.T, svc *app.OrderService, orderID string) {
+	t.Helper()
+	_, err := svc.HandlePlaceOrder(context.Background(), app.PlaceOrder{
+		OrderID:    orderID,
+		CustomerID: "cust-1",
+		Items:      []domain.LineItem{{SKU: "sku-1", Quantity: 2, UnitPriceCents: 500}},
+	})
+	if err != nil {
+		t.Fatalf("place order: %v", err)
+	}
+}
+
+func TestDuplicateIdempotencyKeyReturnsRecordedOutcome(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newService(t)
+	placeOrder(t, svc, "o-1")
+
+	cmd := app.AuthorizePayment{Meta: app.Meta{IdempotencyKey: "k-1", CausationID: "req-1"}, OrderID: "o-1", PaymentID: "pay-1", Amount: 1000}
+	first, err := svc.HandleAuthorizePayment(ctx, cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Duplicate {
+		t.Fatal("first execution reported as duplicate")
+	}
+	// A client retry carries a fresh request ID; it is still the same command.
+	cmd.CausationID = "req-2"
+	again, err := svc.HandleAuthorizePayment(ctx, cmd)
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if !again.Duplicate || again.Version != first.Version {
+		t.Fatalf("retry = %+v, want duplicate at version %d", again, first.Version)
+	}
+}
+
+func TestIdempotencyKeyReusedForDifferentPayloadIsRejected(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newService(t)
+	placeOrder(t, svc, "o-1")
+
+	meta := app.Meta{IdempotencyKey: "k-1"}
+	if _, err := svc.HandleAuthorizePayment(ctx, app.AuthorizePayment{Meta: meta, OrderID: "o-1", PaymentID: "pay-1", Amount: 1000}); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]func() (app.Result, error){
+		"same command, other payload": func() (app.Result, error) {
+			return svc.HandleAuthorizePayment(ctx, app.AuthorizePayment{Meta: meta, OrderID: "o-1", PaymentID: "pay-2", Amount: 1000})
+		},
+		"other command": func() (app.Result, error) {
+			return svc.HandleCancelOrder(ctx, app.CancelOrder{Meta: meta, OrderID: "o-1", Reason: "changed mind"})
+		},
+	}
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := run(); !errors.Is(err, app.ErrIdempotencyKeyReused) {
+				t.Fatalf("err = %v, want ErrIdempotencyKeyReused", err)
+			}
+		})
+	}
+	order, err := svc.LoadOrder(ctx, "o-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Version() != 2 || order.Status() != domain.OrderStatusConfirmed {
+		t.Fatalf("order at version %d status %s, want 2 confirmed", order.Version(), order.Status())
+	}
+}
+
+type failingPublisher struct{}
+
+func (failingPublisher) Publish(context.Context, []domain.Event) error {
+	return errors.New("bus closed")
+}
+
+func TestPublishFailureDoesNotFailStoredCommand(t *testing.T) {
+	ctx := context.Background()
+	store := infrastructure.NewInMemoryStore()
+	svc := app.NewOrderService(store, failingPublisher{})
+	placeOrder(t, svc, "o-1")
+
+	cmd := app.AuthorizePayment{Meta: app.Meta{IdempotencyKey: "k-1"}, OrderID: "o-1", PaymentID: "pay-1", Amount: 1000}
+	res, err := svc.HandleAuthorizePayment(ctx, cmd)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	if res.Version != 2 {
+		t.Fatalf("version = %d, want 2", res.Version)
+	}
+	// The events are in the store for the projection runner to pick up, and a client retry is
+	// recognised as the command that already took effect.
+	recorded, err := store.ReadAll(ctx, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recorded) != 2 {
+		t.Fatalf("stored %d events, want 2", len(recorded))
+	}
+	again, err := svc.HandleAuthorizePayment(ctx, cmd)
+	if err != nil || !again.Duplicate {
+		t.Fatalf("retry = %+v, %v; want duplicate", again, err)
+	}
+}
+
+func TestNonCommutativeCommandIsNotRetriedAfterConflict(t *testing.T) {
+	ctx := context.Background()
+	svc, store := newService(t)
+	placeOrder(t, svc, "o-1")
+
+	store.loseNext(1)
+	_, err := svc.HandleAuthorizePayment(ctx, app.AuthorizePayment{OrderID: "o-1", PaymentID: "pay-1", Amount: 1000})
+	if !errors.Is(err, app.ErrConcurrencyConflict) {
+		t.Fatalf("err = %v, want ErrConcurrencyConflict", err)
+	}
+	if store.appends != 1 {
+		t.Fatalf("appends = %d, want 1", store.appends)
+	}
+}
+
+func TestCommutativeCommandIsRetriedAfterConflict(t *testing.T) {
+	ctx := context.Background()
+	svc, store := newService(t)
+	placeOrder(t, svc, "o-1")
+
+	store.loseNext(1)
+	res, err := svc.HandleCancelOrder(ctx, app.CancelOrder{OrderID: "o-1", Reason: "out of stock"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Version != 2 || store.appends != 2 {
+		t.Fatalf("version %d after %d appends, want version 2 after 2", res.Version, store.appends)
+	}
+}
+
+func TestCommutativeCommandWithPreconditionIsNotRetried(t *testing.T) {
+	ctx := context.Background()
+	svc, store := newService(t)
+	placeOrder(t, svc, "o-1")
+
+	store.loseNext(1)
+	_, err := svc.HandleCancelOrder(ctx, app.CancelOrder{Meta: app.Meta{ExpectedVersion: 1}, OrderID: "o-1", Reason: "out of stock"})
+	if !errors.Is(err, app.ErrConcurrencyConflict) {
+		t.Fatalf("err = %v, want ErrConcurrencyConflict", err)
+	}
+	if store.appends != 1 {
+		t.Fatalf("appends = %d, want 1", store.appends)
+	}
+}
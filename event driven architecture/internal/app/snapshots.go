@@ -0,0 +1,141 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/venkatesh/order-service/internal/domain"
+)
+
+// Snapshot is a serialized aggregate state at Version. SchemaVersion records the layout State was
+// written with so older snapshots can be upcast after the aggregate changes.
+type Snapshot struct {
+	AggregateID   string
+	Version       int
+	SchemaVersion int
+	State         []byte
+	TakenAt       time.Time
+}
+
+// SnapshotStore keeps the latest snapshot per aggregate.
+type SnapshotStore interface {
+	SaveSnapshot(ctx context.Context, snap Snapshot) error
+	// LoadSnapshot returns false when the aggregate has no snapshot.
+	LoadSnapshot(ctx context.Context, aggregateID string) (Snapshot, bool, error)
+}
+
+// SnapshotUpcaster rewrites state written with schema version N into the layout of version N+1.
+type SnapshotUpcaster func(state []byte) ([]byte, error)
+
+// Option customises an OrderService.
+type Option func(*OrderService)
+
+// WithSnapshots stores a snapshot whenever an order's version crosses a multiple of every (0 disables
+// automatic snapshots; TakeSnapshot still works).
+func WithSnapshots(store SnapshotStore, every int) Option {
+	return func(s *OrderService) {
+		s.snapshots = store
+		s.snapshotEvery = every
+	}
+}
+
+// WithSnapshotUpcaster registers the migration from schema version from to from+1.
+func WithSnapshotUpcaster(from int, upcast SnapshotUpcaster) Option {
+	return func(s *OrderService) {
+		s.upcasters[from] = upcast
+	}
+}
+
+// TakeSnapshot snapshots an order on demand and returns the version captured.
+func (s *OrderService) TakeSnapshot(ctx context.Context, orderID string) (int, error) {
+	if s.snapshots == nil {
+		return 0, fmt.Errorf("snapshots are not configured")
+	}
+	order, err := s.loadOrder(ctx, orderID)
+	if err != nil {
+		return 0, err
+	}
+	if order.Version() == 0 {
+		return 0, fmt.Errorf("%w: %s", ErrOrderNotFound, orderID)
+	}
+	return order.Version(), s.saveSnapshot(ctx, order)
+}
+
+func (s *OrderService) saveSnapshot(ctx context.Context, order *domain.Order) error {
+	state, err := json.Marshal(order.Snapshot())
+	if err != nil {
+		return err
+	}
+	return s.snapshots.SaveSnapshot(ctx, Snapshot{
+		AggregateID:   order.ID(),
+		Version:       order.Version(),
+		SchemaVersion: domain.OrderSnapshotSchema,
+		State:         state,
+		TakenAt:       time.Now().UTC(),
+	})
+}
+
+// maybeSnapshot is called after events moved order from version before to its current version. A failed
+// snapshot only costs replay time later, so it is logged rather than failing the command.
+func (s *OrderService) maybeSnapshot(ctx context.Context, order *domain.Order, before int) {
+	if s.snapshots == nil || s.snapshotEvery <= 0 {
+		return
+	}
+	if order.Version()/s.snapshotEvery == before/s.snapshotEvery {
+		return
+	}
+	if err := s.saveSnapshot(ctx, order); err != nil {
+		log.Printf("snapshot order %s at version %d: %v", order.ID(), order.Version(), err)
+	}
+}
+
+// restoreSnapshot returns the order rebuilt from its latest usable snapshot, or nil when there is none.
+// Snapshots that cannot be upcast or decoded are ignored so the caller falls back to full replay.
+func (s *OrderService) restoreSnapshot(ctx context.Context, orderID string) *domain.Order {
+	if s.snapshots == nil {
+		return nil
+	}
+	snap, ok, err := s.snapshots.LoadSnapshot(ctx, orderID)
+	if err != nil || !ok {
+		if err != nil {
+			log.Printf("load snapshot for order %s: %v", orderID, err)
+		}
+		return nil
+	}
+	state, err := s.upcast(snap)
+	if err != nil {
+		log.Printf("snapshot for order %s ignored: %v", orderID, err)
+		return nil
+	}
+	var data domain.OrderSnapshot
+	if err := json.Unmarshal(state, &data); err != nil {
+		log.Printf("snapshot for order %s ignored: %v", orderID, err)
+		return nil
+	}
+	if data.ID != orderID || data.Version != snap.Version {
+		log.Printf("snapshot for order %s ignored: header does not match state", orderID)
+		return nil
+	}
+	return domain.RestoreOrder(data)
+}
+
+func (s *OrderService) upcast(snap Snapshot) ([]byte, error) {
+	state := snap.State
+	for v := snap.SchemaVersion; v < domain.OrderSnapshotSchema; v++ {
+		upcast, ok := s.upcasters[v]
+		if !ok {
+			return nil, fmt.Errorf("no upcaster from schema %d", v)
+		}
+		var err error
+		if state, err = upcast(state); err != nil {
+			return nil, fmt.Errorf("upcast schema %d: %w", v, err)
+		}
+	}
+	if snap.SchemaVersion > domain.OrderSnapshotSchema {
+		return nil, fmt.Errorf("schema %d is newer than supported %d", snap.SchemaVersion, domain.OrderSnapshotSchema)
+	}
+	return state, nil
+}
@@ -0,0 +1,167 @@
+package app_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/venkatesh/order-service/internal/app"
+	"github.com/venkatesh/order-service/internal/domain"
+	"github.com/venkatesh/order-service/internal/infrastructure"
+)
+
+type snapshottingStore interface {
+	app.EventStore
+	app.SnapshotStore
+}
+
+// tailStore records the version each LoadFrom started after, to show a snapshot was used.
+type tailStore struct {
+	snapshottingStore
+	loadedAfter []int
+}
+
+func (s *tailStore) LoadFrom(ctx context.Context, aggregateID string, afterVersion int) ([]domain.Event, error) {
+	s.loadedAfter = append(s.loadedAfter, afterVersion)
+	return s.snapshottingStore.LoadFrom(ctx, aggregateID, afterVersion)
+}
+
+func snapshotStores(t *testing.T) map[string]func() snapshottingStore {
+	return map[string]func() snapshottingStore{
+		"memory": func() snapshottingStore { return infrastructure.NewInMemoryStore() },
+		"sqlite": func() snapshottingStore {
+			store, err := infrastructure.NewSQLiteStore(context.Background(), filepath.Join(t.TempDir(), "events.db"), infrastructure.DefaultRegistry())
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { store.Close() })
+			return store
+		},
+	}
+}
+
+// lifecycles are the command sequences an order can go through; each step is one command.
+var lifecycles = map[string][]func(context.Context, *app.OrderService) error{
+	"fulfilled": {
+		place,
+		func(ctx context.Context, svc *app.OrderService) error {
+			_, err := svc.HandleAuthorizePayment(ctx, app.AuthorizePayment{OrderID: "o-1", PaymentID: "pay-1", Amount: 1000})
+			return err
+		},
+		func(ctx context.Context, svc *app.OrderService) error {
+			_, err := svc.HandleReserveInventory(ctx, app.ReserveInventory{OrderID: "o-1", ReservationID: "res-1"})
+			return err
+		},
+		func(ctx context.Context, svc *app.OrderService) error {
+			_, err := svc.HandleShipOrder(ctx, app.ShipOrder{OrderID: "o-1", TrackingNumber: "1Z", Carrier: "UPS"})
+			return err
+		},
+	},
+	"cancelled": {
+		place,
+		func(ctx context.Context, svc *app.OrderService) error {
+			_, err := svc.HandleAuthorizePayment(ctx, app.AuthorizePayment{OrderID: "o-1", PaymentID: "pay-1", Amount: 1000})
+			return err
+		},
+		func(ctx context.Context, svc *app.OrderService) error {
+			_, err := svc.HandleCancelOrder(ctx, app.CancelOrder{OrderID: "o-1", Reason: "out of stock"})
+			return err
+		},
+	},
+}
+
+func place(ctx context.Context, svc *app.OrderService) error {
+	_, err := svc.HandlePlaceOrder(ctx, app.PlaceOrder{
+		OrderID:    "o-1",
+		CustomerID: "cust-1",
+		Items:      []domain.LineItem{{SKU: "sku-1", Quantity: 2, UnitPriceCents: 500}},
+	})
+	return err
+}
+
+func TestSnapshotPlusTailEqualsFullReplay(t *testing.T) {
+	ctx := context.Background()
+	for storeName, open := range snapshotStores(t) {
+		for lifecycle, steps := range lifecycles {
+			for _, every := range []int{1, 2, 3} {
+				t.Run(fmt.Sprintf("%s/%s/every %d", storeName, lifecycle, every), func(t *testing.T) {
+					store := &tailStore{snapshottingStore: open()}
+					withSnapshots := app.NewOrderService(store, nopPublisher{}, app.WithSnapshots(store, every))
+					replayOnly := app.NewOrderService(store, nopPublisher{})
+
+					for i, step := range steps {
+						if err := step(ctx, withSnapshots); err != nil {
+							t.Fatalf("step %d: %v", i+1, err)
+						}
+						store.loadedAfter = nil
+						fromSnapshot, err := withSnapshots.LoadOrder(ctx, "o-1")
+						if err != nil {
+							t.Fatal(err)
+						}
+						snapshotAt := store.loadedAfter[0]
+						full, err := replayOnly.LoadOrder(ctx, "o-1")
+						if err != nil {
+							t.Fatal(err)
+						}
+						if !reflect.DeepEqual(fromSnapshot.Snapshot(), full.Snapshot()) {
+							t.Fatalf("after step %d (snapshot at %d):\n snapshot+tail %+v\n full replay   %+v",
+								i+1, snapshotAt, fromSnapshot.Snapshot(), full.Snapshot())
+						}
+						if want := (i + 1) / every * every; snapshotAt != want {
+							t.Fatalf("after step %d replay started after version %d, want the snapshot at %d", i+1, snapshotAt, want)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestUnusableSnapshotFallsBackToFullReplay(t *testing.T) {
+	ctx := context.Background()
+	store := infrastructure.NewInMemoryStore()
+	svc := app.NewOrderService(store, nopPublisher{}, app.WithSnapshots(store, 0))
+	for _, step := range lifecycles["fulfilled"] {
+		if err := step(ctx, svc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := app.NewOrderService(store, nopPublisher{}).LoadOrder(ctx, "o-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshots := map[string]app.Snapshot{
+		"corrupt state":        {AggregateID: "o-1", Version: 2, SchemaVersion: domain.OrderSnapshotSchema, State: []byte("{")},
+		"header/state differ":  {AggregateID: "o-1", Version: 3, SchemaVersion: domain.OrderSnapshotSchema, State: []byte(`{"ID":"o-1","Version":2}`)},
+		"newer schema":         {AggregateID: "o-1", Version: 3, SchemaVersion: domain.OrderSnapshotSchema + 1, State: []byte(`{"ID":"o-1","Version":3}`)},
+		"no upcaster for it":   {AggregateID: "o-1", Version: 3, SchemaVersion: 0, State: []byte(`{"ID":"o-1","Version":3}`)},
+		"state of other order": {AggregateID: "o-1", Version: 3, SchemaVersion: domain.OrderSnapshotSchema, State: []byte(`{"ID":"o-2","Version":3}`)},
+	}
+	for name, snap := range snapshots {
+		t.Run(name, func(t *testing.T) {
+			fresh := infrastructure.NewInMemoryStore()
+			recs, err := store.ReadStream(ctx, "o-1", 0, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, rec := range recs {
+				if err := fresh.Append(ctx, "o-1", rec.Version-1, []domain.Event{rec.Event}, rec.Metadata); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := fresh.SaveSnapshot(ctx, snap); err != nil {
+				t.Fatal(err)
+			}
+			got, err := app.NewOrderService(fresh, nopPublisher{}, app.WithSnapshots(fresh, 0)).LoadOrder(ctx, "o-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.Snapshot(), want.Snapshot()) {
+				t.Fatalf("got %+v, want the full replay %+v", got.Snapshot(), want.Snapshot())
+			}
+		})
+	}
+}
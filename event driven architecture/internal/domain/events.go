@@ -7,8 +7,21 @@ type Event interface {
 	EventName() string
 	AggregateID() string
 	OccurredAt() time.Time
+	// SchemaVersion is the payload layout this type serializes to.
+	SchemaVersion() int
 }
 
+// Current payload schema of each event. Stored events keep the schema they were written with; when a
+// type changes shape, bump its constant and register an upcaster from the previous version so old
+// streams still replay.
+const (
+	OrderPlacedSchema       = 1
+	PaymentAuthorizedSchema = 2 // v2 renamed Amount to AmountCents
+	InventoryReservedSchema = 1
+	OrderShippedSchema      = 1
+	OrderCancelledSchema    = 1
+)
+
 // BaseEvent contains metadata shared by all events.
 type BaseEvent struct {
 	Name      string
@@ -33,19 +46,28 @@ type OrderPlaced struct {
 	TotalCents int64
 }
 
+// SchemaVersion implements Event.
+func (OrderPlaced) SchemaVersion() int { return OrderPlacedSchema }
+
 // PaymentAuthorized indicates the payment service approved the charge.
 type PaymentAuthorized struct {
 	BaseEvent
-	PaymentID string
-	Amount    int64
+	PaymentID   string
+	AmountCents int64
 }
 
+// SchemaVersion implements Event.
+func (PaymentAuthorized) SchemaVersion() int { return PaymentAuthorizedSchema }
+
 // InventoryReserved signals the inventory service locked stock.
 type InventoryReserved struct {
 	BaseEvent
 	ReservationID string
 }
 
+// SchemaVersion implements Event.
+func (InventoryReserved) SchemaVersion() int { return InventoryReservedSchema }
+
 // OrderShipped denotes completion of fulfillment.
 type OrderShipped struct {
 	BaseEvent
@@ -53,8 +75,14 @@ type OrderShipped struct {
 	Carrier        string
 }
 
+// SchemaVersion implements Event.
+func (OrderShipped) SchemaVersion() int { return OrderShippedSchema }
+
 // OrderCancelled captures compensating workflows when something fails.
 type OrderCancelled struct {
 	BaseEvent
 	Reason string
 }
+
+// SchemaVersion implements Event.
+func (OrderCancelled) SchemaVersion() int { return OrderCancelledSchema }
@@ -109,8 +109,8 @@ func (o *Order) HandleAuthorizePayment(paymentID string, amount int64) ([]Event,
 			EntityID:  o.id,
 			Timestamp: time.Now().UTC(),
 		},
-		PaymentID: paymentID,
-		Amount:    amount,
+		PaymentID:   paymentID,
+		AmountCents: amount,
 	}
 	return []Event{evt}, nil
 }
@@ -191,3 +191,47 @@ func (o *Order) Apply(event Event) {
 	}
 	o.version++
 }
+
+// OrderSnapshotSchema is the current layout of OrderSnapshot. Bump it whenever fields change meaning
+// and register an upcaster for the previous schema.
+const OrderSnapshotSchema = 1
+
+// OrderSnapshot is the serializable state of an Order at Version.
+type OrderSnapshot struct {
+	ID                string
+	CustomerID        string
+	Items             []LineItem
+	Status            OrderStatus
+	Version           int
+	TotalCents        int64
+	PaymentAuthorized bool
+	InventoryReserved bool
+}
+
+// Snapshot captures the aggregate's current state.
+func (o *Order) Snapshot() OrderSnapshot {
+	return OrderSnapshot{
+		ID:                o.id,
+		CustomerID:        o.customerID,
+		Items:             append([]LineItem(nil), o.items...),
+		Status:            o.status,
+		Version:           o.version,
+		TotalCents:        o.totalCents,
+		PaymentAuthorized: o.paymentAuthorized,
+		InventoryReserved: o.inventoryReserved,
+	}
+}
+
+// RestoreOrder rebuilds an aggregate from a snapshot; later events are applied on top as usual.
+func RestoreOrder(s OrderSnapshot) *Order {
+	return &Order{
+		id:                s.ID,
+		customerID:        s.CustomerID,
+		items:             append([]LineItem(nil), s.Items...),
+		status:            s.Status,
+		version:           s.Version,
+		totalCents:        s.TotalCents,
+		paymentAuthorized: s.PaymentAuthorized,
+		inventoryReserved: s.InventoryReserved,
+	}
+}
@@ -2,7 +2,13 @@ package eventbus
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/venkatesh/order-service/internal/domain"
 )
@@ -10,61 +16,362 @@ import (
 // Handler consumes a domain event.
 type Handler func(ctx context.Context, event domain.Event) error
 
-// Bus is a lightweight in-memory event dispatcher suited for demos/tests.
-type Bus struct {
-	mu           sync.RWMutex
-	subscribers  map[string][]Handler
-	asyncWorkers int
-}
-
 // WildcardEvent routes every event to the handler.
 const WildcardEvent = "*"
 
-// New creates an event bus with the provided async parallelism.
-func New(asyncWorkers int) *Bus {
+// ErrClosed is returned by Publish after Close.
+var ErrClosed = errors.New("event bus closed")
+
+// RetryPolicy controls how often a failing handler is retried before the event is dead-lettered.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+}
+
+// DefaultRetryPolicy tries five times over roughly one and a half seconds.
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 5, InitialBackoff: 100 * time.Millisecond, MaxBackoff: 2 * time.Second, Multiplier: 2}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		d *= p.Multiplier
+	}
+	return min(time.Duration(d), p.MaxBackoff)
+}
+
+// SubscribeOption customises a subscription.
+type SubscribeOption func(*subscriber)
+
+// WithRetry overrides DefaultRetryPolicy for one subscriber.
+func WithRetry(policy RetryPolicy) SubscribeOption {
+	return func(s *subscriber) { s.policy = policy }
+}
+
+type subscriber struct {
+	name    string
+	event   string
+	handler Handler
+	policy  RetryPolicy
+}
+
+// SubscriberStats reports the outcome of deliveries to one subscriber.
+type SubscriberStats struct {
+	Name         string     `json:"name"`
+	Event        string     `json:"event"`
+	Delivered    int64      `json:"delivered"`
+	Retries      int64      `json:"retries"`
+	DeadLettered int64      `json:"dead_lettered"`
+	LastError    string     `json:"last_error,omitempty"`
+	LastErrorAt  *time.Time `json:"last_error_at,omitempty"`
+}
+
+type delivery struct {
+	event  domain.Event
+	target string // non-empty for dead-letter replays: deliver to this subscriber only
+}
+
+// Bus delivers events asynchronously. Events are sharded by aggregate ID across workers, so events of
+// one aggregate reach each subscriber in publish order while different aggregates proceed in parallel.
+// A handler that keeps failing after its retry policy is exhausted is recorded in the dead-letter store
+// and the shard moves on.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers []*subscriber
+
+	statsMu sync.Mutex
+	stats   map[string]*SubscriberStats
+
+	// closeMu guards closed and sending on shards. It is separate from mu so a publisher blocked on a
+	// full shard never stalls the workers that would drain it.
+	closeMu sync.RWMutex
+	closed  bool
+
+	shards      []chan delivery
+	deadLetters DeadLetterStore
+	ctx         context.Context
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+// New starts an event bus with asyncWorkers shards, each buffering queueSize events.
+func New(asyncWorkers int, deadLetters DeadLetterStore) *Bus {
 	if asyncWorkers <= 0 {
 		asyncWorkers = 1
 	}
-	return &Bus{
-		subscribers:  make(map[string][]Handler),
-		asyncWorkers: asyncWorkers,
+	if deadLetters == nil {
+		deadLetters = NewMemoryDeadLetters()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &Bus{
+		stats:       make(map[string]*SubscriberStats),
+		shards:      make([]chan delivery, asyncWorkers),
+		deadLetters: deadLetters,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+	for i := range b.shards {
+		b.shards[i] = make(chan delivery, queueSize)
+		b.wg.Add(1)
+		go b.work(b.shards[i])
 	}
+	return b
 }
 
-// Subscribe registers a handler for a given event name or WildcardEvent.
-func (b *Bus) Subscribe(eventName string, handler Handler) {
+const queueSize = 256
+
+// Subscribe registers a handler for a given event name or WildcardEvent. name labels the subscriber in
+// stats and dead letters, and replays find the subscriber by it, so it must stay the same across
+// restarts for stored dead letters to remain replayable. Subscribe panics if name is empty or taken.
+func (b *Bus) Subscribe(name, eventName string, handler Handler, opts ...SubscribeOption) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	b.subscribers[eventName] = append(b.subscribers[eventName], handler)
+	if name == "" {
+		panic("eventbus: subscriber name is required")
+	}
+	for _, existing := range b.subscribers {
+		if existing.name == name {
+			panic(fmt.Sprintf("eventbus: subscriber %q is already registered", name))
+		}
+	}
+	s := &subscriber{
+		name:    name,
+		event:   eventName,
+		handler: handler,
+		policy:  DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.policy.MaxAttempts <= 0 {
+		s.policy.MaxAttempts = 1
+	}
+	b.subscribers = append(b.subscribers, s)
+	b.statsMu.Lock()
+	b.stats[s.name] = &SubscriberStats{Name: s.name, Event: eventName}
+	b.statsMu.Unlock()
 }
 
-// Publish fan-outs every event to interested subscribers asynchronously.
+// Publish enqueues events for delivery and returns once they are queued. It blocks only while the
+// target shard is full, until ctx is done.
 func (b *Bus) Publish(ctx context.Context, events []domain.Event) error {
+	for _, evt := range events {
+		if err := b.enqueue(ctx, delivery{event: evt}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Replay re-delivers a dead letter to the subscriber that failed it. The dead letter is removed before
+// the event is queued, so replaying one ID twice, even concurrently, delivers it once and the second
+// call gets ErrDeadLetterNotFound. If the event cannot be queued it is stored again under a new ID. If
+// the handler fails again the event is dead-lettered anew.
+func (b *Bus) Replay(ctx context.Context, id string) error {
+	dl, err := b.deadLetters.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if _, ok := b.subscriberByName(dl.Subscriber); !ok {
+		return fmt.Errorf("subscriber %q no longer exists", dl.Subscriber)
+	}
+	if err := b.deadLetters.Delete(ctx, id); err != nil {
+		return err
+	}
+	if err := b.enqueue(ctx, delivery{event: dl.Event, target: dl.Subscriber}); err != nil {
+		if restoreErr := b.deadLetters.Add(context.Background(), dl); restoreErr != nil {
+			return errors.Join(err, fmt.Errorf("restore dead letter %s: %w", id, restoreErr))
+		}
+		return err
+	}
+	return nil
+}
+
+// Discard drops a dead letter without delivering it.
+func (b *Bus) Discard(ctx context.Context, id string) error {
+	return b.deadLetters.Delete(ctx, id)
+}
+
+// DeadLetters lists events that exhausted their retries.
+func (b *Bus) DeadLetters(ctx context.Context) ([]DeadLetter, error) {
+	return b.deadLetters.List(ctx)
+}
+
+// Stats returns per-subscriber delivery counters.
+func (b *Bus) Stats() []SubscriberStats {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+	out := make([]SubscriberStats, 0, len(b.stats))
+	for _, s := range b.stats {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// abandonGrace bounds how long Close waits, once ctx is done, for handlers to return after their
+// context is cancelled.
+const abandonGrace = time.Second
+
+// Close stops accepting events and waits for queued deliveries until ctx is done. After that the
+// handlers' context is cancelled and pending retries are abandoned, sending their events to the dead
+// letter store; Close waits at most abandonGrace more for the workers and returns ctx's error.
+func (b *Bus) Close(ctx context.Context) error {
+	b.closeMu.Lock()
+	if b.closed {
+		b.closeMu.Unlock()
+		return nil
+	}
+	b.closed = true
+	for _, ch := range b.shards {
+		close(ch)
+	}
+	b.closeMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		b.cancel()
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+		select {
+		case <-done:
+			return ctx.Err()
+		case <-time.After(abandonGrace):
+			return fmt.Errorf("%w; handlers still running after cancellation", ctx.Err())
+		}
+	}
+}
+
+func (b *Bus) enqueue(ctx context.Context, d delivery) error {
+	b.closeMu.RLock()
+	defer b.closeMu.RUnlock()
+	if b.closed {
+		return ErrClosed
+	}
+	select {
+	case b.shards[b.shardFor(d.event.AggregateID())] <- d:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *Bus) shardFor(aggregateID string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(aggregateID))
+	return int(h.Sum32() % uint32(len(b.shards)))
+}
+
+func (b *Bus) work(queue <-chan delivery) {
+	defer b.wg.Done()
+	for d := range queue {
+		for _, s := range b.matching(d) {
+			b.deliver(s, d.event)
+		}
+	}
+}
+
+func (b *Bus) matching(d delivery) []*subscriber {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
+	var out []*subscriber
+	for _, s := range b.subscribers {
+		if d.target != "" {
+			if s.name == d.target {
+				out = append(out, s)
+			}
+			continue
+		}
+		if s.event == d.event.EventName() || s.event == WildcardEvent {
+			out = append(out, s)
+		}
+	}
+	return out
+}
 
-	var wg sync.WaitGroup
-	sem := make(chan struct{}, b.asyncWorkers)
-
-	dispatch := func(handler Handler, evt domain.Event) {
-		defer wg.Done()
-		if err := handler(ctx, evt); err != nil {
-			// Errors are intentionally swallowed for brevity; production code
-			// should capture metrics and send failures to a DLQ.
+func (b *Bus) subscriberByName(name string) (*subscriber, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, s := range b.subscribers {
+		if s.name == name {
+			return s, true
 		}
-		<-sem
 	}
+	return nil, false
+}
 
-	for _, evt := range events {
-		handlers := append([]Handler{}, b.subscribers[evt.EventName()]...)
-		handlers = append(handlers, b.subscribers[WildcardEvent]...)
-		for _, handler := range handlers {
-			wg.Add(1)
-			sem <- struct{}{}
-			go dispatch(handler, evt)
+func (b *Bus) deliver(s *subscriber, evt domain.Event) {
+	var (
+		err      error
+		attempts int
+	)
+	for attempts < s.policy.MaxAttempts {
+		attempts++
+		if err = b.invoke(s, evt); err == nil {
+			b.record(s.name, func(st *SubscriberStats) { st.Delivered++ })
+			return
+		}
+		failedAt := time.Now().UTC()
+		b.record(s.name, func(st *SubscriberStats) {
+			st.LastError = err.Error()
+			st.LastErrorAt = &failedAt
+		})
+		if attempts == s.policy.MaxAttempts {
+			break
+		}
+		b.record(s.name, func(st *SubscriberStats) { st.Retries++ })
+		if !b.sleep(s.policy.backoff(attempts)) {
+			err = fmt.Errorf("abandoned at shutdown: %w", err)
+			break
 		}
 	}
+	log.Printf("eventbus: %s failed %s for %s: %v", s.name, evt.EventName(), evt.AggregateID(), err)
+	b.record(s.name, func(st *SubscriberStats) { st.DeadLettered++ })
+	dl := DeadLetter{
+		Subscriber: s.name,
+		Event:      evt,
+		Attempts:   attempts,
+		LastError:  err.Error(),
+		FailedAt:   time.Now().UTC(),
+	}
+	if err := b.deadLetters.Add(context.Background(), dl); err != nil {
+		log.Printf("eventbus: dead-letter %s for %s lost: %v", evt.EventName(), s.name, err)
+	}
+}
 
-	wg.Wait()
-	return nil
+// sleep waits d, returning false if the bus is shutting down.
+func (b *Bus) sleep(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-b.ctx.Done():
+		return false
+	}
+}
+
+// invoke runs the handler, turning a panic into an error so one bad subscriber cannot kill the shard.
+func (b *Bus) invoke(s *subscriber, evt domain.Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panic: %v", r)
+		}
+	}()
+	return s.handler(b.ctx, evt)
+}
+
+func (b *Bus) record(name string, update func(*SubscriberStats)) {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+	if st, ok := b.stats[name]; ok {
+		update(st)
+	}
 }
@@ -0,0 +1,341 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/venkatesh/order-service/internal/domain"
+)
+
+type testEvent struct {
+	domain.BaseEvent
+	Seq int
+}
+
+func (testEvent) SchemaVersion() int { return 1 }
+
+func event(name, aggregateID string, seq int) testEvent {
+	return testEvent{BaseEvent: domain.BaseEvent{Name: name, EntityID: aggregateID, Timestamp: time.Now()}, Seq: seq}
+}
+
+// recorder collects the sequence numbers each subscriber handled, per aggregate.
+type recorder struct {
+	mu   sync.Mutex
+	seen map[string][]int
+}
+
+func (r *recorder) handle(_ context.Context, evt domain.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.seen == nil {
+		r.seen = make(map[string][]int)
+	}
+	r.seen[evt.AggregateID()] = append(r.seen[evt.AggregateID()], evt.(testEvent).Seq)
+	return nil
+}
+
+func (r *recorder) got(aggregateID string) []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.seen[aggregateID])
+}
+
+func closeBus(t *testing.T, b *Bus) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := b.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func stats(b *Bus, name string) SubscriberStats {
+	for _, s := range b.Stats() {
+		if s.Name == name {
+			return s
+		}
+	}
+	return SubscriberStats{}
+}
+
+func TestPerAggregateOrdering(t *testing.T) {
+	b := New(4, nil)
+	rec := &recorder{}
+	slow := func(ctx context.Context, evt domain.Event) error {
+		// Uneven handler latency would reorder events if one aggregate's events ran concurrently.
+		time.Sleep(time.Duration(rand.Intn(200)) * time.Microsecond)
+		return rec.handle(ctx, evt)
+	}
+	b.Subscribe("recorder", WildcardEvent, slow)
+
+	const aggregates, perAggregate = 8, 40
+	var want []int
+	for seq := 0; seq < perAggregate; seq++ {
+		want = append(want, seq)
+		for a := 0; a < aggregates; a++ {
+			if err := b.Publish(context.Background(), []domain.Event{event("OrderPlaced", fmt.Sprintf("order-%d", a), seq)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	closeBus(t, b)
+
+	for a := 0; a < aggregates; a++ {
+		if got := rec.got(fmt.Sprintf("order-%d", a)); !slices.Equal(got, want) {
+			t.Fatalf("order-%d handled %v, want publish order", a, got)
+		}
+	}
+}
+
+func TestSubscribersReceiveTheirEvents(t *testing.T) {
+	b := New(2, nil)
+	placed, all := &recorder{}, &recorder{}
+	b.Subscribe("placed", "OrderPlaced", placed.handle)
+	b.Subscribe("all", WildcardEvent, all.handle)
+	b.Publish(context.Background(), []domain.Event{event("OrderPlaced", "o1", 1), event("OrderShipped", "o1", 2)})
+	closeBus(t, b)
+
+	if got := placed.got("o1"); !slices.Equal(got, []int{1}) {
+		t.Fatalf("OrderPlaced subscriber got %v", got)
+	}
+	if got := all.got("o1"); !slices.Equal(got, []int{1, 2}) {
+		t.Fatalf("wildcard subscriber got %v", got)
+	}
+}
+
+func TestSubscribeRequiresUniqueName(t *testing.T) {
+	b := New(1, nil)
+	defer closeBus(t, b)
+	b.Subscribe("projections", WildcardEvent, (&recorder{}).handle)
+	for _, name := range []string{"", "projections"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("Subscribe(%q) did not panic", name)
+				}
+			}()
+			b.Subscribe(name, WildcardEvent, (&recorder{}).handle)
+		}()
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	b := New(1, nil)
+	var mu sync.Mutex
+	var calls []time.Time
+	flaky := func(context.Context, domain.Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, time.Now())
+		if len(calls) < 4 {
+			return errors.New("downstream unavailable")
+		}
+		return nil
+	}
+	policy := RetryPolicy{MaxAttempts: 5, InitialBackoff: 20 * time.Millisecond, MaxBackoff: 50 * time.Millisecond, Multiplier: 2}
+	b.Subscribe("flaky", WildcardEvent, flaky, WithRetry(policy))
+	b.Publish(context.Background(), []domain.Event{event("OrderPlaced", "o1", 1)})
+	closeBus(t, b)
+
+	if len(calls) != 4 {
+		t.Fatalf("handler called %d times, want 4", len(calls))
+	}
+	for i, min := range []time.Duration{20 * time.Millisecond, 40 * time.Millisecond, 50 * time.Millisecond} {
+		if gap := calls[i+1].Sub(calls[i]); gap < min || gap > min+200*time.Millisecond {
+			t.Errorf("retry %d after %v, want about %v", i+1, gap, min)
+		}
+	}
+	st := stats(b, "flaky")
+	if st.Delivered != 1 || st.Retries != 3 || st.DeadLettered != 0 || st.LastError == "" {
+		t.Fatalf("stats = %+v", st)
+	}
+	if letters, _ := b.DeadLetters(context.Background()); len(letters) != 0 {
+		t.Fatalf("dead letters = %+v", letters)
+	}
+}
+
+func TestBackoffSchedule(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 3}
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 300 * time.Millisecond, 3: 900 * time.Millisecond, 4: time.Second, 10: time.Second} {
+		if got := p.backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+// failing fails every event of the aggregates listed in broken.
+type failing struct {
+	recorder
+	mu     sync.Mutex
+	broken map[string]bool
+}
+
+func (f *failing) handle(ctx context.Context, evt domain.Event) error {
+	f.mu.Lock()
+	broken := f.broken[evt.AggregateID()]
+	f.mu.Unlock()
+	if broken {
+		return fmt.Errorf("cannot handle %s", evt.AggregateID())
+	}
+	return f.recorder.handle(ctx, evt)
+}
+
+func (f *failing) fix() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.broken = nil
+}
+
+func TestDeadLetterAndReplay(t *testing.T) {
+	store := NewMemoryDeadLetters()
+	b := New(1, store)
+	sub := &failing{broken: map[string]bool{"o1": true}}
+	other := &recorder{}
+	b.Subscribe("flaky", WildcardEvent, sub.handle, WithRetry(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, Multiplier: 1}))
+	b.Subscribe("other", WildcardEvent, other.handle)
+
+	// o1 is dead-lettered and the shard moves on to o2.
+	b.Publish(context.Background(), []domain.Event{event("OrderPlaced", "o1", 1), event("OrderPlaced", "o2", 1)})
+	waitFor(t, func() bool { return len(sub.got("o2")) == 1 })
+	letters, err := b.DeadLetters(context.Background())
+	if err != nil || len(letters) != 1 {
+		t.Fatalf("dead letters = %+v, err %v", letters, err)
+	}
+	dl := letters[0]
+	if dl.Subscriber != "flaky" || dl.Attempts != 2 || dl.Event.AggregateID() != "o1" || dl.LastError == "" {
+		t.Fatalf("dead letter = %+v", dl)
+	}
+	if st := stats(b, "flaky"); st.DeadLettered != 1 || st.Retries != 1 {
+		t.Fatalf("stats = %+v", st)
+	}
+
+	// Replays of one ID, even concurrent ones, deliver it once and only to the subscriber that failed.
+	sub.fix()
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- b.Replay(context.Background(), dl.ID)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	replayed := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			replayed++
+		case !errors.Is(err, ErrDeadLetterNotFound):
+			t.Fatal(err)
+		}
+	}
+	if replayed != 1 {
+		t.Fatalf("%d replays succeeded, want 1", replayed)
+	}
+	closeBus(t, b)
+
+	if got := sub.got("o1"); !slices.Equal(got, []int{1}) {
+		t.Fatalf("replayed subscriber handled o1 %v times", got)
+	}
+	if got := other.got("o1"); !slices.Equal(got, []int{1}) {
+		t.Fatalf("other subscriber handled o1 %v, want only the original delivery", got)
+	}
+	if letters, _ := b.DeadLetters(context.Background()); len(letters) != 0 {
+		t.Fatalf("dead letters after replay = %+v", letters)
+	}
+}
+
+func TestReplayFailingAgainIsDeadLetteredAnew(t *testing.T) {
+	b := New(1, nil)
+	sub := &failing{broken: map[string]bool{"o1": true}}
+	b.Subscribe("flaky", WildcardEvent, sub.handle, WithRetry(RetryPolicy{MaxAttempts: 1}))
+	b.Publish(context.Background(), []domain.Event{event("OrderPlaced", "o1", 1)})
+	waitFor(t, func() bool { letters, _ := b.DeadLetters(context.Background()); return len(letters) == 1 })
+	letters, _ := b.DeadLetters(context.Background())
+
+	if err := b.Replay(context.Background(), letters[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	closeBus(t, b)
+	after, _ := b.DeadLetters(context.Background())
+	if len(after) != 1 || after[0].ID == letters[0].ID {
+		t.Fatalf("dead letters after a failed replay = %+v", after)
+	}
+}
+
+func TestReplayRestoresDeadLetterWhenBusClosed(t *testing.T) {
+	store := NewMemoryDeadLetters()
+	b := New(1, store)
+	b.Subscribe("flaky", WildcardEvent, (&recorder{}).handle)
+	store.Add(context.Background(), DeadLetter{Subscriber: "flaky", Event: event("OrderPlaced", "o1", 1), Attempts: 1, FailedAt: time.Now()})
+	closeBus(t, b)
+
+	if err := b.Replay(context.Background(), "dl-1"); !errors.Is(err, ErrClosed) {
+		t.Fatalf("err = %v, want ErrClosed", err)
+	}
+	letters, _ := store.List(context.Background())
+	if len(letters) != 1 || letters[0].Event.AggregateID() != "o1" {
+		t.Fatalf("dead letter lost: %+v", letters)
+	}
+}
+
+func TestCloseAbandonsRetriesAtDeadline(t *testing.T) {
+	store := NewMemoryDeadLetters()
+	b := New(1, store)
+	b.Subscribe("down", WildcardEvent, func(context.Context, domain.Event) error { return errors.New("down") },
+		WithRetry(RetryPolicy{MaxAttempts: 10, InitialBackoff: time.Hour, MaxBackoff: time.Hour, Multiplier: 1}))
+	b.Publish(context.Background(), []domain.Event{event("OrderPlaced", "o1", 1)})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := b.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want the deadline", err)
+	}
+	letters, _ := store.List(context.Background())
+	if len(letters) != 1 || letters[0].Attempts != 1 {
+		t.Fatalf("abandoned retry not dead-lettered: %+v", letters)
+	}
+}
+
+func TestCloseGivesUpOnStuckHandler(t *testing.T) {
+	b := New(1, nil)
+	release := make(chan struct{})
+	defer close(release)
+	b.Subscribe("stuck", WildcardEvent, func(context.Context, domain.Event) error {
+		<-release // ignores its context
+		return nil
+	})
+	b.Publish(context.Background(), []domain.Event{event("OrderPlaced", "o1", 1)})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := b.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want the deadline", err)
+	}
+	if took := time.Since(start); took > abandonGrace+time.Second {
+		t.Fatalf("Close took %v with a stuck handler", took)
+	}
+	if err := b.Publish(context.Background(), []domain.Event{event("OrderPlaced", "o2", 1)}); !errors.Is(err, ErrClosed) {
+		t.Fatalf("publish after close: %v", err)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,89 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/venkatesh/order-service/internal/domain"
+)
+
+// ErrDeadLetterNotFound is returned for unknown dead-letter IDs.
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// DeadLetter is an event a subscriber could not handle within its retry policy.
+type DeadLetter struct {
+	ID         string       `json:"id"`
+	Subscriber string       `json:"subscriber"`
+	Event      domain.Event `json:"event"`
+	Attempts   int          `json:"attempts"`
+	LastError  string       `json:"last_error"`
+	FailedAt   time.Time    `json:"failed_at"`
+}
+
+// DeadLetterStore keeps failed deliveries until they are replayed or discarded. Add assigns the ID.
+type DeadLetterStore interface {
+	Add(ctx context.Context, dl DeadLetter) error
+	List(ctx context.Context) ([]DeadLetter, error)
+	Get(ctx context.Context, id string) (DeadLetter, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// MemoryDeadLetters is a process-local DeadLetterStore.
+type MemoryDeadLetters struct {
+	mu      sync.RWMutex
+	seq     int
+	letters map[string]DeadLetter
+}
+
+// NewMemoryDeadLetters returns an empty store.
+func NewMemoryDeadLetters() *MemoryDeadLetters {
+	return &MemoryDeadLetters{letters: make(map[string]DeadLetter)}
+}
+
+// Add stores dl under a new ID.
+func (m *MemoryDeadLetters) Add(_ context.Context, dl DeadLetter) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.seq++
+	dl.ID = fmt.Sprintf("dl-%d", m.seq)
+	m.letters[dl.ID] = dl
+	return nil
+}
+
+// List returns dead letters oldest first.
+func (m *MemoryDeadLetters) List(_ context.Context) ([]DeadLetter, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]DeadLetter, 0, len(m.letters))
+	for _, dl := range m.letters {
+		out = append(out, dl)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].FailedAt.Before(out[j].FailedAt) })
+	return out, nil
+}
+
+// Get returns one dead letter.
+func (m *MemoryDeadLetters) Get(_ context.Context, id string) (DeadLetter, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	dl, ok := m.letters[id]
+	if !ok {
+		return DeadLetter{}, ErrDeadLetterNotFound
+	}
+	return dl, nil
+}
+
+// Delete removes a dead letter.
+func (m *MemoryDeadLetters) Delete(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.letters[id]; !ok {
+		return ErrDeadLetterNotFound
+	}
+	delete(m.letters, id)
+	return nil
+}
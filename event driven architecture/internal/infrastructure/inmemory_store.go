@@ -2,44 +2,151 @@ package infrastructure
 
 import (
 	"context"
-	"fmt"
+	"sort"
 	"sync"
+	"time"
 
+	"github.com/venkatesh/order-service/internal/app"
 	"github.com/venkatesh/order-service/internal/domain"
 )
 
 // InMemoryStore is a thread-safe event store useful for tests/demos.
 type InMemoryStore struct {
-	mu     sync.RWMutex
-	events map[string][]domain.Event
+	mu        sync.RWMutex
+	log       []app.RecordedEvent
+	streams   map[string][]int // indexes into log
+	versions  map[string]int
+	snapshots map[string]app.Snapshot
 }
 
 // NewInMemoryStore boots an empty store.
 func NewInMemoryStore() *InMemoryStore {
-	return &InMemoryStore{events: make(map[string][]domain.Event)}
+	return &InMemoryStore{
+		streams:   make(map[string][]int),
+		versions:  make(map[string]int),
+		snapshots: make(map[string]app.Snapshot),
+	}
 }
 
 // Load rehydrates the aggregate's event stream.
-func (s *InMemoryStore) Load(_ context.Context, aggregateID string) ([]domain.Event, error) {
+func (s *InMemoryStore) Load(ctx context.Context, aggregateID string) ([]domain.Event, error) {
+	return s.LoadFrom(ctx, aggregateID, 0)
+}
+
+// LoadFrom returns the stream's events after afterVersion; versions start at 1, so the stream index of
+// version v is v-1.
+func (s *InMemoryStore) LoadFrom(_ context.Context, aggregateID string, afterVersion int) ([]domain.Event, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	stream := s.events[aggregateID]
+	stream := s.streams[aggregateID]
+	if afterVersion >= len(stream) {
+		return nil, nil
+	}
+	stream = stream[max(afterVersion, 0):]
 	out := make([]domain.Event, len(stream))
-	copy(out, stream)
+	for i, idx := range stream {
+		out[i] = s.log[idx].Event
+	}
 	return out, nil
 }
 
-// Append enforces optimistic concurrency before adding events.
-func (s *InMemoryStore) Append(_ context.Context, aggregateID string, expectedVersion int, events []domain.Event) error {
+// Append enforces optimistic concurrency against the stream's recorded version before adding events.
+func (s *InMemoryStore) Append(_ context.Context, aggregateID string, expectedVersion int, events []domain.Event, meta app.Metadata) error {
 	if len(events) == 0 {
 		return nil
 	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	current := len(s.events[aggregateID])
+	current := s.versions[aggregateID]
 	if current != expectedVersion {
-		return fmt.Errorf("concurrency conflict for aggregate %s: expected %d got %d", aggregateID, expectedVersion, current)
+		return &app.ConflictError{AggregateID: aggregateID, Expected: expectedVersion, Actual: current}
+	}
+	now := time.Now().UTC()
+	for _, evt := range events {
+		current++
+		s.log = append(s.log, app.RecordedEvent{
+			Position:   int64(len(s.log) + 1),
+			StreamID:   aggregateID,
+			Version:    current,
+			Event:      evt,
+			Metadata:   meta,
+			RecordedAt: now,
+		})
+		s.streams[aggregateID] = append(s.streams[aggregateID], len(s.log)-1)
+	}
+	s.versions[aggregateID] = current
+	return nil
+}
+
+// FindIdempotencyKey returns the highest version written under key in the stream and its metadata.
+func (s *InMemoryStore) FindIdempotencyKey(_ context.Context, aggregateID, key string) (int, app.Metadata, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stream := s.streams[aggregateID]
+	for i := len(stream) - 1; i >= 0; i-- {
+		if rec := s.log[stream[i]]; rec.Metadata.IdempotencyKey == key {
+			return rec.Version, rec.Metadata, true, nil
+		}
+	}
+	return 0, app.Metadata{}, false, nil
+}
+
+// ReadStream returns up to limit events of one stream after afterVersion; limit <= 0 returns them all.
+func (s *InMemoryStore) ReadStream(_ context.Context, aggregateID string, afterVersion, limit int) ([]app.RecordedEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stream := s.streams[aggregateID]
+	if afterVersion >= len(stream) {
+		return nil, nil
+	}
+	stream = stream[max(afterVersion, 0):]
+	if limit > 0 && limit < len(stream) {
+		stream = stream[:limit]
+	}
+	out := make([]app.RecordedEvent, len(stream))
+	for i, idx := range stream {
+		out[i] = s.log[idx]
+	}
+	return out, nil
+}
+
+// ReadAll returns up to limit events with a position greater than after.
+func (s *InMemoryStore) ReadAll(_ context.Context, after int64, limit int) ([]app.RecordedEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if limit <= 0 {
+		limit = 500
+	}
+	start := sort.Search(len(s.log), func(i int) bool { return s.log[i].Position > after })
+	end := min(start+limit, len(s.log))
+	out := make([]app.RecordedEvent, end-start)
+	copy(out, s.log[start:end])
+	return out, nil
+}
+
+// LastPosition returns the newest global position.
+func (s *InMemoryStore) LastPosition(_ context.Context) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return int64(len(s.log)), nil
+}
+
+// SaveSnapshot keeps snap unless a later snapshot is already stored.
+func (s *InMemoryStore) SaveSnapshot(_ context.Context, snap app.Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cur, ok := s.snapshots[snap.AggregateID]; ok && cur.Version >= snap.Version {
+		return nil
 	}
-	s.events[aggregateID] = append(s.events[aggregateID], events...)
+	snap.State = append([]byte(nil), snap.State...)
+	s.snapshots[snap.AggregateID] = snap
 	return nil
 }
+
+// LoadSnapshot returns the latest snapshot for aggregateID.
+func (s *InMemoryStore) LoadSnapshot(_ context.Context, aggregateID string) (app.Snapshot, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	snap, ok := s.snapshots[aggregateID]
+	return snap, ok, nil
+}
@@ -0,0 +1,142 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/venkatesh/order-service/internal/domain"
+)
+
+// EventUpcaster rewrites a payload written with schema version N into the layout of version N+1.
+type EventUpcaster func(payload []byte) ([]byte, error)
+
+type upcasterKey struct {
+	name string
+	from int
+}
+
+// EventRegistry maps event names to concrete Go types so stored JSON decodes back into the same
+// domain.Event values that were appended. Payloads written with an older schema are upcast step by
+// step to the registered type's current schema before decoding.
+type EventRegistry struct {
+	mu        sync.RWMutex
+	types     map[string]reflect.Type
+	schemas   map[string]int
+	upcasters map[upcasterKey]EventUpcaster
+}
+
+// NewEventRegistry returns an empty registry.
+func NewEventRegistry() *EventRegistry {
+	return &EventRegistry{
+		types:     make(map[string]reflect.Type),
+		schemas:   make(map[string]int),
+		upcasters: make(map[upcasterKey]EventUpcaster),
+	}
+}
+
+// DefaultRegistry knows every event emitted by the Order aggregate and every upcaster for their
+// earlier schemas.
+func DefaultRegistry() *EventRegistry {
+	r := NewEventRegistry()
+	r.Register("OrderPlaced", domain.OrderPlaced{})
+	r.Register("PaymentAuthorized", domain.PaymentAuthorized{})
+	r.Register("InventoryReserved", domain.InventoryReserved{})
+	r.Register("OrderShipped", domain.OrderShipped{})
+	r.Register("OrderCancelled", domain.OrderCancelled{})
+	r.RegisterUpcaster("PaymentAuthorized", 1, upcastPaymentAuthorizedV1)
+	return r
+}
+
+// Register associates name with the value type of prototype; the prototype's SchemaVersion is the
+// schema new events are written with.
+func (r *EventRegistry) Register(name string, prototype domain.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.types[name] = reflect.TypeOf(prototype)
+	r.schemas[name] = prototype.SchemaVersion()
+}
+
+// RegisterUpcaster adds the migration of name's payload from schema from to from+1.
+func (r *EventRegistry) RegisterUpcaster(name string, from int, upcast EventUpcaster) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.upcasters[upcasterKey{name: name, from: from}] = upcast
+}
+
+// Marshal encodes evt, refusing types that could not be decoded again. The payload is written with
+// evt.SchemaVersion(), which callers store alongside it.
+func (r *EventRegistry) Marshal(evt domain.Event) ([]byte, error) {
+	r.mu.RLock()
+	t, ok := r.types[evt.EventName()]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("event type %q is not registered", evt.EventName())
+	}
+	if reflect.TypeOf(evt) != t {
+		return nil, fmt.Errorf("event %q registered as %s, got %T", evt.EventName(), t, evt)
+	}
+	return json.Marshal(evt)
+}
+
+// Unmarshal decodes data, written with schema, into the type registered for name.
+func (r *EventRegistry) Unmarshal(name string, schema int, data []byte) (domain.Event, error) {
+	r.mu.RLock()
+	t, ok := r.types[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("event type %q is not registered", name)
+	}
+	data, err := r.upcast(name, schema, data)
+	if err != nil {
+		return nil, err
+	}
+	ptr := reflect.New(t)
+	if err := json.Unmarshal(data, ptr.Interface()); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", name, err)
+	}
+	evt, ok := ptr.Elem().Interface().(domain.Event)
+	if !ok {
+		return nil, fmt.Errorf("registered type %s does not implement domain.Event", t)
+	}
+	return evt, nil
+}
+
+func (r *EventRegistry) upcast(name string, schema int, data []byte) ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	current := r.schemas[name]
+	if schema > current {
+		return nil, fmt.Errorf("%s schema %d is newer than supported %d", name, schema, current)
+	}
+	for v := max(schema, 1); v < current; v++ {
+		upcast, ok := r.upcasters[upcasterKey{name: name, from: v}]
+		if !ok {
+			return nil, fmt.Errorf("no upcaster for %s from schema %d", name, v)
+		}
+		var err error
+		if data, err = upcast(data); err != nil {
+			return nil, fmt.Errorf("upcast %s schema %d: %w", name, v, err)
+		}
+	}
+	return data, nil
+}
+
+// upcastPaymentAuthorizedV1 renames Amount to AmountCents.
+func upcastPaymentAuthorizedV1(payload []byte) ([]byte, error) {
+	return renameField(payload, "Amount", "AmountCents")
+}
+
+// renameField moves a top-level JSON field, leaving every other field untouched.
+func renameField(payload []byte, from, to string) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return nil, err
+	}
+	if v, ok := fields[from]; ok {
+		fields[to] = v
+		delete(fields, from)
+	}
+	return json.Marshal(fields)
+}
@@ -0,0 +1,52 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/venkatesh/order-service/internal/readmodel"
+)
+
+// SQLiteCheckpoints persists projection state and checkpoints so read models resume after a restart
+// instead of replaying the whole log.
+type SQLiteCheckpoints struct {
+	db *sql.DB
+}
+
+// Checkpoints returns a checkpoint store sharing the event store's database.
+func (s *SQLiteStore) Checkpoints() *SQLiteCheckpoints {
+	return &SQLiteCheckpoints{db: s.db}
+}
+
+// SaveCheckpoint upserts the projection's state and position in one row.
+func (c *SQLiteCheckpoints) SaveCheckpoint(ctx context.Context, cp readmodel.Checkpoint) error {
+	_, err := c.db.ExecContext(ctx,
+		`INSERT INTO projection_checkpoints (projection, position, state, saved_at) VALUES (?, ?, ?, ?)
+		 ON CONFLICT (projection) DO UPDATE SET
+		     position = excluded.position,
+		     state = excluded.state,
+		     saved_at = excluded.saved_at`,
+		cp.Projection, cp.Position, cp.State, cp.SavedAt.UTC().Format(time.RFC3339Nano))
+	return err
+}
+
+// LoadCheckpoint returns the projection's last saved checkpoint.
+func (c *SQLiteCheckpoints) LoadCheckpoint(ctx context.Context, projection string) (readmodel.Checkpoint, bool, error) {
+	cp := readmodel.Checkpoint{Projection: projection}
+	var savedAt string
+	err := c.db.QueryRowContext(ctx,
+		`SELECT position, state, saved_at FROM projection_checkpoints WHERE projection = ?`, projection).
+		Scan(&cp.Position, &cp.State, &savedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return readmodel.Checkpoint{}, false, nil
+	}
+	if err != nil {
+		return readmodel.Checkpoint{}, false, err
+	}
+	if cp.SavedAt, err = time.Parse(time.RFC3339Nano, savedAt); err != nil {
+		return readmodel.Checkpoint{}, false, err
+	}
+	return cp, true, nil
+}
@@ -0,0 +1,97 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/venkatesh/order-service/internal/eventbus"
+)
+
+// SQLiteDeadLetters persists failed deliveries next to the events so they survive restarts.
+type SQLiteDeadLetters struct {
+	db       *sql.DB
+	registry *EventRegistry
+}
+
+// DeadLetters returns a dead-letter store sharing the event store's database.
+func (s *SQLiteStore) DeadLetters() *SQLiteDeadLetters {
+	return &SQLiteDeadLetters{db: s.db, registry: s.registry}
+}
+
+// Add stores dl; the ID is the row id.
+func (d *SQLiteDeadLetters) Add(ctx context.Context, dl eventbus.DeadLetter) error {
+	payload, err := d.registry.Marshal(dl.Event)
+	if err != nil {
+		return err
+	}
+	_, err = d.db.ExecContext(ctx,
+		`INSERT INTO dead_letters (subscriber, event_type, schema_version, payload, attempts, last_error, failed_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		dl.Subscriber, dl.Event.EventName(), dl.Event.SchemaVersion(), string(payload), dl.Attempts, dl.LastError, dl.FailedAt.UTC().Format(time.RFC3339Nano))
+	return err
+}
+
+// List returns dead letters oldest first.
+func (d *SQLiteDeadLetters) List(ctx context.Context) ([]eventbus.DeadLetter, error) {
+	rows, err := d.db.QueryContext(ctx,
+		`SELECT id, subscriber, event_type, schema_version, payload, attempts, last_error, failed_at FROM dead_letters ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]eventbus.DeadLetter, 0)
+	for rows.Next() {
+		dl, err := d.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, dl)
+	}
+	return out, rows.Err()
+}
+
+// Get returns one dead letter.
+func (d *SQLiteDeadLetters) Get(ctx context.Context, id string) (eventbus.DeadLetter, error) {
+	dl, err := d.scan(d.db.QueryRowContext(ctx,
+		`SELECT id, subscriber, event_type, schema_version, payload, attempts, last_error, failed_at FROM dead_letters WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return eventbus.DeadLetter{}, eventbus.ErrDeadLetterNotFound
+	}
+	return dl, err
+}
+
+// Delete removes a dead letter.
+func (d *SQLiteDeadLetters) Delete(ctx context.Context, id string) error {
+	res, err := d.db.ExecContext(ctx, `DELETE FROM dead_letters WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return eventbus.ErrDeadLetterNotFound
+	}
+	return nil
+}
+
+func (d *SQLiteDeadLetters) scan(row interface{ Scan(...any) error }) (eventbus.DeadLetter, error) {
+	var (
+		dl                  eventbus.DeadLetter
+		id                  int64
+		name, payload, when string
+		schema              int
+	)
+	if err := row.Scan(&id, &dl.Subscriber, &name, &schema, &payload, &dl.Attempts, &dl.LastError, &when); err != nil {
+		return eventbus.DeadLetter{}, err
+	}
+	dl.ID = strconv.FormatInt(id, 10)
+	var err error
+	if dl.Event, err = d.registry.Unmarshal(name, schema, []byte(payload)); err != nil {
+		return eventbus.DeadLetter{}, err
+	}
+	if dl.FailedAt, err = time.Parse(time.RFC3339Nano, when); err != nil {
+		return eventbus.DeadLetter{}, err
+	}
+	return dl, nil
+}
@@ -0,0 +1,75 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/venkatesh/order-service/internal/saga"
+)
+
+// SQLiteSagas persists fulfilment saga state so in-flight orders resume after a restart.
+type SQLiteSagas struct {
+	db *sql.DB
+}
+
+// Sagas returns a saga store sharing the event store's database.
+func (s *SQLiteStore) Sagas() *SQLiteSagas {
+	return &SQLiteSagas{db: s.db}
+}
+
+// Save upserts st.
+func (s *SQLiteSagas) Save(ctx context.Context, st saga.State) error {
+	state, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO sagas (order_id, step, state, updated_at) VALUES (?, ?, ?, ?)
+		 ON CONFLICT (order_id) DO UPDATE SET step = excluded.step, state = excluded.state, updated_at = excluded.updated_at`,
+		st.OrderID, string(st.Step), string(state), st.UpdatedAt.UTC().Format(time.RFC3339Nano))
+	return err
+}
+
+// Get returns the saga for orderID.
+func (s *SQLiteSagas) Get(ctx context.Context, orderID string) (saga.State, bool, error) {
+	var state string
+	err := s.db.QueryRowContext(ctx, `SELECT state FROM sagas WHERE order_id = ?`, orderID).Scan(&state)
+	if errors.Is(err, sql.ErrNoRows) {
+		return saga.State{}, false, nil
+	}
+	if err != nil {
+		return saga.State{}, false, err
+	}
+	var st saga.State
+	if err := json.Unmarshal([]byte(state), &st); err != nil {
+		return saga.State{}, false, err
+	}
+	return st, true, nil
+}
+
+// ListActive returns unfinished sagas, least recently updated first.
+func (s *SQLiteSagas) ListActive(ctx context.Context) ([]saga.State, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT state FROM sagas WHERE step NOT IN (?, ?) ORDER BY updated_at`,
+		string(saga.StepCompleted), string(saga.StepCancelled))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []saga.State
+	for rows.Next() {
+		var state string
+		if err := rows.Scan(&state); err != nil {
+			return nil, err
+		}
+		var st saga.State
+		if err := json.Unmarshal([]byte(state), &st); err != nil {
+			return nil, err
+		}
+		out = append(out, st)
+	}
+	return out, rows.Err()
+}
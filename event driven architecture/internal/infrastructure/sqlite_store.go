@@ -0,0 +1,338 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"github.com/venkatesh/order-service/internal/app"
+	"github.com/venkatesh/order-service/internal/domain"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS events (
+	position    INTEGER PRIMARY KEY AUTOINCREMENT,
+	stream_id   TEXT    NOT NULL,
+	version     INTEGER NOT NULL,
+	event_type  TEXT    NOT NULL,
+	schema_version INTEGER NOT NULL DEFAULT 1,
+	payload     TEXT    NOT NULL,
+	occurred_at TEXT    NOT NULL,
+	recorded_at TEXT    NOT NULL,
+	idempotency_key TEXT,
+	metadata    TEXT    NOT NULL DEFAULT '{}',
+	UNIQUE (stream_id, version)
+);
+
+CREATE TABLE IF NOT EXISTS snapshots (
+	aggregate_id   TEXT    PRIMARY KEY,
+	version        INTEGER NOT NULL,
+	schema_version INTEGER NOT NULL,
+	state          BLOB    NOT NULL,
+	taken_at       TEXT    NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS dead_letters (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	subscriber TEXT    NOT NULL,
+	event_type TEXT    NOT NULL,
+	schema_version INTEGER NOT NULL DEFAULT 1,
+	payload    TEXT    NOT NULL,
+	attempts   INTEGER NOT NULL,
+	last_error TEXT    NOT NULL,
+	failed_at  TEXT    NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS sagas (
+	order_id   TEXT PRIMARY KEY,
+	step       TEXT NOT NULL,
+	state      TEXT NOT NULL,
+	updated_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS projection_checkpoints (
+	projection TEXT    PRIMARY KEY,
+	position   INTEGER NOT NULL,
+	state      BLOB    NOT NULL,
+	saved_at   TEXT    NOT NULL
+);`
+
+// sqliteColumns are columns added after the tables first shipped; databases created before them are
+// altered in place on open. Rows written before schema_version existed are schema 1 by definition.
+var sqliteColumns = []struct{ table, column, definition string }{
+	{"events", "idempotency_key", "TEXT"},
+	{"events", "metadata", "TEXT NOT NULL DEFAULT '{}'"},
+	{"events", "schema_version", "INTEGER NOT NULL DEFAULT 1"},
+	{"dead_letters", "schema_version", "INTEGER NOT NULL DEFAULT 1"},
+}
+
+const sqliteIndexes = `
+CREATE INDEX IF NOT EXISTS events_idempotency ON events (stream_id, idempotency_key)
+	WHERE idempotency_key IS NOT NULL;`
+
+// SQLiteStore is a durable event store. Every event gets a global position (the append order across all
+// streams) and a per-stream version; the UNIQUE(stream_id, version) constraint is the final word on
+// optimistic concurrency, so two writers racing on the same expected version cannot both commit.
+type SQLiteStore struct {
+	db       *sql.DB
+	registry *EventRegistry
+}
+
+// NewSQLiteStore opens (creating if needed) the database file at path and applies the schema.
+func NewSQLiteStore(ctx context.Context, path string, registry *EventRegistry) (*SQLiteStore, error) {
+	// IMMEDIATE transactions take the write lock up front, so concurrent appenders queue on busy_timeout
+	// instead of failing when a read lock is upgraded.
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate", path)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open event store: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate event store: %w", err)
+	}
+	for _, c := range sqliteColumns {
+		if err := ensureColumn(ctx, db, c.table, c.column, c.definition); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("migrate event store: %w", err)
+		}
+	}
+	if _, err := db.ExecContext(ctx, sqliteIndexes); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate event store: %w", err)
+	}
+	return &SQLiteStore{db: db, registry: registry}, nil
+}
+
+// Close releases the database handle.
+func (s *SQLiteStore) Close() error { return s.db.Close() }
+
+// Load rehydrates the aggregate's event stream in version order.
+func (s *SQLiteStore) Load(ctx context.Context, aggregateID string) ([]domain.Event, error) {
+	return s.LoadFrom(ctx, aggregateID, 0)
+}
+
+// LoadFrom returns the stream's events after afterVersion, in version order.
+func (s *SQLiteStore) LoadFrom(ctx context.Context, aggregateID string, afterVersion int) ([]domain.Event, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT event_type, schema_version, payload FROM events WHERE stream_id = ? AND version > ? ORDER BY version`,
+		aggregateID, afterVersion)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []domain.Event
+	for rows.Next() {
+		var (
+			name, payload string
+			schema        int
+		)
+		if err := rows.Scan(&name, &schema, &payload); err != nil {
+			return nil, err
+		}
+		evt, err := s.registry.Unmarshal(name, schema, []byte(payload))
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, evt)
+	}
+	return events, rows.Err()
+}
+
+// Append writes events as versions expectedVersion+1.. of the stream in one transaction, tagging each
+// with meta.
+func (s *SQLiteStore) Append(ctx context.Context, aggregateID string, expectedVersion int, events []domain.Event, meta app.Metadata) error {
+	if len(events) == 0 {
+		return nil
+	}
+	metadata, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	var idempotencyKey sql.NullString
+	if meta.IdempotencyKey != "" {
+		idempotencyKey = sql.NullString{String: meta.IdempotencyKey, Valid: true}
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var current int
+	if err := tx.QueryRowContext(ctx,
+		`SELECT COALESCE(MAX(version), 0) FROM events WHERE stream_id = ?`, aggregateID).Scan(&current); err != nil {
+		return err
+	}
+	if current != expectedVersion {
+		return &app.ConflictError{AggregateID: aggregateID, Expected: expectedVersion, Actual: current}
+	}
+
+	recordedAt := time.Now().UTC().Format(time.RFC3339Nano)
+	for i, evt := range events {
+		payload, err := s.registry.Marshal(evt)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO events (stream_id, version, event_type, schema_version, payload, occurred_at, recorded_at, idempotency_key, metadata)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			aggregateID, expectedVersion+i+1, evt.EventName(), evt.SchemaVersion(), string(payload),
+			evt.OccurredAt().UTC().Format(time.RFC3339Nano), recordedAt, idempotencyKey, string(metadata))
+		if isUniqueViolation(err) {
+			return &app.ConflictError{AggregateID: aggregateID, Expected: expectedVersion, Actual: expectedVersion + i + 1}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// FindIdempotencyKey returns the highest version written under key in the stream and its metadata.
+func (s *SQLiteStore) FindIdempotencyKey(ctx context.Context, aggregateID, key string) (int, app.Metadata, bool, error) {
+	var (
+		version  int
+		metadata string
+		meta     app.Metadata
+	)
+	err := s.db.QueryRowContext(ctx,
+		`SELECT version, metadata FROM events WHERE stream_id = ? AND idempotency_key = ? ORDER BY version DESC LIMIT 1`,
+		aggregateID, key).Scan(&version, &metadata)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, app.Metadata{}, false, nil
+	}
+	if err != nil {
+		return 0, app.Metadata{}, false, err
+	}
+	if err := json.Unmarshal([]byte(metadata), &meta); err != nil {
+		return 0, app.Metadata{}, false, fmt.Errorf("stream %s version %d: metadata: %w", aggregateID, version, err)
+	}
+	return version, meta, true, nil
+}
+
+// ReadAll returns up to limit events with a position greater than after, in position order.
+func (s *SQLiteStore) ReadAll(ctx context.Context, after int64, limit int) ([]app.RecordedEvent, error) {
+	if limit <= 0 {
+		limit = 500
+	}
+	return s.readRecorded(ctx, `WHERE position > ? ORDER BY position LIMIT ?`, after, limit)
+}
+
+// ReadStream returns up to limit events of one stream after afterVersion; limit <= 0 returns them all.
+func (s *SQLiteStore) ReadStream(ctx context.Context, aggregateID string, afterVersion, limit int) ([]app.RecordedEvent, error) {
+	if limit <= 0 {
+		limit = -1 // SQLite: no limit
+	}
+	return s.readRecorded(ctx, `WHERE stream_id = ? AND version > ? ORDER BY version LIMIT ?`, aggregateID, afterVersion, limit)
+}
+
+func (s *SQLiteStore) readRecorded(ctx context.Context, where string, args ...any) ([]app.RecordedEvent, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT position, stream_id, version, event_type, schema_version, payload, metadata, recorded_at FROM events `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []app.RecordedEvent
+	for rows.Next() {
+		var (
+			rec                     app.RecordedEvent
+			name, payload, metadata string
+			recordedAtString        string
+			schema                  int
+		)
+		if err := rows.Scan(&rec.Position, &rec.StreamID, &rec.Version, &name, &schema, &payload, &metadata, &recordedAtString); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(metadata), &rec.Metadata); err != nil {
+			return nil, fmt.Errorf("event at position %d: metadata: %w", rec.Position, err)
+		}
+		if rec.Event, err = s.registry.Unmarshal(name, schema, []byte(payload)); err != nil {
+			return nil, fmt.Errorf("event at position %d: %w", rec.Position, err)
+		}
+		if rec.RecordedAt, err = time.Parse(time.RFC3339Nano, recordedAtString); err != nil {
+			return nil, fmt.Errorf("event at position %d: %w", rec.Position, err)
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+// LastPosition returns the newest global position.
+func (s *SQLiteStore) LastPosition(ctx context.Context) (int64, error) {
+	var pos int64
+	err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(position), 0) FROM events`).Scan(&pos)
+	return pos, err
+}
+
+// SaveSnapshot keeps snap unless a snapshot at the same or a later version is already stored.
+func (s *SQLiteStore) SaveSnapshot(ctx context.Context, snap app.Snapshot) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO snapshots (aggregate_id, version, schema_version, state, taken_at) VALUES (?, ?, ?, ?, ?)
+		 ON CONFLICT (aggregate_id) DO UPDATE SET
+		     version = excluded.version,
+		     schema_version = excluded.schema_version,
+		     state = excluded.state,
+		     taken_at = excluded.taken_at
+		 WHERE excluded.version > snapshots.version`,
+		snap.AggregateID, snap.Version, snap.SchemaVersion, snap.State, snap.TakenAt.UTC().Format(time.RFC3339Nano))
+	return err
+}
+
+// LoadSnapshot returns the latest snapshot for aggregateID.
+func (s *SQLiteStore) LoadSnapshot(ctx context.Context, aggregateID string) (app.Snapshot, bool, error) {
+	snap := app.Snapshot{AggregateID: aggregateID}
+	var takenAt string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT version, schema_version, state, taken_at FROM snapshots WHERE aggregate_id = ?`, aggregateID).
+		Scan(&snap.Version, &snap.SchemaVersion, &snap.State, &takenAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return app.Snapshot{}, false, nil
+	}
+	if err != nil {
+		return app.Snapshot{}, false, err
+	}
+	if snap.TakenAt, err = time.Parse(time.RFC3339Nano, takenAt); err != nil {
+		return app.Snapshot{}, false, err
+	}
+	return snap, true, nil
+}
+
+// ensureColumn adds column to table unless it already exists.
+func ensureColumn(ctx context.Context, db *sql.DB, table, column, definition string) error {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, typ        string
+			dflt             sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
+
+func isUniqueViolation(err error) bool {
+	var sqlErr *sqlite.Error
+	return errors.As(err, &sqlErr) && sqlErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
@@ -0,0 +1,285 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/venkatesh/order-service/internal/app"
+	"github.com/venkatesh/order-service/internal/domain"
+)
+
+func openSQLite(t *testing.T, path string) *SQLiteStore {
+	t.Helper()
+	store, err := NewSQLiteStore(context.Background(), path, DefaultRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func newSQLite(t *testing.T) *SQLiteStore {
+	t.Helper()
+	return openSQLite(t, filepath.Join(t.TempDir(), "events.db"))
+}
+
+func base(name, orderID string) domain.BaseEvent {
+	return domain.BaseEvent{Name: name, EntityID: orderID, Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func placed(orderID string) domain.Event {
+	return domain.OrderPlaced{
+		BaseEvent:  base("OrderPlaced", orderID),
+		CustomerID: "cust-1",
+		Items:      []domain.LineItem{{SKU: "sku-1", Quantity: 2, UnitPriceCents: 500}},
+		TotalCents: 1000,
+	}
+}
+
+func paid(orderID string) domain.Event {
+	return domain.PaymentAuthorized{BaseEvent: base("PaymentAuthorized", orderID), PaymentID: "pay-1", AmountCents: 1000}
+}
+
+func reserved(orderID string) domain.Event {
+	return domain.InventoryReserved{BaseEvent: base("InventoryReserved", orderID), ReservationID: "res-1"}
+}
+
+func versions(recs []app.RecordedEvent) []int {
+	out := make([]int, len(recs))
+	for i, rec := range recs {
+		out[i] = rec.Version
+	}
+	return out
+}
+
+func TestSQLiteAppendAndLoad(t *testing.T) {
+	ctx := context.Background()
+	store := newSQLite(t)
+	meta := app.Metadata{IdempotencyKey: "k-1", PayloadHash: "h-1", CorrelationID: "corr", CausationID: "req-1", UserID: "u-1"}
+
+	if err := store.Append(ctx, "o-1", 0, []domain.Event{placed("o-1"), paid("o-1")}, meta); err != nil {
+		t.Fatal(err)
+	}
+	events, err := store.Load(ctx, "o-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("loaded %d events, want 2", len(events))
+	}
+	if got, ok := events[0].(domain.OrderPlaced); !ok || got.TotalCents != 1000 || len(got.Items) != 1 {
+		t.Fatalf("event 1 = %#v", events[0])
+	}
+	if got, ok := events[1].(domain.PaymentAuthorized); !ok || got.AmountCents != 1000 || !got.OccurredAt().Equal(base("", "").Timestamp) {
+		t.Fatalf("event 2 = %#v", events[1])
+	}
+
+	recs, err := store.ReadStream(ctx, "o-1", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, rec := range recs {
+		if rec.Version != i+1 || rec.StreamID != "o-1" || rec.Metadata != meta || rec.RecordedAt.IsZero() {
+			t.Fatalf("record %d = %+v", i, rec)
+		}
+	}
+
+	version, found, ok, err := store.FindIdempotencyKey(ctx, "o-1", "k-1")
+	if err != nil || !ok || version != 2 || found != meta {
+		t.Fatalf("FindIdempotencyKey = %d %+v %v %v, want 2 %+v", version, found, ok, err, meta)
+	}
+	if _, _, ok, err := store.FindIdempotencyKey(ctx, "o-1", "other"); err != nil || ok {
+		t.Fatalf("unknown key found: %v %v", ok, err)
+	}
+	if err := store.Append(ctx, "o-1", 2, nil, app.Metadata{}); err != nil {
+		t.Fatalf("empty append: %v", err)
+	}
+}
+
+func TestSQLiteAppendRejectsWrongExpectedVersion(t *testing.T) {
+	ctx := context.Background()
+	store := newSQLite(t)
+	if err := store.Append(ctx, "o-1", 0, []domain.Event{placed("o-1")}, app.Metadata{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []int{0, 2} {
+		err := store.Append(ctx, "o-1", expected, []domain.Event{paid("o-1"), reserved("o-1")}, app.Metadata{})
+		var conflict *app.ConflictError
+		if !errors.As(err, &conflict) || !errors.Is(err, app.ErrConcurrencyConflict) {
+			t.Fatalf("expected %d: err = %v, want a ConflictError", expected, err)
+		}
+		if conflict.Expected != expected || conflict.Actual != 1 {
+			t.Fatalf("conflict = %+v, want expected %d actual 1", conflict, expected)
+		}
+	}
+	recs, err := store.ReadStream(ctx, "o-1", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 1 {
+		t.Fatalf("stream has %d events after rejected appends, want 1", len(recs))
+	}
+}
+
+func TestSQLiteConcurrentAppendsAtSameVersion(t *testing.T) {
+	ctx := context.Background()
+	store := newSQLite(t)
+	if err := store.Append(ctx, "o-1", 0, []domain.Event{placed("o-1")}, app.Metadata{}); err != nil {
+		t.Fatal(err)
+	}
+
+	const writers = 8
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		wins      int
+		conflicts int
+	)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := store.Append(ctx, "o-1", 1, []domain.Event{paid("o-1")}, app.Metadata{})
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				wins++
+			case errors.Is(err, app.ErrConcurrencyConflict):
+				conflicts++
+			default:
+				t.Errorf("append: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if wins != 1 || conflicts != writers-1 {
+		t.Fatalf("%d appends won and %d conflicted, want 1 and %d", wins, conflicts, writers-1)
+	}
+	recs, err := store.ReadStream(ctx, "o-1", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := versions(recs); len(got) != 2 || got[1] != 2 {
+		t.Fatalf("stream versions = %v, want [1 2]", got)
+	}
+}
+
+func TestSQLiteStreamReads(t *testing.T) {
+	ctx := context.Background()
+	store := newSQLite(t)
+	// Interleave two streams so stream versions and global positions differ.
+	appends := []struct {
+		stream   string
+		expected int
+		event    domain.Event
+	}{
+		{"a", 0, placed("a")},
+		{"b", 0, placed("b")},
+		{"a", 1, paid("a")},
+		{"b", 1, paid("b")},
+		{"a", 2, reserved("a")},
+	}
+	for _, a := range appends {
+		if err := store.Append(ctx, a.stream, a.expected, []domain.Event{a.event}, app.Metadata{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	last, err := store.LastPosition(ctx)
+	if err != nil || last != 5 {
+		t.Fatalf("LastPosition = %d %v, want 5", last, err)
+	}
+
+	all, err := store.ReadAll(ctx, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, rec := range all {
+		if rec.Position != int64(i+1) || rec.StreamID != appends[i].stream || rec.Version != appends[i].expected+1 {
+			t.Fatalf("ReadAll[%d] = position %d %s@%d", i, rec.Position, rec.StreamID, rec.Version)
+		}
+	}
+	page, err := store.ReadAll(ctx, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 2 || page[0].Position != 3 || page[1].Position != 4 {
+		t.Fatalf("ReadAll(after 2, limit 2) returned positions %v", page)
+	}
+
+	tests := []struct {
+		name         string
+		stream       string
+		after, limit int
+		want         []int
+	}{
+		{"whole stream", "a", 0, 0, []int{1, 2, 3}},
+		{"after a version", "a", 1, 0, []int{2, 3}},
+		{"limited", "a", 0, 2, []int{1, 2}},
+		{"after and limited", "a", 1, 1, []int{2}},
+		{"past the end", "a", 3, 0, []int{}},
+		{"other stream", "b", 0, 0, []int{1, 2}},
+		{"unknown stream", "zzz", 0, 0, []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recs, err := store.ReadStream(ctx, tt.stream, tt.after, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := versions(recs)
+			if len(got) != len(tt.want) {
+				t.Fatalf("versions = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] || recs[i].StreamID != tt.stream {
+					t.Fatalf("versions = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	tail, err := store.LoadFrom(ctx, "a", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tail) != 1 || tail[0].EventName() != "InventoryReserved" {
+		t.Fatalf("LoadFrom(a, 2) = %v", tail)
+	}
+}
+
+func TestSQLiteStoreSurvivesReopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "events.db")
+	store, err := NewSQLiteStore(ctx, path, DefaultRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Append(ctx, "o-1", 0, []domain.Event{placed("o-1"), paid("o-1")}, app.Metadata{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened := openSQLite(t, path)
+	err = reopened.Append(ctx, "o-1", 1, []domain.Event{reserved("o-1")}, app.Metadata{})
+	if !errors.Is(err, app.ErrConcurrencyConflict) {
+		t.Fatalf("append at a stale version after reopen: %v", err)
+	}
+	if err := reopened.Append(ctx, "o-1", 2, []domain.Event{reserved("o-1")}, app.Metadata{}); err != nil {
+		t.Fatal(err)
+	}
+	events, err := reopened.Load(ctx, "o-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("loaded %d events after reopen, want 3", len(events))
+	}
+}
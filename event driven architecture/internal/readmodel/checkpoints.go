@@ -0,0 +1,51 @@
+package readmodel
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Checkpoint is a projection's serialized state together with the log position it reflects. Both are
+// saved as one record, so a restarted projection resumes exactly where its state left off.
+type Checkpoint struct {
+	Projection string
+	Position   int64
+	State      []byte
+	SavedAt    time.Time
+}
+
+// CheckpointStore persists the latest checkpoint of each projection.
+type CheckpointStore interface {
+	SaveCheckpoint(ctx context.Context, cp Checkpoint) error
+	// LoadCheckpoint returns false when the projection has never been saved.
+	LoadCheckpoint(ctx context.Context, projection string) (Checkpoint, bool, error)
+}
+
+// MemoryCheckpoints is a process-local CheckpointStore.
+type MemoryCheckpoints struct {
+	mu          sync.RWMutex
+	checkpoints map[string]Checkpoint
+}
+
+// NewMemoryCheckpoints returns an empty store.
+func NewMemoryCheckpoints() *MemoryCheckpoints {
+	return &MemoryCheckpoints{checkpoints: make(map[string]Checkpoint)}
+}
+
+// SaveCheckpoint replaces the projection's checkpoint.
+func (m *MemoryCheckpoints) SaveCheckpoint(_ context.Context, cp Checkpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cp.State = append([]byte(nil), cp.State...)
+	m.checkpoints[cp.Projection] = cp
+	return nil
+}
+
+// LoadCheckpoint returns the projection's checkpoint.
+func (m *MemoryCheckpoints) LoadCheckpoint(_ context.Context, projection string) (Checkpoint, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	cp, ok := m.checkpoints[projection]
+	return cp, ok, nil
+}
@@ -0,0 +1,146 @@
+package readmodel
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"github.com/venkatesh/order-service/internal/app"
+	"github.com/venkatesh/order-service/internal/domain"
+)
+
+// CustomerTotals summarises a customer's orders. Cancelled orders are counted but not billed.
+type CustomerTotals struct {
+	CustomerID     string
+	OrderCount     int
+	CancelledCount int
+	TotalCents     int64
+}
+
+type orderRef struct {
+	CustomerID string
+	TotalCents int64
+	Cancelled  bool
+}
+
+// customerTotalsState is the serialized form of the projection.
+type customerTotalsState struct {
+	Customers map[string]CustomerTotals
+	Orders    map[string]orderRef
+}
+
+// CustomerTotalsProjection keeps per-customer order counts and spend.
+type CustomerTotalsProjection struct {
+	mu         sync.RWMutex
+	customers  map[string]CustomerTotals
+	orders     map[string]orderRef
+	checkpoint int64
+}
+
+// NewCustomerTotalsProjection returns an empty projection.
+func NewCustomerTotalsProjection() *CustomerTotalsProjection {
+	return &CustomerTotalsProjection{customers: make(map[string]CustomerTotals), orders: make(map[string]orderRef)}
+}
+
+// Name identifies the projection for the runner and admin API.
+func (p *CustomerTotalsProjection) Name() string { return "customer_totals" }
+
+// Checkpoint returns the position of the last applied event.
+func (p *CustomerTotalsProjection) Checkpoint() int64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.checkpoint
+}
+
+// Reset clears every total.
+func (p *CustomerTotalsProjection) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.customers = make(map[string]CustomerTotals)
+	p.orders = make(map[string]orderRef)
+	p.checkpoint = 0
+}
+
+// State serializes the totals, and the orders they were computed from, with their checkpoint.
+func (p *CustomerTotalsProjection) State() (int64, []byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	state, err := json.Marshal(customerTotalsState{Customers: p.customers, Orders: p.orders})
+	return p.checkpoint, state, err
+}
+
+// Restore replaces all totals with a state produced by State.
+func (p *CustomerTotalsProjection) Restore(checkpoint int64, state []byte) error {
+	var st customerTotalsState
+	if err := json.Unmarshal(state, &st); err != nil {
+		return err
+	}
+	if st.Customers == nil {
+		st.Customers = make(map[string]CustomerTotals)
+	}
+	if st.Orders == nil {
+		st.Orders = make(map[string]orderRef)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.customers = st.Customers
+	p.orders = st.Orders
+	p.checkpoint = checkpoint
+	return nil
+}
+
+// Apply folds order placement and cancellation into the customer's totals.
+func (p *CustomerTotalsProjection) Apply(_ context.Context, rec app.RecordedEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if rec.Position <= p.checkpoint {
+		return nil
+	}
+	switch e := rec.Event.(type) {
+	case domain.OrderPlaced:
+		p.orders[e.AggregateID()] = orderRef{CustomerID: e.CustomerID, TotalCents: e.TotalCents}
+		t := p.customers[e.CustomerID]
+		t.CustomerID = e.CustomerID
+		t.OrderCount++
+		t.TotalCents += e.TotalCents
+		p.customers[e.CustomerID] = t
+	case domain.OrderCancelled:
+		ref, ok := p.orders[e.AggregateID()]
+		if ok && !ref.Cancelled {
+			ref.Cancelled = true
+			p.orders[e.AggregateID()] = ref
+			t := p.customers[ref.CustomerID]
+			t.CancelledCount++
+			t.TotalCents -= ref.TotalCents
+			p.customers[ref.CustomerID] = t
+		}
+	}
+	p.checkpoint = rec.Position
+	return nil
+}
+
+// List returns every customer, highest spend first.
+func (p *CustomerTotalsProjection) List() []CustomerTotals {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	out := make([]CustomerTotals, 0, len(p.customers))
+	for _, t := range p.customers {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].TotalCents != out[j].TotalCents {
+			return out[i].TotalCents > out[j].TotalCents
+		}
+		return out[i].CustomerID < out[j].CustomerID
+	})
+	return out
+}
+
+// Get returns one customer's totals.
+func (p *CustomerTotalsProjection) Get(customerID string) (CustomerTotals, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	t, ok := p.customers[customerID]
+	return t, ok
+}
@@ -2,9 +2,11 @@ package readmodel
 
 import (
 	"context"
+	"encoding/json"
 	"sort"
 	"sync"
 
+	"github.com/venkatesh/order-service/internal/app"
 	"github.com/venkatesh/order-service/internal/domain"
 )
 
@@ -14,13 +16,15 @@ type OrderView struct {
 	CustomerID  string
 	Status      domain.OrderStatus
 	TotalCents  int64
+	Version     int
 	LastUpdated int64
 }
 
-// OrdersProjection subscribes to all order events and maintains a cache.
+// OrdersProjection maintains one OrderView per order.
 type OrdersProjection struct {
-	mu      sync.RWMutex
-	records map[string]OrderView
+	mu         sync.RWMutex
+	records    map[string]OrderView
+	checkpoint int64
 }
 
 // NewOrdersProjection initializes the projector map.
@@ -28,14 +32,60 @@ func NewOrdersProjection() *OrdersProjection {
 	return &OrdersProjection{records: make(map[string]OrderView)}
 }
 
-// Handle processes each domain event.
-func (p *OrdersProjection) Handle(ctx context.Context, event domain.Event) error {
-	_ = ctx
+// Name identifies the projection for the runner and admin API.
+func (p *OrdersProjection) Name() string { return "orders" }
+
+// Checkpoint returns the position of the last applied event.
+func (p *OrdersProjection) Checkpoint() int64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.checkpoint
+}
+
+// Reset clears every view.
+func (p *OrdersProjection) Reset() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	p.records = make(map[string]OrderView)
+	p.checkpoint = 0
+}
+
+// State serializes every view with the checkpoint they reflect.
+func (p *OrdersProjection) State() (int64, []byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	state, err := json.Marshal(p.records)
+	return p.checkpoint, state, err
+}
+
+// Restore replaces every view with a state produced by State.
+func (p *OrdersProjection) Restore(checkpoint int64, state []byte) error {
+	var records map[string]OrderView
+	if err := json.Unmarshal(state, &records); err != nil {
+		return err
+	}
+	if records == nil {
+		records = make(map[string]OrderView)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.records = records
+	p.checkpoint = checkpoint
+	return nil
+}
+
+// Apply processes each domain event.
+func (p *OrdersProjection) Apply(_ context.Context, rec app.RecordedEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if rec.Position <= p.checkpoint {
+		return nil
+	}
+	event := rec.Event
 
 	view := p.records[event.AggregateID()]
 	view.OrderID = event.AggregateID()
+	view.Version = rec.Version
 	view.LastUpdated = event.OccurredAt().Unix()
 
 	switch e := event.(type) {
@@ -54,6 +104,7 @@ func (p *OrdersProjection) Handle(ctx context.Context, event domain.Event) error
 	}
 
 	p.records[view.OrderID] = view
+	p.checkpoint = rec.Position
 	return nil
 }
 
@@ -67,7 +118,10 @@ func (p *OrdersProjection) List() []OrderView {
 		out = append(out, v)
 	}
 	sort.Slice(out, func(i, j int) bool {
-		return out[i].LastUpdated > out[j].LastUpdated
+		if out[i].LastUpdated != out[j].LastUpdated {
+			return out[i].LastUpdated > out[j].LastUpdated
+		}
+		return out[i].OrderID < out[j].OrderID
 	})
 	return out
 }
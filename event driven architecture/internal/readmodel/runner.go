@@ -0,0 +1,250 @@
+package readmodel
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/venkatesh/order-service/internal/app"
+	"github.com/venkatesh/order-service/internal/domain"
+)
+
+// Projection folds the global event log into a query model. Apply and Checkpoint must be consistent:
+// the checkpoint is the position of the last event whose effect is visible, stored together with the
+// state so a crash can never leave one ahead of the other.
+type Projection interface {
+	Name() string
+	Checkpoint() int64
+	Apply(ctx context.Context, rec app.RecordedEvent) error
+	// Reset discards all state and rewinds the checkpoint to 0.
+	Reset()
+	// State serializes the state together with the checkpoint it reflects.
+	State() (checkpoint int64, state []byte, err error)
+	// Restore replaces all state with one produced by State.
+	Restore(checkpoint int64, state []byte) error
+}
+
+// ProjectionStatus reports how far a projection trails the event store.
+type ProjectionStatus struct {
+	Name       string  `json:"name"`
+	Checkpoint int64   `json:"checkpoint"`
+	Head       int64   `json:"head"`
+	LagEvents  int64   `json:"lag_events"`
+	LagSeconds float64 `json:"lag_seconds"`
+	LastError  string  `json:"last_error,omitempty"`
+}
+
+// Runner feeds projections from the event store, each from its own checkpoint.
+type Runner struct {
+	reader      app.EventStreamReader
+	checkpoints CheckpointStore
+	batchSize   int
+	projections []Projection
+
+	mu     sync.Mutex // serialises catch-up, rebuilds and checkpoint saves
+	errors map[string]string
+	saved  map[string]int64 // position of the last checkpoint stored per projection
+}
+
+// NewRunner builds a runner over reader; batchSize bounds each ReadAll call. checkpoints may be nil,
+// in which case projections live only in memory and replay the log from the start on every restart.
+func NewRunner(reader app.EventStreamReader, checkpoints CheckpointStore, batchSize int, projections ...Projection) *Runner {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	return &Runner{
+		reader:      reader,
+		checkpoints: checkpoints,
+		batchSize:   batchSize,
+		projections: projections,
+		errors:      make(map[string]string),
+		saved:       make(map[string]int64),
+	}
+}
+
+// Run resumes from the stored checkpoints and catches up immediately, then again every interval until
+// ctx is cancelled, saving checkpoints after each pass. Polling picks up events appended by other
+// processes; in-process commands also trigger Handle.
+func (r *Runner) Run(ctx context.Context, interval time.Duration) {
+	if err := r.Resume(ctx); err != nil && ctx.Err() == nil {
+		log.Printf("projections: %v", err)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := r.CatchUp(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("projections: %v", err)
+		}
+		if err := r.SaveCheckpoints(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("projections: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Handle lets the runner subscribe to the bus: a published event means the log moved, so catch up
+// before returning to keep read-your-writes for HTTP clients.
+func (r *Runner) Handle(ctx context.Context, _ domain.Event) error {
+	return r.CatchUp(ctx)
+}
+
+// CatchUp applies every event after each projection's checkpoint. A failing projection stops at the
+// offending event and is retried on the next call; the others keep going.
+func (r *Runner) CatchUp(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var firstErr error
+	for _, p := range r.projections {
+		if err := r.catchUp(ctx, p); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Rebuild resets the named projection, replays the whole log into it and saves the result. Until then
+// the stored checkpoint still holds the previous state, so a crash mid-rebuild resumes from that.
+func (r *Runner) Rebuild(ctx context.Context, name string) error {
+	p, ok := r.lookup(name)
+	if !ok {
+		return fmt.Errorf("unknown projection %q", name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p.Reset()
+	if err := r.catchUp(ctx, p); err != nil {
+		return err
+	}
+	return r.save(ctx, p)
+}
+
+// Resume restores every projection from its stored checkpoint, so catching up continues from there
+// instead of replaying the whole log. A checkpoint that cannot be restored is discarded and that
+// projection replays from the start.
+func (r *Runner) Resume(ctx context.Context) error {
+	if r.checkpoints == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.projections {
+		cp, ok, err := r.checkpoints.LoadCheckpoint(ctx, p.Name())
+		if err != nil {
+			return fmt.Errorf("projection %s: load checkpoint: %w", p.Name(), err)
+		}
+		if !ok {
+			continue
+		}
+		if err := p.Restore(cp.Position, cp.State); err != nil {
+			log.Printf("projection %s: checkpoint at position %d discarded, replaying the log: %v", p.Name(), cp.Position, err)
+			p.Reset()
+			continue
+		}
+		r.saved[p.Name()] = cp.Position
+	}
+	return nil
+}
+
+// SaveCheckpoints stores every projection whose checkpoint moved since it was last saved. Saving
+// serializes the whole read model, so it runs on the poll interval and at shutdown rather than after
+// every event; a crash in between only means replaying the events since the last save.
+func (r *Runner) SaveCheckpoints(ctx context.Context) error {
+	if r.checkpoints == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var firstErr error
+	for _, p := range r.projections {
+		if saved, ok := r.saved[p.Name()]; ok && saved == p.Checkpoint() {
+			continue
+		}
+		if err := r.save(ctx, p); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (r *Runner) save(ctx context.Context, p Projection) error {
+	if r.checkpoints == nil {
+		return nil
+	}
+	position, state, err := p.State()
+	if err != nil {
+		return fmt.Errorf("projection %s: serialize state: %w", p.Name(), err)
+	}
+	cp := Checkpoint{Projection: p.Name(), Position: position, State: state, SavedAt: time.Now().UTC()}
+	if err := r.checkpoints.SaveCheckpoint(ctx, cp); err != nil {
+		return fmt.Errorf("projection %s: save checkpoint: %w", p.Name(), err)
+	}
+	r.saved[p.Name()] = position
+	return nil
+}
+
+// Status reports checkpoint and lag for every projection.
+func (r *Runner) Status(ctx context.Context) ([]ProjectionStatus, error) {
+	head, err := r.reader.LastPosition(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	errs := make(map[string]string, len(r.errors))
+	for k, v := range r.errors {
+		errs[k] = v
+	}
+	r.mu.Unlock()
+
+	out := make([]ProjectionStatus, 0, len(r.projections))
+	for _, p := range r.projections {
+		st := ProjectionStatus{Name: p.Name(), Checkpoint: p.Checkpoint(), Head: head, LastError: errs[p.Name()]}
+		st.LagEvents = max(head-st.Checkpoint, 0)
+		if st.LagEvents > 0 {
+			// Lag in time is the age of the oldest event the projection has not applied yet.
+			next, err := r.reader.ReadAll(ctx, st.Checkpoint, 1)
+			if err != nil {
+				return nil, err
+			}
+			if len(next) > 0 {
+				st.LagSeconds = time.Since(next[0].RecordedAt).Seconds()
+			}
+		}
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+func (r *Runner) lookup(name string) (Projection, bool) {
+	for _, p := range r.projections {
+		if p.Name() == name {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+func (r *Runner) catchUp(ctx context.Context, p Projection) error {
+	for {
+		batch, err := r.reader.ReadAll(ctx, p.Checkpoint(), r.batchSize)
+		if err != nil {
+			return fmt.Errorf("projection %s: read: %w", p.Name(), err)
+		}
+		for _, rec := range batch {
+			if err := p.Apply(ctx, rec); err != nil {
+				err = fmt.Errorf("projection %s: position %d: %w", p.Name(), rec.Position, err)
+				r.errors[p.Name()] = err.Error()
+				return err
+			}
+		}
+		delete(r.errors, p.Name())
+		if len(batch) < r.batchSize {
+			return nil
+		}
+	}
+}
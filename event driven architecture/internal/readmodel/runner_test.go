@@ -0,0 +1,232 @@
+package readmodel_test
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/venkatesh/order-service/internal/app"
+	"github.com/venkatesh/order-service/internal/domain"
+	"github.com/venkatesh/order-service/internal/infrastructure"
+	"github.com/venkatesh/order-service/internal/readmodel"
+)
+
+// readRecorder remembers the position every ReadAll started after.
+type readRecorder struct {
+	app.EventStreamReader
+	after []int64
+}
+
+func (r *readRecorder) ReadAll(ctx context.Context, after int64, limit int) ([]app.RecordedEvent, error) {
+	r.after = append(r.after, after)
+	return r.EventStreamReader.ReadAll(ctx, after, limit)
+}
+
+func openStore(t *testing.T, path string) *infrastructure.SQLiteStore {
+	t.Helper()
+	store, err := infrastructure.NewSQLiteStore(context.Background(), path, infrastructure.DefaultRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// appendOrder records a placed order for customer and, when cancelled, its cancellation.
+func appendOrder(t *testing.T, store app.EventStore, orderID, customer string, totalCents int64, cancelled bool) {
+	t.Helper()
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	events := []domain.Event{domain.OrderPlaced{
+		BaseEvent:  domain.BaseEvent{Name: "OrderPlaced", EntityID: orderID, Timestamp: at},
+		CustomerID: customer,
+		Items:      []domain.LineItem{{SKU: "sku-1", Quantity: 1, UnitPriceCents: totalCents}},
+		TotalCents: totalCents,
+	}}
+	if cancelled {
+		events = append(events, domain.OrderCancelled{
+			BaseEvent: domain.BaseEvent{Name: "OrderCancelled", EntityID: orderID, Timestamp: at.Add(time.Minute)},
+			Reason:    "changed mind",
+		})
+	}
+	if err := store.Append(context.Background(), orderID, 0, events, app.Metadata{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type readModels struct {
+	orders    *readmodel.OrdersProjection
+	customers *readmodel.CustomerTotalsProjection
+	reader    *readRecorder
+	runner    *readmodel.Runner
+}
+
+func newReadModels(store *infrastructure.SQLiteStore) *readModels {
+	m := &readModels{
+		orders:    readmodel.NewOrdersProjection(),
+		customers: readmodel.NewCustomerTotalsProjection(),
+		reader:    &readRecorder{EventStreamReader: store},
+	}
+	// A batch size of 2 makes catch-up page through the log.
+	m.runner = readmodel.NewRunner(m.reader, store.Checkpoints(), 2, m.orders, m.customers)
+	return m
+}
+
+func (m *readModels) catchUp(t *testing.T) {
+	t.Helper()
+	if err := m.runner.CatchUp(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func sameViews(t *testing.T, got, want *readModels) {
+	t.Helper()
+	if !reflect.DeepEqual(got.orders.List(), want.orders.List()) {
+		t.Fatalf("orders:\n got  %+v\n want %+v", got.orders.List(), want.orders.List())
+	}
+	if !reflect.DeepEqual(got.customers.List(), want.customers.List()) {
+		t.Fatalf("customer totals:\n got  %+v\n want %+v", got.customers.List(), want.customers.List())
+	}
+}
+
+func TestRunnerResumesFromSavedCheckpoints(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "events.db")
+	store := openStore(t, path)
+	appendOrder(t, store, "o-1", "alice", 1000, false)
+	appendOrder(t, store, "o-2", "alice", 500, true)
+	appendOrder(t, store, "o-3", "bob", 700, false)
+
+	before := newReadModels(store)
+	before.catchUp(t)
+	if err := before.runner.SaveCheckpoints(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A restarted process opens the same database with empty projections.
+	store = openStore(t, path)
+	after := newReadModels(store)
+	if err := after.runner.Resume(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := after.orders.Checkpoint(); got != 4 {
+		t.Fatalf("orders resumed at position %d, want 4", got)
+	}
+	sameViews(t, after, before)
+
+	appendOrder(t, store, "o-4", "bob", 300, false)
+	after.catchUp(t)
+	for _, pos := range after.reader.after {
+		if pos < 4 {
+			t.Fatalf("catch-up after resume read the log from position %d; reads %v", pos, after.reader.after)
+		}
+	}
+	// Events before the checkpoint must not be counted a second time.
+	bob, _ := after.customers.Get("bob")
+	alice, _ := after.customers.Get("alice")
+	if bob.OrderCount != 2 || bob.TotalCents != 1000 || alice.OrderCount != 2 || alice.CancelledCount != 1 || alice.TotalCents != 1000 {
+		t.Fatalf("totals after resume: alice %+v, bob %+v", alice, bob)
+	}
+
+	full := newReadModels(store)
+	full.catchUp(t)
+	sameViews(t, after, full)
+}
+
+func TestSaveCheckpointsSkipsUnchangedProjections(t *testing.T) {
+	ctx := context.Background()
+	store := openStore(t, filepath.Join(t.TempDir(), "events.db"))
+	appendOrder(t, store, "o-1", "alice", 1000, false)
+	m := newReadModels(store)
+	m.catchUp(t)
+	if err := m.runner.SaveCheckpoints(ctx); err != nil {
+		t.Fatal(err)
+	}
+	first, _, err := store.Checkpoints().LoadCheckpoint(ctx, "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.runner.SaveCheckpoints(ctx); err != nil {
+		t.Fatal(err)
+	}
+	again, _, err := store.Checkpoints().LoadCheckpoint(ctx, "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.SavedAt.Equal(first.SavedAt) {
+		t.Fatalf("unchanged projection saved again at %s (first %s)", again.SavedAt, first.SavedAt)
+	}
+}
+
+func TestRebuildReplacesSavedCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	store := openStore(t, filepath.Join(t.TempDir(), "events.db"))
+	appendOrder(t, store, "o-1", "alice", 1000, false)
+	appendOrder(t, store, "o-2", "bob", 500, true)
+	want := newReadModels(store)
+	want.catchUp(t)
+
+	// A checkpoint at the head whose totals are wrong, as a bug in an earlier release could leave.
+	wrong := readmodel.Checkpoint{
+		Projection: "customer_totals",
+		Position:   3,
+		State:      []byte(`{"Customers":{"alice":{"CustomerID":"alice","OrderCount":7,"TotalCents":1}},"Orders":{}}`),
+		SavedAt:    time.Now(),
+	}
+	if err := store.Checkpoints().SaveCheckpoint(ctx, wrong); err != nil {
+		t.Fatal(err)
+	}
+	m := newReadModels(store)
+	if err := m.runner.Resume(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if alice, _ := m.customers.Get("alice"); alice.OrderCount != 7 {
+		t.Fatalf("resumed totals %+v, want the stored (wrong) state", alice)
+	}
+
+	if err := m.runner.Rebuild(ctx, "customer_totals"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m.customers.List(), want.customers.List()) {
+		t.Fatalf("rebuilt totals %+v, want %+v", m.customers.List(), want.customers.List())
+	}
+
+	// The rebuild is saved: the next process resumes the corrected state without replaying.
+	next := newReadModels(store)
+	if err := next.runner.Resume(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(next.customers.List(), want.customers.List()) || next.customers.Checkpoint() != 3 {
+		t.Fatalf("resumed after rebuild at %d: %+v, want %+v", next.customers.Checkpoint(), next.customers.List(), want.customers.List())
+	}
+
+	if err := m.runner.Rebuild(ctx, "nope"); err == nil {
+		t.Fatal("rebuilding an unknown projection succeeded")
+	}
+}
+
+func TestUnreadableCheckpointReplaysTheLog(t *testing.T) {
+	ctx := context.Background()
+	store := openStore(t, filepath.Join(t.TempDir(), "events.db"))
+	appendOrder(t, store, "o-1", "alice", 1000, true)
+	want := newReadModels(store)
+	want.catchUp(t)
+
+	bad := readmodel.Checkpoint{Projection: "orders", Position: 2, State: []byte("not json"), SavedAt: time.Now()}
+	if err := store.Checkpoints().SaveCheckpoint(ctx, bad); err != nil {
+		t.Fatal(err)
+	}
+	m := newReadModels(store)
+	if err := m.runner.Resume(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.orders.Checkpoint(); got != 0 {
+		t.Fatalf("orders resumed at %d from an unreadable checkpoint, want 0", got)
+	}
+	m.catchUp(t)
+	sameViews(t, m, want)
+}
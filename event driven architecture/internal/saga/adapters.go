@@ -0,0 +1,157 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/venkatesh/order-service/internal/domain"
+)
+
+// The adapters' Authorize, Reserve and Ship take an idempotency key: repeating a call with a key that
+// already succeeded returns the first result instead of charging, reserving or shipping again. The
+// saga derives the key from the order and step, so re-running a step after a crash is safe.
+
+// PaymentGateway authorizes and refunds order payments.
+type PaymentGateway interface {
+	Authorize(ctx context.Context, idempotencyKey, orderID string, amountCents int64) (paymentID string, err error)
+	Refund(ctx context.Context, paymentID string, amountCents int64) error
+}
+
+// Inventory reserves and releases stock for an order's line items.
+type Inventory interface {
+	Reserve(ctx context.Context, idempotencyKey, orderID string, items []domain.LineItem) (reservationID string, err error)
+	Release(ctx context.Context, reservationID string) error
+}
+
+// Shipping books a shipment for a reserved order.
+type Shipping interface {
+	Ship(ctx context.Context, idempotencyKey, orderID string) (trackingNumber, carrier string, err error)
+}
+
+// Adapter failures that the saga compensates for.
+var (
+	ErrPaymentDeclined = errors.New("payment declined")
+	ErrOutOfStock      = errors.New("out of stock")
+)
+
+// SimulatedPayments approves charges up to LimitCents after Latency.
+type SimulatedPayments struct {
+	LimitCents int64
+	Latency    time.Duration
+
+	seq      atomic.Int64
+	mu       sync.Mutex
+	byKey    map[string]string
+	refunded map[string]bool
+}
+
+// Authorize declines amounts above LimitCents.
+func (p *SimulatedPayments) Authorize(ctx context.Context, idempotencyKey, orderID string, amountCents int64) (string, error) {
+	if err := wait(ctx, p.Latency); err != nil {
+		return "", err
+	}
+	if p.LimitCents > 0 && amountCents > p.LimitCents {
+		return "", fmt.Errorf("%w: %d cents exceeds limit %d", ErrPaymentDeclined, amountCents, p.LimitCents)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return once(&p.byKey, idempotencyKey, func() string { return fmt.Sprintf("pay-%s-%d", orderID, p.seq.Add(1)) }), nil
+}
+
+// Refund is idempotent per payment.
+func (p *SimulatedPayments) Refund(ctx context.Context, paymentID string, _ int64) error {
+	if err := wait(ctx, p.Latency); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.refunded == nil {
+		p.refunded = make(map[string]bool)
+	}
+	p.refunded[paymentID] = true
+	return nil
+}
+
+// SimulatedInventory reports SKUs starting with "oos-" as out of stock.
+type SimulatedInventory struct {
+	Latency time.Duration
+	seq     atomic.Int64
+	mu      sync.Mutex
+	byKey   map[string]string
+}
+
+// Reserve fails when any line item is out of stock.
+func (i *SimulatedInventory) Reserve(ctx context.Context, idempotencyKey, orderID string, items []domain.LineItem) (string, error) {
+	if err := wait(ctx, i.Latency); err != nil {
+		return "", err
+	}
+	for _, item := range items {
+		if strings.HasPrefix(item.SKU, "oos-") {
+			return "", fmt.Errorf("%w: %s", ErrOutOfStock, item.SKU)
+		}
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return once(&i.byKey, idempotencyKey, func() string { return fmt.Sprintf("res-%s-%d", orderID, i.seq.Add(1)) }), nil
+}
+
+// Release always succeeds.
+func (i *SimulatedInventory) Release(ctx context.Context, _ string) error {
+	return wait(ctx, i.Latency)
+}
+
+// SimulatedShipping hands every order to Carrier.
+type SimulatedShipping struct {
+	Carrier string
+	Latency time.Duration
+	seq     atomic.Int64
+	mu      sync.Mutex
+	byKey   map[string]string
+}
+
+// Ship returns a generated tracking number.
+func (s *SimulatedShipping) Ship(ctx context.Context, idempotencyKey, orderID string) (string, string, error) {
+	if err := wait(ctx, s.Latency); err != nil {
+		return "", "", err
+	}
+	carrier := s.Carrier
+	if carrier == "" {
+		carrier = "SIM"
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return once(&s.byKey, idempotencyKey, func() string { return fmt.Sprintf("TRK-%s-%d", orderID, s.seq.Add(1)) }), carrier, nil
+}
+
+// once returns the result recorded for key, or records and returns next(). Callers hold the lock
+// guarding results.
+func once(results *map[string]string, key string, next func() string) string {
+	if *results == nil {
+		*results = make(map[string]string)
+	}
+	if id, ok := (*results)[key]; ok {
+		return id
+	}
+	id := next()
+	(*results)[key] = id
+	return id
+}
+
+func wait(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
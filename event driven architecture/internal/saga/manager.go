@@ -0,0 +1,423 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/venkatesh/order-service/internal/app"
+	"github.com/venkatesh/order-service/internal/domain"
+)
+
+// Commands is the part of app.OrderService the saga drives.
+type Commands interface {
+	LoadOrder(ctx context.Context, orderID string) (*domain.Order, error)
+	HandleAuthorizePayment(ctx context.Context, cmd app.AuthorizePayment) (app.Result, error)
+	HandleReserveInventory(ctx context.Context, cmd app.ReserveInventory) (app.Result, error)
+	HandleShipOrder(ctx context.Context, cmd app.ShipOrder) (app.Result, error)
+	HandleCancelOrder(ctx context.Context, cmd app.CancelOrder) (app.Result, error)
+}
+
+// Config tunes the process manager.
+type Config struct {
+	// StepTimeout bounds each adapter call; a timeout fails the saga and triggers compensation.
+	StepTimeout time.Duration
+	// Workers is how many sagas advance concurrently.
+	Workers int
+	// ResumeInterval is how often unfinished sagas are re-checked.
+	ResumeInterval time.Duration
+	// ShipAttempts is how many times booking the shipment is tried, one per resume, before the saga
+	// gives up and compensates.
+	ShipAttempts int
+}
+
+// Manager is the fulfilment process manager: OrderPlaced starts a saga that authorizes payment,
+// reserves inventory and ships, issuing the matching order command after each adapter call. A payment or
+// reservation failure or timeout, or a shipment still failing after ShipAttempts tries, releases the
+// reservation, refunds the payment and cancels the order.
+//
+// Every step is derived from the persisted saga state plus the rehydrated order, never from the event
+// that triggered it, so redelivered events and restarts simply re-run advance until the order and saga
+// agree.
+type Manager struct {
+	commands  Commands
+	store     Store
+	payments  PaymentGateway
+	inventory Inventory
+	shipping  Shipping
+	cfg       Config
+
+	wake  chan string
+	locks [64]sync.Mutex // striped per order ID
+}
+
+// NewManager wires the saga to the order service and adapters.
+func NewManager(commands Commands, store Store, payments PaymentGateway, inventory Inventory, shipping Shipping, cfg Config) *Manager {
+	if cfg.StepTimeout <= 0 {
+		cfg.StepTimeout = 5 * time.Second
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.ResumeInterval <= 0 {
+		cfg.ResumeInterval = 5 * time.Second
+	}
+	if cfg.ShipAttempts <= 0 {
+		cfg.ShipAttempts = 3
+	}
+	return &Manager{
+		commands:  commands,
+		store:     store,
+		payments:  payments,
+		inventory: inventory,
+		shipping:  shipping,
+		cfg:       cfg,
+		wake:      make(chan string, 1024),
+	}
+}
+
+// Handle is the event-bus subscriber. It only records new sagas and wakes the workers: advancing
+// issues commands that publish back onto the bus, which must not happen from a bus worker.
+func (m *Manager) Handle(ctx context.Context, event domain.Event) error {
+	if placed, ok := event.(domain.OrderPlaced); ok {
+		if err := m.start(ctx, placed); err != nil {
+			return err
+		}
+	}
+	select {
+	case m.wake <- event.AggregateID():
+	default:
+		// Saturated; the periodic resume picks the saga up.
+	}
+	return nil
+}
+
+// Run advances woken sagas on Workers goroutines and re-queues every unfinished saga each
+// ResumeInterval, which resumes work interrupted by a restart or a failed step. It blocks until ctx is
+// cancelled.
+func (m *Manager) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < m.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case orderID := <-m.wake:
+					if err := m.advance(ctx, orderID); err != nil && ctx.Err() == nil {
+						log.Printf("saga: order %s: %v", orderID, err)
+					}
+				}
+			}
+		}()
+	}
+	ticker := time.NewTicker(m.cfg.ResumeInterval)
+	defer ticker.Stop()
+	for {
+		active, err := m.store.ListActive(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("saga: list active: %v", err)
+		}
+		for _, st := range active {
+			select {
+			case m.wake <- st.OrderID:
+			case <-ctx.Done():
+			}
+		}
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Get returns the saga state for an order.
+func (m *Manager) Get(ctx context.Context, orderID string) (State, bool, error) {
+	return m.store.Get(ctx, orderID)
+}
+
+func (m *Manager) start(ctx context.Context, placed domain.OrderPlaced) error {
+	mu := m.lock(placed.AggregateID())
+	defer mu.Unlock()
+	if _, ok, err := m.store.Get(ctx, placed.AggregateID()); err != nil || ok {
+		return err
+	}
+	now := time.Now().UTC()
+	return m.store.Save(ctx, State{
+		OrderID:     placed.AggregateID(),
+		Step:        StepPayment,
+		AmountCents: placed.TotalCents,
+		StartedAt:   now,
+		UpdatedAt:   now,
+	})
+}
+
+func (m *Manager) advance(ctx context.Context, orderID string) error {
+	mu := m.lock(orderID)
+	defer mu.Unlock()
+
+	st, ok, err := m.store.Get(ctx, orderID)
+	if err != nil || !ok || st.Step.Done() {
+		return err
+	}
+	order, err := m.commands.LoadOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	snap := order.Snapshot()
+
+	switch {
+	case st.Step == StepCompensating:
+		return m.compensate(ctx, &st, snap)
+	case snap.Status == domain.OrderStatusCancelled:
+		st.FailureReason = "order cancelled"
+		return m.compensate(ctx, &st, snap)
+	case snap.Status == domain.OrderStatusShipped:
+		st.Step = StepCompleted
+		return m.save(ctx, &st)
+	case snap.InventoryReserved:
+		return m.ship(ctx, &st, snap)
+	case snap.PaymentAuthorized:
+		return m.reserve(ctx, &st, snap)
+	default:
+		return m.pay(ctx, &st, snap)
+	}
+}
+
+func (m *Manager) pay(ctx context.Context, st *State, snap domain.OrderSnapshot) error {
+	st.Step = StepPayment
+	if st.PaymentID == "" {
+		if err := m.markRequested(ctx, st, &st.PaymentRequested); err != nil {
+			return err
+		}
+		stepCtx, cancel := context.WithTimeout(ctx, m.cfg.StepTimeout)
+		paymentID, err := m.payments.Authorize(stepCtx, stepKey(st.OrderID, "payment"), st.OrderID, snap.TotalCents)
+		cancel()
+		if err != nil {
+			if errors.Is(err, ErrPaymentDeclined) {
+				st.PaymentRequested = false // nothing was charged
+			}
+			return m.fail(ctx, st, snap, fmt.Errorf("authorize payment: %w", err))
+		}
+		st.PaymentID, st.AmountCents = paymentID, snap.TotalCents
+		if err := m.save(ctx, st); err != nil {
+			return err
+		}
+	}
+	_, err := m.commands.HandleAuthorizePayment(ctx, app.AuthorizePayment{
+		Meta:      commandMeta(st.OrderID, "payment", snap.Version),
+		OrderID:   st.OrderID,
+		PaymentID: st.PaymentID,
+		Amount:    st.AmountCents,
+	})
+	return m.afterCommand(ctx, st, snap, err)
+}
+
+func (m *Manager) reserve(ctx context.Context, st *State, snap domain.OrderSnapshot) error {
+	st.Step = StepReservation
+	if st.ReservationID == "" {
+		if err := m.markRequested(ctx, st, &st.ReservationRequested); err != nil {
+			return err
+		}
+		stepCtx, cancel := context.WithTimeout(ctx, m.cfg.StepTimeout)
+		reservationID, err := m.inventory.Reserve(stepCtx, stepKey(st.OrderID, "reservation"), st.OrderID, snap.Items)
+		cancel()
+		if err != nil {
+			if errors.Is(err, ErrOutOfStock) {
+				st.ReservationRequested = false // nothing was reserved
+			}
+			return m.fail(ctx, st, snap, fmt.Errorf("reserve inventory: %w", err))
+		}
+		st.ReservationID = reservationID
+		if err := m.save(ctx, st); err != nil {
+			return err
+		}
+	}
+	_, err := m.commands.HandleReserveInventory(ctx, app.ReserveInventory{
+		Meta:          commandMeta(st.OrderID, "reservation", snap.Version),
+		OrderID:       st.OrderID,
+		ReservationID: st.ReservationID,
+	})
+	return m.afterCommand(ctx, st, snap, err)
+}
+
+// ship books the shipment and records the tracking number before issuing ShipOrder, so a resumed step
+// ships with the booking it already has. A failed booking is retried on the next resume, up to
+// ShipAttempts tries, and then compensated.
+func (m *Manager) ship(ctx context.Context, st *State, snap domain.OrderSnapshot) error {
+	st.Step = StepShipment
+	if st.TrackingNumber == "" {
+		stepCtx, cancel := context.WithTimeout(ctx, m.cfg.StepTimeout)
+		tracking, carrier, err := m.shipping.Ship(stepCtx, stepKey(st.OrderID, "shipment"), st.OrderID)
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			st.ShipAttempts++
+			if st.ShipAttempts >= m.cfg.ShipAttempts {
+				return m.fail(ctx, st, snap, fmt.Errorf("book shipment after %d attempts: %w", st.ShipAttempts, err))
+			}
+			if saveErr := m.save(ctx, st); saveErr != nil {
+				return saveErr
+			}
+			return fmt.Errorf("book shipment for %s (attempt %d of %d): %w", st.OrderID, st.ShipAttempts, m.cfg.ShipAttempts, err)
+		}
+		st.TrackingNumber, st.Carrier = tracking, carrier
+		if err := m.save(ctx, st); err != nil {
+			return err
+		}
+	}
+	_, err := m.commands.HandleShipOrder(ctx, app.ShipOrder{
+		Meta:           commandMeta(st.OrderID, "shipment", snap.Version),
+		OrderID:        st.OrderID,
+		TrackingNumber: st.TrackingNumber,
+		Carrier:        st.Carrier,
+	})
+	return m.afterCommand(ctx, st, snap, err)
+}
+
+// markRequested sets and saves *requested before the first adapter call of a step.
+func (m *Manager) markRequested(ctx context.Context, st *State, requested *bool) error {
+	if *requested {
+		return nil
+	}
+	*requested = true
+	return m.save(ctx, st)
+}
+
+// afterCommand persists the step on success. A lost concurrency race is returned for retry; any other
+// rejection means the order can no longer progress and is compensated.
+func (m *Manager) afterCommand(ctx context.Context, st *State, snap domain.OrderSnapshot, err error) error {
+	if err == nil {
+		return m.save(ctx, st)
+	}
+	if errors.Is(err, app.ErrConcurrencyConflict) {
+		return err
+	}
+	return m.fail(ctx, st, snap, err)
+}
+
+func (m *Manager) fail(ctx context.Context, st *State, snap domain.OrderSnapshot, cause error) error {
+	if ctx.Err() != nil {
+		// Shutting down, not a failed step: leave the saga where it is for the next run.
+		return ctx.Err()
+	}
+	log.Printf("saga: order %s failed at %s: %v", st.OrderID, st.Step, cause)
+	st.Step = StepCompensating
+	st.FailureReason = cause.Error()
+	if err := m.save(ctx, st); err != nil {
+		return err
+	}
+	return m.compensate(ctx, st, snap)
+}
+
+// compensate undoes completed steps in reverse order, saving after each so a retry never repeats one.
+// A step that was requested without its result being recorded is looked up first by repeating the
+// keyed adapter call, which returns the existing payment or reservation (or, if the first call never
+// arrived, makes one that is undone straight away).
+func (m *Manager) compensate(ctx context.Context, st *State, snap domain.OrderSnapshot) error {
+	st.Step = StepCompensating
+	if st.ReservationRequested && st.ReservationID == "" {
+		stepCtx, cancel := context.WithTimeout(ctx, m.cfg.StepTimeout)
+		reservationID, err := m.inventory.Reserve(stepCtx, stepKey(st.OrderID, "reservation"), st.OrderID, snap.Items)
+		cancel()
+		switch {
+		case errors.Is(err, ErrOutOfStock):
+			st.ReservationRequested = false
+		case err != nil:
+			return fmt.Errorf("look up reservation: %w", err)
+		default:
+			st.ReservationID = reservationID
+		}
+		if err := m.save(ctx, st); err != nil {
+			return err
+		}
+	}
+	if st.PaymentRequested && st.PaymentID == "" {
+		stepCtx, cancel := context.WithTimeout(ctx, m.cfg.StepTimeout)
+		paymentID, err := m.payments.Authorize(stepCtx, stepKey(st.OrderID, "payment"), st.OrderID, snap.TotalCents)
+		cancel()
+		switch {
+		case errors.Is(err, ErrPaymentDeclined):
+			st.PaymentRequested = false
+		case err != nil:
+			return fmt.Errorf("look up payment: %w", err)
+		default:
+			st.PaymentID, st.AmountCents = paymentID, snap.TotalCents
+		}
+		if err := m.save(ctx, st); err != nil {
+			return err
+		}
+	}
+	if st.ReservationID != "" && !st.Released {
+		stepCtx, cancel := context.WithTimeout(ctx, m.cfg.StepTimeout)
+		err := m.inventory.Release(stepCtx, st.ReservationID)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("release reservation %s: %w", st.ReservationID, err)
+		}
+		st.Released = true
+		if err := m.save(ctx, st); err != nil {
+			return err
+		}
+	}
+	if st.PaymentID != "" && !st.Refunded {
+		stepCtx, cancel := context.WithTimeout(ctx, m.cfg.StepTimeout)
+		err := m.payments.Refund(stepCtx, st.PaymentID, st.AmountCents)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("refund payment %s: %w", st.PaymentID, err)
+		}
+		st.Refunded = true
+		if err := m.save(ctx, st); err != nil {
+			return err
+		}
+	}
+	if snap.Status != domain.OrderStatusCancelled {
+		cancelOrder := app.CancelOrder{Meta: commandMeta(st.OrderID, "cancel", snap.Version), OrderID: st.OrderID, Reason: st.FailureReason}
+		if _, err := m.commands.HandleCancelOrder(ctx, cancelOrder); err != nil {
+			return err
+		}
+	}
+	st.Step = StepCancelled
+	return m.save(ctx, st)
+}
+
+// commandMeta keys each saga command by order and step, so a step re-run after a crash between the
+// command and the saga save is recognised as a duplicate rather than rejected by the aggregate. The
+// order version the step acted on is recorded as the causation; the correlation is inherited from
+// the order.
+func commandMeta(orderID, step string, version int) app.Meta {
+	return app.Meta{
+		IdempotencyKey: stepKey(orderID, step),
+		CausationID:    fmt.Sprintf("%s@%d", orderID, version),
+		UserID:         "fulfilment-saga",
+	}
+}
+
+// stepKey is the idempotency key of an order's saga step, shared by its adapter call and order command.
+func stepKey(orderID, step string) string {
+	return "saga:" + orderID + ":" + step
+}
+
+func (m *Manager) save(ctx context.Context, st *State) error {
+	st.UpdatedAt = time.Now().UTC()
+	return m.store.Save(ctx, *st)
+}
+
+func (m *Manager) lock(orderID string) *sync.Mutex {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(orderID))
+	mu := &m.locks[h.Sum32()%uint32(len(m.locks))]
+	mu.Lock()
+	return mu
+}
@@ -0,0 +1,407 @@
+package saga_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/venkatesh/order-service/internal/app"
+	"github.com/venkatesh/order-service/internal/domain"
+	"github.com/venkatesh/order-service/internal/infrastructure"
+	"github.com/venkatesh/order-service/internal/saga"
+)
+
+// gateway fakes the three adapters the way real ones behave: a call with a key that already succeeded
+// returns the first result. Each adapter can be made to fail or to stall.
+type gateway struct {
+	mu           sync.Mutex
+	calls        map[string]int    // adapter calls per idempotency key
+	results      map[string]string // first result per key
+	refunds      map[string]int
+	releases     map[string]int
+	declineOver  int64
+	outOfStock   bool
+	shipFailures int           // Ship fails this many more times
+	stallFirst   time.Duration // the first call of each key completes after this long
+}
+
+func newGateway() *gateway {
+	return &gateway{calls: map[string]int{}, results: map[string]string{}, refunds: map[string]int{}, releases: map[string]int{}}
+}
+
+// call records a keyed call and returns the key's result, creating it with id on first success.
+func (g *gateway) call(ctx context.Context, key, id string, fail error) (string, error) {
+	g.mu.Lock()
+	g.calls[key]++
+	stall := g.stallFirst > 0 && g.calls[key] == 1
+	if fail == nil {
+		if _, ok := g.results[key]; !ok {
+			g.results[key] = id
+		}
+	}
+	result := g.results[key]
+	g.mu.Unlock()
+	if stall {
+		// The provider has done the work, but the answer arrives too late.
+		select {
+		case <-time.After(g.stallFirst):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	return result, fail
+}
+
+func (g *gateway) Authorize(ctx context.Context, key, orderID string, amountCents int64) (string, error) {
+	var fail error
+	if g.declineOver > 0 && amountCents > g.declineOver {
+		fail = saga.ErrPaymentDeclined
+	}
+	return g.call(ctx, key, "pay-"+orderID, fail)
+}
+
+func (g *gateway) Refund(_ context.Context, paymentID string, _ int64) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.refunds[paymentID]++
+	return nil
+}
+
+func (g *gateway) Reserve(ctx context.Context, key, orderID string, _ []domain.LineItem) (string, error) {
+	var fail error
+	if g.outOfStock {
+		fail = saga.ErrOutOfStock
+	}
+	return g.call(ctx, key, "res-"+orderID, fail)
+}
+
+func (g *gateway) Release(_ context.Context, reservationID string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.releases[reservationID]++
+	return nil
+}
+
+func (g *gateway) Ship(ctx context.Context, key, orderID string) (string, string, error) {
+	var fail error
+	g.mu.Lock()
+	if g.shipFailures > 0 {
+		g.shipFailures--
+		fail = errors.New("carrier unavailable")
+	}
+	g.mu.Unlock()
+	tracking, err := g.call(ctx, key, "TRK-"+orderID, fail)
+	return tracking, "UPS", err
+}
+
+// result returns the first successful result of orderID's step and how many calls it took.
+func (g *gateway) result(orderID, step string) (string, int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	key := "saga:" + orderID + ":" + step
+	return g.results[key], g.calls[key]
+}
+
+// forwarder publishes order events to whichever manager is current, like the event bus would.
+type forwarder struct {
+	mu sync.Mutex
+	m  *saga.Manager
+}
+
+func (f *forwarder) Publish(ctx context.Context, events []domain.Event) error {
+	f.mu.Lock()
+	m := f.m
+	f.mu.Unlock()
+	for _, evt := range events {
+		if err := m.Handle(ctx, evt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type harness struct {
+	t       *testing.T
+	events  *infrastructure.InMemoryStore
+	service *app.OrderService
+	sagas   saga.Store
+	gw      *gateway
+	pub     *forwarder
+	cfg     saga.Config
+
+	manager *saga.Manager
+	stop    func()
+}
+
+func newHarness(t *testing.T, gw *gateway, cfg saga.Config) *harness {
+	if cfg.StepTimeout == 0 {
+		cfg.StepTimeout = time.Second
+	}
+	cfg.ResumeInterval = 5 * time.Millisecond
+	h := &harness{t: t, events: infrastructure.NewInMemoryStore(), sagas: saga.NewMemoryStore(), gw: gw, pub: &forwarder{}, cfg: cfg}
+	h.service = app.NewOrderService(h.events, h.pub)
+	t.Cleanup(func() { h.stop() })
+	h.start(h.sagas)
+	return h
+}
+
+// start runs a fresh manager, as a restarted process would, on top of the given saga store.
+func (h *harness) start(store saga.Store) {
+	h.manager = saga.NewManager(h.service, store, h.gw, h.gw, h.gw, h.cfg)
+	h.pub.mu.Lock()
+	h.pub.m = h.manager
+	h.pub.mu.Unlock()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.manager.Run(ctx)
+	}()
+	h.stop = func() {
+		cancel()
+		<-done
+	}
+}
+
+func (h *harness) place(orderID string, items ...domain.LineItem) {
+	h.t.Helper()
+	if len(items) == 0 {
+		items = []domain.LineItem{{SKU: "book", Quantity: 2, UnitPriceCents: 1500}}
+	}
+	if _, err := h.service.HandlePlaceOrder(context.Background(), app.PlaceOrder{OrderID: orderID, CustomerID: "c1", Items: items}); err != nil {
+		h.t.Fatal(err)
+	}
+}
+
+// wait returns the saga once it has finished.
+func (h *harness) wait(orderID string) saga.State {
+	h.t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		st, ok, err := h.sagas.Get(context.Background(), orderID)
+		if err != nil {
+			h.t.Fatal(err)
+		}
+		if ok && st.Step.Done() {
+			return st
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+	st, _, _ := h.sagas.Get(context.Background(), orderID)
+	h.t.Fatalf("saga for %s did not finish: %+v", orderID, st)
+	return saga.State{}
+}
+
+func (h *harness) order(orderID string) (domain.OrderSnapshot, []domain.Event) {
+	h.t.Helper()
+	order, err := h.service.LoadOrder(context.Background(), orderID)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	events, err := h.events.Load(context.Background(), orderID)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	return order.Snapshot(), events
+}
+
+func shippedTracking(events []domain.Event) []string {
+	var out []string
+	for _, evt := range events {
+		if shipped, ok := evt.(domain.OrderShipped); ok {
+			out = append(out, shipped.TrackingNumber)
+		}
+	}
+	return out
+}
+
+func TestSagaHappyPath(t *testing.T) {
+	gw := newGateway()
+	h := newHarness(t, gw, saga.Config{})
+	h.place("o1")
+
+	st := h.wait("o1")
+	snap, events := h.order("o1")
+	if st.Step != saga.StepCompleted || snap.Status != domain.OrderStatusShipped {
+		t.Fatalf("saga %s, order %s", st.Step, snap.Status)
+	}
+	if st.PaymentID != "pay-o1" || st.ReservationID != "res-o1" || st.TrackingNumber != "TRK-o1" || st.Carrier != "UPS" {
+		t.Fatalf("saga state = %+v", st)
+	}
+	if got := shippedTracking(events); len(got) != 1 || got[0] != "TRK-o1" {
+		t.Fatalf("OrderShipped tracking numbers %v", got)
+	}
+	if len(gw.refunds) != 0 || len(gw.releases) != 0 {
+		t.Fatalf("compensated a successful saga: refunds %v, releases %v", gw.refunds, gw.releases)
+	}
+}
+
+func TestSagaCompensatesEachFailedStep(t *testing.T) {
+	cases := []struct {
+		name         string
+		gw           func(*gateway)
+		wantRefund   bool
+		wantRelease  bool
+		wantAttempts int
+	}{
+		{name: "payment declined", gw: func(g *gateway) { g.declineOver = 100 }},
+		{name: "out of stock", gw: func(g *gateway) { g.outOfStock = true }, wantRefund: true},
+		{name: "shipment keeps failing", gw: func(g *gateway) { g.shipFailures = 100 }, wantRefund: true, wantRelease: true, wantAttempts: 3},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gw := newGateway()
+			tc.gw(gw)
+			h := newHarness(t, gw, saga.Config{ShipAttempts: 3})
+			h.place("o1")
+
+			st := h.wait("o1")
+			snap, events := h.order("o1")
+			if st.Step != saga.StepCancelled || snap.Status != domain.OrderStatusCancelled || st.FailureReason == "" {
+				t.Fatalf("saga %s (%q), order %s", st.Step, st.FailureReason, snap.Status)
+			}
+			if refunded := gw.refunds["pay-o1"] == 1; refunded != tc.wantRefund || st.Refunded != tc.wantRefund {
+				t.Fatalf("refunds %v, saga refunded %v, want refund %v", gw.refunds, st.Refunded, tc.wantRefund)
+			}
+			if released := gw.releases["res-o1"] == 1; released != tc.wantRelease || st.Released != tc.wantRelease {
+				t.Fatalf("releases %v, saga released %v, want release %v", gw.releases, st.Released, tc.wantRelease)
+			}
+			if st.ShipAttempts != tc.wantAttempts {
+				t.Fatalf("ship attempts %d, want %d", st.ShipAttempts, tc.wantAttempts)
+			}
+			if got := shippedTracking(events); len(got) != 0 {
+				t.Fatalf("cancelled order shipped: %v", got)
+			}
+		})
+	}
+}
+
+func TestSagaRetriesShipmentWithinLimit(t *testing.T) {
+	gw := newGateway()
+	gw.shipFailures = 2
+	h := newHarness(t, gw, saga.Config{ShipAttempts: 3})
+	h.place("o1")
+
+	st := h.wait("o1")
+	if st.Step != saga.StepCompleted || st.ShipAttempts != 2 || st.TrackingNumber != "TRK-o1" {
+		t.Fatalf("saga state = %+v", st)
+	}
+}
+
+func TestSagaTimeoutsCompensate(t *testing.T) {
+	for _, step := range []string{"payment", "reservation"} {
+		t.Run(step, func(t *testing.T) {
+			gw := newGateway()
+			h := newHarness(t, gw, saga.Config{StepTimeout: 20 * time.Millisecond})
+			// Only this step's first call stalls, past the step timeout.
+			gw.mu.Lock()
+			gw.stallFirst = time.Hour
+			if step == "reservation" {
+				gw.calls["saga:o1:payment"] = 1
+			}
+			gw.mu.Unlock()
+			h.place("o1")
+
+			st := h.wait("o1")
+			snap, _ := h.order("o1")
+			if st.Step != saga.StepCancelled || snap.Status != domain.OrderStatusCancelled {
+				t.Fatalf("saga %s, order %s", st.Step, snap.Status)
+			}
+			// The timed-out call went through at the provider; compensation must find and undo it.
+			if step == "payment" && (st.PaymentID != "pay-o1" || gw.refunds["pay-o1"] != 1) {
+				t.Fatalf("timed-out payment not refunded: state %+v, refunds %v", st, gw.refunds)
+			}
+			if step == "reservation" && (st.ReservationID != "res-o1" || gw.releases["res-o1"] != 1 || gw.refunds["pay-o1"] != 1) {
+				t.Fatalf("timed-out reservation not released: state %+v, releases %v, refunds %v", st, gw.releases, gw.refunds)
+			}
+		})
+	}
+}
+
+// crashingStore fails the n-th Save, as if the process died right before it, and reports when it did.
+type crashingStore struct {
+	saga.Store
+	mu      sync.Mutex
+	saves   int
+	crashAt int
+	crashed chan struct{}
+}
+
+func (s *crashingStore) Save(ctx context.Context, st saga.State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saves++
+	if s.saves == s.crashAt {
+		close(s.crashed)
+		return errors.New("crash")
+	}
+	if s.saves > s.crashAt {
+		// The process is dead: nothing more is saved.
+		return errors.New("crashed")
+	}
+	return s.Store.Save(ctx, st)
+}
+
+func TestSagaResumesAfterCrashAtEveryStep(t *testing.T) {
+	// A successful saga saves 10 times; the first, when the saga starts, happens inside the event
+	// handler and is retried by the bus, so the crash points start after it.
+	for crashAt := 2; crashAt <= 10; crashAt++ {
+		t.Run(fmt.Sprintf("save %d", crashAt), func(t *testing.T) {
+			gw := newGateway()
+			h := newHarness(t, gw, saga.Config{})
+			h.stop()
+			crashing := &crashingStore{Store: h.sagas, crashAt: crashAt, crashed: make(chan struct{})}
+			h.start(crashing)
+			h.place("o1")
+
+			select {
+			case <-crashing.crashed:
+			case <-time.After(5 * time.Second):
+				t.Fatal("no crash")
+			}
+			h.stop()
+			h.start(h.sagas)
+
+			st := h.wait("o1")
+			snap, events := h.order("o1")
+			if st.Step != saga.StepCompleted || snap.Status != domain.OrderStatusShipped {
+				t.Fatalf("saga %s, order %s", st.Step, snap.Status)
+			}
+			// Steps repeated after the restart reuse their idempotency key, so each happened once.
+			for step, want := range map[string]string{"payment": "pay-o1", "reservation": "res-o1", "shipment": "TRK-o1"} {
+				if got, _ := gw.result("o1", step); got != want {
+					t.Fatalf("%s result %q, want %q", step, got, want)
+				}
+			}
+			if st.PaymentID != "pay-o1" || st.TrackingNumber != "TRK-o1" {
+				t.Fatalf("saga state = %+v", st)
+			}
+			if got := shippedTracking(events); len(got) != 1 || got[0] != "TRK-o1" {
+				t.Fatalf("OrderShipped tracking numbers %v", got)
+			}
+		})
+	}
+}
+
+func TestSimulatedAdaptersAreIdempotent(t *testing.T) {
+	ctx := context.Background()
+	payments := &saga.SimulatedPayments{}
+	first, _ := payments.Authorize(ctx, "k1", "o1", 100)
+	again, _ := payments.Authorize(ctx, "k1", "o1", 100)
+	other, _ := payments.Authorize(ctx, "k2", "o1", 100)
+	if first != again || first == other {
+		t.Fatalf("payments: %s, %s, %s", first, again, other)
+	}
+	inventory := &saga.SimulatedInventory{}
+	r1, _ := inventory.Reserve(ctx, "k1", "o1", nil)
+	r2, _ := inventory.Reserve(ctx, "k1", "o1", nil)
+	shipping := &saga.SimulatedShipping{}
+	t1, _, _ := shipping.Ship(ctx, "k1", "o1")
+	t2, _, _ := shipping.Ship(ctx, "k1", "o1")
+	if r1 != r2 || t1 != t2 {
+		t.Fatalf("reservations %s/%s, shipments %s/%s", r1, r2, t1, t2)
+	}
+}
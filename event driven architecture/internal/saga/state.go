@@ -0,0 +1,95 @@
+package saga
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Step is where a fulfilment saga currently stands.
+type Step string
+
+const (
+	StepPayment      Step = "awaiting_payment"
+	StepReservation  Step = "awaiting_reservation"
+	StepShipment     Step = "awaiting_shipment"
+	StepCompensating Step = "compensating"
+	StepCompleted    Step = "completed"
+	StepCancelled    Step = "cancelled"
+)
+
+// Done reports whether the saga needs no further work.
+func (s Step) Done() bool { return s == StepCompleted || s == StepCancelled }
+
+// State is the persisted progress of one order's fulfilment. Each adapter result is recorded as soon as
+// the adapter returns it, so compensation knows what to undo and a resumed step reuses it even after a
+// crash. The Requested flags are saved before the adapter is called: a crash or timeout can hide a
+// charge or reservation that went through, and compensation has to look for it.
+type State struct {
+	OrderID              string
+	Step                 Step
+	PaymentRequested     bool
+	PaymentID            string
+	AmountCents          int64
+	Refunded             bool
+	ReservationRequested bool
+	ReservationID        string
+	Released             bool
+	TrackingNumber       string
+	Carrier              string
+	ShipAttempts         int
+	FailureReason        string
+	StartedAt            time.Time
+	UpdatedAt            time.Time
+}
+
+// Store persists saga state.
+type Store interface {
+	Save(ctx context.Context, st State) error
+	// Get returns false when no saga exists for orderID.
+	Get(ctx context.Context, orderID string) (State, bool, error)
+	// ListActive returns sagas that are not completed or cancelled.
+	ListActive(ctx context.Context) ([]State, error)
+}
+
+// MemoryStore is a process-local Store.
+type MemoryStore struct {
+	mu     sync.RWMutex
+	states map[string]State
+}
+
+// NewMemoryStore returns an empty store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: make(map[string]State)}
+}
+
+// Save upserts st.
+func (m *MemoryStore) Save(_ context.Context, st State) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.states[st.OrderID] = st
+	return nil
+}
+
+// Get returns the saga for orderID.
+func (m *MemoryStore) Get(_ context.Context, orderID string) (State, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	st, ok := m.states[orderID]
+	return st, ok, nil
+}
+
+// ListActive returns unfinished sagas, oldest first.
+func (m *MemoryStore) ListActive(_ context.Context) ([]State, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var out []State
+	for _, st := range m.states {
+		if !st.Step.Done() {
+			out = append(out, st)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].StartedAt.Before(out[j].StartedAt) })
+	return out, nil
+}